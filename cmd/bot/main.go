@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/alerts"
 	"github.com/philip-857.bit/byb-bot/internal/botsetup"
 	"github.com/philip-857.bit/byb-bot/internal/captcha"
 	"github.com/philip-857.bit/byb-bot/internal/commands"
 	"github.com/philip-857.bit/byb-bot/internal/config"
 	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/filters"
+	"github.com/philip-857.bit/byb-bot/internal/inline"
+	"github.com/philip-857.bit/byb-bot/internal/rules"
+	"github.com/philip-857.bit/byb-bot/internal/tokengate"
+	"github.com/philip-857.bit/byb-bot/internal/watchlist"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
 )
 
 func main() {
@@ -17,9 +25,9 @@ func main() {
 		log.Fatalf("Could not load config: %v", err)
 	}
 
-	db, err := database.NewClient(cfg.SupabaseURL, cfg.SupabaseKey)
+	db, err := openStore(cfg)
 	if err != nil {
-		log.Fatalf("Could not connect to Supabase: %v", err)
+		log.Fatalf("Could not open the database: %v", err)
 	}
 
 	bot, err := tgbotapi.NewBotAPI(cfg.TelegramToken)
@@ -33,6 +41,13 @@ func main() {
 
 	botsetup.SetDefaultCommands(bot)
 
+	// Price alerts are checked in the background for as long as the bot runs.
+	go alerts.RunPoller(bot, db)
+	// Members of token-gated chats are removed once they no longer hold the token.
+	go tokengate.RunRechecker(bot, db)
+	// Watchlist digests are posted at the local time each chat scheduled them for.
+	go watchlist.RunScheduler(bot, db)
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	updates := bot.GetUpdatesChan(u)
@@ -43,10 +58,39 @@ func main() {
 	}
 }
 
-func handleUpdate(bot *tgbotapi.BotAPI, db *database.Client, update tgbotapi.Update) {
+// openStore connects to the storage backend the config picks.
+func openStore(cfg *config.Config) (database.Store, error) {
+	if cfg.StorageBackend == config.StorageSQL {
+		return database.OpenSQL(context.Background(), cfg.DatabaseURL)
+	}
+	return database.NewClient(cfg.SupabaseURL, cfg.SupabaseKey)
+}
+
+func handleUpdate(bot *tgbotapi.BotAPI, db database.Store, update tgbotapi.Update) {
 	// Handle button clicks (Callback Queries) first, as they are a distinct update type.
 	if update.CallbackQuery != nil {
-		captcha.HandleCallbackQuery(bot, db, update.CallbackQuery)
+		switch {
+		case rules.IsCallback(update.CallbackQuery.Data):
+			rules.HandleCallbackQuery(bot, db, update.CallbackQuery)
+		case web3.IsCallback(update.CallbackQuery.Data):
+			web3.HandleCallbackQuery(bot, db, update.CallbackQuery)
+		case alerts.IsCallback(update.CallbackQuery.Data):
+			alerts.HandleCallbackQuery(bot, db, update.CallbackQuery)
+		default:
+			captcha.HandleCallbackQuery(bot, db, update.CallbackQuery)
+		}
+		return
+	}
+
+	// Inline queries ("@bot eth" typed in any chat) have no message either.
+	if update.InlineQuery != nil {
+		inline.HandleInlineQuery(bot, db, update.InlineQuery)
+		return
+	}
+
+	// Requests to join a chat that requires admin approval, which token-gated chats use.
+	if update.ChatJoinRequest != nil {
+		tokengate.HandleJoinRequest(bot, db, update.ChatJoinRequest)
 		return
 	}
 
@@ -55,6 +99,11 @@ func handleUpdate(bot *tgbotapi.BotAPI, db *database.Client, update tgbotapi.Upd
 		return
 	}
 
+	// Remember which groups people are active in, for the notes their inline queries search.
+	if !update.Message.Chat.IsPrivate() && update.Message.From != nil {
+		inline.Seen(update.Message.Chat.ID, update.Message.From.ID)
+	}
+
 	// The switch statement now cleanly routes all message types.
 	switch {
 	case update.Message.IsCommand():
@@ -76,5 +125,9 @@ func handleUpdate(bot *tgbotapi.BotAPI, db *database.Client, update tgbotapi.Upd
 
 		// REMOVED: The case for `update.Message.Chat.IsPrivate()` was removed as it's no longer needed.
 		// Verification is now handled by the CallbackQuery above.
+
+	case !update.Message.Chat.IsPrivate():
+		// Every other group message is checked against the chat's keyword filters.
+		filters.HandleMessage(bot, db, update.Message)
 	}
 }
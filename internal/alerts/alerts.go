@@ -0,0 +1,60 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+// Alert conditions. Above and below compare the price with a threshold; up, down and
+// move compare it with the reference price and take a percentage.
+const (
+	Above = "above"
+	Below = "below"
+	Up    = "up"
+	Down  = "down"
+	Move  = "move"
+)
+
+// isPercent reports whether a condition's target is a percentage.
+func isPercent(condition string) bool {
+	return condition == Up || condition == Down || condition == Move
+}
+
+// Evaluate checks an alert against the current price. Threshold alerts only fire when the
+// price crosses the threshold: if it was already past it when the alert was (re-)armed,
+// the price has to come back first, which resetReference reports so it can be recorded.
+func Evaluate(alert *models.PriceAlert, price float64) (fire, resetReference bool) {
+	ref := alert.ReferencePrice
+	switch alert.Condition {
+	case Above:
+		if price >= alert.Target {
+			return ref < alert.Target, false
+		}
+		return false, ref >= alert.Target
+	case Below:
+		if price <= alert.Target {
+			return ref > alert.Target, false
+		}
+		return false, ref <= alert.Target
+	case Up:
+		return price >= ref*(1+alert.Target/100), false
+	case Down:
+		return price <= ref*(1-alert.Target/100), false
+	case Move:
+		return price >= ref*(1+alert.Target/100) || price <= ref*(1-alert.Target/100), false
+	}
+	return false, false
+}
+
+// Describe explains what an alert waits for, such as "ETH above $4,000.00".
+func Describe(l i18n.Localizer, alert *models.PriceAlert) string {
+	symbol := strings.ToUpper(alert.CoinSymbol)
+	if isPercent(alert.Condition) {
+		return l.T("alerts.cond_"+alert.Condition, symbol, fmt.Sprintf("%g", alert.Target))
+	}
+	return l.T("alerts.cond_"+alert.Condition, symbol, web3.FormatMoney(alert.Currency, alert.Target))
+}
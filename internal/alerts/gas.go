@@ -0,0 +1,289 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+const gasRearmPrefix = "gasalert_rearm_"
+
+// HandleGasAlertCommand manages the chat's gas alerts:
+//
+//	/gasalert 15 [chain]   notify when the standard fee drops to 15 Gwei or below
+//	/gasalert              list the chat's gas alerts
+//	/gasalert off <id>     delete one
+func HandleGasAlertCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	fields := strings.Fields(strings.ToLower(message.CommandArguments()))
+
+	switch {
+	case len(fields) == 0:
+		listGasAlerts(bot, db, l, message)
+	case fields[0] == "off":
+		deleteGasAlert(bot, db, l, message, fields[1:])
+	default:
+		createGasAlert(bot, db, l, message, fields)
+	}
+}
+
+func createGasAlert(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, fields []string) {
+	threshold, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "gwei"), 64)
+	if err != nil || threshold <= 0 || len(fields) > 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.gas_usage")))
+		return
+	}
+	chainName := ""
+	if len(fields) == 2 {
+		chainName = fields[1]
+	}
+	chain, ok := web3.LookupChain(chainName)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", chainName, strings.Join(web3.ChainKeys(), ", "))))
+		return
+	}
+
+	existing, err := db.GetGasAlerts(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load gas alerts of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.load_error")))
+		return
+	}
+	if len(existing) >= maxAlertsPerChat {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.gas_limit", maxAlertsPerChat)))
+		return
+	}
+
+	gas, err := web3.FetchGasPrices(context.Background(), chain)
+	if err != nil {
+		log.Printf("Failed to fetch %s gas fees for alert: %v", chain.Name, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, web3.GasErrorText(l, err)))
+		return
+	}
+
+	alert := &models.GasAlert{
+		ChatID:        message.Chat.ID,
+		CreatedBy:     message.From.ID,
+		Chain:         chain.Key,
+		Threshold:     threshold,
+		ReferenceGwei: gas.Standard,
+		Active:        true,
+		CreatedAt:     time.Now(),
+	}
+	if err := db.AddGasAlert(context.Background(), alert); err != nil {
+		log.Printf("Failed to save gas alert: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.save_error")))
+		return
+	}
+	text := l.T("alerts.gas_created", alert.ID, describeGas(l, alert), web3.FormatGwei(gas.Standard))
+	// EvaluateGas waits for the fee to drop from above the threshold, so say why nothing happens yet.
+	if gas.Standard <= threshold {
+		text += "\n\n" + l.T("alerts.gas_already_below", web3.FormatGwei(threshold))
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+func listGasAlerts(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message) {
+	alerts, err := db.GetGasAlerts(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load gas alerts of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.load_error")))
+		return
+	}
+	if len(alerts) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.gas_none")+"\n\n"+l.T("alerts.gas_usage")))
+		return
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].ID < alerts[j].ID })
+
+	var sb strings.Builder
+	sb.WriteString(l.N("alerts.gas_list_header", len(alerts), len(alerts)))
+	sb.WriteString("\n")
+	for i := range alerts {
+		state := l.T("alerts.status_active")
+		if !alerts[i].Active {
+			state = l.T("alerts.status_triggered")
+		}
+		sb.WriteString("\n")
+		sb.WriteString(l.T("alerts.item", alerts[i].ID, describeGas(l, &alerts[i]), state))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(l.T("alerts.gas_usage"))
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, sb.String()))
+}
+
+func deleteGasAlert(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, args []string) {
+	if len(args) != 1 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.gas_usage")))
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.gas_usage")))
+		return
+	}
+
+	alert, err := db.GetGasAlert(context.Background(), id)
+	if err != nil {
+		log.Printf("Failed to load gas alert %d: %v", id, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.load_error")))
+		return
+	}
+	if alert == nil || alert.ChatID != message.Chat.ID {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.not_found", id)))
+		return
+	}
+	if !mayChange(bot, alert.CreatedBy, message.Chat, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.not_allowed")))
+		return
+	}
+
+	if err := db.RemoveGasAlert(context.Background(), id); err != nil {
+		log.Printf("Failed to delete gas alert %d: %v", id, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.save_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.deleted", id)))
+}
+
+// EvaluateGas checks a gas alert against the current standard fee. Like threshold price
+// alerts, it only fires when the fee drops from above the threshold: an alert set while the
+// fee is already below waits for it to rise above first, which createGasAlert tells the chat.
+func EvaluateGas(alert *models.GasAlert, gwei float64) (fire, resetReference bool) {
+	if gwei <= alert.Threshold {
+		return alert.ReferenceGwei > alert.Threshold, false
+	}
+	return false, alert.ReferenceGwei <= alert.Threshold
+}
+
+// pollGas evaluates every active gas alert, reading each chain's fees once.
+func pollGas(bot *tgbotapi.BotAPI, db database.Store) {
+	ctx := context.Background()
+	alerts, err := db.GetActiveGasAlerts(ctx)
+	if err != nil {
+		log.Printf("Failed to load active gas alerts: %v", err)
+		return
+	}
+
+	fees := make(map[string]*web3.GasPrices)
+	for i := range alerts {
+		alert := &alerts[i]
+		chain, ok := web3.LookupChain(alert.Chain)
+		if !ok {
+			continue
+		}
+
+		gas, ok := fees[chain.Key]
+		if !ok {
+			gas, err = web3.FetchGasPrices(ctx, chain)
+			if err != nil {
+				log.Printf("Failed to fetch %s gas fees for alerts: %v", chain.Name, err)
+			}
+			fees[chain.Key] = gas
+		}
+		if gas == nil {
+			continue
+		}
+
+		fire, resetReference := EvaluateGas(alert, gas.Standard)
+		switch {
+		case fire:
+			triggerGas(bot, db, alert, gas)
+		case resetReference:
+			alert.ReferenceGwei = gas.Standard
+			if err := db.UpdateGasAlert(ctx, alert); err != nil {
+				log.Printf("Failed to update reference fee of gas alert %d: %v", alert.ID, err)
+			}
+		}
+	}
+}
+
+// triggerGas marks a gas alert as fired and notifies its chat, saving first like trigger does.
+func triggerGas(bot *tgbotapi.BotAPI, db database.Store, alert *models.GasAlert, gas *web3.GasPrices) {
+	now := time.Now()
+	alert.Active = false
+	alert.TriggeredAt = &now
+	if err := db.UpdateGasAlert(context.Background(), alert); err != nil {
+		log.Printf("Failed to mark gas alert %d as triggered: %v", alert.ID, err)
+		return
+	}
+
+	l := i18n.ForGroup(db, alert.ChatID, nil)
+	msg := tgbotapi.NewMessage(alert.ChatID, l.T("alerts.gas_triggered", alert.ID, describeGas(l, alert), web3.FormatGwei(gas.Standard)))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(l.T("alerts.rearm_button"), fmt.Sprintf("%s%d", gasRearmPrefix, alert.ID)),
+	))
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("Failed to deliver gas alert %d to chat %d: %v", alert.ID, alert.ChatID, err)
+	}
+}
+
+// handleGasRearm re-arms a gas alert from its notification. It fires again the next time
+// the fee drops below the threshold after rising above it.
+func handleGasRearm(bot *tgbotapi.BotAPI, db database.Store, query *tgbotapi.CallbackQuery) {
+	l := i18n.For(db, query.Message.Chat, query.From)
+	id, err := strconv.ParseInt(strings.TrimPrefix(query.Data, gasRearmPrefix), 10, 64)
+	if err != nil {
+		bot.Request(tgbotapi.NewCallback(query.ID, ""))
+		return
+	}
+
+	alert, err := db.GetGasAlert(context.Background(), id)
+	if err != nil {
+		log.Printf("Failed to load gas alert %d: %v", id, err)
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.load_error")))
+		return
+	}
+	// Callback data can be forged, so the alert must belong to the chat the button was pressed in.
+	if alert == nil || alert.ChatID != query.Message.Chat.ID {
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.not_found", id)))
+		return
+	}
+	if !mayChange(bot, alert.CreatedBy, query.Message.Chat, query.From.ID) {
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.not_allowed")))
+		return
+	}
+
+	chain, ok := web3.LookupChain(alert.Chain)
+	if !ok {
+		// The chain was dropped from the bot since the alert was set.
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("web3.gas_unknown_chain", alert.Chain, strings.Join(web3.ChainKeys(), ", "))))
+		return
+	}
+	gas, err := web3.FetchGasPrices(context.Background(), chain)
+	if err != nil {
+		log.Printf("Failed to fetch %s gas fees to re-arm alert %d: %v", chain.Name, id, err)
+		bot.Request(tgbotapi.NewCallback(query.ID, web3.GasErrorText(l, err)))
+		return
+	}
+
+	alert.Active = true
+	alert.TriggeredAt = nil
+	alert.ReferenceGwei = gas.Standard
+	if err := db.UpdateGasAlert(context.Background(), alert); err != nil {
+		log.Printf("Failed to update gas alert %d: %v", id, err)
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.save_error")))
+		return
+	}
+	bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.rearmed")))
+	removeButtons(bot, query.Message)
+}
+
+// describeGas explains what a gas alert waits for, such as "Base gas at or below 0.05 Gwei".
+func describeGas(l i18n.Localizer, alert *models.GasAlert) string {
+	name := alert.Chain
+	if chain, ok := web3.LookupChain(alert.Chain); ok {
+		name = chain.Name
+	}
+	return l.T("alerts.gas_condition", name, web3.FormatGwei(alert.Threshold))
+}
@@ -0,0 +1,184 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/config"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+	"github.com/philip-857.bit/byb-bot/internal/web3/rpcfake"
+)
+
+// fakeGas points each chain at a fake node whose standard fee is the given Gwei, until the
+// test ends. A fee of 0 makes the chain's node fail. Fees are cached per chain for a while,
+// so each test uses chains of its own.
+func fakeGas(t *testing.T, fees map[string]float64) {
+	t.Helper()
+	previous := web3.Cfg
+	web3.Cfg = &config.Config{RPCURLs: make(map[string]string)}
+	t.Cleanup(func() { web3.Cfg = previous })
+
+	for chain, gwei := range fees {
+		node := rpcfake.New()
+		t.Cleanup(node.Close)
+		web3.Cfg.RPCURLs[chain] = node.URL
+		if gwei == 0 {
+			continue
+		}
+		wei := fmt.Sprintf("0x%x", int64(gwei*1e9))
+		node.Handle("eth_feeHistory", func([]json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{"baseFeePerGas": []string{wei}, "reward": [][]string{{"0x0", "0x0", "0x0"}}}, nil
+		})
+	}
+}
+
+func TestEvaluateGas(t *testing.T) {
+	tests := []struct {
+		name           string
+		reference, now float64
+		fire, reset    bool
+	}{
+		{"drops to the threshold", 30, 15, true, false},
+		{"drops below the threshold", 30, 10, true, false},
+		{"stays above", 30, 20, false, false},
+		{"set while below, still below", 10, 12, false, false},
+		{"set while below, rises above", 10, 20, false, true},
+	}
+	for _, tt := range tests {
+		alert := &models.GasAlert{Threshold: 15, ReferenceGwei: tt.reference}
+		if fire, reset := EvaluateGas(alert, tt.now); fire != tt.fire || reset != tt.reset {
+			t.Errorf("%s: EvaluateGas = %v, %v, want %v, %v", tt.name, fire, reset, tt.fire, tt.reset)
+		}
+	}
+}
+
+func TestGasAlertCommands(t *testing.T) {
+	fakeGas(t, map[string]float64{"base": 10, "arbitrum": 30})
+	_, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	group := tgfake.Group(-100)
+	creator := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	member := &tgbotapi.User{ID: 43, FirstName: "Bob"}
+
+	got := server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, creator, "/gasalert 15 base"))
+	if !strings.Contains(got, "Gas alert #1 set: Base gas at or below 15.0 Gwei") || !strings.Contains(got, "already at or below 15.0 Gwei") {
+		t.Errorf("a gas alert already met answered %q", got)
+	}
+	got = server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, creator, "/gasalert 20gwei arbitrum"))
+	if !strings.Contains(got, "Gas alert #2 set") || strings.Contains(got, "already") {
+		t.Errorf("/gasalert 20gwei arbitrum answered %q", got)
+	}
+	if a, _ := db.GetGasAlert(context.Background(), 2); a == nil || a.ReferenceGwei != 30 || a.Chain != "arbitrum" || !a.Active {
+		t.Errorf("stored alert = %+v", a)
+	}
+
+	for _, bad := range []string{"/gasalert 0", "/gasalert cheap", "/gasalert 15 base now"} {
+		if got := server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, creator, bad)); !strings.Contains(got, "Usage") {
+			t.Errorf("%s answered %q", bad, got)
+		}
+	}
+	if got := server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, creator, "/gasalert 15 solana")); !strings.Contains(got, "Unknown chain") {
+		t.Errorf("an unknown chain answered %q", got)
+	}
+
+	if got := server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, member, "/gasalert")); !strings.Contains(got, "2 gas alerts") || !strings.Contains(got, "#2") {
+		t.Errorf("/gasalert answered %q", got)
+	}
+	if got := server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, member, "/gasalert off 1")); !strings.Contains(got, "Only the member") {
+		t.Errorf("a member deleting someone's alert got %q", got)
+	}
+	if got := server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, creator, "/gasalert off #1")); !strings.Contains(got, "deleted") {
+		t.Errorf("deleting answered %q", got)
+	}
+	if got := server.Answer(t, HandleGasAlertCommand, db, tgfake.Command(group, creator, "/gasalert off 1")); !strings.Contains(got, "no alert #1") {
+		t.Errorf("deleting twice answered %q", got)
+	}
+}
+
+func TestGasAlertTriggersAndRearms(t *testing.T) {
+	fakeGas(t, map[string]float64{"optimism": 10, "polygon": 0})
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	ctx := context.Background()
+	group := tgfake.Group(-100)
+	creator := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	member := &tgbotapi.User{ID: 43, FirstName: "Bob"}
+
+	add := func(chain string, threshold, reference float64) *models.GasAlert {
+		alert := &models.GasAlert{ChatID: group.ID, CreatedBy: creator.ID, Chain: chain, Threshold: threshold, ReferenceGwei: reference, Active: true}
+		if err := db.AddGasAlert(ctx, alert); err != nil {
+			t.Fatal(err)
+		}
+		return alert
+	}
+	dropped := add("optimism", 15, 30)
+	alreadyBelow := add("optimism", 12, 11)
+	below := add("optimism", 5, 3)
+	broken := add("polygon", 15, 30)
+
+	pollGas(bot, db)
+	if texts := server.Texts(); len(texts) != 1 || !strings.Contains(texts[0], fmt.Sprintf("Gas alert #%d", dropped.ID)) {
+		t.Fatalf("polling sent %q, want only alert #%d", texts, dropped.ID)
+	}
+	if keyboard := server.Requests("sendMessage")[0].Params.Get("reply_markup"); !strings.Contains(keyboard, fmt.Sprintf("%s%d", gasRearmPrefix, dropped.ID)) {
+		t.Errorf("the notification's buttons are %s", keyboard)
+	}
+	if a, _ := db.GetGasAlert(ctx, dropped.ID); a.Active || a.TriggeredAt == nil {
+		t.Errorf("the fired alert = %+v", a)
+	}
+	if a, _ := db.GetGasAlert(ctx, alreadyBelow.ID); !a.Active || a.ReferenceGwei != 11 {
+		t.Errorf("the alert set while below = %+v, want it untouched", a)
+	}
+	// The fee rose above the threshold, so the alert now waits for it to drop back.
+	if a, _ := db.GetGasAlert(ctx, below.ID); !a.Active || a.ReferenceGwei != 10 {
+		t.Errorf("the alert whose fee rose = %+v, want its reference at 10", a)
+	}
+
+	press := func(from *tgbotapi.User, data string) string {
+		t.Helper()
+		server.Reset()
+		HandleCallbackQuery(bot, db, &tgbotapi.CallbackQuery{ID: "q", From: from, Data: data,
+			Message: &tgbotapi.Message{MessageID: 7, Chat: group}})
+		answers := server.Requests("answerCallbackQuery")
+		if len(answers) != 1 {
+			t.Fatalf("%s answered %d times", data, len(answers))
+		}
+		return answers[0].Params.Get("text")
+	}
+	rearm := func(id int64) string { return fmt.Sprintf("%s%d", gasRearmPrefix, id) }
+
+	if got := press(member, rearm(dropped.ID)); !strings.Contains(got, "Only the member") {
+		t.Errorf("a member re-arming answered %q", got)
+	}
+	if got := press(creator, rearm(dropped.ID)); !strings.Contains(got, "re-armed") {
+		t.Errorf("re-arming answered %q", got)
+	}
+	if a, _ := db.GetGasAlert(ctx, dropped.ID); !a.Active || a.TriggeredAt != nil || a.ReferenceGwei != 10 {
+		t.Errorf("the re-armed alert = %+v", a)
+	}
+	if got, want := press(creator, rearm(broken.ID)), web3.GasErrorText(i18n.New("en"), errors.New("down")); got != want {
+		t.Errorf("re-arming while the node is down answered %q, want %q", got, want)
+	}
+	if a, _ := db.GetGasAlert(ctx, broken.ID); a.ReferenceGwei != 30 {
+		t.Errorf("a failed re-arm changed the alert: %+v", a)
+	}
+
+	// Every press is answered, even one the bot can't act on.
+	gone := add("fantom", 15, 30)
+	if got := press(creator, rearm(gone.ID)); !strings.Contains(got, "Unknown chain 'fantom'") {
+		t.Errorf("re-arming on a dropped chain answered %q", got)
+	}
+	press(creator, gasRearmPrefix+"x")
+	if got := press(creator, rearm(999)); !strings.Contains(got, "no alert #999") {
+		t.Errorf("re-arming a missing alert answered %q", got)
+	}
+}
@@ -0,0 +1,272 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+const (
+	// maxAlertsPerChat keeps the poller's work and the /alerts list bounded.
+	maxAlertsPerChat = 25
+	// snoozeDuration is how long the snooze button silences an alert.
+	snoozeDuration = time.Hour
+
+	snoozePrefix = "alert_snooze_"
+	rearmPrefix  = "alert_rearm_"
+)
+
+// HandleAlertCommand creates a price alert for the chat:
+//
+//	/alert eth above 4000 [currency]
+//	/alert btc below 50000
+//	/alert sol up 10%   (also down and move, measured from the current price)
+func HandleAlertCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	args, currency := web3.SplitCurrency(strings.TrimSpace(message.CommandArguments()))
+	fields := strings.Fields(strings.ToLower(args))
+	cond := -1
+	for i, f := range fields {
+		switch f {
+		case Above, Below, Up, Down, Move:
+			cond = i
+		}
+	}
+	if cond < 1 || cond != len(fields)-2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.usage")))
+		return
+	}
+	coinName, condition := strings.Join(fields[:cond], " "), fields[cond]
+
+	value := strings.NewReplacer(",", "", "%", "", "$", "").Replace(fields[cond+1])
+	target, err := strconv.ParseFloat(value, 64)
+	if err != nil || target <= 0 || (isPercent(condition) && target >= 1000) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.invalid_value", fields[cond+1])))
+		return
+	}
+	if currency == "" {
+		currency = web3.ChatCurrency(db, message.Chat.ID)
+	}
+
+	existing, err := db.GetPriceAlerts(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load price alerts of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.load_error")))
+		return
+	}
+	if len(existing) >= maxAlertsPerChat {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.limit", maxAlertsPerChat)))
+		return
+	}
+
+	res := web3.Coins.Resolve(coinName)
+	if res.ID == "" {
+		if len(res.Suggestions) > 0 {
+			names := make([]string, len(res.Suggestions))
+			for i, c := range res.Suggestions {
+				names[i] = fmt.Sprintf("%s (%s)", c.Name, c.ID)
+			}
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.did_you_mean", coinName, strings.Join(names, ", "))))
+			return
+		}
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.price_not_found", coinName)))
+		return
+	}
+
+	// The current price is the reference that moves and threshold crossings are measured from.
+	coin, err := web3.Prices.Coin(context.Background(), res.ID)
+	if err != nil {
+		log.Printf("Failed to fetch price of %q for alert: %v", res.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, web3.PriceErrorText(l, coinName, err)))
+		return
+	}
+	quote, currency := coin.Quote(currency)
+
+	alert := &models.PriceAlert{
+		ChatID:         message.Chat.ID,
+		CreatedBy:      message.From.ID,
+		CoinID:         coin.ID,
+		CoinSymbol:     coin.Symbol,
+		Currency:       currency,
+		Condition:      condition,
+		Target:         target,
+		ReferencePrice: quote.Price,
+		Active:         true,
+		CreatedAt:      time.Now(),
+	}
+	if err := db.AddPriceAlert(context.Background(), alert); err != nil {
+		log.Printf("Failed to save price alert: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.save_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.created", alert.ID, Describe(l, alert), web3.FormatMoney(currency, quote.Price))))
+}
+
+// HandleAlertsCommand lists the chat's price alerts.
+func HandleAlertsCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	alerts, err := db.GetPriceAlerts(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load price alerts of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.load_error")))
+		return
+	}
+	if len(alerts) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.none")))
+		return
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].ID < alerts[j].ID })
+
+	var sb strings.Builder
+	sb.WriteString(l.N("alerts.list_header", len(alerts), len(alerts)))
+	sb.WriteString("\n")
+	for i := range alerts {
+		sb.WriteString("\n")
+		sb.WriteString(l.T("alerts.item", alerts[i].ID, Describe(l, &alerts[i]), status(l, &alerts[i])))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(l.T("alerts.delete_usage"))
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, sb.String()))
+}
+
+// HandleDelAlertCommand deletes one of the chat's price alerts by ID.
+func HandleDelAlertCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "#"), 10, 64)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.delete_usage")))
+		return
+	}
+
+	alert, err := db.GetPriceAlert(context.Background(), id)
+	if err != nil {
+		log.Printf("Failed to load price alert %d: %v", id, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.load_error")))
+		return
+	}
+	if alert == nil || alert.ChatID != message.Chat.ID {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.not_found", id)))
+		return
+	}
+	if !mayChange(bot, alert.CreatedBy, message.Chat, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.not_allowed")))
+		return
+	}
+
+	if err := db.RemovePriceAlert(context.Background(), id); err != nil {
+		log.Printf("Failed to delete price alert %d: %v", id, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.save_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("alerts.deleted", id)))
+}
+
+// IsCallback reports whether callback data belongs to this package.
+func IsCallback(data string) bool {
+	return strings.HasPrefix(data, snoozePrefix) || strings.HasPrefix(data, rearmPrefix) || strings.HasPrefix(data, gasRearmPrefix)
+}
+
+// HandleCallbackQuery handles the snooze and re-arm buttons under a triggered alert.
+// Snoozing brings the alert back in an hour if its condition still holds; re-arming
+// measures it from the current price again, so it fires on the next crossing or move.
+func HandleCallbackQuery(bot *tgbotapi.BotAPI, db database.Store, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		return
+	}
+	if strings.HasPrefix(query.Data, gasRearmPrefix) {
+		handleGasRearm(bot, db, query)
+		return
+	}
+	l := i18n.For(db, query.Message.Chat, query.From)
+
+	snooze := strings.HasPrefix(query.Data, snoozePrefix)
+	raw := strings.TrimPrefix(strings.TrimPrefix(query.Data, snoozePrefix), rearmPrefix)
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return
+	}
+
+	alert, err := db.GetPriceAlert(context.Background(), id)
+	if err != nil {
+		log.Printf("Failed to load price alert %d: %v", id, err)
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.load_error")))
+		return
+	}
+	// Callback data can be forged, so the alert must belong to the chat the button was pressed in.
+	if alert == nil || alert.ChatID != query.Message.Chat.ID {
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.not_found", id)))
+		return
+	}
+	if !mayChange(bot, alert.CreatedBy, query.Message.Chat, query.From.ID) {
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.not_allowed")))
+		return
+	}
+
+	answer := l.T("alerts.rearmed")
+	if snooze {
+		until := time.Now().Add(snoozeDuration)
+		alert.SnoozedUntil = &until
+		answer = l.T("alerts.snoozed")
+	} else {
+		coin, err := web3.Prices.Coin(context.Background(), alert.CoinID)
+		if err != nil {
+			log.Printf("Failed to fetch price of %q to re-arm alert %d: %v", alert.CoinID, id, err)
+			bot.Request(tgbotapi.NewCallback(query.ID, web3.PriceErrorText(l, alert.CoinID, err)))
+			return
+		}
+		quote, _ := coin.Quote(alert.Currency)
+		alert.ReferencePrice = quote.Price
+		alert.SnoozedUntil = nil
+	}
+	alert.Active = true
+	alert.TriggeredAt = nil
+
+	if err := db.UpdatePriceAlert(context.Background(), alert); err != nil {
+		log.Printf("Failed to update price alert %d: %v", id, err)
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("alerts.save_error")))
+		return
+	}
+	bot.Request(tgbotapi.NewCallback(query.ID, answer))
+	removeButtons(bot, query.Message)
+}
+
+// removeButtons drops the buttons under an alert, so it can't be snoozed or re-armed twice from the same message.
+func removeButtons(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	bot.Request(tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+}
+
+// mayChange reports whether a user may delete, snooze or re-arm an alert of a chat. Alerts
+// belong to whoever created them, and group alerts to the admins too.
+func mayChange(bot *tgbotapi.BotAPI, createdBy int64, chat *tgbotapi.Chat, userID int64) bool {
+	if createdBy == userID {
+		return true
+	}
+	return !chat.IsPrivate() && moderation.IsUserAdmin(bot, chat.ID, userID)
+}
+
+// status tells whether an alert is waiting, snoozed or has fired.
+func status(l i18n.Localizer, alert *models.PriceAlert) string {
+	switch {
+	case !alert.Active:
+		return l.T("alerts.status_triggered")
+	case alert.SnoozedUntil != nil && alert.SnoozedUntil.After(time.Now()):
+		return l.T("alerts.status_snoozed", alert.SnoozedUntil.UTC().Format("15:04 UTC"))
+	default:
+		return l.T("alerts.status_active")
+	}
+}
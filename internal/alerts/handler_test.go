@@ -0,0 +1,121 @@
+package alerts
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+	"github.com/philip-857.bit/byb-bot/internal/web3/pricefake"
+)
+
+func TestAlertCommands(t *testing.T) {
+	pricefake.Serve(t, pricefake.Ether)
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	ctx := context.Background()
+	group, other := tgfake.Group(-100), tgfake.Group(-200)
+	creator := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	member := &tgbotapi.User{ID: 43, FirstName: "Bob"}
+	admin := &tgbotapi.User{ID: 44, FirstName: "Cy"}
+	server.SetStatus(group.ID, admin.ID, "administrator")
+
+	HandleAlertsCommand(bot, db, tgfake.Command(group, creator, "/alerts"))
+	if text := server.LastText(t); !strings.Contains(text, "no price alerts") {
+		t.Errorf("/alerts without alerts answered:\n%s", text)
+	}
+
+	HandleAlertCommand(bot, db, tgfake.Command(group, creator, "/alert eth above 3000"))
+	if text := server.LastText(t); !strings.Contains(text, "Alert #1 set") || !strings.Contains(text, "$2,000") {
+		t.Errorf("/alert answered:\n%s", text)
+	}
+	alerts, err := db.GetPriceAlerts(ctx, group.ID)
+	if err != nil || len(alerts) != 1 {
+		t.Fatalf("GetPriceAlerts = %v, %v", alerts, err)
+	}
+	if a := alerts[0]; a.CoinID != "ethereum" || a.Condition != Above || a.Target != 3000 || a.ReferencePrice != 2000 || a.CreatedBy != creator.ID {
+		t.Errorf("stored alert = %+v", a)
+	}
+	HandleAlertCommand(bot, db, tgfake.Command(group, creator, "/alert eth sideways 3000"))
+	if text := server.LastText(t); !strings.Contains(text, "Usage") {
+		t.Errorf("/alert with an unknown condition answered:\n%s", text)
+	}
+
+	HandleAlertsCommand(bot, db, tgfake.Command(group, member, "/alerts"))
+	if text := server.LastText(t); !strings.Contains(text, "#1") {
+		t.Errorf("/alerts answered:\n%s", text)
+	}
+	HandleAlertsCommand(bot, db, tgfake.Command(other, member, "/alerts"))
+	if text := server.LastText(t); !strings.Contains(text, "no price alerts") {
+		t.Errorf("/alerts of another chat answered:\n%s", text)
+	}
+
+	// Only the creator and the admins may delete an alert, and only from its own chat.
+	HandleDelAlertCommand(bot, db, tgfake.Command(other, creator, "/delalert 1"))
+	if text := server.LastText(t); !strings.Contains(text, "no alert #1") {
+		t.Errorf("deleting from another chat answered:\n%s", text)
+	}
+	HandleDelAlertCommand(bot, db, tgfake.Command(group, member, "/delalert 1"))
+	if text := server.LastText(t); !strings.Contains(text, "Only the member who created") {
+		t.Errorf("a member deleting another's alert got:\n%s", text)
+	}
+	HandleDelAlertCommand(bot, db, tgfake.Command(group, admin, "/delalert #1"))
+	if text := server.LastText(t); !strings.Contains(text, "Alert #1 deleted") {
+		t.Errorf("an admin deleting an alert got:\n%s", text)
+	}
+	if alerts, _ := db.GetPriceAlerts(ctx, group.ID); len(alerts) != 0 {
+		t.Errorf("%d alerts left after deleting", len(alerts))
+	}
+}
+
+func TestAlertCallbacks(t *testing.T) {
+	pricefake.Serve(t, pricefake.Ether)
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	ctx := context.Background()
+	group, other := tgfake.Group(-100), tgfake.Group(-200)
+	creator := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+
+	HandleAlertCommand(bot, db, tgfake.Command(group, creator, "/alert eth up 10%"))
+	alert, err := db.GetPriceAlert(ctx, 1)
+	if err != nil || alert == nil {
+		t.Fatalf("GetPriceAlert = %v, %v", alert, err)
+	}
+	alert.Active, alert.ReferencePrice = false, 1500
+	db.UpdatePriceAlert(ctx, alert)
+
+	press := func(chat *tgbotapi.Chat, data string) string {
+		server.Reset()
+		HandleCallbackQuery(bot, db, &tgbotapi.CallbackQuery{ID: "q", From: creator, Data: data,
+			Message: &tgbotapi.Message{MessageID: 7, Chat: chat}})
+		answers := server.Requests("answerCallbackQuery")
+		if len(answers) != 1 {
+			t.Fatalf("%s answered %d times", data, len(answers))
+		}
+		return answers[0].Params.Get("text")
+	}
+
+	// A button forged in another chat doesn't touch the alert.
+	if answer := press(other, rearmPrefix+"1"); !strings.Contains(answer, "no alert #1") {
+		t.Errorf("re-arming from another chat answered %q", answer)
+	}
+	if alert, _ := db.GetPriceAlert(ctx, 1); alert.Active {
+		t.Error("an alert was re-armed from another chat")
+	}
+
+	press(group, rearmPrefix+"1")
+	alert, _ = db.GetPriceAlert(ctx, 1)
+	if !alert.Active || alert.ReferencePrice != 2000 {
+		t.Errorf("re-armed alert = %+v, want it active from the current price", alert)
+	}
+	if len(server.Requests("editMessageReplyMarkup")) != 1 {
+		t.Error("the buttons weren't removed after re-arming")
+	}
+
+	press(group, snoozePrefix+"1")
+	if alert, _ := db.GetPriceAlert(ctx, 1); alert.SnoozedUntil == nil {
+		t.Error("snoozing didn't set SnoozedUntil")
+	}
+}
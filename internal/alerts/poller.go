@@ -0,0 +1,95 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+// pollInterval matches the price cache TTL, so every poll sees fresh prices and
+// lookups from commands in between are served from the cache.
+const pollInterval = time.Minute
+
+// RunPoller checks the active price and gas alerts every pollInterval, forever. Start it once on startup.
+func RunPoller(bot *tgbotapi.BotAPI, db database.Store) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		poll(bot, db)
+		pollGas(bot, db)
+	}
+}
+
+// poll evaluates every active alert, looking each coin up once through the shared price cache.
+func poll(bot *tgbotapi.BotAPI, db database.Store) {
+	ctx := context.Background()
+	alerts, err := db.GetActivePriceAlerts(ctx)
+	if err != nil {
+		log.Printf("Failed to load active price alerts: %v", err)
+		return
+	}
+
+	coins := make(map[string]*web3.Coin)
+	now := time.Now()
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.SnoozedUntil != nil && alert.SnoozedUntil.After(now) {
+			continue
+		}
+
+		coin, ok := coins[alert.CoinID]
+		if !ok {
+			coin, err = web3.Prices.Coin(ctx, alert.CoinID)
+			if err != nil {
+				log.Printf("Failed to fetch price of %q for alerts: %v", alert.CoinID, err)
+			}
+			// Remember failures too, so one bad coin costs one lookup per poll.
+			coins[alert.CoinID] = coin
+		}
+		if coin == nil {
+			continue
+		}
+
+		quote, _ := coin.Quote(alert.Currency)
+		fire, resetReference := Evaluate(alert, quote.Price)
+		switch {
+		case fire:
+			trigger(bot, db, alert, quote.Price)
+		case resetReference:
+			alert.ReferencePrice = quote.Price
+			if err := db.UpdatePriceAlert(ctx, alert); err != nil {
+				log.Printf("Failed to update reference price of alert %d: %v", alert.ID, err)
+			}
+		}
+	}
+}
+
+// trigger marks an alert as fired and notifies its chat. The alert is saved first, so a
+// failed save can't turn into the same notification every minute.
+func trigger(bot *tgbotapi.BotAPI, db database.Store, alert *models.PriceAlert, price float64) {
+	now := time.Now()
+	alert.Active = false
+	alert.TriggeredAt = &now
+	alert.SnoozedUntil = nil
+	if err := db.UpdatePriceAlert(context.Background(), alert); err != nil {
+		log.Printf("Failed to mark price alert %d as triggered: %v", alert.ID, err)
+		return
+	}
+
+	l := i18n.ForGroup(db, alert.ChatID, nil)
+	msg := tgbotapi.NewMessage(alert.ChatID, l.T("alerts.triggered", alert.ID, Describe(l, alert), web3.FormatMoney(alert.Currency, price)))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(l.T("alerts.snooze_button"), fmt.Sprintf("%s%d", snoozePrefix, alert.ID)),
+		tgbotapi.NewInlineKeyboardButtonData(l.T("alerts.rearm_button"), fmt.Sprintf("%s%d", rearmPrefix, alert.ID)),
+	))
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("Failed to deliver price alert %d to chat %d: %v", alert.ID, alert.ChatID, err)
+	}
+}
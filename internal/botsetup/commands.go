@@ -2,58 +2,83 @@ package botsetup
 
 import (
 	"log"
+	"slices"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
 )
 
-// SetDefaultCommands sets the general commands visible to all users in private chats.
-func SetDefaultCommands(bot *tgbotapi.BotAPI) {
-	userCommands := []tgbotapi.BotCommand{
-		{Command: "start", Description: "Welcome message"},
-		{Command: "rules", Description: "Show community rules"},
-		{Command: "help", Description: "Show this help message"},
-		{Command: "price", Description: "Get cryptocurrency price"},
-		{Command: "p", Description: "Alias for /price"}, // Added /p alias
-		{Command: "gas", Description: "Get current Ethereum gas fees"},
-	}
+// privateCommands are shown to everyone in private chats. Each is described by the
+// "commands.<name>" catalog key.
+var privateCommands = []string{
+	"start", "rules", "help", "lang", "price", "p", "chart", "convert", "trending", "movers",
+	"wallet", "tx", "token", "nft", "nfts", "linkwallet", "verifywallet", "profile", "unlink",
+	"portfolio", "gas", "alert", "alerts", "delalert", "gasalert", "watch", "digest", "currency",
+}
 
-	config := tgbotapi.NewSetMyCommands(userCommands...)
-	if _, err := bot.Request(config); err != nil {
-		log.Printf("Failed to set default bot commands: %v", err)
-	}
+// groupCommands are shown to every member of a group.
+var groupCommands = []string{
+	"rules", "rulehistory", "help", "lang", "price", "p", "chart", "convert", "trending", "movers",
+	"wallet", "tx", "token", "nft", "nfts", "gas", "alert", "alerts", "delalert", "gasalert",
+	"watch", "digest", "filters", "invite", "profile", "note", "notes",
 }
 
-// SetGroupCommands sets specific commands for a group, with different lists for users and admins.
-func SetGroupCommands(bot *tgbotapi.BotAPI, chatID int64) {
-	// Commands for regular users in the group
-	userCommands := []tgbotapi.BotCommand{
-		{Command: "rules", Description: "Show community rules"},
-		{Command: "help", Description: "Show this help message"},
-		{Command: "price", Description: "Get cryptocurrency price"},
-		{Command: "p", Description: "Alias for /price"}, // Added /p alias
-		{Command: "gas", Description: "Get current Ethereum gas fees"},
+// adminCommands are shown to group admins after groupCommands. They are described by the
+// "commands.admin.<name>" catalog key.
+var adminCommands = []string{
+	"warn", "mute", "setup", "setrules", "requirerules", "tokengate", "save", "delnote",
+	"filter", "stop", "currency",
+}
+
+// botCommands describes commands in a language, using keyPrefix+name as the catalog key.
+func botCommands(l i18n.Localizer, keyPrefix string, names []string) []tgbotapi.BotCommand {
+	commands := make([]tgbotapi.BotCommand, 0, len(names))
+	for _, name := range names {
+		commands = append(commands, tgbotapi.BotCommand{Command: name, Description: l.T(keyPrefix + name)})
 	}
-	userScope := tgbotapi.NewBotCommandScopeChat(chatID)
-	userConfig := tgbotapi.NewSetMyCommandsWithScope(userScope, userCommands...)
-	if _, err := bot.Request(userConfig); err != nil {
-		log.Printf("Failed to set user commands for chat %d: %v", chatID, err)
+	return commands
+}
+
+// languageCode is the language_code a command list is registered under. The default
+// language's list is registered without one, so it's shown to users of every other language.
+func languageCode(lang string) string {
+	if lang == i18n.DefaultLanguage {
+		return ""
 	}
+	return lang
+}
 
-	// Commands for admins in the group (includes all user commands + admin commands)
-	adminCommands := []tgbotapi.BotCommand{
-		{Command: "rules", Description: "Show community rules"},
-		{Command: "help", Description: "Show this help message"},
-		{Command: "price", Description: "Get cryptocurrency price"},
-		{Command: "p", Description: "Alias for /price"}, // Added /p alias
-		{Command: "gas", Description: "Get current Ethereum gas fees"},
-		{Command: "warn", Description: "(Admin) Warn a user"},
-		{Command: "mute", Description: "(Admin) Mute a user"},
-		{Command: "setup", Description: "(Admin) Refresh bot commands"},
+// SetDefaultCommands sets the general commands visible to all users in private chats,
+// in every supported language.
+func SetDefaultCommands(bot *tgbotapi.BotAPI) {
+	for _, lang := range i18n.Supported() {
+		config := tgbotapi.NewSetMyCommands(botCommands(i18n.New(lang), "commands.", privateCommands)...)
+		config.LanguageCode = languageCode(lang)
+		if _, err := bot.Request(config); err != nil {
+			log.Printf("Failed to set default bot commands in %s: %v", lang, err)
+		}
 	}
-	adminScope := tgbotapi.NewBotCommandScopeChatAdministrators(chatID)
-	adminConfig := tgbotapi.NewSetMyCommandsWithScope(adminScope, adminCommands...)
-	if _, err := bot.Request(adminConfig); err != nil {
-		log.Printf("Failed to set admin commands for chat %d: %v", chatID, err)
+}
+
+// SetGroupCommands sets specific commands for a group, with different lists for users and admins,
+// in every supported language.
+func SetGroupCommands(bot *tgbotapi.BotAPI, chatID int64) {
+	for _, lang := range i18n.Supported() {
+		l := i18n.New(lang)
+		userCommands := botCommands(l, "commands.", groupCommands)
+		userScope := tgbotapi.NewBotCommandScopeChat(chatID)
+		userConfig := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(userScope, languageCode(lang), userCommands...)
+		if _, err := bot.Request(userConfig); err != nil {
+			log.Printf("Failed to set user commands in %s for chat %d: %v", lang, chatID, err)
+		}
+
+		// Admins see all user commands followed by the admin commands.
+		adminList := slices.Concat(userCommands, botCommands(l, "commands.admin.", adminCommands))
+		adminScope := tgbotapi.NewBotCommandScopeChatAdministrators(chatID)
+		adminConfig := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(adminScope, languageCode(lang), adminList...)
+		if _, err := bot.Request(adminConfig); err != nil {
+			log.Printf("Failed to set admin commands in %s for chat %d: %v", lang, chatID, err)
+		}
 	}
 
 	log.Printf("Successfully set commands for group %d", chatID)
@@ -0,0 +1,85 @@
+package botsetup
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+// registered decodes the command lists set so far, keyed by scope type and language code.
+func registered(t *testing.T, server *tgfake.Server) map[string][]tgbotapi.BotCommand {
+	t.Helper()
+	lists := make(map[string][]tgbotapi.BotCommand)
+	for _, r := range server.Requests("setMyCommands") {
+		var commands []tgbotapi.BotCommand
+		if err := json.Unmarshal([]byte(r.Params.Get("commands")), &commands); err != nil {
+			t.Fatal(err)
+		}
+		scope := "default"
+		if s := r.Params.Get("scope"); s != "" {
+			var decoded tgbotapi.BotCommandScope
+			if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			scope = decoded.Type
+		}
+		lists[scope+"/"+r.Params.Get("language_code")] = commands
+	}
+	return lists
+}
+
+func description(commands []tgbotapi.BotCommand, name string) string {
+	for _, c := range commands {
+		if c.Command == name {
+			return c.Description
+		}
+	}
+	return ""
+}
+
+func TestSetDefaultCommands(t *testing.T) {
+	bot, server := tgfake.Bot(t)
+	SetDefaultCommands(bot)
+
+	lists := registered(t, server)
+	if len(lists) != 3 {
+		t.Fatalf("registered %d lists, want one per language", len(lists))
+	}
+	for key, want := range map[string]string{
+		"default/":   "Get cryptocurrency price",
+		"default/es": "Ver el precio de una criptomoneda",
+		"default/fr": "Obtenir le prix d'une cryptomonnaie",
+	} {
+		if got := description(lists[key], "price"); got != want {
+			t.Errorf("%s /price = %q, want %q", key, got, want)
+		}
+		for _, c := range lists[key] {
+			if strings.HasPrefix(c.Description, "commands.") {
+				t.Errorf("%s /%s isn't translated", key, c.Command)
+			}
+		}
+	}
+}
+
+func TestSetGroupCommands(t *testing.T) {
+	bot, server := tgfake.Bot(t)
+	SetGroupCommands(bot, -100)
+
+	lists := registered(t, server)
+	users, admins := lists["chat/es"], lists["chat_administrators/es"]
+	if len(users) != len(groupCommands) || len(admins) != len(groupCommands)+len(adminCommands) {
+		t.Fatalf("Spanish lists have %d and %d commands", len(users), len(admins))
+	}
+	if got := description(users, "currency"); got != "" {
+		t.Errorf("members see /currency: %q", got)
+	}
+	if got := description(admins, "currency"); got != "(Admin) Elegir la moneda de los precios del chat" {
+		t.Errorf("admin /currency = %q", got)
+	}
+	if got := description(lists["chat_administrators/"], "warn"); got != "(Admin) Warn a user" {
+		t.Errorf("English admin /warn = %q", got)
+	}
+}
@@ -11,30 +11,80 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/deeplink"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
 	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/rules"
+	"github.com/philip-857.bit/byb-bot/internal/tokengate"
+	"github.com/philip-857.bit/byb-bot/internal/wallets"
 )
 
+// pendingKey identifies a member who still has to verify in a specific chat.
+type pendingKey struct {
+	chatID int64
+	userID int64
+}
+
+// pendingVerification tracks a member who hasn't passed the captcha yet.
+type pendingVerification struct {
+	messageID    int // The verification message, deleted once the member verifies or is kicked
+	rulesVersion int // The rules version the member accepts by verifying, or -1 if the chat doesn't require it
+}
+
 var (
-	pendingUsers          = make(map[int64]bool)
+	pendingUsers          = make(map[pendingKey]pendingVerification)
 	lastWelcomeMessageIDs = make(map[int64]int) // Tracks the last welcome message ID per chat
 	mu                    sync.Mutex
 )
 
 const captchaTimeout = 2 * time.Minute
 
+// gatedCaptchaTimeout leaves members of token-gated chats time to link a wallet before verifying.
+const gatedCaptchaTimeout = 15 * time.Minute
+
 // HandleNewMember sends a verification message with a button.
-func HandleNewMember(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
+// If the chat requires it, the message also shows the current rules and clicking the button accepts them.
+func HandleNewMember(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	chatRules := rulesToAccept(db, message.Chat.ID)
+	gate, err := db.GetTokenGate(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load token gate of chat %d: %v", message.Chat.ID, err)
+	}
+	timeout := captchaTimeout
+	if gate != nil {
+		timeout = gatedCaptchaTimeout
+	}
+
 	for _, user := range message.NewChatMembers {
 		if user.IsBot {
 			continue
 		}
 
-		verifyButton := tgbotapi.NewInlineKeyboardButtonData("✅ Click here to verify", fmt.Sprintf("verify_%d", user.ID))
+		l := i18n.For(db, message.Chat, &user)
+		minutes := int(timeout / time.Minute)
+		question := l.N("captcha.question", minutes, user.FirstName, minutes)
+		if gate != nil {
+			question += "\n\n" + l.T("captcha.token_gate", gate.Symbol)
+		}
+
+		buttonText := l.T("captcha.verify_button")
+		rulesVersion := -1
+		if chatRules != nil {
+			question = fmt.Sprintf("%s\n\n%s\n\n%s", question, rules.PlainText(l, chatRules), l.T("captcha.accept_rules"))
+			buttonText = l.T("captcha.accept_button")
+			rulesVersion = chatRules.Version
+		}
+
+		verifyButton := tgbotapi.NewInlineKeyboardButtonData(buttonText, fmt.Sprintf("verify_%d", user.ID))
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(verifyButton),
 		)
+		// Members can also verify in private chat, which keeps working if the group message scrolls away.
+		if link, err := deeplink.URL(bot, deeplink.ActionVerify, message.Chat.ID, user.ID); err == nil {
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard,
+				tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(l.T("captcha.verify_private_button"), link)))
+		}
 
-		question := fmt.Sprintf("Welcome, %s! Please click the button below to prove you're human and join the community.", user.FirstName)
 		msg := tgbotapi.NewMessage(message.Chat.ID, question)
 		msg.ReplyMarkup = keyboard
 
@@ -45,15 +95,18 @@ func HandleNewMember(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotap
 		}
 
 		mu.Lock()
-		pendingUsers[user.ID] = true
+		pendingUsers[pendingKey{message.Chat.ID, user.ID}] = pendingVerification{
+			messageID:    sentMsg.MessageID,
+			rulesVersion: rulesVersion,
+		}
 		mu.Unlock()
 
-		go kickUnverifiedUser(bot, db, message.Chat.ID, user.ID, sentMsg.MessageID)
+		go kickUnverifiedUser(bot, db, message.Chat.ID, user.ID, timeout)
 	}
 }
 
 // HandleCallbackQuery processes the button click from the verification message.
-func HandleCallbackQuery(bot *tgbotapi.BotAPI, db *database.Client, query *tgbotapi.CallbackQuery) {
+func HandleCallbackQuery(bot *tgbotapi.BotAPI, db database.Store, query *tgbotapi.CallbackQuery) {
 	fromUser := query.From
 	callbackData := query.Data
 
@@ -63,63 +116,166 @@ func HandleCallbackQuery(bot *tgbotapi.BotAPI, db *database.Client, query *tgbot
 	}
 
 	targetUserID, _ := strconv.ParseInt(parts[1], 10, 64)
+	l := i18n.For(db, query.Message.Chat, fromUser)
 
 	if fromUser.ID != targetUserID {
-		callback := tgbotapi.NewCallback(query.ID, "This is not your verification button.")
+		callback := tgbotapi.NewCallback(query.ID, l.T("captcha.not_your_button"))
+		bot.Request(callback)
+		return
+	}
+
+	if !admitted(db, query.Message.Chat.ID, fromUser.ID) {
+		// Opening the bot with the wallet link explains what to hold and how to link it.
+		callback := tgbotapi.NewCallback(query.ID, "")
+		if link, err := tokengate.LinkURL(bot, query.Message.Chat.ID); err == nil {
+			callback.URL = link
+		}
 		bot.Request(callback)
 		return
 	}
 
+	if completeVerification(bot, db, l, query.Message.Chat.ID, fromUser) {
+		callback := tgbotapi.NewCallback(query.ID, l.T("captcha.success"))
+		bot.Request(callback)
+	}
+}
+
+// HandleVerifyDeepLink completes a verification from the private chat link on the verification message.
+func HandleVerifyDeepLink(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message, args []int64) {
+	l := i18n.ForMessage(db, message)
+	if len(args) != 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("deeplink.invalid")))
+		return
+	}
+	chatID, userID := args[0], args[1]
+
+	if message.From.ID != userID {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("captcha.not_your_button")))
+		return
+	}
+
+	if !admitted(db, chatID, userID) {
+		wallets.HandleWalletDeepLink(bot, db, message, []int64{chatID})
+		return
+	}
+
+	// The welcome message goes to the group, so it uses the group's language rather than the private chat's.
+	groupLang := i18n.ForGroup(db, chatID, message.From)
+	if !completeVerification(bot, db, groupLang, chatID, message.From) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("captcha.nothing_pending")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("captcha.verified_private")))
+}
+
+// HandleWalletLinked completes the pending verification of a member who linked a wallet holding
+// enough for a token-gated chat.
+func HandleWalletLinked(bot *tgbotapi.BotAPI, db database.Store, user *tgbotapi.User, chatID int64) {
 	mu.Lock()
-	isPending, exists := pendingUsers[fromUser.ID]
+	_, pending := pendingUsers[pendingKey{chatID, user.ID}]
 	mu.Unlock()
+	if chatID == 0 || !pending || !admitted(db, chatID, user.ID) {
+		return
+	}
 
-	if exists && isPending {
-		log.Printf("User %s (%d) passed button verification", fromUser.FirstName, fromUser.ID)
+	if completeVerification(bot, db, i18n.ForGroup(db, chatID, user), chatID, user) {
+		l := i18n.For(db, nil, user)
+		bot.Send(tgbotapi.NewMessage(user.ID, l.T("captcha.verified_private")))
+	}
+}
 
-		deleteMsg := tgbotapi.NewDeleteMessage(query.Message.Chat.ID, query.Message.MessageID)
-		bot.Request(deleteMsg)
+// admitted reports whether a member may verify in a chat: always, unless the chat is token-gated
+// and their linked wallets don't hold enough. Like the rules, a gate that can't be loaded is
+// skipped, but holdings that can't be checked keep the member waiting.
+func admitted(db database.Store, chatID, userID int64) bool {
+	gate, ok, err := tokengate.Admit(context.Background(), db, chatID, userID)
+	if err != nil {
+		log.Printf("Failed to check token gate of chat %d for user %d: %v", chatID, userID, err)
+	}
+	return gate == nil || ok
+}
 
-		mu.Lock()
-		delete(pendingUsers, fromUser.ID)
-		mu.Unlock()
+// completeVerification marks a pending member as verified, stores them and welcomes them in the group.
+// It reports false if the member had no pending verification in the chat.
+func completeVerification(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, chatID int64, user *tgbotapi.User) bool {
+	key := pendingKey{chatID, user.ID}
 
-		newUser := models.User{
-			TelegramID: fromUser.ID,
-			FirstName:  fromUser.FirstName,
-			LastName:   fromUser.LastName,
-			Username:   fromUser.UserName,
-		}
-		if err := db.AddUser(context.Background(), &newUser); err != nil {
-			log.Printf("Failed to add user to DB: %v", err)
-		}
+	mu.Lock()
+	pending, exists := pendingUsers[key]
+	delete(pendingUsers, key)
+	mu.Unlock()
 
-		sendWelcomeMessage(bot, query.Message.Chat.ID, fromUser.FirstName)
+	if !exists {
+		return false
+	}
+	log.Printf("User %s (%d) passed button verification", user.FirstName, user.ID)
 
-		callback := tgbotapi.NewCallback(query.ID, "Verification successful!")
-		bot.Request(callback)
+	deleteMsg := tgbotapi.NewDeleteMessage(chatID, pending.messageID)
+	bot.Request(deleteMsg)
+
+	newUser := models.User{
+		TelegramID: user.ID,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Username:   user.UserName,
+	}
+	if err := db.AddUser(context.Background(), &newUser); err != nil {
+		log.Printf("Failed to add user to DB: %v", err)
+	}
+
+	// Verifying also accepts the rules the member was shown, if the chat requires it.
+	if pending.rulesVersion >= 0 {
+		if err := rules.Accept(db, chatID, user.ID, pending.rulesVersion); err != nil {
+			log.Printf("Failed to record rules acceptance: %v", err)
+		}
 	}
+
+	sendWelcomeMessage(bot, l, chatID, user.FirstName)
+	return true
 }
 
 // HandleLeavingMember remains the same.
-func HandleLeavingMember(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
+func HandleLeavingMember(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
 	leftUser := message.LeftChatMember
 	if leftUser != nil {
 		err := db.RemoveUser(context.Background(), leftUser.ID)
 		if err != nil {
 			log.Printf("Failed to remove user %d from DB: %v", leftUser.ID, err)
 		}
+		if err := db.RemoveTokenGateMember(context.Background(), message.Chat.ID, leftUser.ID); err != nil {
+			log.Printf("Failed to forget token gate member %d of chat %d: %v", leftUser.ID, message.Chat.ID, err)
+		}
 	}
 }
 
+// rulesToAccept returns the rules new members of a chat must accept, or nil if the chat doesn't require it.
+func rulesToAccept(db database.Store, chatID int64) *models.RulesVersion {
+	settings, err := db.GetChatSettings(context.Background(), chatID)
+	if err != nil {
+		log.Printf("Failed to load settings for chat %d: %v", chatID, err)
+		return nil
+	}
+	if !settings.RequireRulesAcceptance {
+		return nil
+	}
+
+	chatRules, err := rules.Current(db, chatID)
+	if err != nil {
+		log.Printf("Failed to load rules for chat %d: %v", chatID, err)
+		return nil
+	}
+	return chatRules
+}
+
 // kickUnverifiedUser kicks a user if they don't click the button in time.
-func kickUnverifiedUser(bot *tgbotapi.BotAPI, db *database.Client, chatID int64, userID int64, captchaMsgID int) {
-	time.Sleep(captchaTimeout)
+func kickUnverifiedUser(bot *tgbotapi.BotAPI, db database.Store, chatID int64, userID int64, timeout time.Duration) {
+	time.Sleep(timeout)
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	if _, stillPending := pendingUsers[userID]; stillPending {
+	key := pendingKey{chatID, userID}
+	if pending, stillPending := pendingUsers[key]; stillPending {
 		log.Printf("Kicking user %d for failing to verify", userID)
 		kickConfig := tgbotapi.KickChatMemberConfig{
 			ChatMemberConfig: tgbotapi.ChatMemberConfig{ChatID: chatID, UserID: userID},
@@ -127,41 +283,27 @@ func kickUnverifiedUser(bot *tgbotapi.BotAPI, db *database.Client, chatID int64,
 		}
 		bot.Request(kickConfig)
 
-		bot.Send(tgbotapi.NewDeleteMessage(chatID, captchaMsgID))
-		delete(pendingUsers, userID)
+		bot.Send(tgbotapi.NewDeleteMessage(chatID, pending.messageID))
+		delete(pendingUsers, key)
 	}
 }
 
 // sendWelcomeMessage now deletes the previous welcome message and sends the new, detailed one.
-func sendWelcomeMessage(bot *tgbotapi.BotAPI, chatID int64, firstName string) {
+func sendWelcomeMessage(bot *tgbotapi.BotAPI, l i18n.Localizer, chatID int64, firstName string) {
 	mu.Lock()
 	if oldMsgID, ok := lastWelcomeMessageIDs[chatID]; ok {
 		bot.Request(tgbotapi.NewDeleteMessage(chatID, oldMsgID))
 	}
 	mu.Unlock()
 
-	welcomeText := fmt.Sprintf(`🎉 %s Welcome to BYB BUILDERS COMMUNITY– Block by Block! 🚀
-
-Hey there, builder! We're so glad to have you in the family. This space is where future Web3 legends are made, and you’re now officially one of us. 💪🏽🧱
-
-Here’s what we ask from every member:
-
-🤝 Be kind and respectful – we're a supportive family, not a battleground.
-
-🧠 Come with the mindset to learn, grow, and build.
-
-🚫 No insults, no F-word, no negativity – we keep it clean and empowering.
-
-🌍 Share your journey! Feel free to introduce yourself – what do you do or want to do in Web3?
-
-
-Whether you're here to explore DeFi, NFTs, DAOs, or just make new connections — you're in the right place.
-
-Let’s build something great, block by block. 🧱🧱🧱
-
-#BYBFam 💚`, firstName)
+	welcomeText := l.T("captcha.welcome", firstName)
 
 	msg := tgbotapi.NewMessage(chatID, welcomeText)
+	if link, err := deeplink.URL(bot, deeplink.ActionRules, chatID); err == nil {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(l.T("captcha.read_rules_button"), link)),
+		)
+	}
 	sentMsg, err := bot.Send(msg)
 	if err != nil {
 		log.Printf("Failed to send welcome message: %v", err)
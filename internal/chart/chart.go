@@ -0,0 +1,410 @@
+// Package chart renders price history as PNG line or candlestick charts. It only depends on
+// the data it is given, so charts can be rendered from fixture data without any network access.
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// ErrNotEnoughData is returned when there are fewer than two points or candles to draw.
+var ErrNotEnoughData = errors.New("not enough data points for a chart")
+
+const (
+	width  = 800
+	height = 450
+
+	// padding keeps text off the image edges.
+	padding = 20
+
+	// Plot area margins. The right margin holds the price axis.
+	marginLeft   = padding
+	marginRight  = 110
+	marginTop    = 80
+	marginBottom = 40
+
+	gridLines = 4
+
+	// candleSpacing is the width of the slot each candle gets at least, in pixels. Longer
+	// histories are merged into fewer candles so bodies stay wide enough to tell apart.
+	candleSpacing = 5
+)
+
+var (
+	background = color.RGBA{0x13, 0x17, 0x22, 0xff}
+	gridColor  = color.RGBA{0x2a, 0x2e, 0x39, 0xff}
+	textColor  = color.RGBA{0xd1, 0xd4, 0xdc, 0xff}
+	mutedColor = color.RGBA{0x78, 0x7b, 0x86, 0xff}
+	upColor    = color.RGBA{0x26, 0xa6, 0x9a, 0xff}
+	downColor  = color.RGBA{0xef, 0x53, 0x50, 0xff}
+)
+
+// Point is one price sample.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Candle is the open, high, low and close price of the interval starting at Time.
+type Candle struct {
+	Time                   time.Time
+	Open, High, Low, Close float64
+}
+
+// Chart describes what to draw.
+type Chart struct {
+	// Title is drawn in the top left corner, such as "BTC/USD · 7d".
+	Title string
+	// Points must be sorted by time.
+	Points []Point
+	// Candles, if set, are drawn as candlesticks instead of the line through Points. They
+	// must be sorted by time.
+	Candles []Candle
+	// FormatValue formats prices on the axis and in the annotations.
+	FormatValue func(float64) string
+	// TimeLayout formats the dates under the chart.
+	TimeLayout string
+}
+
+// Change is the change from the first to the last point, or from the first open to the
+// last close, in percent.
+func (c Chart) Change() float64 {
+	first, last := c.first(), c.last()
+	if first == 0 {
+		return 0
+	}
+	return (last - first) / first * 100
+}
+
+// Range returns the lowest and highest value.
+func (c Chart) Range() (low, high float64) {
+	low, high = math.Inf(1), math.Inf(-1)
+	if len(c.Candles) > 0 {
+		for _, k := range c.Candles {
+			low, high = math.Min(low, k.Low), math.Max(high, k.High)
+		}
+		return low, high
+	}
+	for _, p := range c.Points {
+		low, high = math.Min(low, p.Value), math.Max(high, p.Value)
+	}
+	return low, high
+}
+
+func (c Chart) first() float64 {
+	if len(c.Candles) > 0 {
+		return c.Candles[0].Open
+	}
+	return c.Points[0].Value
+}
+
+func (c Chart) last() float64 {
+	if len(c.Candles) > 0 {
+		return c.Candles[len(c.Candles)-1].Close
+	}
+	return c.Points[len(c.Points)-1].Value
+}
+
+// Downsample reduces points to at most n, for histories with more samples than the chart
+// has pixels. Each bucket of neighbouring points keeps its lowest and highest point, in time
+// order, so spikes survive; the first and last points are always kept.
+func Downsample(points []Point, n int) []Point {
+	if len(points) <= n || n < 4 {
+		return points
+	}
+	inner := points[1 : len(points)-1]
+	buckets := (n - 2) / 2
+	out := make([]Point, 0, n)
+	out = append(out, points[0])
+	for b := 0; b < buckets; b++ {
+		bucket := inner[b*len(inner)/buckets : (b+1)*len(inner)/buckets]
+		if len(bucket) == 0 {
+			continue
+		}
+		lo, hi := 0, 0
+		for i, p := range bucket {
+			if p.Value < bucket[lo].Value {
+				lo = i
+			}
+			if p.Value > bucket[hi].Value {
+				hi = i
+			}
+		}
+		switch {
+		case lo == hi:
+			out = append(out, bucket[lo])
+		case lo < hi:
+			out = append(out, bucket[lo], bucket[hi])
+		default:
+			out = append(out, bucket[hi], bucket[lo])
+		}
+	}
+	return append(out, points[len(points)-1])
+}
+
+// MergeCandles reduces candles to at most n by merging runs of neighbouring candles into one
+// that opens with the first, closes with the last and spans their whole range.
+func MergeCandles(candles []Candle, n int) []Candle {
+	if len(candles) <= n || n < 1 {
+		return candles
+	}
+	size := (len(candles) + n - 1) / n
+	out := make([]Candle, 0, n)
+	for start := 0; start < len(candles); start += size {
+		run := candles[start:min(start+size, len(candles))]
+		merged := Candle{Time: run[0].Time, Open: run[0].Open, High: run[0].High, Low: run[0].Low, Close: run[len(run)-1].Close}
+		for _, k := range run[1:] {
+			merged.High, merged.Low = math.Max(merged.High, k.High), math.Min(merged.Low, k.Low)
+		}
+		out = append(out, merged)
+	}
+	return out
+}
+
+// Render draws the chart and encodes it as PNG. The line is green if the price went up
+// over the period and red if it went down; candles are colored one by one.
+func Render(c Chart) ([]byte, error) {
+	if len(c.Points) < 2 && len(c.Candles) < 2 {
+		return nil, ErrNotEnoughData
+	}
+	if c.FormatValue == nil {
+		c.FormatValue = func(v float64) string { return fmt.Sprintf("%.2f", v) }
+	}
+	if c.TimeLayout == "" {
+		c.TimeLayout = "Jan 2"
+	}
+	regular, bold, err := faces()
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	plot := image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+	if len(c.Candles) > 0 {
+		c.Candles = MergeCandles(c.Candles, plot.Dx()/candleSpacing)
+	} else {
+		c.Points = Downsample(c.Points, plot.Dx())
+	}
+	low, high := c.Range()
+	span := high - low
+	if span == 0 {
+		// A flat line still needs a scale; center it.
+		span = math.Max(math.Abs(high)*0.01, 1e-9)
+		low, high = low-span/2, high+span/2
+	}
+	y := func(v float64) float64 {
+		return float64(plot.Max.Y) - (v-low)/(high-low)*float64(plot.Dy())
+	}
+
+	// Horizontal grid with the price axis on the right.
+	for i := 0; i <= gridLines; i++ {
+		v := low + (high-low)*float64(i)/gridLines
+		gy := int(math.Round(y(v)))
+		for gx := plot.Min.X; gx < plot.Max.X; gx++ {
+			img.Set(gx, gy, gridColor)
+		}
+		drawText(img, regular, mutedColor, plot.Max.X+10, gy+5, c.FormatValue(v))
+	}
+
+	lineColor := upColor
+	if c.Change() < 0 {
+		lineColor = downColor
+	}
+
+	var dates []time.Time
+	var dateX func(i int) float64
+	if len(c.Candles) > 0 {
+		dates, dateX = drawCandles(img, plot, c.Candles, y)
+	} else {
+		dates, dateX = drawLineChart(img, plot, c.Points, y, lineColor)
+	}
+
+	// Dates at the start, middle and end of the period.
+	for i, t := range dates {
+		label := t.UTC().Format(c.TimeLayout)
+		lx := int(dateX(i)) - textWidth(regular, label)/2
+		lx = max(plot.Min.X, min(lx, plot.Max.X-textWidth(regular, label)))
+		drawText(img, regular, mutedColor, lx, height-marginBottom/2+5, label)
+	}
+
+	// Annotations: title and change on the first line, the price range on the second.
+	drawText(img, bold, textColor, marginLeft, 32, c.Title)
+	change := fmt.Sprintf("%s  %+.2f%%", c.FormatValue(c.last()), c.Change())
+	drawText(img, bold, lineColor, width-padding-textWidth(bold, change), 32, change)
+	lowValue, highValue := c.Range()
+	drawText(img, regular, mutedColor, marginLeft, 58, fmt.Sprintf("H %s   L %s", c.FormatValue(highValue), c.FormatValue(lowValue)))
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLineChart draws points as a line over a shaded area, spread over the plot by time. It
+// returns the times to label under the chart and where they are.
+func drawLineChart(img *image.RGBA, plot image.Rectangle, points []Point, y func(float64) float64, lineColor color.RGBA) ([]time.Time, func(int) float64) {
+	start, end := points[0].Time, points[len(points)-1].Time
+	duration := end.Sub(start)
+	x := func(t time.Time) float64 {
+		if duration <= 0 {
+			return float64(plot.Min.X)
+		}
+		return float64(plot.Min.X) + float64(t.Sub(start))/float64(duration)*float64(plot.Dx())
+	}
+	fill := lineColor
+	fill.A = 0x33
+
+	// Shade the area under the line first, then draw the line over it.
+	for i := 1; i < len(points); i++ {
+		x0, y0 := x(points[i-1].Time), y(points[i-1].Value)
+		x1, y1 := x(points[i].Time), y(points[i].Value)
+		// Stop short of the next point so columns shared by two segments aren't shaded twice.
+		for px := int(x0); px < int(x1); px++ {
+			top := y0
+			if x1 > x0 {
+				top = y0 + (y1-y0)*(float64(px)-x0)/(x1-x0)
+			}
+			for py := int(top); py < plot.Max.Y; py++ {
+				img.Set(px, py, blend(img.RGBAAt(px, py), fill))
+			}
+		}
+	}
+	for i := 1; i < len(points); i++ {
+		drawLine(img, x(points[i-1].Time), y(points[i-1].Value), x(points[i].Time), y(points[i].Value), lineColor)
+	}
+
+	dates := []time.Time{start, start.Add(duration / 2), end}
+	return dates, func(i int) float64 { return x(dates[i]) }
+}
+
+// drawCandles draws each candle in a slot of equal width: a wick from its low to its high
+// and a body from its open to its close, green if it closed higher and red if lower. It
+// returns the times to label under the chart and where they are.
+func drawCandles(img *image.RGBA, plot image.Rectangle, candles []Candle, y func(float64) float64) ([]time.Time, func(int) float64) {
+	slot := float64(plot.Dx()) / float64(len(candles))
+	center := func(i int) float64 { return float64(plot.Min.X) + (float64(i)+0.5)*slot }
+	body := max(1, int(slot*0.6))
+
+	for i, k := range candles {
+		c := upColor
+		if k.Close < k.Open {
+			c = downColor
+		}
+		cx := int(center(i))
+		for py := int(math.Round(y(k.High))); py <= int(math.Round(y(k.Low))); py++ {
+			img.SetRGBA(cx, py, c)
+		}
+		top, bottom := int(math.Round(y(math.Max(k.Open, k.Close)))), int(math.Round(y(math.Min(k.Open, k.Close))))
+		for py := top; py <= max(bottom, top+1); py++ {
+			for px := cx - body/2; px < cx-body/2+body; px++ {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+
+	labeled := []int{0, len(candles) / 2, len(candles) - 1}
+	dates := make([]time.Time, len(labeled))
+	for i, n := range labeled {
+		dates[i] = candles[n].Time
+	}
+	return dates, func(i int) float64 { return center(labeled[i]) }
+}
+
+var (
+	loadFaces   sync.Once
+	regularFace font.Face
+	boldFace    font.Face
+	facesErr    error
+)
+
+// faces parses the embedded Go fonts once.
+func faces() (regular, bold font.Face, err error) {
+	loadFaces.Do(func() {
+		regularFace, facesErr = newFace(goregular.TTF, 14)
+		if facesErr == nil {
+			boldFace, facesErr = newFace(gobold.TTF, 20)
+		}
+	})
+	return regularFace, boldFace, facesErr
+}
+
+func newFace(ttf []byte, size float64) (font.Face, error) {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse chart font: %w", err)
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// drawText draws text with its baseline at y.
+func drawText(img *image.RGBA, face font.Face, c color.Color, x, y int, text string) {
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(text)
+}
+
+func textWidth(face font.Face, text string) int {
+	return font.MeasureString(face, text).Round()
+}
+
+// drawLine draws a two pixel wide line with Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	ix0, iy0 := int(math.Round(x0)), int(math.Round(y0))
+	ix1, iy1 := int(math.Round(x1)), int(math.Round(y1))
+	dx, dy := abs(ix1-ix0), -abs(iy1-iy0)
+	sx, sy := 1, 1
+	if ix0 > ix1 {
+		sx = -1
+	}
+	if iy0 > iy1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(ix0, iy0, c)
+		img.SetRGBA(ix0+1, iy0, c)
+		img.SetRGBA(ix0, iy0+1, c)
+		if ix0 == ix1 && iy0 == iy1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			ix0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			iy0 += sy
+		}
+	}
+}
+
+// blend draws a translucent color over an opaque one.
+func blend(dst, src color.RGBA) color.RGBA {
+	a := uint32(src.A)
+	mix := func(d, s uint8) uint8 { return uint8((uint32(d)*(255-a) + uint32(s)*a) / 255) }
+	return color.RGBA{mix(dst.R, src.R), mix(dst.G, src.G), mix(dst.B, src.B), 0xff}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// wave returns n hourly points on a sine wave, with a spike at spike.
+func wave(n, spike int) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{Time: start.Add(time.Duration(i) * time.Hour), Value: 100 + 10*math.Sin(float64(i)/20)}
+	}
+	points[spike].Value = 500
+	return points
+}
+
+func TestDownsample(t *testing.T) {
+	points := wave(2000, 1234)
+	got := Downsample(points, 300)
+
+	if len(got) > 300 {
+		t.Fatalf("kept %d points, want at most 300", len(got))
+	}
+	if got[0] != points[0] || got[len(got)-1] != points[len(points)-1] {
+		t.Error("first or last point was dropped")
+	}
+	for i := 1; i < len(got); i++ {
+		if !got[i].Time.After(got[i-1].Time) {
+			t.Fatalf("points out of order at %d", i)
+		}
+	}
+	lo0, hi0 := Chart{Points: points}.Range()
+	if lo1, hi1 := (Chart{Points: got}).Range(); lo1 != lo0 || hi1 != hi0 {
+		t.Errorf("range changed from %v-%v to %v-%v", lo0, hi0, lo1, hi1)
+	}
+
+	short := wave(50, 10)
+	if got := Downsample(short, 300); len(got) != len(short) {
+		t.Errorf("a short history went from %d to %d points", len(short), len(got))
+	}
+}
+
+func TestMergeCandles(t *testing.T) {
+	candles := []Candle{
+		{Time: start, Open: 10, High: 12, Low: 9, Close: 11},
+		{Time: start.Add(time.Hour), Open: 11, High: 15, Low: 10, Close: 14},
+		{Time: start.Add(2 * time.Hour), Open: 14, High: 14, Low: 7, Close: 8},
+		{Time: start.Add(3 * time.Hour), Open: 8, High: 9, Low: 6, Close: 9},
+		{Time: start.Add(4 * time.Hour), Open: 9, High: 10, Low: 8, Close: 10},
+	}
+	got := MergeCandles(candles, 2)
+	want := []Candle{
+		{Time: start, Open: 10, High: 15, Low: 7, Close: 8},
+		{Time: start.Add(3 * time.Hour), Open: 8, High: 10, Low: 6, Close: 10},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("MergeCandles = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("candle %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if got := MergeCandles(candles, 10); len(got) != len(candles) {
+		t.Errorf("merged %d candles that already fit", len(candles)-len(got))
+	}
+}
+
+func TestRender(t *testing.T) {
+	candles := make([]Candle, 400)
+	for i := range candles {
+		open := 100 + float64(i%7)
+		candles[i] = Candle{Time: start.Add(time.Duration(i) * 4 * time.Hour), Open: open, High: open + 3, Low: open - 2, Close: open + 1}
+	}
+
+	for name, c := range map[string]Chart{
+		"line":    {Title: "BTC/USD · 30d", Points: wave(720, 100)},
+		"candles": {Title: "BTC/USD · 30d", Candles: candles},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := Render(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+				t.Errorf("image is %dx%d, want %dx%d", b.Dx(), b.Dy(), width, height)
+			}
+		})
+	}
+
+	if _, err := Render(Chart{Candles: candles[:1]}); !errors.Is(err, ErrNotEnoughData) {
+		t.Errorf("one candle: err = %v, want ErrNotEnoughData", err)
+	}
+}
+
+func TestCandleRangeAndChange(t *testing.T) {
+	c := Chart{Candles: []Candle{
+		{Time: start, Open: 100, High: 120, Low: 95, Close: 110},
+		{Time: start.Add(time.Hour), Open: 110, High: 112, Low: 80, Close: 90},
+	}}
+	if low, high := c.Range(); low != 80 || high != 120 {
+		t.Errorf("Range = %v, %v, want 80, 120", low, high)
+	}
+	if change := c.Change(); change != -10 {
+		t.Errorf("Change = %v, want -10", change)
+	}
+}
@@ -1,18 +1,29 @@
 package commands
 
 import (
-	"fmt"
 	"log"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/alerts"
+	"github.com/philip-857.bit/byb-bot/internal/captcha"
 	"github.com/philip-857.bit/byb-bot/internal/config"
 	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/deeplink"
+	"github.com/philip-857.bit/byb-bot/internal/filters"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
 	"github.com/philip-857.bit/byb-bot/internal/moderation"
+	"github.com/philip-857.bit/byb-bot/internal/notes"
+	"github.com/philip-857.bit/byb-bot/internal/portfolio"
+	"github.com/philip-857.bit/byb-bot/internal/referrals"
+	"github.com/philip-857.bit/byb-bot/internal/rules"
+	"github.com/philip-857.bit/byb-bot/internal/tokengate"
+	"github.com/philip-857.bit/byb-bot/internal/wallets"
+	"github.com/philip-857.bit/byb-bot/internal/watchlist"
 	"github.com/philip-857.bit/byb-bot/internal/web3"
 )
 
 // Command holds the function to be executed for a command.
-type Command func(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message)
+type Command func(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message)
 
 // commandRegistry holds all registered bot commands.
 var commandRegistry = make(map[string]Command)
@@ -24,24 +35,71 @@ func RegisterCommands(cfg *config.Config) {
 
 	// User commands
 	commandRegistry["start"] = handleStartCommand
-	commandRegistry["rules"] = handleRulesCommand
+	commandRegistry["rules"] = rules.HandleRulesCommand
+	commandRegistry["rulehistory"] = rules.HandleRulesHistoryCommand
 	commandRegistry["help"] = handleHelpCommand
+	commandRegistry["lang"] = handleLangCommand
+	commandRegistry["invite"] = referrals.HandleInviteCommand
+	commandRegistry["note"] = notes.HandleNoteCommand
+	commandRegistry["notes"] = notes.HandleNotesCommand
 
 	// Web3 commands
-	// Pass the config to the web3 package before registering commands that use it.
-	web3.Cfg = cfg
+	// Configure the web3 package and its price providers before registering commands that use them.
+	web3.Configure(cfg)
 	commandRegistry["price"] = web3.HandlePriceCommand
 	commandRegistry["p"] = web3.HandlePriceCommand
+	commandRegistry["chart"] = web3.HandleChartCommand
+	commandRegistry["convert"] = web3.HandleConvertCommand
+	commandRegistry["trending"] = web3.HandleTrendingCommand
+	commandRegistry["movers"] = web3.HandleMoversCommand
+	commandRegistry["wallet"] = web3.HandleWalletCommand
+	commandRegistry["tx"] = web3.HandleTxCommand
+	commandRegistry["token"] = web3.HandleTokenCommand
+	commandRegistry["nft"] = web3.HandleNFTCommand
+	commandRegistry["nfts"] = web3.HandleNFTsCommand
+	commandRegistry["linkwallet"] = wallets.HandleLinkWalletCommand
+	commandRegistry["verifywallet"] = wallets.HandleVerifyWalletCommand
+	commandRegistry["profile"] = wallets.HandleProfileCommand
+	commandRegistry["unlink"] = wallets.HandleUnlinkCommand
+	commandRegistry["portfolio"] = portfolio.HandlePortfolioCommand
 	commandRegistry["gas"] = web3.HandleGasCommand
+	commandRegistry["currency"] = web3.HandleCurrencyCommand
+	commandRegistry["alert"] = alerts.HandleAlertCommand
+	commandRegistry["alerts"] = alerts.HandleAlertsCommand
+	commandRegistry["delalert"] = alerts.HandleDelAlertCommand
+	commandRegistry["gasalert"] = alerts.HandleGasAlertCommand
+	commandRegistry["watch"] = watchlist.HandleWatchCommand
+	commandRegistry["digest"] = watchlist.HandleDigestCommand
 
 	// Admin commands
 	commandRegistry["warn"] = moderation.HandleWarnCommand
 	commandRegistry["mute"] = moderation.HandleMuteCommand
 	commandRegistry["setup"] = moderation.HandleSetupCommand
+	commandRegistry["setrules"] = rules.HandleSetRulesCommand
+	commandRegistry["requirerules"] = rules.HandleRequireRulesCommand
+	commandRegistry["save"] = notes.HandleSaveCommand
+	commandRegistry["delnote"] = notes.HandleDelNoteCommand
+	commandRegistry["tokengate"] = tokengate.HandleTokenGateCommand
+
+	// Filter commands
+	commandRegistry["filter"] = filters.HandleFilterCommand
+	commandRegistry["stop"] = filters.HandleStopCommand
+	commandRegistry["filters"] = filters.HandleFiltersCommand
+
+	// Deep links opened with /start <payload>
+	deeplink.Configure(cfg)
+	deeplink.Register(deeplink.ActionRules, rules.HandleRulesDeepLink)
+	deeplink.Register(deeplink.ActionVerify, captcha.HandleVerifyDeepLink)
+	deeplink.Register(deeplink.ActionReferral, referrals.HandleReferralDeepLink)
+	deeplink.Register(deeplink.ActionWallet, wallets.HandleWalletDeepLink)
+
+	// Linking a wallet for a token-gated chat lets the user in, by join request or captcha.
+	wallets.OnLinked(tokengate.HandleWalletLinked)
+	wallets.OnLinked(captcha.HandleWalletLinked)
 }
 
 // Handle is the main router for all commands.
-func Handle(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
+func Handle(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
 	commandName := message.Command()
 
 	cmd, exists := commandRegistry[commandName]
@@ -54,40 +112,21 @@ func Handle(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message
 
 // --- User Command Handler Implementations ---
 
-func handleStartCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
-	text := fmt.Sprintf("Hello, %s! I am the BYB Builders Bot. Use /help to see what I can do.", message.From.FirstName)
-	msg := tgbotapi.NewMessage(message.Chat.ID, text)
-	bot.Send(msg)
-}
-
-func handleRulesCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
-	rules := `*BYB BUILDERS COMMUNITY RULES* 🧱
-
-1.  *Be Kind & Respectful*: We are a supportive family, not a battleground.
-2.  *Stay On Topic*: Keep discussions related to Web3, building, and technology.
-3.  *No Spam*: Unsolicited promotions or spam are strictly forbidden.
-4.  *Help Each Other*: Come with a mindset to learn, grow, and build together.
-5.  *No Insults, No F-word, No Negativity*: Keep it clean and empowering.`
+func handleStartCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	// Deep links (t.me/<bot>?start=<payload>) always open a private chat.
+	if payload := message.CommandArguments(); payload != "" && message.Chat.IsPrivate() {
+		deeplink.Handle(bot, db, message, payload)
+		return
+	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, rules)
-	msg.ParseMode = "Markdown"
+	l := i18n.ForMessage(db, message)
+	msg := tgbotapi.NewMessage(message.Chat.ID, l.T("start.greeting", message.From.FirstName))
 	bot.Send(msg)
 }
 
-func handleHelpCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
-	helpText := `Here are the available commands:
-
-*/start* - Welcome message
-*/rules* - Show community rules
-*/help* - Show this message
-*/price* <coin> - Get cryptocurrency price
-*/gas* - Get current Ethereum gas fees
-
-*Admin Commands:*
-*/warn* - Warn a user
-*/mute* - Mute a user
-*/setup* - Refresh bot commands`
-	msg := tgbotapi.NewMessage(message.Chat.ID, helpText)
+func handleHelpCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	msg := tgbotapi.NewMessage(message.Chat.ID, l.T("help.text"))
 	msg.ParseMode = "Markdown"
 	bot.Send(msg)
 }
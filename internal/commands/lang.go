@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+)
+
+// handleLangCommand shows or changes the reply language.
+// In a group it sets the chat's language and is restricted to admins; in private chat it sets the user's own language.
+func handleLangCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	available := availableLanguages()
+
+	code := strings.TrimSpace(message.CommandArguments())
+	if code == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("lang.usage", l.T("language.name"), available)))
+		return
+	}
+
+	lang := i18n.Normalize(code)
+	if lang == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("lang.unsupported", code, available)))
+		return
+	}
+
+	inGroup := !message.Chat.IsPrivate()
+	if inGroup && !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	if err := i18n.SetPreference(db, message.Chat.ID, lang); err != nil {
+		log.Printf("Failed to set language for %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("lang.error")))
+		return
+	}
+
+	// Confirm in the newly chosen language.
+	l = i18n.New(lang)
+	if inGroup {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("lang.set_chat")))
+	} else {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("lang.set_user")))
+	}
+}
+
+// availableLanguages lists the supported languages as "code (Name)" pairs.
+func availableLanguages() string {
+	var names []string
+	for _, lang := range i18n.Supported() {
+		names = append(names, fmt.Sprintf("%s (%s)", lang, i18n.New(lang).T("language.name")))
+	}
+	return strings.Join(names, ", ")
+}
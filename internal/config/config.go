@@ -4,16 +4,45 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// Storage backends the bot can keep its data in.
+const (
+	StorageSupabase = "supabase"
+	StorageSQL      = "sql"
+)
+
 // Config now includes the Etherscan API key.
 type Config struct {
-	TelegramToken   string
-	SupabaseURL     string
-	SupabaseKey     string
+	TelegramToken string
+
+	// StorageBackend picks where the bot keeps its data: StorageSupabase, which reads
+	// SupabaseURL and SupabaseKey, or StorageSQL, which reads DatabaseURL.
+	StorageBackend string
+	SupabaseURL    string
+	SupabaseKey    string
+	// DatabaseURL is a Postgres URL or a SQLite file, like "sqlite:///var/lib/byb/bot.db".
+	DatabaseURL string
+
 	EtherscanAPIKey string
+	DeepLinkSecret  string
+
+	// Price data providers, tried in PriceProviders order until one answers.
+	PriceProviders  []string
+	CoinGeckoAPIKey string
+	// CoinGeckoPro marks CoinGeckoAPIKey as a paid plan key, which is sent to the pro API.
+	CoinGeckoPro        bool
+	CoinMarketCapAPIKey string
+	PriceStaticFile     string
+
+	// OpenSeaAPIKey enables /nft and /nfts, which read collection stats from OpenSea.
+	OpenSeaAPIKey string
+
+	// JSON-RPC endpoints per chain, overriding the public defaults, e.g. "base=https://...".
+	RPCURLs map[string]string
 }
 
 // Load reads all configuration from environment variables.
@@ -27,27 +56,94 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("TELEGRAM_TOKEN not set")
 	}
 
-	sbURL := os.Getenv("SUPABASE_URL")
-	if sbURL == "" {
-		return nil, fmt.Errorf("SUPABASE_URL not set")
+	// Supabase stays the default, so existing deployments keep working without new settings.
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_BACKEND")))
+	if backend == "" {
+		backend = StorageSupabase
 	}
-
-	sbKey := os.Getenv("SUPABASE_KEY")
-	if sbKey == "" {
-		return nil, fmt.Errorf("SUPABASE_KEY not set")
+	sbURL, sbKey, databaseURL := os.Getenv("SUPABASE_URL"), os.Getenv("SUPABASE_KEY"), os.Getenv("DATABASE_URL")
+	switch backend {
+	case StorageSupabase:
+		if sbURL == "" {
+			return nil, fmt.Errorf("SUPABASE_URL not set")
+		}
+		if sbKey == "" {
+			return nil, fmt.Errorf("SUPABASE_KEY not set")
+		}
+	case StorageSQL:
+		if databaseURL == "" {
+			return nil, fmt.Errorf("DATABASE_URL not set")
+		}
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q, expected %q or %q", backend, StorageSupabase, StorageSQL)
 	}
 
 	// Load the new key from the environment.
 	etherscanKey := os.Getenv("EtherscanAPIKey")
 	if etherscanKey == "" {
-		// We'll log a warning but not fail, so the bot can run without the /gas command.
-		log.Println("WARNING: EtherscanAPIKey not set. The /gas command will not work.")
+		// We'll log a warning but not fail, /gas falls back to reading fees over JSON-RPC.
+		log.Println("WARNING: EtherscanAPIKey not set. Gas fees will only be read from RPC endpoints.")
+	}
+
+	// Deep-link payloads are signed with this secret. Without it a key is derived from the bot token,
+	// which works but means rotating the token invalidates links that were already shared.
+	deepLinkSecret := os.Getenv("DEEPLINK_SECRET")
+	if deepLinkSecret == "" {
+		log.Println("WARNING: DEEPLINK_SECRET not set. Deep links will be signed with a key derived from the bot token.")
+	}
+
+	// Providers are listed by name, e.g. "coingecko,coinmarketcap". CoinGecko works without a key,
+	// so it is the default; the others are only usable once their key or file is configured.
+	priceProviders := splitList(os.Getenv("PRICE_PROVIDERS"))
+	if len(priceProviders) == 0 {
+		priceProviders = []string{"coingecko"}
 	}
 
 	return &Config{
-		TelegramToken:   token,
-		SupabaseURL:     sbURL,
-		SupabaseKey:     sbKey,
+		TelegramToken: token,
+
+		StorageBackend: backend,
+		SupabaseURL:    sbURL,
+		SupabaseKey:    sbKey,
+		DatabaseURL:    databaseURL,
+
 		EtherscanAPIKey: etherscanKey,
+		DeepLinkSecret:  deepLinkSecret,
+
+		PriceProviders:      priceProviders,
+		CoinGeckoAPIKey:     os.Getenv("COINGECKO_API_KEY"),
+		CoinGeckoPro:        strings.EqualFold(os.Getenv("COINGECKO_PLAN"), "pro"),
+		CoinMarketCapAPIKey: os.Getenv("COINMARKETCAP_API_KEY"),
+		PriceStaticFile:     os.Getenv("PRICE_STATIC_FILE"),
+
+		OpenSeaAPIKey: os.Getenv("OPENSEA_API_KEY"),
+
+		RPCURLs: splitPairs(os.Getenv("RPC_URLS")),
 	}, nil
 }
+
+// splitList parses a comma separated environment variable, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitPairs parses a comma separated list of key=value pairs. Keys are lowercased, values kept as is.
+func splitPairs(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			pairs[key] = strings.TrimSpace(val)
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// AddPriceAlert inserts a price alert into the 'price_alerts' table and sets its ID.
+func (c *Client) AddPriceAlert(ctx context.Context, alert *models.PriceAlert) error {
+	data := []models.PriceAlert{*alert}
+
+	var inserted []models.PriceAlert
+	_, err := c.From("price_alerts").Insert(data, false, "", "representation", "").ExecuteTo(&inserted)
+	if err != nil {
+		return fmt.Errorf("failed to add price alert to supabase: %w", err)
+	}
+	if len(inserted) == 1 {
+		alert.ID = inserted[0].ID
+	}
+
+	log.Printf("Added price alert %d for %s in chat %d.", alert.ID, alert.CoinID, alert.ChatID)
+	return nil
+}
+
+// GetPriceAlert returns a price alert by ID, or nil if it doesn't exist.
+func (c *Client) GetPriceAlert(ctx context.Context, id int64) (*models.PriceAlert, error) {
+	var alerts []models.PriceAlert
+	_, err := c.From("price_alerts").Select("*", "", false).
+		Eq("id", fmt.Sprintf("%d", id)).
+		ExecuteTo(&alerts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price alert from supabase: %w", err)
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+	return &alerts[0], nil
+}
+
+// GetPriceAlerts returns all price alerts of a chat, triggered ones included.
+func (c *Client) GetPriceAlerts(ctx context.Context, chatID int64) ([]models.PriceAlert, error) {
+	var alerts []models.PriceAlert
+	_, err := c.From("price_alerts").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&alerts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price alerts from supabase: %w", err)
+	}
+	return alerts, nil
+}
+
+// GetActivePriceAlerts returns the alerts of every chat that haven't fired yet.
+func (c *Client) GetActivePriceAlerts(ctx context.Context) ([]models.PriceAlert, error) {
+	var alerts []models.PriceAlert
+	_, err := c.From("price_alerts").Select("*", "", false).
+		Eq("active", "true").
+		ExecuteTo(&alerts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active price alerts from supabase: %w", err)
+	}
+	return alerts, nil
+}
+
+// UpdatePriceAlert saves the state of an existing price alert.
+func (c *Client) UpdatePriceAlert(ctx context.Context, alert *models.PriceAlert) error {
+	_, _, err := c.From("price_alerts").Update(alert, "minimal", "").
+		Eq("id", fmt.Sprintf("%d", alert.ID)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update price alert in supabase: %w", err)
+	}
+	return nil
+}
+
+// RemovePriceAlert deletes a price alert by ID.
+func (c *Client) RemovePriceAlert(ctx context.Context, id int64) error {
+	_, _, err := c.From("price_alerts").Delete("", "").Eq("id", fmt.Sprintf("%d", id)).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove price alert from supabase: %w", err)
+	}
+
+	log.Printf("Removed price alert %d.", id)
+	return nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// SaveFilter inserts or replaces a keyword filter in the 'filters' table.
+// A chat can only have one filter per trigger, so saving an existing trigger overwrites it.
+func (c *Client) SaveFilter(ctx context.Context, filter *models.Filter) error {
+	data := []models.Filter{*filter}
+
+	_, _, err := c.From("filters").Upsert(data, "chat_id,trigger", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to save filter to supabase: %w", err)
+	}
+
+	log.Printf("Saved filter %q for chat %d.", filter.Trigger, filter.ChatID)
+	return nil
+}
+
+// RemoveFilter deletes a keyword filter from the 'filters' table.
+func (c *Client) RemoveFilter(ctx context.Context, chatID int64, trigger string) error {
+	_, _, err := c.From("filters").Delete("", "").
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("trigger", trigger).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove filter from supabase: %w", err)
+	}
+
+	log.Printf("Removed filter %q from chat %d.", trigger, chatID)
+	return nil
+}
+
+// GetFilters returns every keyword filter configured for a chat.
+func (c *Client) GetFilters(ctx context.Context, chatID int64) ([]models.Filter, error) {
+	var filters []models.Filter
+	_, err := c.From("filters").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch filters from supabase: %w", err)
+	}
+	return filters, nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// AddGasAlert inserts a gas alert into the 'gas_alerts' table and sets its ID.
+func (c *Client) AddGasAlert(ctx context.Context, alert *models.GasAlert) error {
+	data := []models.GasAlert{*alert}
+
+	var inserted []models.GasAlert
+	_, err := c.From("gas_alerts").Insert(data, false, "", "representation", "").ExecuteTo(&inserted)
+	if err != nil {
+		return fmt.Errorf("failed to add gas alert to supabase: %w", err)
+	}
+	if len(inserted) == 1 {
+		alert.ID = inserted[0].ID
+	}
+
+	log.Printf("Added gas alert %d for %s in chat %d.", alert.ID, alert.Chain, alert.ChatID)
+	return nil
+}
+
+// GetGasAlert returns a gas alert by ID, or nil if it doesn't exist.
+func (c *Client) GetGasAlert(ctx context.Context, id int64) (*models.GasAlert, error) {
+	var alerts []models.GasAlert
+	_, err := c.From("gas_alerts").Select("*", "", false).
+		Eq("id", fmt.Sprintf("%d", id)).
+		ExecuteTo(&alerts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gas alert from supabase: %w", err)
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+	return &alerts[0], nil
+}
+
+// GetGasAlerts returns all gas alerts of a chat, triggered ones included.
+func (c *Client) GetGasAlerts(ctx context.Context, chatID int64) ([]models.GasAlert, error) {
+	var alerts []models.GasAlert
+	_, err := c.From("gas_alerts").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&alerts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gas alerts from supabase: %w", err)
+	}
+	return alerts, nil
+}
+
+// GetActiveGasAlerts returns the alerts of every chat that haven't fired yet.
+func (c *Client) GetActiveGasAlerts(ctx context.Context) ([]models.GasAlert, error) {
+	var alerts []models.GasAlert
+	_, err := c.From("gas_alerts").Select("*", "", false).
+		Eq("active", "true").
+		ExecuteTo(&alerts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active gas alerts from supabase: %w", err)
+	}
+	return alerts, nil
+}
+
+// UpdateGasAlert saves the state of an existing gas alert.
+func (c *Client) UpdateGasAlert(ctx context.Context, alert *models.GasAlert) error {
+	_, _, err := c.From("gas_alerts").Update(alert, "minimal", "").
+		Eq("id", fmt.Sprintf("%d", alert.ID)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update gas alert in supabase: %w", err)
+	}
+	return nil
+}
+
+// RemoveGasAlert deletes a gas alert by ID.
+func (c *Client) RemoveGasAlert(ctx context.Context, id int64) error {
+	_, _, err := c.From("gas_alerts").Delete("", "").Eq("id", fmt.Sprintf("%d", id)).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove gas alert from supabase: %w", err)
+	}
+
+	log.Printf("Removed gas alert %d.", id)
+	return nil
+}
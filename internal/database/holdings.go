@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// AddHolding inserts a purchase into the 'portfolio_holdings' table and sets its ID.
+func (c *Client) AddHolding(ctx context.Context, holding *models.Holding) error {
+	data := []models.Holding{*holding}
+
+	var inserted []models.Holding
+	_, err := c.From("portfolio_holdings").Insert(data, false, "", "representation", "").ExecuteTo(&inserted)
+	if err != nil {
+		return fmt.Errorf("failed to add holding to supabase: %w", err)
+	}
+	if len(inserted) == 1 {
+		holding.ID = inserted[0].ID
+	}
+
+	log.Printf("Added holding %d of %s for user %d.", holding.ID, holding.CoinID, holding.TelegramID)
+	return nil
+}
+
+// GetHoldings returns the portfolio of a user, oldest purchase first.
+func (c *Client) GetHoldings(ctx context.Context, telegramID int64) ([]models.Holding, error) {
+	var holdings []models.Holding
+	_, err := c.From("portfolio_holdings").Select("*", "", false).
+		Eq("telegram_id", fmt.Sprintf("%d", telegramID)).
+		ExecuteTo(&holdings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch holdings from supabase: %w", err)
+	}
+	sort.Slice(holdings, func(i, j int) bool { return holdings[i].ID < holdings[j].ID })
+	return holdings, nil
+}
+
+// UpdateHolding saves the amount and price of an existing purchase.
+func (c *Client) UpdateHolding(ctx context.Context, holding *models.Holding) error {
+	_, _, err := c.From("portfolio_holdings").Update(holding, "minimal", "").
+		Eq("id", fmt.Sprintf("%d", holding.ID)).
+		Eq("telegram_id", fmt.Sprintf("%d", holding.TelegramID)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update holding in supabase: %w", err)
+	}
+	return nil
+}
+
+// RemoveHolding deletes a purchase of a user by ID and reports whether it existed.
+// The user is part of the filter, so nobody can delete someone else's holdings.
+func (c *Client) RemoveHolding(ctx context.Context, telegramID, id int64) (bool, error) {
+	var removed []models.Holding
+	_, err := c.From("portfolio_holdings").Delete("representation", "").
+		Eq("id", fmt.Sprintf("%d", id)).
+		Eq("telegram_id", fmt.Sprintf("%d", telegramID)).
+		ExecuteTo(&removed)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove holding from supabase: %w", err)
+	}
+
+	log.Printf("Removed holding %d of user %d.", id, telegramID)
+	return len(removed) > 0, nil
+}
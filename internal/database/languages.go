@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// SetLanguage stores the preferred language of a chat or user in the 'language_preferences' table.
+func (c *Client) SetLanguage(ctx context.Context, id int64, language string) error {
+	data := []models.LanguagePreference{{ID: id, Language: language}}
+
+	_, _, err := c.From("language_preferences").Upsert(data, "id", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to save language preference to supabase: %w", err)
+	}
+
+	log.Printf("Set language of %d to %s.", id, language)
+	return nil
+}
+
+// GetLanguage returns the preferred language of a chat or user, or an empty string if none was set.
+func (c *Client) GetLanguage(ctx context.Context, id int64) (string, error) {
+	var prefs []models.LanguagePreference
+	_, err := c.From("language_preferences").Select("*", "", false).
+		Eq("id", fmt.Sprintf("%d", id)).
+		ExecuteTo(&prefs)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch language preference from supabase: %w", err)
+	}
+	if len(prefs) == 0 {
+		return "", nil
+	}
+	return prefs[0].Language, nil
+}
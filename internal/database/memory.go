@@ -0,0 +1,622 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// Memory is a Store that keeps everything in memory, for tests and for trying the bot out
+// without a database. It behaves like the Supabase tables: the same keys replace records,
+// IDs count up per table, and lookups return copies, so callers can't change stored records
+// behind its back. Everything is lost when the process exits.
+type Memory struct {
+	mu  sync.Mutex
+	ids map[string]int64
+
+	users       []models.User
+	languages   map[int64]string
+	settings    map[int64]models.ChatSettings
+	rules       []models.RulesVersion
+	acceptances []models.RulesAcceptance
+	notes       []models.Note
+	filters     []models.Filter
+	priceAlerts []models.PriceAlert
+	gasAlerts   []models.GasAlert
+	referrals   []models.Referral
+	wallets     []models.LinkedWallet
+	gates       []models.TokenGate
+	gateMembers []models.TokenGateMember
+	collections []models.PinnedCollection
+	watchlist   []models.WatchlistCoin
+	digests     []models.DigestSchedule
+	holdings    []models.Holding
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		ids:       make(map[string]int64),
+		languages: make(map[int64]string),
+		settings:  make(map[int64]models.ChatSettings),
+	}
+}
+
+// nextID returns the next ID of a table, like a serial column would. Callers hold m.mu.
+func (m *Memory) nextID(table string) int64 {
+	m.ids[table]++
+	return m.ids[table]
+}
+
+// where returns copies of the rows that match.
+func where[T any](rows []T, match func(*T) bool) []T {
+	var found []T
+	for i := range rows {
+		if match(&rows[i]) {
+			found = append(found, rows[i])
+		}
+	}
+	return found
+}
+
+// first returns a copy of the first row that matches, or nil.
+func first[T any](rows []T, match func(*T) bool) *T {
+	if i := slices.IndexFunc(rows, func(row T) bool { return match(&row) }); i >= 0 {
+		row := rows[i]
+		return &row
+	}
+	return nil
+}
+
+// put replaces the first row that matches, or appends the row if none does. keep copies
+// what the stored row keeps from the one it replaces, like its ID.
+func put[T any](rows []T, row T, match func(*T) bool, keep func(row, old *T)) []T {
+	if i := slices.IndexFunc(rows, func(old T) bool { return match(&old) }); i >= 0 {
+		if keep != nil {
+			keep(&row, &rows[i])
+		}
+		rows[i] = row
+		return rows
+	}
+	return append(rows, row)
+}
+
+// without removes the rows that match and returns what is left and how many were removed.
+func without[T any](rows []T, match func(*T) bool) ([]T, int) {
+	left := slices.DeleteFunc(rows, func(row T) bool { return match(&row) })
+	return left, len(rows) - len(left)
+}
+
+// AddUser implements MemberRepository.
+func (m *Memory) AddUser(ctx context.Context, user *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if first(m.users, func(u *models.User) bool { return u.TelegramID == user.TelegramID }) != nil {
+		return nil
+	}
+	row := *user
+	row.ID = m.nextID("members")
+	m.users = append(m.users, row)
+	return nil
+}
+
+// RemoveUser implements MemberRepository.
+func (m *Memory) RemoveUser(ctx context.Context, telegramID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users, _ = without(m.users, func(u *models.User) bool { return u.TelegramID == telegramID })
+	return nil
+}
+
+// GetUser implements MemberRepository.
+func (m *Memory) GetUser(ctx context.Context, telegramID int64) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.users, func(u *models.User) bool { return u.TelegramID == telegramID }), nil
+}
+
+// SetLanguage implements LanguageRepository.
+func (m *Memory) SetLanguage(ctx context.Context, id int64, language string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.languages[id] = language
+	return nil
+}
+
+// GetLanguage implements LanguageRepository.
+func (m *Memory) GetLanguage(ctx context.Context, id int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.languages[id], nil
+}
+
+// GetChatSettings implements SettingsRepository.
+func (m *Memory) GetChatSettings(ctx context.Context, chatID int64) (*models.ChatSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	settings, ok := m.settings[chatID]
+	if !ok {
+		return &models.ChatSettings{ChatID: chatID}, nil
+	}
+	return &settings, nil
+}
+
+// SaveChatSettings implements SettingsRepository.
+func (m *Memory) SaveChatSettings(ctx context.Context, settings *models.ChatSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[settings.ChatID] = *settings
+	return nil
+}
+
+// AddRulesVersion implements RulesRepository.
+func (m *Memory) AddRulesVersion(ctx context.Context, rules *models.RulesVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules.Version = 1
+	for _, r := range m.rules {
+		if r.ChatID == rules.ChatID && r.Version >= rules.Version {
+			rules.Version = r.Version + 1
+		}
+	}
+	row := *rules
+	row.ID = m.nextID("rules_versions")
+	m.rules = append(m.rules, row)
+	return nil
+}
+
+// GetLatestRules implements RulesRepository.
+func (m *Memory) GetLatestRules(ctx context.Context, chatID int64) (*models.RulesVersion, error) {
+	history, _ := m.GetRulesHistory(ctx, chatID)
+	if len(history) == 0 {
+		return nil, nil
+	}
+	return &history[0], nil
+}
+
+// GetRulesHistory implements RulesRepository.
+func (m *Memory) GetRulesHistory(ctx context.Context, chatID int64) ([]models.RulesVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history := where(m.rules, func(r *models.RulesVersion) bool { return r.ChatID == chatID })
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Version > history[j].Version })
+	return history, nil
+}
+
+// RecordRulesAcceptance implements RulesRepository.
+func (m *Memory) RecordRulesAcceptance(ctx context.Context, acceptance *models.RulesAcceptance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acceptances = put(m.acceptances, *acceptance, func(a *models.RulesAcceptance) bool {
+		return a.ChatID == acceptance.ChatID && a.TelegramID == acceptance.TelegramID
+	}, nil)
+	return nil
+}
+
+// GetRulesAcceptance implements RulesRepository.
+func (m *Memory) GetRulesAcceptance(ctx context.Context, chatID, telegramID int64) (*models.RulesAcceptance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.acceptances, func(a *models.RulesAcceptance) bool {
+		return a.ChatID == chatID && a.TelegramID == telegramID
+	}), nil
+}
+
+// SaveNote implements NoteRepository.
+func (m *Memory) SaveNote(ctx context.Context, note *models.Note) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *note
+	row.ID = m.nextID("notes")
+	m.notes = put(m.notes, row, func(n *models.Note) bool { return n.ChatID == note.ChatID && n.Name == note.Name },
+		func(row, old *models.Note) { row.ID = old.ID })
+	return nil
+}
+
+// GetNote implements NoteRepository.
+func (m *Memory) GetNote(ctx context.Context, chatID int64, name string) (*models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.notes, func(n *models.Note) bool { return n.ChatID == chatID && n.Name == name }), nil
+}
+
+// SearchNotes implements NoteRepository. Matches are sorted by name.
+func (m *Memory) SearchNotes(ctx context.Context, chatID int64, prefix string, limit int) ([]models.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	notes := where(m.notes, func(n *models.Note) bool { return n.ChatID == chatID && strings.HasPrefix(n.Name, prefix) })
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Name < notes[j].Name })
+	return notes[:min(len(notes), limit)], nil
+}
+
+// GetNoteChats implements NoteRepository.
+func (m *Memory) GetNoteChats(ctx context.Context) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var chats []int64
+	for _, n := range m.notes {
+		if !slices.Contains(chats, n.ChatID) {
+			chats = append(chats, n.ChatID)
+		}
+	}
+	slices.Sort(chats)
+	return chats, nil
+}
+
+// RemoveNote implements NoteRepository.
+func (m *Memory) RemoveNote(ctx context.Context, chatID int64, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notes, _ = without(m.notes, func(n *models.Note) bool { return n.ChatID == chatID && n.Name == name })
+	return nil
+}
+
+// SaveFilter implements FilterRepository.
+func (m *Memory) SaveFilter(ctx context.Context, filter *models.Filter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *filter
+	row.ID = m.nextID("filters")
+	m.filters = put(m.filters, row, func(f *models.Filter) bool {
+		return f.ChatID == filter.ChatID && f.Trigger == filter.Trigger
+	}, func(row, old *models.Filter) { row.ID = old.ID })
+	return nil
+}
+
+// RemoveFilter implements FilterRepository.
+func (m *Memory) RemoveFilter(ctx context.Context, chatID int64, trigger string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filters, _ = without(m.filters, func(f *models.Filter) bool { return f.ChatID == chatID && f.Trigger == trigger })
+	return nil
+}
+
+// GetFilters implements FilterRepository.
+func (m *Memory) GetFilters(ctx context.Context, chatID int64) ([]models.Filter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.filters, func(f *models.Filter) bool { return f.ChatID == chatID }), nil
+}
+
+// AddPriceAlert implements AlertRepository.
+func (m *Memory) AddPriceAlert(ctx context.Context, alert *models.PriceAlert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert.ID = m.nextID("price_alerts")
+	m.priceAlerts = append(m.priceAlerts, *alert)
+	return nil
+}
+
+// GetPriceAlert implements AlertRepository.
+func (m *Memory) GetPriceAlert(ctx context.Context, id int64) (*models.PriceAlert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.priceAlerts, func(a *models.PriceAlert) bool { return a.ID == id }), nil
+}
+
+// GetPriceAlerts implements AlertRepository.
+func (m *Memory) GetPriceAlerts(ctx context.Context, chatID int64) ([]models.PriceAlert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.priceAlerts, func(a *models.PriceAlert) bool { return a.ChatID == chatID }), nil
+}
+
+// GetActivePriceAlerts implements AlertRepository.
+func (m *Memory) GetActivePriceAlerts(ctx context.Context) ([]models.PriceAlert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.priceAlerts, func(a *models.PriceAlert) bool { return a.Active }), nil
+}
+
+// UpdatePriceAlert implements AlertRepository.
+func (m *Memory) UpdatePriceAlert(ctx context.Context, alert *models.PriceAlert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := slices.IndexFunc(m.priceAlerts, func(a models.PriceAlert) bool { return a.ID == alert.ID }); i >= 0 {
+		m.priceAlerts[i] = *alert
+	}
+	return nil
+}
+
+// RemovePriceAlert implements AlertRepository.
+func (m *Memory) RemovePriceAlert(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.priceAlerts, _ = without(m.priceAlerts, func(a *models.PriceAlert) bool { return a.ID == id })
+	return nil
+}
+
+// AddGasAlert implements AlertRepository.
+func (m *Memory) AddGasAlert(ctx context.Context, alert *models.GasAlert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert.ID = m.nextID("gas_alerts")
+	m.gasAlerts = append(m.gasAlerts, *alert)
+	return nil
+}
+
+// GetGasAlert implements AlertRepository.
+func (m *Memory) GetGasAlert(ctx context.Context, id int64) (*models.GasAlert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.gasAlerts, func(a *models.GasAlert) bool { return a.ID == id }), nil
+}
+
+// GetGasAlerts implements AlertRepository.
+func (m *Memory) GetGasAlerts(ctx context.Context, chatID int64) ([]models.GasAlert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.gasAlerts, func(a *models.GasAlert) bool { return a.ChatID == chatID }), nil
+}
+
+// GetActiveGasAlerts implements AlertRepository.
+func (m *Memory) GetActiveGasAlerts(ctx context.Context) ([]models.GasAlert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.gasAlerts, func(a *models.GasAlert) bool { return a.Active }), nil
+}
+
+// UpdateGasAlert implements AlertRepository.
+func (m *Memory) UpdateGasAlert(ctx context.Context, alert *models.GasAlert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := slices.IndexFunc(m.gasAlerts, func(a models.GasAlert) bool { return a.ID == alert.ID }); i >= 0 {
+		m.gasAlerts[i] = *alert
+	}
+	return nil
+}
+
+// RemoveGasAlert implements AlertRepository.
+func (m *Memory) RemoveGasAlert(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gasAlerts, _ = without(m.gasAlerts, func(a *models.GasAlert) bool { return a.ID == id })
+	return nil
+}
+
+// AddReferral implements ReferralRepository.
+func (m *Memory) AddReferral(ctx context.Context, referral *models.Referral) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.referrals = append(m.referrals, *referral)
+	return nil
+}
+
+// GetReferral implements ReferralRepository.
+func (m *Memory) GetReferral(ctx context.Context, chatID, referredID int64) (*models.Referral, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.referrals, func(r *models.Referral) bool { return r.ChatID == chatID && r.ReferredID == referredID }), nil
+}
+
+// GetReferralsBy implements ReferralRepository.
+func (m *Memory) GetReferralsBy(ctx context.Context, chatID, referrerID int64) ([]models.Referral, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.referrals, func(r *models.Referral) bool { return r.ChatID == chatID && r.ReferrerID == referrerID }), nil
+}
+
+// AddLinkedWallet implements WalletRepository.
+func (m *Memory) AddLinkedWallet(ctx context.Context, wallet *models.LinkedWallet) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *wallet
+	row.ID = m.nextID("linked_wallets")
+	m.wallets = put(m.wallets, row, func(w *models.LinkedWallet) bool {
+		return w.Chain == wallet.Chain && w.Address == wallet.Address
+	}, func(row, old *models.LinkedWallet) { row.ID = old.ID })
+	return nil
+}
+
+// GetLinkedWallets implements WalletRepository.
+func (m *Memory) GetLinkedWallets(ctx context.Context, telegramID int64) ([]models.LinkedWallet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.wallets, func(w *models.LinkedWallet) bool { return w.TelegramID == telegramID }), nil
+}
+
+// RemoveLinkedWallet implements WalletRepository.
+func (m *Memory) RemoveLinkedWallet(ctx context.Context, telegramID int64, address, chain string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed int
+	m.wallets, removed = without(m.wallets, func(w *models.LinkedWallet) bool {
+		return w.TelegramID == telegramID && w.Address == address && (chain == "" || w.Chain == chain)
+	})
+	return removed, nil
+}
+
+// SaveTokenGate implements TokenGateRepository.
+func (m *Memory) SaveTokenGate(ctx context.Context, gate *models.TokenGate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gates = put(m.gates, *gate, func(g *models.TokenGate) bool { return g.ChatID == gate.ChatID }, nil)
+	return nil
+}
+
+// GetTokenGate implements TokenGateRepository.
+func (m *Memory) GetTokenGate(ctx context.Context, chatID int64) (*models.TokenGate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.gates, func(g *models.TokenGate) bool { return g.ChatID == chatID }), nil
+}
+
+// GetTokenGates implements TokenGateRepository.
+func (m *Memory) GetTokenGates(ctx context.Context) ([]models.TokenGate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.gates), nil
+}
+
+// RemoveTokenGate implements TokenGateRepository.
+func (m *Memory) RemoveTokenGate(ctx context.Context, chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gates, _ = without(m.gates, func(g *models.TokenGate) bool { return g.ChatID == chatID })
+	m.gateMembers, _ = without(m.gateMembers, func(g *models.TokenGateMember) bool { return g.ChatID == chatID })
+	return nil
+}
+
+// SaveTokenGateMember implements TokenGateRepository.
+func (m *Memory) SaveTokenGateMember(ctx context.Context, member *models.TokenGateMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gateMembers = put(m.gateMembers, *member, func(g *models.TokenGateMember) bool {
+		return g.ChatID == member.ChatID && g.TelegramID == member.TelegramID
+	}, nil)
+	return nil
+}
+
+// GetTokenGateMembers implements TokenGateRepository.
+func (m *Memory) GetTokenGateMembers(ctx context.Context, chatID int64) ([]models.TokenGateMember, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.gateMembers, func(g *models.TokenGateMember) bool { return g.ChatID == chatID }), nil
+}
+
+// RemoveTokenGateMember implements TokenGateRepository.
+func (m *Memory) RemoveTokenGateMember(ctx context.Context, chatID, telegramID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gateMembers, _ = without(m.gateMembers, func(g *models.TokenGateMember) bool {
+		return g.ChatID == chatID && g.TelegramID == telegramID
+	})
+	return nil
+}
+
+// PinCollection implements CollectionRepository.
+func (m *Memory) PinCollection(ctx context.Context, pin *models.PinnedCollection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *pin
+	row.ID = m.nextID("pinned_collections")
+	m.collections = put(m.collections, row, func(p *models.PinnedCollection) bool {
+		return p.ChatID == pin.ChatID && p.Slug == pin.Slug
+	}, func(row, old *models.PinnedCollection) { row.ID = old.ID })
+	return nil
+}
+
+// GetPinnedCollections implements CollectionRepository.
+func (m *Memory) GetPinnedCollections(ctx context.Context, chatID int64) ([]models.PinnedCollection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pins := where(m.collections, func(p *models.PinnedCollection) bool { return p.ChatID == chatID })
+	sort.SliceStable(pins, func(i, j int) bool { return pins[i].CreatedAt.Before(pins[j].CreatedAt) })
+	return pins, nil
+}
+
+// UnpinCollection implements CollectionRepository.
+func (m *Memory) UnpinCollection(ctx context.Context, chatID int64, slug string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed int
+	m.collections, removed = without(m.collections, func(p *models.PinnedCollection) bool {
+		return p.ChatID == chatID && p.Slug == slug
+	})
+	return removed > 0, nil
+}
+
+// AddWatchlistCoins implements WatchlistRepository.
+func (m *Memory) AddWatchlistCoins(ctx context.Context, coins []models.WatchlistCoin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, coin := range coins {
+		coin.ID = m.nextID("watchlist_coins")
+		m.watchlist = put(m.watchlist, coin, func(w *models.WatchlistCoin) bool {
+			return w.ChatID == coin.ChatID && w.CoinID == coin.CoinID
+		}, func(row, old *models.WatchlistCoin) { row.ID = old.ID })
+	}
+	return nil
+}
+
+// GetWatchlist implements WatchlistRepository.
+func (m *Memory) GetWatchlist(ctx context.Context, chatID int64) ([]models.WatchlistCoin, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	coins := where(m.watchlist, func(w *models.WatchlistCoin) bool { return w.ChatID == chatID })
+	sort.SliceStable(coins, func(i, j int) bool { return coins[i].CreatedAt.Before(coins[j].CreatedAt) })
+	return coins, nil
+}
+
+// RemoveWatchlistCoins implements WatchlistRepository.
+func (m *Memory) RemoveWatchlistCoins(ctx context.Context, chatID int64, coinIDs []string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed int
+	m.watchlist, removed = without(m.watchlist, func(w *models.WatchlistCoin) bool {
+		return w.ChatID == chatID && slices.Contains(coinIDs, w.CoinID)
+	})
+	return removed, nil
+}
+
+// SaveDigestSchedule implements WatchlistRepository.
+func (m *Memory) SaveDigestSchedule(ctx context.Context, schedule *models.DigestSchedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.digests = put(m.digests, *schedule, func(d *models.DigestSchedule) bool { return d.ChatID == schedule.ChatID }, nil)
+	return nil
+}
+
+// GetDigestSchedule implements WatchlistRepository.
+func (m *Memory) GetDigestSchedule(ctx context.Context, chatID int64) (*models.DigestSchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return first(m.digests, func(d *models.DigestSchedule) bool { return d.ChatID == chatID }), nil
+}
+
+// GetDigestSchedules implements WatchlistRepository.
+func (m *Memory) GetDigestSchedules(ctx context.Context) ([]models.DigestSchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.digests), nil
+}
+
+// RemoveDigestSchedule implements WatchlistRepository.
+func (m *Memory) RemoveDigestSchedule(ctx context.Context, chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.digests, _ = without(m.digests, func(d *models.DigestSchedule) bool { return d.ChatID == chatID })
+	return nil
+}
+
+// AddHolding implements PortfolioRepository.
+func (m *Memory) AddHolding(ctx context.Context, holding *models.Holding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	holding.ID = m.nextID("portfolio_holdings")
+	m.holdings = append(m.holdings, *holding)
+	return nil
+}
+
+// GetHoldings implements PortfolioRepository.
+func (m *Memory) GetHoldings(ctx context.Context, telegramID int64) ([]models.Holding, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return where(m.holdings, func(h *models.Holding) bool { return h.TelegramID == telegramID }), nil
+}
+
+// UpdateHolding implements PortfolioRepository.
+func (m *Memory) UpdateHolding(ctx context.Context, holding *models.Holding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.holdings, func(h models.Holding) bool {
+		return h.ID == holding.ID && h.TelegramID == holding.TelegramID
+	})
+	if i >= 0 {
+		m.holdings[i] = *holding
+	}
+	return nil
+}
+
+// RemoveHolding implements PortfolioRepository.
+func (m *Memory) RemoveHolding(ctx context.Context, telegramID, id int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed int
+	m.holdings, removed = without(m.holdings, func(h *models.Holding) bool { return h.ID == id && h.TelegramID == telegramID })
+	return removed > 0, nil
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the schema of each dialect as numbered scripts, like
+// "migrations/sqlite/0002_add_warnings.sql". A script runs once, in its own transaction,
+// and is never edited after it shipped: schema changes go in a new script with the next
+// number, written for both dialects.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	script  string
+}
+
+// migrations returns the migrations of a dialect, oldest first.
+func migrations(dialect string) ([]migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %w", dialect, err)
+	}
+
+	var found []migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		number, name, _ := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(number)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s doesn't start with a version number", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		script, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, migration{version: version, name: name, script: string(script)})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].version < found[j].version })
+	return found, nil
+}
+
+// migrate applies the migrations the database hasn't seen yet, recording each in the
+// schema_migrations table.
+func (s *SQL) migrate(ctx context.Context) error {
+	pending, err := migrations(s.dialect)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(ctx, s.db, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	versions, err := selectAll(ctx, s, func(row scanner, version *int) error { return row.Scan(version) },
+		"SELECT version FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	for _, m := range pending {
+		if applied[m.version] {
+			delete(applied, m.version)
+			continue
+		}
+		err := s.inTx(ctx, func(tx *sql.Tx) error {
+			// Scripts hold several statements, so they run without placeholders.
+			if _, err := tx.ExecContext(ctx, m.script); err != nil {
+				return err
+			}
+			_, err := s.exec(ctx, tx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				m.version, m.name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Applied database migration %d (%s).", m.version, m.name)
+	}
+
+	// Whatever is left was applied by a newer build. Its changes are additions this build
+	// doesn't know about, so it can still run, but a rollback deserves a warning.
+	for version := range applied {
+		log.Printf("WARNING: database has migration %d, which this build doesn't know. Was the bot downgraded?", version)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+-- The tables the bot used on Supabase, so a Postgres database can take over from it.
+
+CREATE TABLE members (
+    id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    telegram_id BIGINT NOT NULL UNIQUE,
+    first_name  TEXT NOT NULL DEFAULT '',
+    last_name   TEXT NOT NULL DEFAULT '',
+    username    TEXT NOT NULL DEFAULT '',
+    joined_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE language_preferences (
+    id       BIGINT PRIMARY KEY,
+    language TEXT NOT NULL
+);
+
+CREATE TABLE chat_settings (
+    chat_id                  BIGINT PRIMARY KEY,
+    require_rules_acceptance BOOLEAN NOT NULL DEFAULT FALSE,
+    currency                 TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE rules_versions (
+    id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    chat_id    BIGINT NOT NULL,
+    version    INTEGER NOT NULL,
+    text       TEXT NOT NULL,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (chat_id, version)
+);
+
+CREATE TABLE rules_acceptances (
+    chat_id     BIGINT NOT NULL,
+    telegram_id BIGINT NOT NULL,
+    version     INTEGER NOT NULL,
+    accepted_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, telegram_id)
+);
+
+CREATE TABLE notes (
+    id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name       TEXT NOT NULL,
+    text       TEXT NOT NULL,
+    chat_id    BIGINT NOT NULL,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (chat_id, name)
+);
+
+CREATE TABLE filters (
+    id               BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    chat_id          BIGINT NOT NULL,
+    "trigger"        TEXT NOT NULL,
+    match_type       TEXT NOT NULL,
+    response         TEXT NOT NULL,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    created_by       BIGINT NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL,
+    UNIQUE (chat_id, "trigger")
+);
+
+CREATE TABLE price_alerts (
+    id              BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    chat_id         BIGINT NOT NULL,
+    created_by      BIGINT NOT NULL,
+    coin_id         TEXT NOT NULL,
+    coin_symbol     TEXT NOT NULL,
+    currency        TEXT NOT NULL,
+    condition       TEXT NOT NULL,
+    target          DOUBLE PRECISION NOT NULL,
+    reference_price DOUBLE PRECISION NOT NULL,
+    active          BOOLEAN NOT NULL,
+    snoozed_until   TIMESTAMPTZ,
+    triggered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX price_alerts_chat_id ON price_alerts (chat_id);
+
+CREATE TABLE gas_alerts (
+    id             BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    chat_id        BIGINT NOT NULL,
+    created_by     BIGINT NOT NULL,
+    chain          TEXT NOT NULL,
+    threshold      DOUBLE PRECISION NOT NULL,
+    reference_gwei DOUBLE PRECISION NOT NULL,
+    active         BOOLEAN NOT NULL,
+    triggered_at   TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX gas_alerts_chat_id ON gas_alerts (chat_id);
+
+CREATE TABLE referrals (
+    chat_id     BIGINT NOT NULL,
+    referrer_id BIGINT NOT NULL,
+    referred_id BIGINT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, referred_id)
+);
+CREATE INDEX referrals_referrer ON referrals (chat_id, referrer_id);
+
+CREATE TABLE linked_wallets (
+    id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    telegram_id BIGINT NOT NULL,
+    chain       TEXT NOT NULL,
+    address     TEXT NOT NULL,
+    linked_at   TIMESTAMPTZ NOT NULL,
+    UNIQUE (chain, address)
+);
+CREATE INDEX linked_wallets_user ON linked_wallets (telegram_id);
+
+CREATE TABLE token_gates (
+    chat_id     BIGINT PRIMARY KEY,
+    chain       TEXT NOT NULL,
+    contract    TEXT NOT NULL,
+    symbol      TEXT NOT NULL,
+    decimals    INTEGER NOT NULL,
+    min_balance TEXT NOT NULL,
+    created_by  BIGINT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE token_gate_members (
+    chat_id     BIGINT NOT NULL,
+    telegram_id BIGINT NOT NULL,
+    checked_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, telegram_id)
+);
+
+CREATE TABLE pinned_collections (
+    id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    chat_id    BIGINT NOT NULL,
+    slug       TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    added_by   BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (chat_id, slug)
+);
+
+CREATE TABLE watchlist_coins (
+    id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    chat_id    BIGINT NOT NULL,
+    coin_id    TEXT NOT NULL,
+    symbol     TEXT NOT NULL,
+    added_by   BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (chat_id, coin_id)
+);
+
+CREATE TABLE digest_schedules (
+    chat_id      BIGINT PRIMARY KEY,
+    "time"       TEXT NOT NULL,
+    timezone     TEXT NOT NULL,
+    chain        TEXT NOT NULL,
+    last_sent_on TEXT NOT NULL DEFAULT '',
+    created_by   BIGINT NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+
+-- Amounts and prices are decimal strings, like on Supabase, so they round-trip exactly.
+CREATE TABLE portfolio_holdings (
+    id          BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    telegram_id BIGINT NOT NULL,
+    coin_id     TEXT NOT NULL,
+    symbol      TEXT NOT NULL,
+    amount      TEXT NOT NULL,
+    price       TEXT NOT NULL,
+    currency    TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX portfolio_holdings_telegram_id ON portfolio_holdings (telegram_id);
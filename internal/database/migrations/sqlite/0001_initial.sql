@@ -0,0 +1,172 @@
+-- The tables the bot used on Supabase, in a single SQLite file.
+
+CREATE TABLE members (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    telegram_id INTEGER NOT NULL UNIQUE,
+    first_name  TEXT NOT NULL DEFAULT '',
+    last_name   TEXT NOT NULL DEFAULT '',
+    username    TEXT NOT NULL DEFAULT '',
+    joined_at   TIMESTAMP NOT NULL
+);
+
+CREATE TABLE language_preferences (
+    id       INTEGER PRIMARY KEY,
+    language TEXT NOT NULL
+);
+
+CREATE TABLE chat_settings (
+    chat_id                  INTEGER PRIMARY KEY,
+    require_rules_acceptance BOOLEAN NOT NULL DEFAULT 0,
+    currency                 TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE rules_versions (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id    INTEGER NOT NULL,
+    version    INTEGER NOT NULL,
+    text       TEXT NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (chat_id, version)
+);
+
+CREATE TABLE rules_acceptances (
+    chat_id     INTEGER NOT NULL,
+    telegram_id INTEGER NOT NULL,
+    version     INTEGER NOT NULL,
+    accepted_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chat_id, telegram_id)
+);
+
+CREATE TABLE notes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT NOT NULL,
+    text       TEXT NOT NULL,
+    chat_id    INTEGER NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (chat_id, name)
+);
+
+CREATE TABLE filters (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id          INTEGER NOT NULL,
+    "trigger"        TEXT NOT NULL,
+    match_type       TEXT NOT NULL,
+    response         TEXT NOT NULL,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    created_by       INTEGER NOT NULL,
+    created_at       TIMESTAMP NOT NULL,
+    UNIQUE (chat_id, "trigger")
+);
+
+CREATE TABLE price_alerts (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id         INTEGER NOT NULL,
+    created_by      INTEGER NOT NULL,
+    coin_id         TEXT NOT NULL,
+    coin_symbol     TEXT NOT NULL,
+    currency        TEXT NOT NULL,
+    condition       TEXT NOT NULL,
+    target          REAL NOT NULL,
+    reference_price REAL NOT NULL,
+    active          BOOLEAN NOT NULL,
+    snoozed_until   TIMESTAMP,
+    triggered_at    TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL
+);
+CREATE INDEX price_alerts_chat_id ON price_alerts (chat_id);
+
+CREATE TABLE gas_alerts (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id        INTEGER NOT NULL,
+    created_by     INTEGER NOT NULL,
+    chain          TEXT NOT NULL,
+    threshold      REAL NOT NULL,
+    reference_gwei REAL NOT NULL,
+    active         BOOLEAN NOT NULL,
+    triggered_at   TIMESTAMP,
+    created_at     TIMESTAMP NOT NULL
+);
+CREATE INDEX gas_alerts_chat_id ON gas_alerts (chat_id);
+
+CREATE TABLE referrals (
+    chat_id     INTEGER NOT NULL,
+    referrer_id INTEGER NOT NULL,
+    referred_id INTEGER NOT NULL,
+    created_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (chat_id, referred_id)
+);
+CREATE INDEX referrals_referrer ON referrals (chat_id, referrer_id);
+
+CREATE TABLE linked_wallets (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    telegram_id INTEGER NOT NULL,
+    chain       TEXT NOT NULL,
+    address     TEXT NOT NULL,
+    linked_at   TIMESTAMP NOT NULL,
+    UNIQUE (chain, address)
+);
+CREATE INDEX linked_wallets_user ON linked_wallets (telegram_id);
+
+CREATE TABLE token_gates (
+    chat_id     INTEGER PRIMARY KEY,
+    chain       TEXT NOT NULL,
+    contract    TEXT NOT NULL,
+    symbol      TEXT NOT NULL,
+    decimals    INTEGER NOT NULL,
+    min_balance TEXT NOT NULL,
+    created_by  INTEGER NOT NULL,
+    created_at  TIMESTAMP NOT NULL
+);
+
+CREATE TABLE token_gate_members (
+    chat_id     INTEGER NOT NULL,
+    telegram_id INTEGER NOT NULL,
+    checked_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (chat_id, telegram_id)
+);
+
+CREATE TABLE pinned_collections (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id    INTEGER NOT NULL,
+    slug       TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    added_by   INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (chat_id, slug)
+);
+
+CREATE TABLE watchlist_coins (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id    INTEGER NOT NULL,
+    coin_id    TEXT NOT NULL,
+    symbol     TEXT NOT NULL,
+    added_by   INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (chat_id, coin_id)
+);
+
+CREATE TABLE digest_schedules (
+    chat_id      INTEGER PRIMARY KEY,
+    "time"       TEXT NOT NULL,
+    timezone     TEXT NOT NULL,
+    chain        TEXT NOT NULL,
+    last_sent_on TEXT NOT NULL DEFAULT '',
+    created_by   INTEGER NOT NULL,
+    updated_at   TIMESTAMP NOT NULL
+);
+
+-- Amounts and prices are decimal strings, like on Supabase, so they round-trip exactly.
+CREATE TABLE portfolio_holdings (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    telegram_id INTEGER NOT NULL,
+    coin_id     TEXT NOT NULL,
+    symbol      TEXT NOT NULL,
+    amount      TEXT NOT NULL,
+    price       TEXT NOT NULL,
+    currency    TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX portfolio_holdings_telegram_id ON portfolio_holdings (telegram_id);
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// SaveNote inserts or replaces a chat's note in the 'notes' table.
+func (c *Client) SaveNote(ctx context.Context, note *models.Note) error {
+	data := []models.Note{*note}
+
+	_, _, err := c.From("notes").Upsert(data, "chat_id,name", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to save note to supabase: %w", err)
+	}
+
+	log.Printf("Saved note %q for chat %d.", note.Name, note.ChatID)
+	return nil
+}
+
+// GetNote returns a chat's note by name, or nil if it doesn't exist.
+func (c *Client) GetNote(ctx context.Context, chatID int64, name string) (*models.Note, error) {
+	var notes []models.Note
+	_, err := c.From("notes").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("name", name).
+		ExecuteTo(&notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch note from supabase: %w", err)
+	}
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	return &notes[0], nil
+}
+
+// SearchNotes returns up to limit notes of a chat whose name starts with prefix. An empty prefix lists all of them.
+func (c *Client) SearchNotes(ctx context.Context, chatID int64, prefix string, limit int) ([]models.Note, error) {
+	var notes []models.Note
+	query := c.From("notes").Select("*", "", false).Eq("chat_id", fmt.Sprintf("%d", chatID))
+	if prefix != "" {
+		query = query.Like("name", prefix+"*")
+	}
+	_, err := query.Limit(limit, "").ExecuteTo(&notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes in supabase: %w", err)
+	}
+	return notes, nil
+}
+
+// GetNoteChats returns the chats that have notes.
+func (c *Client) GetNoteChats(ctx context.Context) ([]int64, error) {
+	var notes []models.Note
+	_, err := c.From("notes").Select("chat_id", "", false).ExecuteTo(&notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch note chats from supabase: %w", err)
+	}
+
+	seen := make(map[int64]bool)
+	var chats []int64
+	for _, n := range notes {
+		if !seen[n.ChatID] {
+			seen[n.ChatID] = true
+			chats = append(chats, n.ChatID)
+		}
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i] < chats[j] })
+	return chats, nil
+}
+
+// RemoveNote deletes a chat's note by name.
+func (c *Client) RemoveNote(ctx context.Context, chatID int64, name string) error {
+	_, _, err := c.From("notes").Delete("", "").
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("name", name).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove note from supabase: %w", err)
+	}
+
+	log.Printf("Removed note %q from chat %d.", name, chatID)
+	return nil
+}
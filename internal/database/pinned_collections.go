@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// PinCollection adds an NFT collection to a chat's 'pinned_collections'. Pinning it again only
+// refreshes its name.
+func (c *Client) PinCollection(ctx context.Context, pin *models.PinnedCollection) error {
+	data := []models.PinnedCollection{*pin}
+
+	_, _, err := c.From("pinned_collections").Upsert(data, "chat_id,slug", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to pin collection in supabase: %w", err)
+	}
+
+	log.Printf("Pinned collection %s in chat %d.", pin.Slug, pin.ChatID)
+	return nil
+}
+
+// GetPinnedCollections returns the NFT collections a chat pinned, oldest first.
+func (c *Client) GetPinnedCollections(ctx context.Context, chatID int64) ([]models.PinnedCollection, error) {
+	var pins []models.PinnedCollection
+	_, err := c.From("pinned_collections").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&pins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pinned collections from supabase: %w", err)
+	}
+	sort.SliceStable(pins, func(i, j int) bool { return pins[i].CreatedAt.Before(pins[j].CreatedAt) })
+	return pins, nil
+}
+
+// UnpinCollection removes an NFT collection from a chat's pins and reports whether it was pinned.
+func (c *Client) UnpinCollection(ctx context.Context, chatID int64, slug string) (bool, error) {
+	var removed []models.PinnedCollection
+	_, err := c.From("pinned_collections").Delete("representation", "").
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("slug", slug).
+		ExecuteTo(&removed)
+	if err != nil {
+		return false, fmt.Errorf("failed to unpin collection in supabase: %w", err)
+	}
+
+	log.Printf("Unpinned collection %s in chat %d.", slug, chatID)
+	return len(removed) > 0, nil
+}
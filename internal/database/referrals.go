@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// AddReferral inserts a referral into the 'referrals' table.
+func (c *Client) AddReferral(ctx context.Context, referral *models.Referral) error {
+	data := []models.Referral{*referral}
+
+	_, _, err := c.From("referrals").Insert(data, false, "", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to add referral to supabase: %w", err)
+	}
+
+	log.Printf("User %d was referred to chat %d by %d.", referral.ReferredID, referral.ChatID, referral.ReferrerID)
+	return nil
+}
+
+// GetReferral returns who referred a user to a chat, or nil if nobody did.
+func (c *Client) GetReferral(ctx context.Context, chatID, referredID int64) (*models.Referral, error) {
+	var referrals []models.Referral
+	_, err := c.From("referrals").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("referred_id", fmt.Sprintf("%d", referredID)).
+		ExecuteTo(&referrals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch referral from supabase: %w", err)
+	}
+	if len(referrals) == 0 {
+		return nil, nil
+	}
+	return &referrals[0], nil
+}
+
+// GetReferralsBy returns every referral a member made to a chat.
+func (c *Client) GetReferralsBy(ctx context.Context, chatID, referrerID int64) ([]models.Referral, error) {
+	var referrals []models.Referral
+	_, err := c.From("referrals").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("referrer_id", fmt.Sprintf("%d", referrerID)).
+		ExecuteTo(&referrals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch referrals from supabase: %w", err)
+	}
+	return referrals, nil
+}
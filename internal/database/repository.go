@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// The repositories below split storage by domain, so code can ask for only what it uses.
+// Every method has the same meaning in every implementation: lookups of a single record
+// return nil, not an error, when there is none, and saves of records with a natural key
+// replace the record with that key.
+
+// MemberRepository stores the members the bot has seen join.
+type MemberRepository interface {
+	// AddUser stores a member, leaving an existing member with the same Telegram ID alone.
+	AddUser(ctx context.Context, user *models.User) error
+	RemoveUser(ctx context.Context, telegramID int64) error
+	GetUser(ctx context.Context, telegramID int64) (*models.User, error)
+}
+
+// LanguageRepository stores the reply language of chats and users.
+type LanguageRepository interface {
+	SetLanguage(ctx context.Context, id int64, language string) error
+	// GetLanguage returns "" if no language was chosen.
+	GetLanguage(ctx context.Context, id int64) (string, error)
+}
+
+// SettingsRepository stores per-chat settings.
+type SettingsRepository interface {
+	// GetChatSettings returns the defaults for chats that never changed a setting.
+	GetChatSettings(ctx context.Context, chatID int64) (*models.ChatSettings, error)
+	SaveChatSettings(ctx context.Context, settings *models.ChatSettings) error
+}
+
+// RulesRepository stores the versioned rules of chats and which version members accepted.
+type RulesRepository interface {
+	// AddRulesVersion stores rules as the chat's next version and sets rules.Version to it.
+	// Versions stay unique when admins save at the same time.
+	AddRulesVersion(ctx context.Context, rules *models.RulesVersion) error
+	GetLatestRules(ctx context.Context, chatID int64) (*models.RulesVersion, error)
+	// GetRulesHistory returns every version, newest first.
+	GetRulesHistory(ctx context.Context, chatID int64) ([]models.RulesVersion, error)
+	RecordRulesAcceptance(ctx context.Context, acceptance *models.RulesAcceptance) error
+	GetRulesAcceptance(ctx context.Context, chatID, telegramID int64) (*models.RulesAcceptance, error)
+}
+
+// NoteRepository stores the notes of each chat, keyed by chat and name.
+type NoteRepository interface {
+	SaveNote(ctx context.Context, note *models.Note) error
+	GetNote(ctx context.Context, chatID int64, name string) (*models.Note, error)
+	// SearchNotes returns up to limit notes of a chat whose name starts with prefix, or any
+	// of its notes if prefix is "".
+	SearchNotes(ctx context.Context, chatID int64, prefix string, limit int) ([]models.Note, error)
+	// GetNoteChats returns the chats that have at least one note.
+	GetNoteChats(ctx context.Context) ([]int64, error)
+	RemoveNote(ctx context.Context, chatID int64, name string) error
+}
+
+// FilterRepository stores keyword filters, keyed by chat and trigger.
+type FilterRepository interface {
+	SaveFilter(ctx context.Context, filter *models.Filter) error
+	RemoveFilter(ctx context.Context, chatID int64, trigger string) error
+	GetFilters(ctx context.Context, chatID int64) ([]models.Filter, error)
+}
+
+// AlertRepository stores price and gas alerts. Adding an alert sets its ID.
+type AlertRepository interface {
+	AddPriceAlert(ctx context.Context, alert *models.PriceAlert) error
+	GetPriceAlert(ctx context.Context, id int64) (*models.PriceAlert, error)
+	GetPriceAlerts(ctx context.Context, chatID int64) ([]models.PriceAlert, error)
+	GetActivePriceAlerts(ctx context.Context) ([]models.PriceAlert, error)
+	UpdatePriceAlert(ctx context.Context, alert *models.PriceAlert) error
+	RemovePriceAlert(ctx context.Context, id int64) error
+
+	AddGasAlert(ctx context.Context, alert *models.GasAlert) error
+	GetGasAlert(ctx context.Context, id int64) (*models.GasAlert, error)
+	GetGasAlerts(ctx context.Context, chatID int64) ([]models.GasAlert, error)
+	GetActiveGasAlerts(ctx context.Context) ([]models.GasAlert, error)
+	UpdateGasAlert(ctx context.Context, alert *models.GasAlert) error
+	RemoveGasAlert(ctx context.Context, id int64) error
+}
+
+// ReferralRepository stores who invited whom to a chat.
+type ReferralRepository interface {
+	AddReferral(ctx context.Context, referral *models.Referral) error
+	GetReferral(ctx context.Context, chatID, referredID int64) (*models.Referral, error)
+	GetReferralsBy(ctx context.Context, chatID, referrerID int64) ([]models.Referral, error)
+}
+
+// WalletRepository stores the wallets users linked. An address belongs to one user per chain.
+type WalletRepository interface {
+	// AddLinkedWallet links an address to a user, moving it away from whoever linked it before.
+	// Callers must have just verified a signature by the wallet.
+	AddLinkedWallet(ctx context.Context, wallet *models.LinkedWallet) error
+	GetLinkedWallets(ctx context.Context, telegramID int64) ([]models.LinkedWallet, error)
+	// RemoveLinkedWallet unlinks an address on one chain, or on all of them if chain is "",
+	// and returns how many links it removed.
+	RemoveLinkedWallet(ctx context.Context, telegramID int64, address, chain string) (int, error)
+}
+
+// TokenGateRepository stores the token gate of each chat and the members it admitted.
+type TokenGateRepository interface {
+	SaveTokenGate(ctx context.Context, gate *models.TokenGate) error
+	GetTokenGate(ctx context.Context, chatID int64) (*models.TokenGate, error)
+	GetTokenGates(ctx context.Context) ([]models.TokenGate, error)
+	// RemoveTokenGate removes a chat's gate together with its admitted members.
+	RemoveTokenGate(ctx context.Context, chatID int64) error
+	SaveTokenGateMember(ctx context.Context, member *models.TokenGateMember) error
+	GetTokenGateMembers(ctx context.Context, chatID int64) ([]models.TokenGateMember, error)
+	RemoveTokenGateMember(ctx context.Context, chatID, telegramID int64) error
+}
+
+// CollectionRepository stores the NFT collections chats pinned, keyed by chat and slug.
+type CollectionRepository interface {
+	PinCollection(ctx context.Context, pin *models.PinnedCollection) error
+	// GetPinnedCollections returns a chat's collections, oldest pin first.
+	GetPinnedCollections(ctx context.Context, chatID int64) ([]models.PinnedCollection, error)
+	UnpinCollection(ctx context.Context, chatID int64, slug string) (bool, error)
+}
+
+// WatchlistRepository stores chat watchlists and their digest schedules.
+type WatchlistRepository interface {
+	AddWatchlistCoins(ctx context.Context, coins []models.WatchlistCoin) error
+	// GetWatchlist returns a chat's coins, oldest first.
+	GetWatchlist(ctx context.Context, chatID int64) ([]models.WatchlistCoin, error)
+	RemoveWatchlistCoins(ctx context.Context, chatID int64, coinIDs []string) (int, error)
+	SaveDigestSchedule(ctx context.Context, schedule *models.DigestSchedule) error
+	GetDigestSchedule(ctx context.Context, chatID int64) (*models.DigestSchedule, error)
+	GetDigestSchedules(ctx context.Context) ([]models.DigestSchedule, error)
+	RemoveDigestSchedule(ctx context.Context, chatID int64) error
+}
+
+// PortfolioRepository stores the purchases in users' portfolios. Adding a holding sets its ID.
+type PortfolioRepository interface {
+	AddHolding(ctx context.Context, holding *models.Holding) error
+	// GetHoldings returns a user's holdings in the order they were added.
+	GetHoldings(ctx context.Context, telegramID int64) ([]models.Holding, error)
+	UpdateHolding(ctx context.Context, holding *models.Holding) error
+	RemoveHolding(ctx context.Context, telegramID, id int64) (bool, error)
+}
+
+// Store is all of the bot's storage. Handlers take a Store; the Supabase Client, the SQL
+// database and the in-memory Memory are its implementations.
+type Store interface {
+	MemberRepository
+	LanguageRepository
+	SettingsRepository
+	RulesRepository
+	NoteRepository
+	FilterRepository
+	AlertRepository
+	ReferralRepository
+	WalletRepository
+	TokenGateRepository
+	CollectionRepository
+	WatchlistRepository
+	PortfolioRepository
+}
+
+var (
+	_ Store = (*Client)(nil)
+	_ Store = (*SQL)(nil)
+	_ Store = (*Memory)(nil)
+)
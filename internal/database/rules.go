@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// rulesVersionAttempts is how many version numbers AddRulesVersion tries when other saves
+// keep taking the next one first.
+const rulesVersionAttempts = 5
+
+// AddRulesVersion stores a new revision of a chat's rules in the 'rules_versions' table.
+// The unique (chat_id, version) key rejects a version another save took in the meantime,
+// and the next one is tried.
+func (c *Client) AddRulesVersion(ctx context.Context, rules *models.RulesVersion) error {
+	for attempt := 1; ; attempt++ {
+		latest, err := c.GetLatestRules(ctx, rules.ChatID)
+		if err != nil {
+			return err
+		}
+		row := *rules
+		row.Version = 1
+		if latest != nil {
+			row.Version = latest.Version + 1
+		}
+
+		_, _, err = c.From("rules_versions").Insert([]models.RulesVersion{row}, false, "", "minimal", "").Execute()
+		if err != nil && strings.HasPrefix(err.Error(), "(23505)") && attempt < rulesVersionAttempts {
+			continue // Unique violation: someone else saved this version first.
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add rules version to supabase: %w", err)
+		}
+
+		rules.Version = row.Version
+		log.Printf("Saved rules version %d for chat %d.", rules.Version, rules.ChatID)
+		return nil
+	}
+}
+
+// GetLatestRules returns the current rules of a chat, or nil if the chat never set any.
+func (c *Client) GetLatestRules(ctx context.Context, chatID int64) (*models.RulesVersion, error) {
+	var rules []models.RulesVersion
+	_, err := c.From("rules_versions").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Order("version", nil). // Descending by default.
+		Limit(1, "").
+		ExecuteTo(&rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules from supabase: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return &rules[0], nil
+}
+
+// GetRulesHistory returns every rules version of a chat, newest first.
+func (c *Client) GetRulesHistory(ctx context.Context, chatID int64) ([]models.RulesVersion, error) {
+	var rules []models.RulesVersion
+	_, err := c.From("rules_versions").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Order("version", nil).
+		ExecuteTo(&rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules history from supabase: %w", err)
+	}
+	return rules, nil
+}
+
+// RecordRulesAcceptance stores the rules version a member accepted, replacing any earlier acceptance.
+func (c *Client) RecordRulesAcceptance(ctx context.Context, acceptance *models.RulesAcceptance) error {
+	data := []models.RulesAcceptance{*acceptance}
+
+	_, _, err := c.From("rules_acceptances").Upsert(data, "chat_id,telegram_id", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to record rules acceptance in supabase: %w", err)
+	}
+
+	log.Printf("User %d accepted rules version %d in chat %d.", acceptance.TelegramID, acceptance.Version, acceptance.ChatID)
+	return nil
+}
+
+// GetRulesAcceptance returns the acceptance record of a member, or nil if they never accepted the rules.
+func (c *Client) GetRulesAcceptance(ctx context.Context, chatID, telegramID int64) (*models.RulesAcceptance, error) {
+	var acceptances []models.RulesAcceptance
+	_, err := c.From("rules_acceptances").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("telegram_id", fmt.Sprintf("%d", telegramID)).
+		ExecuteTo(&acceptances)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules acceptance from supabase: %w", err)
+	}
+	if len(acceptances) == 0 {
+		return nil, nil
+	}
+	return &acceptances[0], nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// GetChatSettings returns the settings of a chat, or the defaults if the chat never changed any.
+func (c *Client) GetChatSettings(ctx context.Context, chatID int64) (*models.ChatSettings, error) {
+	var settings []models.ChatSettings
+	_, err := c.From("chat_settings").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chat settings from supabase: %w", err)
+	}
+	if len(settings) == 0 {
+		return &models.ChatSettings{ChatID: chatID}, nil
+	}
+	return &settings[0], nil
+}
+
+// SaveChatSettings inserts or replaces the settings row of a chat.
+func (c *Client) SaveChatSettings(ctx context.Context, settings *models.ChatSettings) error {
+	data := []models.ChatSettings{*settings}
+
+	_, _, err := c.From("chat_settings").Upsert(data, "chat_id", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to save chat settings to supabase: %w", err)
+	}
+	return nil
+}
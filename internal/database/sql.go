@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	// Registers the "pgx" driver for Postgres.
+	_ "github.com/jackc/pgx/v5/stdlib"
+	// Registers the "sqlite" driver, which is pure Go, so the bot still builds without cgo.
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQL dialects the SQL store speaks.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// sqliteOptions make a SQLite file usable by a bot that handles updates concurrently:
+// writers wait for each other instead of failing, and readers don't block writers.
+const sqliteOptions = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+
+// SQL is a Store backed by a plain SQL database, for running the bot without Supabase:
+// SQLite, which needs nothing but a file, or Postgres. The schema is created and kept up
+// to date by the migrations embedded in the binary.
+type SQL struct {
+	db      *sql.DB
+	dialect string
+}
+
+// OpenSQL connects to the database at url and applies any pending migrations. URLs starting
+// with "postgres://" or "postgresql://" go to Postgres. Anything else is a SQLite file,
+// optionally prefixed with "sqlite://" or "sqlite:", like "sqlite:///var/lib/byb/bot.db".
+func OpenSQL(ctx context.Context, url string) (*SQL, error) {
+	driver, dialect, dsn := "pgx", DialectPostgres, url
+	if !strings.HasPrefix(url, "postgres://") && !strings.HasPrefix(url, "postgresql://") {
+		driver, dialect = "sqlite", DialectSQLite
+		dsn = strings.TrimPrefix(strings.TrimPrefix(url, "sqlite://"), "sqlite:")
+		if dsn == "" {
+			return nil, errors.New("no SQLite database file given")
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + sqliteOptions
+		} else {
+			dsn += "?" + sqliteOptions
+		}
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", dialect, err)
+	}
+	if dialect == DialectSQLite {
+		// SQLite has a single writer anyway, and one connection keeps ":memory:" databases whole.
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %w", dialect, err)
+	}
+
+	s := &SQL{db: db, dialect: dialect}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Printf("Successfully connected to the %s database.", dialect)
+	return s, nil
+}
+
+// Close closes the database.
+func (s *SQL) Close() error { return s.db.Close() }
+
+// Dialect returns DialectSQLite or DialectPostgres.
+func (s *SQL) Dialect() string { return s.dialect }
+
+// rebind turns the "?" placeholders queries are written with into Postgres' "$1", "$2"...
+// Queries never contain a literal "?", so no quoting has to be respected.
+func (s *SQL) rebind(query string) string {
+	if s.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// execer is a *sql.DB or a *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (s *SQL) exec(ctx context.Context, e execer, query string, args ...any) (int, error) {
+	result, err := e.ExecContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// insertID runs an INSERT ... RETURNING id and returns the new row's ID.
+func (s *SQL) insertID(ctx context.Context, query string, args ...any) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, s.rebind(query+" RETURNING id"), args...).Scan(&id)
+	return id, err
+}
+
+// inTx runs fn in a transaction, which is rolled back if fn fails.
+func (s *SQL) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanner is a *sql.Row or *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// selectAll runs a query and scans every row it returns.
+func selectAll[T any](ctx context.Context, s *SQL, scan func(scanner, *T) error, query string, args ...any) ([]T, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []T
+	for rows.Next() {
+		var row T
+		if err := scan(rows, &row); err != nil {
+			return nil, err
+		}
+		found = append(found, row)
+	}
+	return found, rows.Err()
+}
+
+// selectOne runs a query and scans the first row it returns, or returns nil if there is none.
+func selectOne[T any](ctx context.Context, s *SQL, scan func(scanner, *T) error, query string, args ...any) (*T, error) {
+	var row T
+	err := scan(s.db.QueryRowContext(ctx, s.rebind(query), args...), &row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint rejecting a duplicate key.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// placeholders returns "?, ?, ?" for n values, for IN lists.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,762 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"unicode/utf8"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// The columns each table is read with, in the order its scan function expects.
+const (
+	memberColumns          = "id, telegram_id, first_name, last_name, username, joined_at"
+	settingsColumns        = "chat_id, require_rules_acceptance, currency"
+	rulesColumns           = "id, chat_id, version, text, created_by, created_at"
+	acceptanceColumns      = "chat_id, telegram_id, version, accepted_at"
+	noteColumns            = "id, name, text, chat_id, created_by, created_at"
+	filterColumns          = `id, chat_id, "trigger", match_type, response, cooldown_seconds, created_by, created_at`
+	priceAlertColumns      = "id, chat_id, created_by, coin_id, coin_symbol, currency, condition, target, reference_price, active, snoozed_until, triggered_at, created_at"
+	gasAlertColumns        = "id, chat_id, created_by, chain, threshold, reference_gwei, active, triggered_at, created_at"
+	referralColumns        = "chat_id, referrer_id, referred_id, created_at"
+	walletColumns          = "id, telegram_id, chain, address, linked_at"
+	tokenGateColumns       = "chat_id, chain, contract, symbol, decimals, min_balance, created_by, created_at"
+	tokenGateMemberColumns = "chat_id, telegram_id, checked_at"
+	collectionColumns      = "id, chat_id, slug, name, added_by, created_at"
+	watchlistColumns       = "id, chat_id, coin_id, symbol, added_by, created_at"
+	digestColumns          = `chat_id, "time", timezone, chain, last_sent_on, created_by, updated_at`
+	holdingColumns         = "id, telegram_id, coin_id, symbol, amount, price, currency, created_at, updated_at"
+)
+
+func scanMember(row scanner, u *models.User) error {
+	return row.Scan(&u.ID, &u.TelegramID, &u.FirstName, &u.LastName, &u.Username, &u.JoinedAt)
+}
+
+func scanSettings(row scanner, s *models.ChatSettings) error {
+	return row.Scan(&s.ChatID, &s.RequireRulesAcceptance, &s.Currency)
+}
+
+func scanRules(row scanner, r *models.RulesVersion) error {
+	return row.Scan(&r.ID, &r.ChatID, &r.Version, &r.Text, &r.CreatedBy, &r.CreatedAt)
+}
+
+func scanAcceptance(row scanner, a *models.RulesAcceptance) error {
+	return row.Scan(&a.ChatID, &a.TelegramID, &a.Version, &a.AcceptedAt)
+}
+
+func scanNote(row scanner, n *models.Note) error {
+	return row.Scan(&n.ID, &n.Name, &n.Text, &n.ChatID, &n.CreatedBy, &n.CreatedAt)
+}
+
+func scanFilter(row scanner, f *models.Filter) error {
+	return row.Scan(&f.ID, &f.ChatID, &f.Trigger, &f.MatchType, &f.Response, &f.CooldownSeconds, &f.CreatedBy, &f.CreatedAt)
+}
+
+func scanPriceAlert(row scanner, a *models.PriceAlert) error {
+	return row.Scan(&a.ID, &a.ChatID, &a.CreatedBy, &a.CoinID, &a.CoinSymbol, &a.Currency, &a.Condition,
+		&a.Target, &a.ReferencePrice, &a.Active, &a.SnoozedUntil, &a.TriggeredAt, &a.CreatedAt)
+}
+
+func scanGasAlert(row scanner, a *models.GasAlert) error {
+	return row.Scan(&a.ID, &a.ChatID, &a.CreatedBy, &a.Chain, &a.Threshold, &a.ReferenceGwei, &a.Active, &a.TriggeredAt, &a.CreatedAt)
+}
+
+func scanReferral(row scanner, r *models.Referral) error {
+	return row.Scan(&r.ChatID, &r.ReferrerID, &r.ReferredID, &r.CreatedAt)
+}
+
+func scanWallet(row scanner, w *models.LinkedWallet) error {
+	return row.Scan(&w.ID, &w.TelegramID, &w.Chain, &w.Address, &w.LinkedAt)
+}
+
+func scanTokenGate(row scanner, g *models.TokenGate) error {
+	return row.Scan(&g.ChatID, &g.Chain, &g.Contract, &g.Symbol, &g.Decimals, &g.MinBalance, &g.CreatedBy, &g.CreatedAt)
+}
+
+func scanTokenGateMember(row scanner, m *models.TokenGateMember) error {
+	return row.Scan(&m.ChatID, &m.TelegramID, &m.CheckedAt)
+}
+
+func scanCollection(row scanner, p *models.PinnedCollection) error {
+	return row.Scan(&p.ID, &p.ChatID, &p.Slug, &p.Name, &p.AddedBy, &p.CreatedAt)
+}
+
+func scanWatchlistCoin(row scanner, w *models.WatchlistCoin) error {
+	return row.Scan(&w.ID, &w.ChatID, &w.CoinID, &w.Symbol, &w.AddedBy, &w.CreatedAt)
+}
+
+func scanDigest(row scanner, d *models.DigestSchedule) error {
+	return row.Scan(&d.ChatID, &d.Time, &d.Timezone, &d.Chain, &d.LastSentOn, &d.CreatedBy, &d.UpdatedAt)
+}
+
+func scanHolding(row scanner, h *models.Holding) error {
+	return row.Scan(&h.ID, &h.TelegramID, &h.CoinID, &h.Symbol, &h.Amount, &h.Price, &h.Currency, &h.CreatedAt, &h.UpdatedAt)
+}
+
+// AddUser implements MemberRepository.
+func (s *SQL) AddUser(ctx context.Context, user *models.User) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO members (telegram_id, first_name, last_name, username, joined_at)
+		VALUES (?, ?, ?, ?, ?) ON CONFLICT (telegram_id) DO NOTHING`,
+		user.TelegramID, user.FirstName, user.LastName, user.Username, user.JoinedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add user to the database: %w", err)
+	}
+	log.Printf("Successfully added/verified user %d in database.", user.TelegramID)
+	return nil
+}
+
+// RemoveUser implements MemberRepository.
+func (s *SQL) RemoveUser(ctx context.Context, telegramID int64) error {
+	if _, err := s.exec(ctx, s.db, "DELETE FROM members WHERE telegram_id = ?", telegramID); err != nil {
+		return fmt.Errorf("failed to remove user from the database: %w", err)
+	}
+	log.Printf("Successfully removed user %d from database.", telegramID)
+	return nil
+}
+
+// GetUser implements MemberRepository.
+func (s *SQL) GetUser(ctx context.Context, telegramID int64) (*models.User, error) {
+	user, err := selectOne(ctx, s, scanMember, "SELECT "+memberColumns+" FROM members WHERE telegram_id = ?", telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user from the database: %w", err)
+	}
+	return user, nil
+}
+
+// SetLanguage implements LanguageRepository.
+func (s *SQL) SetLanguage(ctx context.Context, id int64, language string) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO language_preferences (id, language) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET language = excluded.language`, id, language)
+	if err != nil {
+		return fmt.Errorf("failed to save language preference to the database: %w", err)
+	}
+	log.Printf("Set language of %d to %s.", id, language)
+	return nil
+}
+
+// GetLanguage implements LanguageRepository.
+func (s *SQL) GetLanguage(ctx context.Context, id int64) (string, error) {
+	language, err := selectOne(ctx, s, func(row scanner, language *string) error { return row.Scan(language) },
+		"SELECT language FROM language_preferences WHERE id = ?", id)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch language preference from the database: %w", err)
+	}
+	if language == nil {
+		return "", nil
+	}
+	return *language, nil
+}
+
+// GetChatSettings implements SettingsRepository.
+func (s *SQL) GetChatSettings(ctx context.Context, chatID int64) (*models.ChatSettings, error) {
+	settings, err := selectOne(ctx, s, scanSettings, "SELECT "+settingsColumns+" FROM chat_settings WHERE chat_id = ?", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chat settings from the database: %w", err)
+	}
+	if settings == nil {
+		return &models.ChatSettings{ChatID: chatID}, nil
+	}
+	return settings, nil
+}
+
+// SaveChatSettings implements SettingsRepository.
+func (s *SQL) SaveChatSettings(ctx context.Context, settings *models.ChatSettings) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO chat_settings (chat_id, require_rules_acceptance, currency) VALUES (?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET require_rules_acceptance = excluded.require_rules_acceptance, currency = excluded.currency`,
+		settings.ChatID, settings.RequireRulesAcceptance, settings.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to save chat settings to the database: %w", err)
+	}
+	return nil
+}
+
+// AddRulesVersion implements RulesRepository. The unique (chat_id, version) key rejects a
+// version another save took in the meantime, and the next one is tried.
+func (s *SQL) AddRulesVersion(ctx context.Context, rules *models.RulesVersion) error {
+	for attempt := 1; ; attempt++ {
+		var version int
+		err := s.db.QueryRowContext(ctx, s.rebind("SELECT COALESCE(MAX(version), 0) + 1 FROM rules_versions WHERE chat_id = ?"),
+			rules.ChatID).Scan(&version)
+		if err != nil {
+			return fmt.Errorf("failed to fetch rules from the database: %w", err)
+		}
+
+		_, err = s.exec(ctx, s.db, "INSERT INTO rules_versions (chat_id, version, text, created_by, created_at) VALUES (?, ?, ?, ?, ?)",
+			rules.ChatID, version, rules.Text, rules.CreatedBy, rules.CreatedAt)
+		if isUniqueViolation(err) && attempt < rulesVersionAttempts {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add rules version to the database: %w", err)
+		}
+
+		rules.Version = version
+		log.Printf("Saved rules version %d for chat %d.", rules.Version, rules.ChatID)
+		return nil
+	}
+}
+
+// GetLatestRules implements RulesRepository.
+func (s *SQL) GetLatestRules(ctx context.Context, chatID int64) (*models.RulesVersion, error) {
+	rules, err := selectOne(ctx, s, scanRules,
+		"SELECT "+rulesColumns+" FROM rules_versions WHERE chat_id = ? ORDER BY version DESC LIMIT 1", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules from the database: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRulesHistory implements RulesRepository.
+func (s *SQL) GetRulesHistory(ctx context.Context, chatID int64) ([]models.RulesVersion, error) {
+	rules, err := selectAll(ctx, s, scanRules,
+		"SELECT "+rulesColumns+" FROM rules_versions WHERE chat_id = ? ORDER BY version DESC", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules history from the database: %w", err)
+	}
+	return rules, nil
+}
+
+// RecordRulesAcceptance implements RulesRepository.
+func (s *SQL) RecordRulesAcceptance(ctx context.Context, acceptance *models.RulesAcceptance) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO rules_acceptances (chat_id, telegram_id, version, accepted_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chat_id, telegram_id) DO UPDATE SET version = excluded.version, accepted_at = excluded.accepted_at`,
+		acceptance.ChatID, acceptance.TelegramID, acceptance.Version, acceptance.AcceptedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record rules acceptance in the database: %w", err)
+	}
+	log.Printf("User %d accepted rules version %d in chat %d.", acceptance.TelegramID, acceptance.Version, acceptance.ChatID)
+	return nil
+}
+
+// GetRulesAcceptance implements RulesRepository.
+func (s *SQL) GetRulesAcceptance(ctx context.Context, chatID, telegramID int64) (*models.RulesAcceptance, error) {
+	acceptance, err := selectOne(ctx, s, scanAcceptance,
+		"SELECT "+acceptanceColumns+" FROM rules_acceptances WHERE chat_id = ? AND telegram_id = ?", chatID, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules acceptance from the database: %w", err)
+	}
+	return acceptance, nil
+}
+
+// SaveNote implements NoteRepository.
+func (s *SQL) SaveNote(ctx context.Context, note *models.Note) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO notes (name, text, chat_id, created_by, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id, name) DO UPDATE SET text = excluded.text,
+			created_by = excluded.created_by, created_at = excluded.created_at`,
+		note.Name, note.Text, note.ChatID, note.CreatedBy, note.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save note to the database: %w", err)
+	}
+	log.Printf("Saved note %q for chat %d.", note.Name, note.ChatID)
+	return nil
+}
+
+// GetNote implements NoteRepository.
+func (s *SQL) GetNote(ctx context.Context, chatID int64, name string) (*models.Note, error) {
+	note, err := selectOne(ctx, s, scanNote, "SELECT "+noteColumns+" FROM notes WHERE chat_id = ? AND name = ?", chatID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch note from the database: %w", err)
+	}
+	return note, nil
+}
+
+// SearchNotes implements NoteRepository. The prefix is compared as is, since LIKE would treat
+// "%" and "_" in it as wildcards and differs in case sensitivity between SQLite and Postgres.
+func (s *SQL) SearchNotes(ctx context.Context, chatID int64, prefix string, limit int) ([]models.Note, error) {
+	notes, err := selectAll(ctx, s, scanNote,
+		"SELECT "+noteColumns+" FROM notes WHERE chat_id = ? AND substr(name, 1, ?) = ? ORDER BY name LIMIT ?",
+		chatID, utf8.RuneCountInString(prefix), prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes in the database: %w", err)
+	}
+	return notes, nil
+}
+
+// GetNoteChats implements NoteRepository.
+func (s *SQL) GetNoteChats(ctx context.Context) ([]int64, error) {
+	chats, err := selectAll(ctx, s, func(row scanner, chatID *int64) error { return row.Scan(chatID) },
+		"SELECT DISTINCT chat_id FROM notes ORDER BY chat_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch note chats from the database: %w", err)
+	}
+	return chats, nil
+}
+
+// RemoveNote implements NoteRepository.
+func (s *SQL) RemoveNote(ctx context.Context, chatID int64, name string) error {
+	if _, err := s.exec(ctx, s.db, "DELETE FROM notes WHERE chat_id = ? AND name = ?", chatID, name); err != nil {
+		return fmt.Errorf("failed to remove note from the database: %w", err)
+	}
+	log.Printf("Removed note %q from chat %d.", name, chatID)
+	return nil
+}
+
+// SaveFilter implements FilterRepository.
+func (s *SQL) SaveFilter(ctx context.Context, filter *models.Filter) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO filters (chat_id, "trigger", match_type, response, cooldown_seconds, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id, "trigger") DO UPDATE SET match_type = excluded.match_type, response = excluded.response,
+			cooldown_seconds = excluded.cooldown_seconds, created_by = excluded.created_by, created_at = excluded.created_at`,
+		filter.ChatID, filter.Trigger, filter.MatchType, filter.Response, filter.CooldownSeconds, filter.CreatedBy, filter.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save filter to the database: %w", err)
+	}
+	log.Printf("Saved filter %q for chat %d.", filter.Trigger, filter.ChatID)
+	return nil
+}
+
+// RemoveFilter implements FilterRepository.
+func (s *SQL) RemoveFilter(ctx context.Context, chatID int64, trigger string) error {
+	if _, err := s.exec(ctx, s.db, `DELETE FROM filters WHERE chat_id = ? AND "trigger" = ?`, chatID, trigger); err != nil {
+		return fmt.Errorf("failed to remove filter from the database: %w", err)
+	}
+	log.Printf("Removed filter %q from chat %d.", trigger, chatID)
+	return nil
+}
+
+// GetFilters implements FilterRepository.
+func (s *SQL) GetFilters(ctx context.Context, chatID int64) ([]models.Filter, error) {
+	filters, err := selectAll(ctx, s, scanFilter, "SELECT "+filterColumns+" FROM filters WHERE chat_id = ? ORDER BY id", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch filters from the database: %w", err)
+	}
+	return filters, nil
+}
+
+// AddPriceAlert implements AlertRepository.
+func (s *SQL) AddPriceAlert(ctx context.Context, alert *models.PriceAlert) error {
+	id, err := s.insertID(ctx, `INSERT INTO price_alerts (chat_id, created_by, coin_id, coin_symbol, currency, condition,
+			target, reference_price, active, snoozed_until, triggered_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		alert.ChatID, alert.CreatedBy, alert.CoinID, alert.CoinSymbol, alert.Currency, alert.Condition,
+		alert.Target, alert.ReferencePrice, alert.Active, alert.SnoozedUntil, alert.TriggeredAt, alert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add price alert to the database: %w", err)
+	}
+	alert.ID = id
+	log.Printf("Added price alert %d for %s in chat %d.", alert.ID, alert.CoinID, alert.ChatID)
+	return nil
+}
+
+// GetPriceAlert implements AlertRepository.
+func (s *SQL) GetPriceAlert(ctx context.Context, id int64) (*models.PriceAlert, error) {
+	alert, err := selectOne(ctx, s, scanPriceAlert, "SELECT "+priceAlertColumns+" FROM price_alerts WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price alert from the database: %w", err)
+	}
+	return alert, nil
+}
+
+// GetPriceAlerts implements AlertRepository.
+func (s *SQL) GetPriceAlerts(ctx context.Context, chatID int64) ([]models.PriceAlert, error) {
+	alerts, err := selectAll(ctx, s, scanPriceAlert, "SELECT "+priceAlertColumns+" FROM price_alerts WHERE chat_id = ? ORDER BY id", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price alerts from the database: %w", err)
+	}
+	return alerts, nil
+}
+
+// GetActivePriceAlerts implements AlertRepository.
+func (s *SQL) GetActivePriceAlerts(ctx context.Context) ([]models.PriceAlert, error) {
+	alerts, err := selectAll(ctx, s, scanPriceAlert, "SELECT "+priceAlertColumns+" FROM price_alerts WHERE active = ? ORDER BY id", true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active price alerts from the database: %w", err)
+	}
+	return alerts, nil
+}
+
+// UpdatePriceAlert implements AlertRepository.
+func (s *SQL) UpdatePriceAlert(ctx context.Context, alert *models.PriceAlert) error {
+	_, err := s.exec(ctx, s.db, `UPDATE price_alerts SET chat_id = ?, created_by = ?, coin_id = ?, coin_symbol = ?, currency = ?,
+			condition = ?, target = ?, reference_price = ?, active = ?, snoozed_until = ?, triggered_at = ?, created_at = ?
+		WHERE id = ?`,
+		alert.ChatID, alert.CreatedBy, alert.CoinID, alert.CoinSymbol, alert.Currency,
+		alert.Condition, alert.Target, alert.ReferencePrice, alert.Active, alert.SnoozedUntil, alert.TriggeredAt, alert.CreatedAt,
+		alert.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update price alert in the database: %w", err)
+	}
+	return nil
+}
+
+// RemovePriceAlert implements AlertRepository.
+func (s *SQL) RemovePriceAlert(ctx context.Context, id int64) error {
+	if _, err := s.exec(ctx, s.db, "DELETE FROM price_alerts WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove price alert from the database: %w", err)
+	}
+	log.Printf("Removed price alert %d.", id)
+	return nil
+}
+
+// AddGasAlert implements AlertRepository.
+func (s *SQL) AddGasAlert(ctx context.Context, alert *models.GasAlert) error {
+	id, err := s.insertID(ctx, `INSERT INTO gas_alerts (chat_id, created_by, chain, threshold, reference_gwei, active, triggered_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		alert.ChatID, alert.CreatedBy, alert.Chain, alert.Threshold, alert.ReferenceGwei, alert.Active, alert.TriggeredAt, alert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add gas alert to the database: %w", err)
+	}
+	alert.ID = id
+	log.Printf("Added gas alert %d for %s in chat %d.", alert.ID, alert.Chain, alert.ChatID)
+	return nil
+}
+
+// GetGasAlert implements AlertRepository.
+func (s *SQL) GetGasAlert(ctx context.Context, id int64) (*models.GasAlert, error) {
+	alert, err := selectOne(ctx, s, scanGasAlert, "SELECT "+gasAlertColumns+" FROM gas_alerts WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gas alert from the database: %w", err)
+	}
+	return alert, nil
+}
+
+// GetGasAlerts implements AlertRepository.
+func (s *SQL) GetGasAlerts(ctx context.Context, chatID int64) ([]models.GasAlert, error) {
+	alerts, err := selectAll(ctx, s, scanGasAlert, "SELECT "+gasAlertColumns+" FROM gas_alerts WHERE chat_id = ? ORDER BY id", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gas alerts from the database: %w", err)
+	}
+	return alerts, nil
+}
+
+// GetActiveGasAlerts implements AlertRepository.
+func (s *SQL) GetActiveGasAlerts(ctx context.Context) ([]models.GasAlert, error) {
+	alerts, err := selectAll(ctx, s, scanGasAlert, "SELECT "+gasAlertColumns+" FROM gas_alerts WHERE active = ? ORDER BY id", true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active gas alerts from the database: %w", err)
+	}
+	return alerts, nil
+}
+
+// UpdateGasAlert implements AlertRepository.
+func (s *SQL) UpdateGasAlert(ctx context.Context, alert *models.GasAlert) error {
+	_, err := s.exec(ctx, s.db, `UPDATE gas_alerts SET chat_id = ?, created_by = ?, chain = ?, threshold = ?, reference_gwei = ?,
+			active = ?, triggered_at = ?, created_at = ?
+		WHERE id = ?`,
+		alert.ChatID, alert.CreatedBy, alert.Chain, alert.Threshold, alert.ReferenceGwei,
+		alert.Active, alert.TriggeredAt, alert.CreatedAt, alert.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update gas alert in the database: %w", err)
+	}
+	return nil
+}
+
+// RemoveGasAlert implements AlertRepository.
+func (s *SQL) RemoveGasAlert(ctx context.Context, id int64) error {
+	if _, err := s.exec(ctx, s.db, "DELETE FROM gas_alerts WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove gas alert from the database: %w", err)
+	}
+	log.Printf("Removed gas alert %d.", id)
+	return nil
+}
+
+// AddReferral implements ReferralRepository.
+func (s *SQL) AddReferral(ctx context.Context, referral *models.Referral) error {
+	_, err := s.exec(ctx, s.db, "INSERT INTO referrals (chat_id, referrer_id, referred_id, created_at) VALUES (?, ?, ?, ?)",
+		referral.ChatID, referral.ReferrerID, referral.ReferredID, referral.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add referral to the database: %w", err)
+	}
+	log.Printf("User %d was referred to chat %d by %d.", referral.ReferredID, referral.ChatID, referral.ReferrerID)
+	return nil
+}
+
+// GetReferral implements ReferralRepository.
+func (s *SQL) GetReferral(ctx context.Context, chatID, referredID int64) (*models.Referral, error) {
+	referral, err := selectOne(ctx, s, scanReferral,
+		"SELECT "+referralColumns+" FROM referrals WHERE chat_id = ? AND referred_id = ?", chatID, referredID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch referral from the database: %w", err)
+	}
+	return referral, nil
+}
+
+// GetReferralsBy implements ReferralRepository.
+func (s *SQL) GetReferralsBy(ctx context.Context, chatID, referrerID int64) ([]models.Referral, error) {
+	referrals, err := selectAll(ctx, s, scanReferral,
+		"SELECT "+referralColumns+" FROM referrals WHERE chat_id = ? AND referrer_id = ? ORDER BY created_at", chatID, referrerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch referrals from the database: %w", err)
+	}
+	return referrals, nil
+}
+
+// AddLinkedWallet implements WalletRepository.
+func (s *SQL) AddLinkedWallet(ctx context.Context, wallet *models.LinkedWallet) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO linked_wallets (telegram_id, chain, address, linked_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chain, address) DO UPDATE SET telegram_id = excluded.telegram_id, linked_at = excluded.linked_at`,
+		wallet.TelegramID, wallet.Chain, wallet.Address, wallet.LinkedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add linked wallet to the database: %w", err)
+	}
+	log.Printf("Linked wallet %s on %s to user %d.", wallet.Address, wallet.Chain, wallet.TelegramID)
+	return nil
+}
+
+// GetLinkedWallets implements WalletRepository.
+func (s *SQL) GetLinkedWallets(ctx context.Context, telegramID int64) ([]models.LinkedWallet, error) {
+	wallets, err := selectAll(ctx, s, scanWallet, "SELECT "+walletColumns+" FROM linked_wallets WHERE telegram_id = ? ORDER BY id", telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch linked wallets from the database: %w", err)
+	}
+	return wallets, nil
+}
+
+// RemoveLinkedWallet implements WalletRepository.
+func (s *SQL) RemoveLinkedWallet(ctx context.Context, telegramID int64, address, chain string) (int, error) {
+	query, args := "DELETE FROM linked_wallets WHERE telegram_id = ? AND address = ?", []any{telegramID, address}
+	if chain != "" {
+		query, args = query+" AND chain = ?", append(args, chain)
+	}
+	removed, err := s.exec(ctx, s.db, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove linked wallet from the database: %w", err)
+	}
+	log.Printf("Unlinked wallet %s from user %d (%d links).", address, telegramID, removed)
+	return removed, nil
+}
+
+// SaveTokenGate implements TokenGateRepository.
+func (s *SQL) SaveTokenGate(ctx context.Context, gate *models.TokenGate) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO token_gates (chat_id, chain, contract, symbol, decimals, min_balance, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET chain = excluded.chain, contract = excluded.contract, symbol = excluded.symbol,
+			decimals = excluded.decimals, min_balance = excluded.min_balance, created_by = excluded.created_by,
+			created_at = excluded.created_at`,
+		gate.ChatID, gate.Chain, gate.Contract, gate.Symbol, gate.Decimals, gate.MinBalance, gate.CreatedBy, gate.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save token gate to the database: %w", err)
+	}
+	log.Printf("Saved token gate on %s %s for chat %d.", gate.Chain, gate.Contract, gate.ChatID)
+	return nil
+}
+
+// GetTokenGate implements TokenGateRepository.
+func (s *SQL) GetTokenGate(ctx context.Context, chatID int64) (*models.TokenGate, error) {
+	gate, err := selectOne(ctx, s, scanTokenGate, "SELECT "+tokenGateColumns+" FROM token_gates WHERE chat_id = ?", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token gate from the database: %w", err)
+	}
+	return gate, nil
+}
+
+// GetTokenGates implements TokenGateRepository.
+func (s *SQL) GetTokenGates(ctx context.Context) ([]models.TokenGate, error) {
+	gates, err := selectAll(ctx, s, scanTokenGate, "SELECT "+tokenGateColumns+" FROM token_gates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token gates from the database: %w", err)
+	}
+	return gates, nil
+}
+
+// RemoveTokenGate implements TokenGateRepository.
+func (s *SQL) RemoveTokenGate(ctx context.Context, chatID int64) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.exec(ctx, tx, "DELETE FROM token_gates WHERE chat_id = ?", chatID); err != nil {
+			return err
+		}
+		_, err := s.exec(ctx, tx, "DELETE FROM token_gate_members WHERE chat_id = ?", chatID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove token gate from the database: %w", err)
+	}
+	log.Printf("Removed token gate of chat %d.", chatID)
+	return nil
+}
+
+// SaveTokenGateMember implements TokenGateRepository.
+func (s *SQL) SaveTokenGateMember(ctx context.Context, member *models.TokenGateMember) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO token_gate_members (chat_id, telegram_id, checked_at) VALUES (?, ?, ?)
+		ON CONFLICT (chat_id, telegram_id) DO UPDATE SET checked_at = excluded.checked_at`,
+		member.ChatID, member.TelegramID, member.CheckedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save token gate member to the database: %w", err)
+	}
+	return nil
+}
+
+// GetTokenGateMembers implements TokenGateRepository.
+func (s *SQL) GetTokenGateMembers(ctx context.Context, chatID int64) ([]models.TokenGateMember, error) {
+	members, err := selectAll(ctx, s, scanTokenGateMember,
+		"SELECT "+tokenGateMemberColumns+" FROM token_gate_members WHERE chat_id = ?", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token gate members from the database: %w", err)
+	}
+	return members, nil
+}
+
+// RemoveTokenGateMember implements TokenGateRepository.
+func (s *SQL) RemoveTokenGateMember(ctx context.Context, chatID, telegramID int64) error {
+	_, err := s.exec(ctx, s.db, "DELETE FROM token_gate_members WHERE chat_id = ? AND telegram_id = ?", chatID, telegramID)
+	if err != nil {
+		return fmt.Errorf("failed to remove token gate member from the database: %w", err)
+	}
+	return nil
+}
+
+// PinCollection implements CollectionRepository.
+func (s *SQL) PinCollection(ctx context.Context, pin *models.PinnedCollection) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO pinned_collections (chat_id, slug, name, added_by, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id, slug) DO UPDATE SET name = excluded.name, added_by = excluded.added_by, created_at = excluded.created_at`,
+		pin.ChatID, pin.Slug, pin.Name, pin.AddedBy, pin.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to pin collection in the database: %w", err)
+	}
+	log.Printf("Pinned collection %s in chat %d.", pin.Slug, pin.ChatID)
+	return nil
+}
+
+// GetPinnedCollections implements CollectionRepository.
+func (s *SQL) GetPinnedCollections(ctx context.Context, chatID int64) ([]models.PinnedCollection, error) {
+	pins, err := selectAll(ctx, s, scanCollection,
+		"SELECT "+collectionColumns+" FROM pinned_collections WHERE chat_id = ? ORDER BY created_at, id", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pinned collections from the database: %w", err)
+	}
+	return pins, nil
+}
+
+// UnpinCollection implements CollectionRepository.
+func (s *SQL) UnpinCollection(ctx context.Context, chatID int64, slug string) (bool, error) {
+	removed, err := s.exec(ctx, s.db, "DELETE FROM pinned_collections WHERE chat_id = ? AND slug = ?", chatID, slug)
+	if err != nil {
+		return false, fmt.Errorf("failed to unpin collection in the database: %w", err)
+	}
+	log.Printf("Unpinned collection %s in chat %d.", slug, chatID)
+	return removed > 0, nil
+}
+
+// AddWatchlistCoins implements WatchlistRepository.
+func (s *SQL) AddWatchlistCoins(ctx context.Context, coins []models.WatchlistCoin) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, coin := range coins {
+			_, err := s.exec(ctx, tx, `INSERT INTO watchlist_coins (chat_id, coin_id, symbol, added_by, created_at) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (chat_id, coin_id) DO UPDATE SET symbol = excluded.symbol, added_by = excluded.added_by,
+					created_at = excluded.created_at`,
+				coin.ChatID, coin.CoinID, coin.Symbol, coin.AddedBy, coin.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add watchlist coins to the database: %w", err)
+	}
+	return nil
+}
+
+// GetWatchlist implements WatchlistRepository.
+func (s *SQL) GetWatchlist(ctx context.Context, chatID int64) ([]models.WatchlistCoin, error) {
+	coins, err := selectAll(ctx, s, scanWatchlistCoin,
+		"SELECT "+watchlistColumns+" FROM watchlist_coins WHERE chat_id = ? ORDER BY created_at, id", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watchlist from the database: %w", err)
+	}
+	return coins, nil
+}
+
+// RemoveWatchlistCoins implements WatchlistRepository.
+func (s *SQL) RemoveWatchlistCoins(ctx context.Context, chatID int64, coinIDs []string) (int, error) {
+	if len(coinIDs) == 0 {
+		return 0, nil
+	}
+	args := []any{chatID}
+	for _, id := range coinIDs {
+		args = append(args, id)
+	}
+	removed, err := s.exec(ctx, s.db, "DELETE FROM watchlist_coins WHERE chat_id = ? AND coin_id IN ("+placeholders(len(coinIDs))+")", args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove watchlist coins from the database: %w", err)
+	}
+	return removed, nil
+}
+
+// SaveDigestSchedule implements WatchlistRepository.
+func (s *SQL) SaveDigestSchedule(ctx context.Context, schedule *models.DigestSchedule) error {
+	_, err := s.exec(ctx, s.db, `INSERT INTO digest_schedules (chat_id, "time", timezone, chain, last_sent_on, created_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET "time" = excluded."time", timezone = excluded.timezone, chain = excluded.chain,
+			last_sent_on = excluded.last_sent_on, created_by = excluded.created_by, updated_at = excluded.updated_at`,
+		schedule.ChatID, schedule.Time, schedule.Timezone, schedule.Chain, schedule.LastSentOn, schedule.CreatedBy, schedule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save digest schedule to the database: %w", err)
+	}
+	return nil
+}
+
+// GetDigestSchedule implements WatchlistRepository.
+func (s *SQL) GetDigestSchedule(ctx context.Context, chatID int64) (*models.DigestSchedule, error) {
+	schedule, err := selectOne(ctx, s, scanDigest, "SELECT "+digestColumns+" FROM digest_schedules WHERE chat_id = ?", chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch digest schedule from the database: %w", err)
+	}
+	return schedule, nil
+}
+
+// GetDigestSchedules implements WatchlistRepository.
+func (s *SQL) GetDigestSchedules(ctx context.Context) ([]models.DigestSchedule, error) {
+	schedules, err := selectAll(ctx, s, scanDigest, "SELECT "+digestColumns+" FROM digest_schedules")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch digest schedules from the database: %w", err)
+	}
+	return schedules, nil
+}
+
+// RemoveDigestSchedule implements WatchlistRepository.
+func (s *SQL) RemoveDigestSchedule(ctx context.Context, chatID int64) error {
+	if _, err := s.exec(ctx, s.db, "DELETE FROM digest_schedules WHERE chat_id = ?", chatID); err != nil {
+		return fmt.Errorf("failed to remove digest schedule from the database: %w", err)
+	}
+	log.Printf("Removed digest schedule of chat %d.", chatID)
+	return nil
+}
+
+// AddHolding implements PortfolioRepository.
+func (s *SQL) AddHolding(ctx context.Context, holding *models.Holding) error {
+	id, err := s.insertID(ctx, `INSERT INTO portfolio_holdings (telegram_id, coin_id, symbol, amount, price, currency, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		holding.TelegramID, holding.CoinID, holding.Symbol, holding.Amount, holding.Price, holding.Currency, holding.CreatedAt, holding.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add holding to the database: %w", err)
+	}
+	holding.ID = id
+	log.Printf("Added holding %d of %s for user %d.", holding.ID, holding.CoinID, holding.TelegramID)
+	return nil
+}
+
+// GetHoldings implements PortfolioRepository.
+func (s *SQL) GetHoldings(ctx context.Context, telegramID int64) ([]models.Holding, error) {
+	holdings, err := selectAll(ctx, s, scanHolding,
+		"SELECT "+holdingColumns+" FROM portfolio_holdings WHERE telegram_id = ? ORDER BY id", telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch holdings from the database: %w", err)
+	}
+	return holdings, nil
+}
+
+// UpdateHolding implements PortfolioRepository.
+func (s *SQL) UpdateHolding(ctx context.Context, holding *models.Holding) error {
+	_, err := s.exec(ctx, s.db, `UPDATE portfolio_holdings SET coin_id = ?, symbol = ?, amount = ?, price = ?, currency = ?,
+			created_at = ?, updated_at = ?
+		WHERE id = ? AND telegram_id = ?`,
+		holding.CoinID, holding.Symbol, holding.Amount, holding.Price, holding.Currency,
+		holding.CreatedAt, holding.UpdatedAt, holding.ID, holding.TelegramID)
+	if err != nil {
+		return fmt.Errorf("failed to update holding in the database: %w", err)
+	}
+	return nil
+}
+
+// RemoveHolding implements PortfolioRepository.
+func (s *SQL) RemoveHolding(ctx context.Context, telegramID, id int64) (bool, error) {
+	removed, err := s.exec(ctx, s.db, "DELETE FROM portfolio_holdings WHERE id = ? AND telegram_id = ?", id, telegramID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove holding from the database: %w", err)
+	}
+	log.Printf("Removed holding %d of user %d.", id, telegramID)
+	return removed > 0, nil
+}
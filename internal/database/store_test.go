@@ -0,0 +1,595 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// storeCases check the Store contract. TestStores runs each on a fresh store of every
+// implementation, so they all behave alike.
+var storeCases = []struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, s Store)
+}{
+	{"members", testMembers},
+	{"languages and settings", testLanguagesAndSettings},
+	{"rules", testRules},
+	{"notes", testNotes},
+	{"filters", testFilters},
+	{"alerts", testAlerts},
+	{"referrals", testReferrals},
+	{"wallets", testWallets},
+	{"token gates", testTokenGates},
+	{"collections", testCollections},
+	{"watchlists", testWatchlists},
+	{"holdings", testHoldings},
+}
+
+// TestStores runs storeCases against Memory, a SQLite file and, if TEST_POSTGRES_URL is set,
+// Postgres. The Postgres database is emptied before each case, so don't point it at real data.
+func TestStores(t *testing.T) {
+	ctx := context.Background()
+	stores := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{"memory", func(t *testing.T) Store { return NewMemory() }},
+		{"sqlite", func(t *testing.T) Store {
+			return openSQL(t, ctx, "sqlite:"+filepath.Join(t.TempDir(), "bot.db"))
+		}},
+		{"postgres", func(t *testing.T) Store {
+			url := os.Getenv("TEST_POSTGRES_URL")
+			if url == "" {
+				t.Skip("TEST_POSTGRES_URL not set")
+			}
+			s := openSQL(t, ctx, url)
+			if err := truncate(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+			return s
+		}},
+	}
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			for _, c := range storeCases {
+				t.Run(c.name, func(t *testing.T) { c.run(t, ctx, store.open(t)) })
+			}
+		})
+	}
+}
+
+func openSQL(t *testing.T, ctx context.Context, url string) *SQL {
+	t.Helper()
+	s, err := OpenSQL(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// truncate empties every table but schema_migrations and restarts their IDs.
+func truncate(ctx context.Context, s *SQL) error {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf("TRUNCATE %s RESTART IDENTITY CASCADE", strings.Join(tables, ", ")))
+	return err
+}
+
+// now is a timestamp every store keeps exactly.
+func now() time.Time { return time.Now().UTC().Truncate(time.Second) }
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testMembers(t *testing.T, ctx context.Context, s Store) {
+	if u, err := s.GetUser(ctx, 1); err != nil || u != nil {
+		t.Fatalf("GetUser of an unknown member = %v, %v", u, err)
+	}
+	must(t, s.AddUser(ctx, &models.User{TelegramID: 1, FirstName: "Ada", Username: "ada", JoinedAt: now()}))
+	must(t, s.AddUser(ctx, &models.User{TelegramID: 1, FirstName: "Someone else", JoinedAt: now()}))
+	u, err := s.GetUser(ctx, 1)
+	must(t, err)
+	if u == nil || u.FirstName != "Ada" || u.Username != "ada" {
+		t.Errorf("adding a member twice left %+v", u)
+	}
+
+	must(t, s.RemoveUser(ctx, 1))
+	if u, _ := s.GetUser(ctx, 1); u != nil {
+		t.Errorf("removed member still stored: %+v", u)
+	}
+}
+
+func testLanguagesAndSettings(t *testing.T, ctx context.Context, s Store) {
+	if l, err := s.GetLanguage(ctx, -100); err != nil || l != "" {
+		t.Fatalf("GetLanguage with none set = %q, %v", l, err)
+	}
+	must(t, s.SetLanguage(ctx, -100, "es"))
+	must(t, s.SetLanguage(ctx, -100, "fr"))
+	must(t, s.SetLanguage(ctx, 7, "en"))
+	if l, _ := s.GetLanguage(ctx, -100); l != "fr" {
+		t.Errorf("GetLanguage = %q, want the last language set", l)
+	}
+
+	settings, err := s.GetChatSettings(ctx, -100)
+	must(t, err)
+	if settings == nil || *settings != (models.ChatSettings{ChatID: -100}) {
+		t.Fatalf("GetChatSettings of a new chat = %+v, want the defaults", settings)
+	}
+	want := models.ChatSettings{ChatID: -100, RequireRulesAcceptance: true, Currency: "eur"}
+	must(t, s.SaveChatSettings(ctx, &want))
+	want.Currency = "gbp"
+	must(t, s.SaveChatSettings(ctx, &want))
+	if got, _ := s.GetChatSettings(ctx, -100); got == nil || *got != want {
+		t.Errorf("GetChatSettings = %+v, want %+v", got, want)
+	}
+	if got, _ := s.GetChatSettings(ctx, -200); got == nil || *got != (models.ChatSettings{ChatID: -200}) {
+		t.Errorf("another chat's settings = %+v", got)
+	}
+}
+
+func testRules(t *testing.T, ctx context.Context, s Store) {
+	if r, err := s.GetLatestRules(ctx, -100); err != nil || r != nil {
+		t.Fatalf("GetLatestRules without rules = %+v, %v", r, err)
+	}
+	for v, text := range []string{"Be nice", "Be nice. No spam"} {
+		rules := models.RulesVersion{ChatID: -100, Text: text, CreatedBy: 1, CreatedAt: now()}
+		must(t, s.AddRulesVersion(ctx, &rules))
+		if rules.Version != v+1 {
+			t.Errorf("AddRulesVersion set version %d, want %d", rules.Version, v+1)
+		}
+	}
+	other := models.RulesVersion{ChatID: -200, Text: "Other", CreatedAt: now()}
+	must(t, s.AddRulesVersion(ctx, &other))
+	if other.Version != 1 {
+		t.Errorf("another chat's first rules got version %d", other.Version)
+	}
+
+	latest, err := s.GetLatestRules(ctx, -100)
+	must(t, err)
+	if latest == nil || latest.Version != 2 || latest.Text != "Be nice. No spam" {
+		t.Errorf("GetLatestRules = %+v", latest)
+	}
+	history, err := s.GetRulesHistory(ctx, -100)
+	must(t, err)
+	if len(history) != 2 || history[0].Version != 2 || history[1].Version != 1 {
+		t.Errorf("GetRulesHistory = %+v, want versions 2 and 1", history)
+	}
+
+	if a, err := s.GetRulesAcceptance(ctx, -100, 1); err != nil || a != nil {
+		t.Fatalf("GetRulesAcceptance before accepting = %+v, %v", a, err)
+	}
+	must(t, s.RecordRulesAcceptance(ctx, &models.RulesAcceptance{ChatID: -100, TelegramID: 1, Version: 1, AcceptedAt: now()}))
+	must(t, s.RecordRulesAcceptance(ctx, &models.RulesAcceptance{ChatID: -100, TelegramID: 1, Version: 2, AcceptedAt: now()}))
+	if a, _ := s.GetRulesAcceptance(ctx, -100, 1); a == nil || a.Version != 2 {
+		t.Errorf("GetRulesAcceptance = %+v, want version 2", a)
+	}
+	if a, _ := s.GetRulesAcceptance(ctx, -200, 1); a != nil {
+		t.Errorf("acceptance leaked to another chat: %+v", a)
+	}
+
+	// Admins saving at the same moment each get their own version. There are fewer saves than
+	// rulesVersionAttempts, so even a save that loses every race gets one.
+	var wg sync.WaitGroup
+	versions := make([]int, rulesVersionAttempts-1)
+	for i := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rules := models.RulesVersion{ChatID: -300, Text: "Concurrent", CreatedAt: now()}
+			if err := s.AddRulesVersion(ctx, &rules); err != nil {
+				t.Error(err)
+			}
+			versions[i] = rules.Version
+		}()
+	}
+	wg.Wait()
+	slices.Sort(versions)
+	for i, v := range versions {
+		if v != i+1 {
+			t.Errorf("concurrent saves got versions %v", versions)
+			break
+		}
+	}
+}
+
+func testNotes(t *testing.T, ctx context.Context, s Store) {
+	if n, err := s.GetNote(ctx, -100, "faq"); err != nil || n != nil {
+		t.Fatalf("GetNote of a missing note = %+v, %v", n, err)
+	}
+	for _, n := range []models.Note{
+		{ChatID: -100, Name: "faq", Text: "Old"},
+		{ChatID: -100, Name: "faq", Text: "Read the pins"},
+		{ChatID: -100, Name: "gas", Text: "Check /gas"},
+		{ChatID: -100, Name: "fees", Text: "Low"},
+		{ChatID: -200, Name: "faq", Text: "Ask in #help"},
+	} {
+		n.CreatedBy, n.CreatedAt = 1, now()
+		must(t, s.SaveNote(ctx, &n))
+	}
+
+	// Notes are per chat: the same name holds different text in each.
+	if n, _ := s.GetNote(ctx, -100, "faq"); n == nil || n.Text != "Read the pins" {
+		t.Errorf("GetNote = %+v, want the replaced note", n)
+	}
+	if n, _ := s.GetNote(ctx, -200, "faq"); n == nil || n.Text != "Ask in #help" {
+		t.Errorf("GetNote in the other chat = %+v", n)
+	}
+
+	names := func(notes []models.Note) []string {
+		var out []string
+		for _, n := range notes {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+	all, err := s.SearchNotes(ctx, -100, "", 10)
+	must(t, err)
+	if got := names(all); !slices.Equal(got, []string{"faq", "fees", "gas"}) {
+		t.Errorf("SearchNotes of every note = %v", got)
+	}
+	if got, _ := s.SearchNotes(ctx, -100, "f", 1); !slices.Equal(names(got), []string{"faq"}) {
+		t.Errorf("SearchNotes with a prefix and limit = %v", names(got))
+	}
+	if got, _ := s.SearchNotes(ctx, -100, "x", 10); len(got) != 0 {
+		t.Errorf("SearchNotes without matches = %v", names(got))
+	}
+	if chats, _ := s.GetNoteChats(ctx); !slices.Equal(chats, []int64{-200, -100}) {
+		t.Errorf("GetNoteChats = %v", chats)
+	}
+
+	must(t, s.RemoveNote(ctx, -100, "faq"))
+	if n, _ := s.GetNote(ctx, -100, "faq"); n != nil {
+		t.Errorf("removed note still stored: %+v", n)
+	}
+	if n, _ := s.GetNote(ctx, -200, "faq"); n == nil {
+		t.Error("removing a note removed the other chat's note too")
+	}
+}
+
+func testFilters(t *testing.T, ctx context.Context, s Store) {
+	for _, f := range []models.Filter{
+		{ChatID: -100, Trigger: "gm", MatchType: "word", Response: "gm"},
+		{ChatID: -100, Trigger: "wen", MatchType: "contains", Response: "Soon"},
+		{ChatID: -100, Trigger: "gm", MatchType: "exact", Response: "Good morning"},
+		{ChatID: -200, Trigger: "gm", MatchType: "word", Response: "Hi"},
+	} {
+		f.CreatedBy, f.CreatedAt = 1, now()
+		must(t, s.SaveFilter(ctx, &f))
+	}
+	filters, err := s.GetFilters(ctx, -100)
+	must(t, err)
+	if len(filters) != 2 || filters[0].Trigger != "gm" || filters[0].Response != "Good morning" ||
+		filters[0].MatchType != "exact" || filters[1].Trigger != "wen" {
+		t.Errorf("GetFilters = %+v", filters)
+	}
+
+	must(t, s.RemoveFilter(ctx, -100, "gm"))
+	if filters, _ := s.GetFilters(ctx, -100); len(filters) != 1 || filters[0].Trigger != "wen" {
+		t.Errorf("GetFilters after removing one = %+v", filters)
+	}
+	if filters, _ := s.GetFilters(ctx, -200); len(filters) != 1 {
+		t.Errorf("removing a filter touched the other chat: %+v", filters)
+	}
+}
+
+func testAlerts(t *testing.T, ctx context.Context, s Store) {
+	if a, err := s.GetPriceAlert(ctx, 1); err != nil || a != nil {
+		t.Fatalf("GetPriceAlert of a missing alert = %+v, %v", a, err)
+	}
+	above := models.PriceAlert{ChatID: -100, CreatedBy: 1, CoinID: "bitcoin", CoinSymbol: "BTC", Currency: "usd",
+		Condition: "above", Target: 100000, Active: true, CreatedAt: now()}
+	below := above
+	below.Condition, below.Target = "below", 50000
+	must(t, s.AddPriceAlert(ctx, &above))
+	must(t, s.AddPriceAlert(ctx, &below))
+	if above.ID == 0 || below.ID == 0 || above.ID == below.ID {
+		t.Fatalf("AddPriceAlert set IDs %d and %d", above.ID, below.ID)
+	}
+
+	triggered := now()
+	below.Active, below.TriggeredAt = false, &triggered
+	must(t, s.UpdatePriceAlert(ctx, &below))
+	got, err := s.GetPriceAlert(ctx, below.ID)
+	must(t, err)
+	if got == nil || got.Active || got.TriggeredAt == nil || !got.TriggeredAt.Equal(triggered) || got.Target != 50000 {
+		t.Errorf("GetPriceAlert after an update = %+v", got)
+	}
+	if alerts, _ := s.GetPriceAlerts(ctx, -100); len(alerts) != 2 || alerts[0].ID != above.ID {
+		t.Errorf("GetPriceAlerts = %+v", alerts)
+	}
+	if alerts, _ := s.GetActivePriceAlerts(ctx); len(alerts) != 1 || alerts[0].ID != above.ID {
+		t.Errorf("GetActivePriceAlerts = %+v", alerts)
+	}
+	must(t, s.RemovePriceAlert(ctx, above.ID))
+	if a, _ := s.GetPriceAlert(ctx, above.ID); a != nil {
+		t.Errorf("removed alert still stored: %+v", a)
+	}
+
+	gas := models.GasAlert{ChatID: -100, CreatedBy: 1, Chain: "ethereum", Threshold: 10, Active: true, CreatedAt: now()}
+	must(t, s.AddGasAlert(ctx, &gas))
+	if gas.ID == 0 {
+		t.Fatal("AddGasAlert left the ID unset")
+	}
+	gas.Active = false
+	must(t, s.UpdateGasAlert(ctx, &gas))
+	if a, _ := s.GetGasAlert(ctx, gas.ID); a == nil || a.Active || a.Chain != "ethereum" || a.Threshold != 10 {
+		t.Errorf("GetGasAlert after an update = %+v", a)
+	}
+	if alerts, _ := s.GetActiveGasAlerts(ctx); len(alerts) != 0 {
+		t.Errorf("GetActiveGasAlerts = %+v", alerts)
+	}
+	if alerts, _ := s.GetGasAlerts(ctx, -100); len(alerts) != 1 {
+		t.Errorf("GetGasAlerts = %+v", alerts)
+	}
+	must(t, s.RemoveGasAlert(ctx, gas.ID))
+	if a, _ := s.GetGasAlert(ctx, gas.ID); a != nil {
+		t.Errorf("removed gas alert still stored: %+v", a)
+	}
+}
+
+func testReferrals(t *testing.T, ctx context.Context, s Store) {
+	if r, err := s.GetReferral(ctx, -100, 2); err != nil || r != nil {
+		t.Fatalf("GetReferral of a member nobody referred = %+v, %v", r, err)
+	}
+	for _, r := range []models.Referral{
+		{ChatID: -100, ReferrerID: 1, ReferredID: 2},
+		{ChatID: -100, ReferrerID: 1, ReferredID: 3},
+		{ChatID: -200, ReferrerID: 1, ReferredID: 4},
+	} {
+		r.CreatedAt = now()
+		must(t, s.AddReferral(ctx, &r))
+	}
+	if r, _ := s.GetReferral(ctx, -100, 2); r == nil || r.ReferrerID != 1 {
+		t.Errorf("GetReferral = %+v", r)
+	}
+	if refs, _ := s.GetReferralsBy(ctx, -100, 1); len(refs) != 2 {
+		t.Errorf("GetReferralsBy = %+v, want the two referrals in the chat", refs)
+	}
+}
+
+func testWallets(t *testing.T, ctx context.Context, s Store) {
+	const address = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	for _, chain := range []string{"ethereum", "base"} {
+		must(t, s.AddLinkedWallet(ctx, &models.LinkedWallet{TelegramID: 1, Chain: chain, Address: address, LinkedAt: now()}))
+	}
+	// Linking it again on a chain keeps one row.
+	must(t, s.AddLinkedWallet(ctx, &models.LinkedWallet{TelegramID: 1, Chain: "base", Address: address, LinkedAt: now()}))
+	if wallets, _ := s.GetLinkedWallets(ctx, 1); len(wallets) != 2 {
+		t.Fatalf("GetLinkedWallets = %+v, want one wallet per chain", wallets)
+	}
+
+	// Another user proving the address on a chain takes it over there.
+	must(t, s.AddLinkedWallet(ctx, &models.LinkedWallet{TelegramID: 2, Chain: "base", Address: address, LinkedAt: now()}))
+	if wallets, _ := s.GetLinkedWallets(ctx, 1); len(wallets) != 1 || wallets[0].Chain != "ethereum" {
+		t.Errorf("the first user's wallets after the move = %+v", wallets)
+	}
+	if wallets, _ := s.GetLinkedWallets(ctx, 2); len(wallets) != 1 || wallets[0].Chain != "base" || wallets[0].Address != address {
+		t.Errorf("the second user's wallets after the move = %+v", wallets)
+	}
+
+	// A user can only unlink their own wallets.
+	if n, err := s.RemoveLinkedWallet(ctx, 1, address, "base"); err != nil || n != 0 {
+		t.Errorf("unlinking another user's wallet removed %d, %v", n, err)
+	}
+	must(t, s.AddLinkedWallet(ctx, &models.LinkedWallet{TelegramID: 1, Chain: "polygon", Address: address, LinkedAt: now()}))
+	if n, err := s.RemoveLinkedWallet(ctx, 1, address, ""); err != nil || n != 2 {
+		t.Errorf("unlinking on every chain removed %d, %v, want 2", n, err)
+	}
+	if wallets, _ := s.GetLinkedWallets(ctx, 1); len(wallets) != 0 {
+		t.Errorf("wallets left after unlinking everywhere: %+v", wallets)
+	}
+	if n, _ := s.RemoveLinkedWallet(ctx, 2, address, "base"); n != 1 {
+		t.Errorf("unlinking on one chain removed %d", n)
+	}
+}
+
+func testTokenGates(t *testing.T, ctx context.Context, s Store) {
+	if g, err := s.GetTokenGate(ctx, -100); err != nil || g != nil {
+		t.Fatalf("GetTokenGate without a gate = %+v, %v", g, err)
+	}
+	gate := models.TokenGate{ChatID: -100, Chain: "base", Contract: "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913",
+		Symbol: "USDC", Decimals: 6, MinBalance: "1000000", CreatedBy: 1, CreatedAt: now()}
+	must(t, s.SaveTokenGate(ctx, &gate))
+	gate.MinBalance = "5000000"
+	must(t, s.SaveTokenGate(ctx, &gate))
+	if g, _ := s.GetTokenGate(ctx, -100); g == nil || g.MinBalance != "5000000" || g.Decimals != 6 || g.Symbol != "USDC" {
+		t.Errorf("GetTokenGate = %+v", g)
+	}
+	other := gate
+	other.ChatID = -200
+	must(t, s.SaveTokenGate(ctx, &other))
+	if gates, _ := s.GetTokenGates(ctx); len(gates) != 2 {
+		t.Errorf("GetTokenGates = %+v", gates)
+	}
+
+	for _, m := range []models.TokenGateMember{{ChatID: -100, TelegramID: 1}, {ChatID: -100, TelegramID: 1},
+		{ChatID: -100, TelegramID: 2}, {ChatID: -200, TelegramID: 1}} {
+		m.CheckedAt = now()
+		must(t, s.SaveTokenGateMember(ctx, &m))
+	}
+	if members, _ := s.GetTokenGateMembers(ctx, -100); len(members) != 2 {
+		t.Errorf("GetTokenGateMembers = %+v, want one row per member", members)
+	}
+	must(t, s.RemoveTokenGateMember(ctx, -100, 2))
+	if members, _ := s.GetTokenGateMembers(ctx, -100); len(members) != 1 || members[0].TelegramID != 1 {
+		t.Errorf("GetTokenGateMembers after removing one = %+v", members)
+	}
+
+	// Removing a gate forgets its members, and only its own.
+	must(t, s.RemoveTokenGate(ctx, -100))
+	if g, _ := s.GetTokenGate(ctx, -100); g != nil {
+		t.Errorf("removed gate still stored: %+v", g)
+	}
+	if members, _ := s.GetTokenGateMembers(ctx, -100); len(members) != 0 {
+		t.Errorf("members of a removed gate: %+v", members)
+	}
+	if members, _ := s.GetTokenGateMembers(ctx, -200); len(members) != 1 {
+		t.Errorf("removing a gate touched another chat's members: %+v", members)
+	}
+}
+
+func testCollections(t *testing.T, ctx context.Context, s Store) {
+	start := now()
+	for i, slug := range []string{"pudgypenguins", "azuki", "milady"} {
+		pin := models.PinnedCollection{ChatID: -100, Slug: slug, Name: slug, AddedBy: 1, CreatedAt: start.Add(time.Duration(2-i) * time.Minute)}
+		must(t, s.PinCollection(ctx, &pin))
+	}
+	// Pinning a collection again replaces it.
+	must(t, s.PinCollection(ctx, &models.PinnedCollection{ChatID: -100, Slug: "azuki", Name: "Azuki", AddedBy: 2, CreatedAt: start.Add(time.Minute)}))
+
+	pins, err := s.GetPinnedCollections(ctx, -100)
+	must(t, err)
+	var slugs []string
+	for _, p := range pins {
+		slugs = append(slugs, p.Slug)
+	}
+	if !slices.Equal(slugs, []string{"milady", "azuki", "pudgypenguins"}) {
+		t.Errorf("GetPinnedCollections = %v, want oldest pin first", slugs)
+	}
+	if pins[1].Name != "Azuki" || pins[1].AddedBy != 2 {
+		t.Errorf("re-pinned collection = %+v", pins[1])
+	}
+
+	if ok, err := s.UnpinCollection(ctx, -100, "azuki"); err != nil || !ok {
+		t.Errorf("UnpinCollection = %v, %v", ok, err)
+	}
+	if ok, _ := s.UnpinCollection(ctx, -100, "azuki"); ok {
+		t.Error("unpinning a collection twice reported a removal")
+	}
+}
+
+func testWatchlists(t *testing.T, ctx context.Context, s Store) {
+	start := now()
+	must(t, s.AddWatchlistCoins(ctx, []models.WatchlistCoin{
+		{ChatID: -100, CoinID: "ethereum", Symbol: "ETH", AddedBy: 1, CreatedAt: start.Add(time.Minute)},
+		{ChatID: -100, CoinID: "bitcoin", Symbol: "BTC", AddedBy: 1, CreatedAt: start},
+	}))
+	// Adding a coin that's already on the list keeps one row.
+	must(t, s.AddWatchlistCoins(ctx, []models.WatchlistCoin{
+		{ChatID: -100, CoinID: "bitcoin", Symbol: "BTC", AddedBy: 2, CreatedAt: start},
+		{ChatID: -200, CoinID: "bitcoin", Symbol: "BTC", AddedBy: 2, CreatedAt: start},
+	}))
+	coins, err := s.GetWatchlist(ctx, -100)
+	must(t, err)
+	if len(coins) != 2 || coins[0].CoinID != "bitcoin" || coins[1].CoinID != "ethereum" {
+		t.Errorf("GetWatchlist = %+v, want bitcoin then ethereum", coins)
+	}
+
+	if n, err := s.RemoveWatchlistCoins(ctx, -100, []string{"bitcoin", "solana"}); err != nil || n != 1 {
+		t.Errorf("RemoveWatchlistCoins = %d, %v, want 1", n, err)
+	}
+	if coins, _ := s.GetWatchlist(ctx, -200); len(coins) != 1 {
+		t.Errorf("removing a coin touched another chat's list: %+v", coins)
+	}
+
+	if d, err := s.GetDigestSchedule(ctx, -100); err != nil || d != nil {
+		t.Fatalf("GetDigestSchedule without a schedule = %+v, %v", d, err)
+	}
+	schedule := models.DigestSchedule{ChatID: -100, Time: "09:00", Timezone: "Europe/Madrid", Chain: "ethereum", CreatedBy: 1, UpdatedAt: now()}
+	must(t, s.SaveDigestSchedule(ctx, &schedule))
+	schedule.LastSentOn = "2026-10-18"
+	must(t, s.SaveDigestSchedule(ctx, &schedule))
+	if d, _ := s.GetDigestSchedule(ctx, -100); d == nil || d.LastSentOn != "2026-10-18" || d.Timezone != "Europe/Madrid" {
+		t.Errorf("GetDigestSchedule = %+v", d)
+	}
+	if all, _ := s.GetDigestSchedules(ctx); len(all) != 1 {
+		t.Errorf("GetDigestSchedules = %+v", all)
+	}
+	must(t, s.RemoveDigestSchedule(ctx, -100))
+	if d, _ := s.GetDigestSchedule(ctx, -100); d != nil {
+		t.Errorf("removed schedule still stored: %+v", d)
+	}
+}
+
+func testHoldings(t *testing.T, ctx context.Context, s Store) {
+	var added []models.Holding
+	for _, h := range []models.Holding{
+		{TelegramID: 1, CoinID: "ethereum", Symbol: "ETH", Amount: "1.5", Price: "2000.10"},
+		{TelegramID: 1, CoinID: "bitcoin", Symbol: "BTC", Amount: "0.00000001", Price: "60000"},
+		{TelegramID: 2, CoinID: "bitcoin", Symbol: "BTC", Amount: "1", Price: "50000"},
+	} {
+		h.Currency, h.CreatedAt, h.UpdatedAt = "usd", now(), now()
+		must(t, s.AddHolding(ctx, &h))
+		if h.ID == 0 {
+			t.Fatal("AddHolding left the ID unset")
+		}
+		added = append(added, h)
+	}
+
+	holdings, err := s.GetHoldings(ctx, 1)
+	must(t, err)
+	if len(holdings) != 2 || holdings[0].CoinID != "ethereum" || holdings[1].Amount != "0.00000001" {
+		t.Errorf("GetHoldings = %+v, want them in the order added with exact amounts", holdings)
+	}
+
+	// Members can only change and remove their own holdings.
+	stolen := added[2]
+	stolen.TelegramID, stolen.Amount = 1, "100"
+	must(t, s.UpdateHolding(ctx, &stolen))
+	if holdings, _ := s.GetHoldings(ctx, 2); len(holdings) != 1 || holdings[0].Amount != "1" {
+		t.Errorf("updating another member's holding changed it: %+v", holdings)
+	}
+	if ok, _ := s.RemoveHolding(ctx, 1, added[2].ID); ok {
+		t.Error("removing another member's holding reported a removal")
+	}
+
+	updated := added[0]
+	updated.Amount = "2"
+	must(t, s.UpdateHolding(ctx, &updated))
+	if holdings, _ := s.GetHoldings(ctx, 1); len(holdings) != 2 || holdings[0].Amount != "2" {
+		t.Errorf("GetHoldings after an update = %+v", holdings)
+	}
+	if ok, err := s.RemoveHolding(ctx, 1, added[0].ID); err != nil || !ok {
+		t.Errorf("RemoveHolding = %v, %v", ok, err)
+	}
+	if holdings, _ := s.GetHoldings(ctx, 1); len(holdings) != 1 {
+		t.Errorf("GetHoldings after removing one = %+v", holdings)
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	ctx := context.Background()
+	s := openSQL(t, ctx, "sqlite:"+filepath.Join(t.TempDir(), "bot.db"))
+	insert := func() error {
+		_, err := s.exec(ctx, s.db, "INSERT INTO rules_versions (chat_id, version, text, created_by, created_at) VALUES (?, ?, ?, ?, ?)",
+			-100, 1, "Be nice", 1, now())
+		return err
+	}
+	must(t, insert())
+	if err := insert(); !isUniqueViolation(err) {
+		t.Errorf("isUniqueViolation(%v) = false", err)
+	}
+	if isUniqueViolation(errors.New("(23505) duplicate key")) || isUniqueViolation(nil) {
+		t.Error("isUniqueViolation matched an error that isn't from a driver")
+	}
+}
@@ -54,3 +54,18 @@ func (c *Client) RemoveUser(ctx context.Context, telegramID int64) error {
 	log.Printf("Successfully removed user %d from database.", telegramID)
 	return nil
 }
+
+// GetUser returns a member by Telegram ID, or nil if they aren't in the 'members' table.
+func (c *Client) GetUser(ctx context.Context, telegramID int64) (*models.User, error) {
+	var users []models.User
+	_, err := c.From("members").Select("*", "", false).
+		Eq("telegram_id", fmt.Sprintf("%d", telegramID)).
+		ExecuteTo(&users)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user from supabase: %w", err)
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return &users[0], nil
+}
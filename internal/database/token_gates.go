@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// SaveTokenGate inserts or replaces the token gate of a chat in the 'token_gates' table.
+func (c *Client) SaveTokenGate(ctx context.Context, gate *models.TokenGate) error {
+	data := []models.TokenGate{*gate}
+
+	_, _, err := c.From("token_gates").Upsert(data, "chat_id", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to save token gate to supabase: %w", err)
+	}
+
+	log.Printf("Saved token gate on %s %s for chat %d.", gate.Chain, gate.Contract, gate.ChatID)
+	return nil
+}
+
+// GetTokenGate returns the token gate of a chat, or nil if the chat isn't gated.
+func (c *Client) GetTokenGate(ctx context.Context, chatID int64) (*models.TokenGate, error) {
+	var gates []models.TokenGate
+	_, err := c.From("token_gates").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&gates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token gate from supabase: %w", err)
+	}
+	if len(gates) == 0 {
+		return nil, nil
+	}
+	return &gates[0], nil
+}
+
+// GetTokenGates returns the token gates of every chat.
+func (c *Client) GetTokenGates(ctx context.Context) ([]models.TokenGate, error) {
+	var gates []models.TokenGate
+	_, err := c.From("token_gates").Select("*", "", false).ExecuteTo(&gates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token gates from supabase: %w", err)
+	}
+	return gates, nil
+}
+
+// RemoveTokenGate lifts the token gate of a chat and forgets its admitted members.
+func (c *Client) RemoveTokenGate(ctx context.Context, chatID int64) error {
+	_, _, err := c.From("token_gates").Delete("", "").Eq("chat_id", fmt.Sprintf("%d", chatID)).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove token gate from supabase: %w", err)
+	}
+	_, _, err = c.From("token_gate_members").Delete("", "").Eq("chat_id", fmt.Sprintf("%d", chatID)).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove token gate members from supabase: %w", err)
+	}
+
+	log.Printf("Removed token gate of chat %d.", chatID)
+	return nil
+}
+
+// SaveTokenGateMember records that a member was admitted to a gated chat, or when they were last checked.
+func (c *Client) SaveTokenGateMember(ctx context.Context, member *models.TokenGateMember) error {
+	data := []models.TokenGateMember{*member}
+
+	_, _, err := c.From("token_gate_members").Upsert(data, "chat_id,telegram_id", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to save token gate member to supabase: %w", err)
+	}
+	return nil
+}
+
+// GetTokenGateMembers returns the admitted members of a gated chat.
+func (c *Client) GetTokenGateMembers(ctx context.Context, chatID int64) ([]models.TokenGateMember, error) {
+	var members []models.TokenGateMember
+	_, err := c.From("token_gate_members").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&members)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token gate members from supabase: %w", err)
+	}
+	return members, nil
+}
+
+// RemoveTokenGateMember forgets a member of a gated chat, after they left or were removed.
+func (c *Client) RemoveTokenGateMember(ctx context.Context, chatID, telegramID int64) error {
+	_, _, err := c.From("token_gate_members").Delete("", "").
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		Eq("telegram_id", fmt.Sprintf("%d", telegramID)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove token gate member from supabase: %w", err)
+	}
+	return nil
+}
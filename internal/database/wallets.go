@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// AddLinkedWallet stores a verified wallet in the 'linked_wallets' table. An address is linked
+// to one user per chain, so linking it again moves it to the user who signed last.
+func (c *Client) AddLinkedWallet(ctx context.Context, wallet *models.LinkedWallet) error {
+	data := []models.LinkedWallet{*wallet}
+
+	_, _, err := c.From("linked_wallets").Upsert(data, "chain,address", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to add linked wallet to supabase: %w", err)
+	}
+
+	log.Printf("Linked wallet %s on %s to user %d.", wallet.Address, wallet.Chain, wallet.TelegramID)
+	return nil
+}
+
+// GetLinkedWallets returns the wallets a user has linked, on every chain.
+func (c *Client) GetLinkedWallets(ctx context.Context, telegramID int64) ([]models.LinkedWallet, error) {
+	var wallets []models.LinkedWallet
+	_, err := c.From("linked_wallets").Select("*", "", false).
+		Eq("telegram_id", fmt.Sprintf("%d", telegramID)).
+		ExecuteTo(&wallets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch linked wallets from supabase: %w", err)
+	}
+	return wallets, nil
+}
+
+// RemoveLinkedWallet unlinks an address from a user on one chain, or on every chain if chain is "".
+// It returns how many links were removed.
+func (c *Client) RemoveLinkedWallet(ctx context.Context, telegramID int64, address, chain string) (int, error) {
+	query := c.From("linked_wallets").Delete("representation", "").
+		Eq("telegram_id", fmt.Sprintf("%d", telegramID)).
+		Eq("address", address)
+	if chain != "" {
+		query = query.Eq("chain", chain)
+	}
+
+	var removed []models.LinkedWallet
+	if _, err := query.ExecuteTo(&removed); err != nil {
+		return 0, fmt.Errorf("failed to remove linked wallet from supabase: %w", err)
+	}
+
+	log.Printf("Unlinked wallet %s from user %d (%d links).", address, telegramID, len(removed))
+	return len(removed), nil
+}
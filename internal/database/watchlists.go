@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// AddWatchlistCoins adds coins to a chat's 'watchlist_coins'. Adding a coin that is already on it replaces its row.
+func (c *Client) AddWatchlistCoins(ctx context.Context, coins []models.WatchlistCoin) error {
+	_, _, err := c.From("watchlist_coins").Upsert(coins, "chat_id,coin_id", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to add watchlist coins to supabase: %w", err)
+	}
+	return nil
+}
+
+// GetWatchlist returns the coins on a chat's watchlist, oldest first.
+func (c *Client) GetWatchlist(ctx context.Context, chatID int64) ([]models.WatchlistCoin, error) {
+	var coins []models.WatchlistCoin
+	_, err := c.From("watchlist_coins").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&coins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watchlist from supabase: %w", err)
+	}
+	sort.SliceStable(coins, func(i, j int) bool { return coins[i].CreatedAt.Before(coins[j].CreatedAt) })
+	return coins, nil
+}
+
+// RemoveWatchlistCoins takes coins off a chat's watchlist and returns how many were on it.
+func (c *Client) RemoveWatchlistCoins(ctx context.Context, chatID int64, coinIDs []string) (int, error) {
+	var removed []models.WatchlistCoin
+	_, err := c.From("watchlist_coins").Delete("representation", "").
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		In("coin_id", coinIDs).
+		ExecuteTo(&removed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove watchlist coins from supabase: %w", err)
+	}
+	return len(removed), nil
+}
+
+// SaveDigestSchedule inserts or replaces the digest schedule of a chat.
+func (c *Client) SaveDigestSchedule(ctx context.Context, schedule *models.DigestSchedule) error {
+	data := []models.DigestSchedule{*schedule}
+
+	_, _, err := c.From("digest_schedules").Upsert(data, "chat_id", "minimal", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to save digest schedule to supabase: %w", err)
+	}
+	return nil
+}
+
+// GetDigestSchedule returns the digest schedule of a chat, or nil if it has none.
+func (c *Client) GetDigestSchedule(ctx context.Context, chatID int64) (*models.DigestSchedule, error) {
+	var schedules []models.DigestSchedule
+	_, err := c.From("digest_schedules").Select("*", "", false).
+		Eq("chat_id", fmt.Sprintf("%d", chatID)).
+		ExecuteTo(&schedules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch digest schedule from supabase: %w", err)
+	}
+	if len(schedules) == 0 {
+		return nil, nil
+	}
+	return &schedules[0], nil
+}
+
+// GetDigestSchedules returns the digest schedules of all chats, for the scheduler.
+func (c *Client) GetDigestSchedules(ctx context.Context) ([]models.DigestSchedule, error) {
+	var schedules []models.DigestSchedule
+	_, err := c.From("digest_schedules").Select("*", "", false).ExecuteTo(&schedules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch digest schedules from supabase: %w", err)
+	}
+	return schedules, nil
+}
+
+// RemoveDigestSchedule stops the daily digest of a chat.
+func (c *Client) RemoveDigestSchedule(ctx context.Context, chatID int64) error {
+	_, _, err := c.From("digest_schedules").Delete("", "").Eq("chat_id", fmt.Sprintf("%d", chatID)).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to remove digest schedule from supabase: %w", err)
+	}
+
+	log.Printf("Removed digest schedule of chat %d.", chatID)
+	return nil
+}
@@ -0,0 +1,66 @@
+package deeplink
+
+import (
+	"crypto/sha256"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/config"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+)
+
+// Handler runs the feature behind a deep link. It is only called in private chat with verified arguments.
+type Handler func(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message, args []int64)
+
+// handlerRegistry maps each action to the feature that handles it.
+var handlerRegistry = make(map[Action]Handler)
+
+// secret is the HMAC key for payloads, set by Configure on startup.
+var secret []byte
+
+// Configure sets the signing key from the config. When no dedicated secret is configured,
+// the key is derived from the bot token so links still can't be forged by anyone without it.
+func Configure(cfg *config.Config) {
+	if cfg.DeepLinkSecret != "" {
+		secret = []byte(cfg.DeepLinkSecret)
+		return
+	}
+	sum := sha256.Sum256([]byte("byb-bot deep links:" + cfg.TelegramToken))
+	secret = sum[:]
+}
+
+// Register sets the handler for an action.
+func Register(action Action, handler Handler) {
+	handlerRegistry[action] = handler
+}
+
+// URL builds a signed t.me link that opens the bot with an action.
+func URL(bot *tgbotapi.BotAPI, action Action, args ...int64) (string, error) {
+	payload, err := Encode(action, args...)
+	if err != nil {
+		return "", err
+	}
+	return Link(bot.Self.UserName, payload), nil
+}
+
+// Handle routes a /start payload to the feature that handles it.
+func Handle(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message, payload string) {
+	l := i18n.ForMessage(db, message)
+
+	action, args, err := Decode(payload)
+	if err != nil {
+		log.Printf("Rejected deep link from user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("deeplink.invalid")))
+		return
+	}
+
+	handler, exists := handlerRegistry[action]
+	if !exists {
+		log.Printf("No handler registered for deep link action %d", action)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("deeplink.unavailable")))
+		return
+	}
+
+	handler(bot, db, message, args)
+}
@@ -0,0 +1,107 @@
+package deeplink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Action identifies the feature a deep link opens.
+type Action byte
+
+const (
+	// ActionRules shows a group's rules in private chat. Args: chat ID.
+	ActionRules Action = iota + 1
+	// ActionVerify completes a pending join verification in private chat. Args: chat ID, user ID.
+	ActionVerify
+	// ActionWallet starts linking a wallet. Args: none, or the token-gated chat the wallet is for.
+	ActionWallet
+	// ActionReferral attributes a new member to the member who invited them. Args: chat ID, referrer ID.
+	ActionReferral
+
+	// actionCount is one past the last action; new actions go above it.
+	actionCount
+)
+
+// signatureSize is the number of HMAC bytes kept in a payload. Telegram limits payloads to
+// 64 characters, and 64 bits is plenty to stop anyone from guessing a valid signature.
+const signatureSize = 8
+
+// maxPayloadLength is Telegram's limit for /start parameters.
+const maxPayloadLength = 64
+
+var (
+	errMalformed     = errors.New("malformed deep link payload")
+	errBadSignature  = errors.New("deep link payload signature mismatch")
+	errUnknownAction = errors.New("unknown deep link action")
+)
+
+// Encode builds a signed payload for an action and its arguments.
+// The layout is action byte, zig-zag varint arguments, then a truncated HMAC-SHA256 of everything before it,
+// all base64url encoded without padding, which only uses characters Telegram allows in /start parameters.
+func Encode(action Action, args ...int64) (string, error) {
+	if !action.known() {
+		return "", errUnknownAction
+	}
+	buf := []byte{byte(action)}
+	for _, arg := range args {
+		buf = binary.AppendVarint(buf, arg)
+	}
+	buf = append(buf, sign(buf)...)
+
+	payload := base64.RawURLEncoding.EncodeToString(buf)
+	if len(payload) > maxPayloadLength {
+		return "", fmt.Errorf("deep link payload is %d characters, the limit is %d", len(payload), maxPayloadLength)
+	}
+	return payload, nil
+}
+
+// Decode verifies a payload and returns its action and arguments.
+func Decode(payload string) (Action, []int64, error) {
+	if len(payload) > maxPayloadLength {
+		return 0, nil, errMalformed
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(buf) < 1+signatureSize {
+		return 0, nil, errMalformed
+	}
+
+	data, signature := buf[:len(buf)-signatureSize], buf[len(buf)-signatureSize:]
+	if !hmac.Equal(signature, sign(data)) {
+		return 0, nil, errBadSignature
+	}
+
+	action := Action(data[0])
+	if !action.known() {
+		return 0, nil, errUnknownAction
+	}
+	var args []int64
+	for rest := data[1:]; len(rest) > 0; {
+		arg, n := binary.Varint(rest)
+		if n <= 0 {
+			return 0, nil, errMalformed
+		}
+		args = append(args, arg)
+		rest = rest[n:]
+	}
+	return action, args, nil
+}
+
+// Link returns the t.me URL that opens the bot with a payload.
+func Link(botUsername, payload string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", botUsername, payload)
+}
+
+// known reports whether the action is one this version of the bot defines.
+func (a Action) known() bool {
+	return a >= ActionRules && a < actionCount
+}
+
+func sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return mac.Sum(nil)[:signatureSize]
+}
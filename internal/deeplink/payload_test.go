@@ -0,0 +1,108 @@
+package deeplink
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useSecret signs payloads with key until the test ends.
+func useSecret(t *testing.T, key string) {
+	t.Helper()
+	previous := secret
+	secret = []byte(key)
+	t.Cleanup(func() { secret = previous })
+}
+
+func TestRoundTrip(t *testing.T) {
+	useSecret(t, "test secret")
+	tests := []struct {
+		action Action
+		args   []int64
+	}{
+		{ActionRules, []int64{-1001234567890}},
+		{ActionVerify, []int64{-1001234567890, 42}},
+		{ActionWallet, nil},
+		{ActionWallet, []int64{-1001234567890}},
+		{ActionReferral, []int64{-1001234567890, 7_000_000_000}},
+		{ActionReferral, []int64{math.MinInt64, math.MaxInt64}},
+	}
+	for _, tt := range tests {
+		payload, err := Encode(tt.action, tt.args...)
+		if err != nil {
+			t.Fatalf("Encode(%d, %v): %v", tt.action, tt.args, err)
+		}
+		if strings.ContainsFunc(payload, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+		}) {
+			t.Errorf("payload %q has characters Telegram doesn't allow", payload)
+		}
+		action, args, err := Decode(payload)
+		if err != nil || action != tt.action || !slices.Equal(args, tt.args) {
+			t.Errorf("Decode(Encode(%d, %v)) = %d, %v, %v", tt.action, tt.args, action, args, err)
+		}
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	useSecret(t, "test secret")
+	valid, err := Encode(ActionVerify, -1001234567890, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(valid)
+	encode := base64.RawURLEncoding.EncodeToString
+
+	flipped := slices.Clone(raw)
+	flipped[len(flipped)-1] ^= 1
+	// A payload that changes an argument but keeps the old signature.
+	forged := slices.Clone(raw)
+	forged[len(forged)-signatureSize-1] ^= 2
+	// A correctly signed payload of an action this bot doesn't know.
+	unknown := []byte{byte(actionCount)}
+	unknown = append(unknown, sign(unknown)...)
+
+	tests := []struct {
+		name    string
+		payload string
+		want    error
+	}{
+		{"flipped MAC byte", encode(flipped), errBadSignature},
+		{"changed argument", encode(forged), errBadSignature},
+		{"truncated", encode(raw[:len(raw)-1]), errBadSignature},
+		{"only a signature", encode(raw[len(raw)-signatureSize:]), errMalformed},
+		{"empty", "", errMalformed},
+		{"not base64", "not a payload!", errMalformed},
+		{"over-long", valid + strings.Repeat("A", maxPayloadLength), errMalformed},
+		{"unknown action", encode(unknown), errUnknownAction},
+	}
+	for _, tt := range tests {
+		if _, _, err := Decode(tt.payload); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Decode(%q) = %v, want %v", tt.name, tt.payload, err, tt.want)
+		}
+	}
+
+	// Links signed by another deployment's secret don't work here.
+	useSecret(t, "another secret")
+	if _, _, err := Decode(valid); !errors.Is(err, errBadSignature) {
+		t.Errorf("a payload signed with another secret decoded with %v", err)
+	}
+}
+
+func TestEncodeRejects(t *testing.T) {
+	useSecret(t, "test secret")
+	if _, err := Encode(0); !errors.Is(err, errUnknownAction) {
+		t.Errorf("Encode of action 0 = %v", err)
+	}
+	if _, err := Encode(actionCount); !errors.Is(err, errUnknownAction) {
+		t.Errorf("Encode of an undefined action = %v", err)
+	}
+	// Five 64-bit arguments don't fit in Telegram's 64 characters.
+	args := []int64{math.MaxInt64, math.MaxInt64, math.MaxInt64, math.MaxInt64, math.MaxInt64}
+	if _, err := Encode(ActionReferral, args...); err == nil {
+		t.Error("Encode accepted a payload over Telegram's limit")
+	}
+}
@@ -0,0 +1,288 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+)
+
+const (
+	defaultCooldown = 30 * time.Second
+	// pruneInterval is how often expired cooldowns are dropped.
+	pruneInterval = 10 * time.Minute
+)
+
+var (
+	matchers      = make(map[int64]*matcher)   // Compiled filters per chat, loaded lazily from the database
+	cooldownUntil = make(map[string]time.Time) // End of the cooldown per chat and trigger
+	lastPrune     time.Time                    // When expired cooldowns were last dropped
+	mu            sync.Mutex
+)
+
+// HandleFilterCommand lets an admin add or replace a keyword filter.
+// Usage: /filter [contains:|regex:]<trigger> [cooldown] <response>
+// A multi-word trigger can be wrapped in double quotes, and replying to a message uses its text as the response.
+func HandleFilterCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	filter, err := parseFilterArgs(l, message.CommandArguments())
+	if err == nil && filter.Response == "" && message.ReplyToMessage != nil {
+		filter.Response = message.ReplyToMessage.Text
+	}
+	if err != nil || filter.Response == "" {
+		usage := l.T("filters.usage")
+		if err != nil {
+			usage = fmt.Sprintf("%v\n\n%s", err, usage)
+		}
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, usage))
+		return
+	}
+
+	filter.ChatID = message.Chat.ID
+	filter.CreatedBy = message.From.ID
+	filter.CreatedAt = time.Now()
+
+	if err := db.SaveFilter(context.Background(), filter); err != nil {
+		log.Printf("Failed to save filter: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.save_error")))
+		return
+	}
+	invalidate(message.Chat.ID)
+
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.saved", filter.MatchType, filter.Trigger)))
+}
+
+// HandleStopCommand lets an admin remove a keyword filter.
+func HandleStopCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	trigger, _ := splitTrigger(strings.TrimSpace(message.CommandArguments()))
+	_, trigger = splitMatchType(trigger)
+	if trigger == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.stop_usage")))
+		return
+	}
+
+	filters, err := db.GetFilters(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load filters for chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.remove_error")))
+		return
+	}
+
+	// Look the trigger up case-insensitively so admins don't have to remember the original spelling.
+	stored := ""
+	for _, f := range filters {
+		if strings.EqualFold(f.Trigger, trigger) {
+			stored = f.Trigger
+			break
+		}
+	}
+	if stored == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.not_found", trigger)))
+		return
+	}
+
+	if err := db.RemoveFilter(context.Background(), message.Chat.ID, stored); err != nil {
+		log.Printf("Failed to remove filter: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.remove_error")))
+		return
+	}
+	invalidate(message.Chat.ID)
+
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.removed", stored)))
+}
+
+// HandleFiltersCommand lists the keyword filters configured for the chat.
+func HandleFiltersCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	filters, err := db.GetFilters(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load filters for chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.load_error")))
+		return
+	}
+	if len(filters) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("filters.none")))
+		return
+	}
+
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Trigger < filters[j].Trigger })
+
+	var sb strings.Builder
+	sb.WriteString(l.N("filters.list_header", len(filters), len(filters)))
+	sb.WriteString("\n")
+	for _, f := range filters {
+		sb.WriteString("\n")
+		sb.WriteString(l.T("filters.list_item", f.Trigger, f.MatchType, cooldownFor(&f)))
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, sb.String()))
+}
+
+// HandleMessage checks a group message against the chat's filters and replies for every trigger that is not cooling down.
+func HandleMessage(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	text := message.Text
+	if text == "" {
+		text = message.Caption
+	}
+	if text == "" {
+		return
+	}
+
+	m, err := matcherFor(db, message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load filters for chat %d: %v", message.Chat.ID, err)
+		return
+	}
+
+	for _, f := range m.match(text) {
+		if !claimCooldown(message.Chat.ID, f) {
+			continue
+		}
+		reply := tgbotapi.NewMessage(message.Chat.ID, f.Response)
+		reply.ReplyToMessageID = message.MessageID
+		if _, err := bot.Send(reply); err != nil {
+			log.Printf("Failed to send filter reply for %q: %v", f.Trigger, err)
+		}
+	}
+}
+
+// matcherFor returns the compiled filters of a chat, loading them from the database on first use.
+func matcherFor(db database.Store, chatID int64) (*matcher, error) {
+	mu.Lock()
+	m, ok := matchers[chatID]
+	mu.Unlock()
+	if ok {
+		return m, nil
+	}
+
+	filters, err := db.GetFilters(context.Background(), chatID)
+	if err != nil {
+		return nil, err
+	}
+	m, err = newMatcher(filters)
+	if err != nil {
+		log.Printf("Filters for chat %d: %v", chatID, err)
+	}
+
+	mu.Lock()
+	matchers[chatID] = m
+	mu.Unlock()
+	return m, nil
+}
+
+// invalidate drops the cached matcher of a chat so the next message reloads it.
+func invalidate(chatID int64) {
+	mu.Lock()
+	delete(matchers, chatID)
+	mu.Unlock()
+}
+
+// claimCooldown reports whether a filter may fire now, and if so starts its cooldown.
+func claimCooldown(chatID int64, f *models.Filter) bool {
+	key := fmt.Sprintf("%d:%s", chatID, strings.ToLower(f.Trigger))
+	now := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if now.Sub(lastPrune) >= pruneInterval {
+		pruneCooldowns(now)
+	}
+	if until, ok := cooldownUntil[key]; ok && now.Before(until) {
+		return false
+	}
+	cooldownUntil[key] = now.Add(cooldownFor(f))
+	return true
+}
+
+// pruneCooldowns drops cooldowns that are over, so triggers that fired once don't stay in
+// memory forever. Callers hold mu.
+func pruneCooldowns(now time.Time) {
+	for key, until := range cooldownUntil {
+		if !now.Before(until) {
+			delete(cooldownUntil, key)
+		}
+	}
+	lastPrune = now
+}
+
+func cooldownFor(f *models.Filter) time.Duration {
+	if f.CooldownSeconds <= 0 {
+		return defaultCooldown
+	}
+	return time.Duration(f.CooldownSeconds) * time.Second
+}
+
+// parseFilterArgs parses the arguments of /filter into an unsaved filter.
+// Errors are already translated since they are shown to the admin as-is.
+func parseFilterArgs(l i18n.Localizer, args string) (*models.Filter, error) {
+	trigger, rest := splitTrigger(strings.TrimSpace(args))
+	matchType, trigger := splitMatchType(trigger)
+	if trigger == "" {
+		return nil, errors.New(l.T("filters.trigger_required"))
+	}
+	if matchType == models.FilterMatchRegex {
+		if _, err := compileRegexTrigger(trigger); err != nil {
+			return nil, errors.New(l.T("filters.invalid_regex", err))
+		}
+	} else {
+		trigger = strings.ToLower(trigger)
+	}
+
+	filter := &models.Filter{Trigger: trigger, MatchType: matchType}
+
+	// An optional duration right after the trigger sets the cooldown.
+	if fields := strings.Fields(rest); len(fields) > 0 {
+		if d, err := time.ParseDuration(fields[0]); err == nil && d > 0 {
+			filter.CooldownSeconds = int(d.Seconds())
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[0]))
+		}
+	}
+	filter.Response = strings.TrimSpace(rest)
+	return filter, nil
+}
+
+// splitTrigger separates the leading trigger from the rest of the arguments.
+// The trigger is either the first word or a double-quoted phrase.
+func splitTrigger(args string) (trigger, rest string) {
+	if prefixEnd := strings.Index(args, `"`); prefixEnd >= 0 && !strings.ContainsAny(args[:prefixEnd], " \t\n") {
+		if end := strings.Index(args[prefixEnd+1:], `"`); end >= 0 {
+			closing := prefixEnd + 1 + end
+			return args[:prefixEnd] + args[prefixEnd+1:closing], strings.TrimSpace(args[closing+1:])
+		}
+	}
+	end := strings.IndexAny(args, " \t\n")
+	if end < 0 {
+		return args, ""
+	}
+	return args[:end], strings.TrimSpace(args[end:])
+}
+
+// splitMatchType strips a "contains:" or "regex:" prefix from a trigger.
+func splitMatchType(trigger string) (string, string) {
+	for _, t := range []string{models.FilterMatchContains, models.FilterMatchRegex} {
+		if strings.HasPrefix(strings.ToLower(trigger), t+":") {
+			return t, trigger[len(t)+1:]
+		}
+	}
+	return models.FilterMatchWord, trigger
+}
@@ -0,0 +1,118 @@
+package filters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// matcher holds the compiled filters of a single chat.
+// Word and substring triggers are folded into one alternation regex each, so a message
+// is scanned a constant number of times no matter how many triggers a chat has.
+// RE2 guarantees linear-time matching, so user supplied patterns can't stall the bot.
+type matcher struct {
+	words      *regexp.Regexp
+	substrings *regexp.Regexp
+	regexes    []compiledRegex
+	byTrigger  map[string]*models.Filter
+}
+
+type compiledRegex struct {
+	re     *regexp.Regexp
+	filter *models.Filter
+}
+
+// newMatcher compiles a chat's filters. Regex filters that fail to compile are skipped
+// and reported, since they were validated when saved and should never be invalid.
+func newMatcher(filters []models.Filter) (*matcher, error) {
+	m := &matcher{byTrigger: make(map[string]*models.Filter)}
+
+	var words, substrings []string
+	var badPatterns []string
+	for i := range filters {
+		f := &filters[i]
+		switch f.MatchType {
+		case models.FilterMatchRegex:
+			re, err := compileRegexTrigger(f.Trigger)
+			if err != nil {
+				badPatterns = append(badPatterns, f.Trigger)
+				continue
+			}
+			m.regexes = append(m.regexes, compiledRegex{re: re, filter: f})
+		case models.FilterMatchContains:
+			m.byTrigger[strings.ToLower(f.Trigger)] = f
+			substrings = append(substrings, regexp.QuoteMeta(strings.ToLower(f.Trigger)))
+		default:
+			m.byTrigger[strings.ToLower(f.Trigger)] = f
+			words = append(words, regexp.QuoteMeta(strings.ToLower(f.Trigger)))
+		}
+	}
+
+	// RE2 alternation prefers the first alternative that matches at a position, so longer
+	// triggers go first; otherwise "gm" would shadow "gm fam".
+	longestFirst(words)
+	longestFirst(substrings)
+
+	if len(words) > 0 {
+		// \b only understands ASCII, so word boundaries are spelled out to work with any script.
+		pattern := fmt.Sprintf(`(?i)(?:^|[^\p{L}\p{N}_])(%s)(?:$|[^\p{L}\p{N}_])`, strings.Join(words, "|"))
+		m.words = regexp.MustCompile(pattern)
+	}
+	if len(substrings) > 0 {
+		m.substrings = regexp.MustCompile(fmt.Sprintf(`(?i)(%s)`, strings.Join(substrings, "|")))
+	}
+
+	if len(badPatterns) > 0 {
+		return m, fmt.Errorf("skipped invalid regex filters: %s", strings.Join(badPatterns, ", "))
+	}
+	return m, nil
+}
+
+// longestFirst sorts quoted triggers by length, longest first.
+func longestFirst(triggers []string) {
+	sort.SliceStable(triggers, func(i, j int) bool { return len(triggers[i]) > len(triggers[j]) })
+}
+
+// compileRegexTrigger compiles a regex trigger. Matching is case-insensitive like the other match types.
+func compileRegexTrigger(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// match returns every filter triggered by the text, in word, substring, regex order.
+func (m *matcher) match(text string) []*models.Filter {
+	var matched []*models.Filter
+	seen := make(map[*models.Filter]bool)
+	add := func(f *models.Filter) {
+		if f != nil && !seen[f] {
+			seen[f] = true
+			matched = append(matched, f)
+		}
+	}
+
+	if m.words != nil {
+		// RE2 has no lookahead, so the trailing boundary is consumed by each match.
+		// Resume scanning right after the matched word so adjacent triggers are still found.
+		for pos := 0; pos < len(text); {
+			loc := m.words.FindStringSubmatchIndex(text[pos:])
+			if loc == nil {
+				break
+			}
+			add(m.byTrigger[strings.ToLower(text[pos+loc[2]:pos+loc[3]])])
+			pos += loc[3]
+		}
+	}
+	if m.substrings != nil {
+		for _, sub := range m.substrings.FindAllString(text, -1) {
+			add(m.byTrigger[strings.ToLower(sub)])
+		}
+	}
+	for _, r := range m.regexes {
+		if r.re.MatchString(text) {
+			add(r.filter)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,92 @@
+package filters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+func TestMatchPrefersLongerTriggers(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []models.Filter
+		text    string
+		want    []string
+	}{
+		{
+			name: "word",
+			filters: []models.Filter{
+				{Trigger: "gm", MatchType: models.FilterMatchWord},
+				{Trigger: "gm fam", MatchType: models.FilterMatchWord},
+			},
+			text: "GM fam, coffee time",
+			want: []string{"gm fam"},
+		},
+		{
+			name: "contains",
+			filters: []models.Filter{
+				{Trigger: "moon", MatchType: models.FilterMatchContains},
+				{Trigger: "moonshot", MatchType: models.FilterMatchContains},
+			},
+			text: "is this a moonshot?",
+			want: []string{"moonshot"},
+		},
+		{
+			name: "shorter still matches on its own",
+			filters: []models.Filter{
+				{Trigger: "gm", MatchType: models.FilterMatchWord},
+				{Trigger: "gm fam", MatchType: models.FilterMatchWord},
+			},
+			text: "gm everyone",
+			want: []string{"gm"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newMatcher(tt.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range m.match(tt.text) {
+				got = append(got, f.Trigger)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("match(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("match(%q) = %q, want %q", tt.text, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestClaimCooldownPrunesExpired(t *testing.T) {
+	f := &models.Filter{Trigger: "wen", CooldownSeconds: 60}
+	if !claimCooldown(1, f) {
+		t.Fatal("first reply was held back")
+	}
+	if claimCooldown(1, f) {
+		t.Fatal("second reply fired within the cooldown")
+	}
+
+	mu.Lock()
+	cooldownUntil["1:wen"] = time.Now().Add(-time.Second)
+	lastPrune = time.Time{}
+	mu.Unlock()
+	if !claimCooldown(2, f) {
+		t.Fatal("reply in another chat was held back")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := cooldownUntil["1:wen"]; ok {
+		t.Error("expired cooldown was not pruned")
+	}
+	if _, ok := cooldownUntil["2:wen"]; !ok {
+		t.Error("running cooldown was pruned")
+	}
+}
@@ -0,0 +1,131 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is used when no preference is set and a user's Telegram language isn't supported.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// message is a single catalog entry. Plain strings only fill "other";
+// pluralized entries are written as an object keyed by CLDR category ("one", "other", ...).
+type message map[string]string
+
+// catalogs maps a language code to its messages. It is read-only after init.
+var catalogs = make(map[string]map[string]message)
+
+func init() {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		log.Fatalf("Could not read embedded locale files: %v", err)
+	}
+
+	for _, entry := range entries {
+		lang := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			log.Fatalf("Could not read locale %s: %v", lang, err)
+		}
+		catalog, err := parseCatalog(data)
+		if err != nil {
+			log.Fatalf("Could not parse locale %s: %v", lang, err)
+		}
+		catalogs[lang] = catalog
+	}
+
+	if _, ok := catalogs[DefaultLanguage]; !ok {
+		log.Fatalf("The default locale %q is missing", DefaultLanguage)
+	}
+}
+
+// parseCatalog decodes a locale file whose values are either strings or plural objects.
+func parseCatalog(data []byte) (map[string]message, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	catalog := make(map[string]message, len(raw))
+	for key, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			catalog[key] = message{"other": text}
+			continue
+		}
+		var forms message
+		if err := json.Unmarshal(value, &forms); err != nil {
+			return nil, fmt.Errorf("key %q must be a string or an object of plural forms", key)
+		}
+		if _, ok := forms["other"]; !ok {
+			return nil, fmt.Errorf("key %q is missing the \"other\" plural form", key)
+		}
+		catalog[key] = forms
+	}
+	return catalog, nil
+}
+
+// Supported returns the codes of all languages that have a catalog, sorted.
+func Supported() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize maps a Telegram language_code such as "fr-CA" to a supported language,
+// returning an empty string if there is no catalog for it.
+func Normalize(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := catalogs[code]; ok {
+		return code
+	}
+	return ""
+}
+
+// lookup finds the plural form of a key, falling back to the default language and finally to the key itself.
+func lookup(lang, key, form string) string {
+	for _, l := range []string{lang, DefaultLanguage} {
+		msg, ok := catalogs[l][key]
+		if !ok {
+			continue
+		}
+		if text, ok := msg[form]; ok {
+			return text
+		}
+		return msg["other"]
+	}
+	log.Printf("Missing translation key %q", key)
+	return key
+}
+
+// pluralForm returns the CLDR plural category of n for a language.
+// Only the rules of the languages we ship are implemented; everything else behaves like English.
+func pluralForm(lang string, n int) string {
+	switch lang {
+	case "fr":
+		// French treats 0 and 1 as singular.
+		if n == 0 || n == 1 {
+			return "one"
+		}
+		return "other"
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
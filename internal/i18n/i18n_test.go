@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestN(t *testing.T) {
+	tests := []struct {
+		lang  string
+		count int
+		want  string
+	}{
+		{"en", 0, "0 versions of the rules:"},
+		{"en", 1, "1 version of the rules:"},
+		{"en", 2, "2 versions of the rules:"},
+		// French uses the singular for zero too.
+		{"fr", 0, "0 version des règles :"},
+		{"fr", 1, "1 version des règles :"},
+		{"fr", 2, "2 versions des règles :"},
+	}
+	for _, tt := range tests {
+		if got := New(tt.lang).N("rules.history_header", tt.count, tt.count); got != tt.want {
+			t.Errorf("%s N(%d) = %q, want %q", tt.lang, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	if got := New("de").Lang; got != DefaultLanguage {
+		t.Errorf("New of an unsupported language = %q, want %q", got, DefaultLanguage)
+	}
+	if got := New("fr-CA").Lang; got != "fr" {
+		t.Errorf("New(fr-CA) = %q, want fr", got)
+	}
+	if got := Normalize("pt-BR"); got != "" {
+		t.Errorf("Normalize(pt-BR) = %q, want none", got)
+	}
+
+	// A key missing from a language falls back to English, and a key missing everywhere to itself.
+	catalogs[DefaultLanguage]["test.only_english"] = message{"other": "Only in %s"}
+	t.Cleanup(func() { delete(catalogs[DefaultLanguage], "test.only_english") })
+	if got := New("es").T("test.only_english", "English"); got != "Only in English" {
+		t.Errorf("missing Spanish key = %q, want the English text", got)
+	}
+	if got := New("es").T("test.nowhere"); got != "test.nowhere" {
+		t.Errorf("missing key = %q, want the key itself", got)
+	}
+	// Plain strings have no plural forms, so N uses the one text for every count.
+	if got := New("en").N("common.admin_only", 1); got != "This command is for admins only." {
+		t.Errorf("N of a plain string = %q", got)
+	}
+}
+
+func TestParseCatalog(t *testing.T) {
+	catalog, err := parseCatalog([]byte(`{"a": "A", "b": {"one": "B", "other": "Bs"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalog["a"]["other"] != "A" || catalog["b"]["one"] != "B" {
+		t.Errorf("parseCatalog = %v", catalog)
+	}
+	for _, bad := range []string{`{"a": {"one": "A"}}`, `{"a": 1}`, `[]`} {
+		if _, err := parseCatalog([]byte(bad)); err == nil {
+			t.Errorf("parseCatalog(%s) succeeded", bad)
+		}
+	}
+}
+
+// Every language must translate every key, or users silently get English.
+func TestCatalogsComplete(t *testing.T) {
+	want := slices.Sorted(maps.Keys(catalogs[DefaultLanguage]))
+	for _, lang := range Supported() {
+		for _, key := range want {
+			if _, ok := catalogs[lang][key]; !ok {
+				t.Errorf("%s is missing %q", lang, key)
+			}
+		}
+		for key := range catalogs[lang] {
+			if _, ok := catalogs[DefaultLanguage][key]; !ok {
+				t.Errorf("%s has %q, which %s doesn't", lang, key, DefaultLanguage)
+			}
+		}
+	}
+}
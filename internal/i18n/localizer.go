@@ -0,0 +1,114 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+)
+
+var (
+	preferences = make(map[int64]string) // Cached language preference per chat or user ID; "" means none set
+	mu          sync.Mutex
+)
+
+// Localizer translates catalog keys into one language.
+type Localizer struct {
+	Lang string
+}
+
+// New returns a Localizer for a language code, falling back to the default language if it isn't supported.
+func New(lang string) Localizer {
+	if l := Normalize(lang); l != "" {
+		return Localizer{Lang: l}
+	}
+	return Localizer{Lang: DefaultLanguage}
+}
+
+// For picks the reply language for a user in a chat. A group's language wins so replies
+// stay readable to everyone, then the user's own choice, then their Telegram app language.
+func For(db database.LanguageRepository, chat *tgbotapi.Chat, user *tgbotapi.User) Localizer {
+	if chat != nil && !chat.IsPrivate() {
+		return ForGroup(db, chat.ID, user)
+	}
+	return forUser(db, user)
+}
+
+// ForGroup picks the language for a message sent to a group, for callers that only know the chat ID.
+func ForGroup(db database.LanguageRepository, chatID int64, user *tgbotapi.User) Localizer {
+	if lang := preference(db, chatID); lang != "" {
+		return Localizer{Lang: lang}
+	}
+	return forUser(db, user)
+}
+
+func forUser(db database.LanguageRepository, user *tgbotapi.User) Localizer {
+	if user != nil {
+		if lang := preference(db, user.ID); lang != "" {
+			return Localizer{Lang: lang}
+		}
+		return New(user.LanguageCode)
+	}
+	return New(DefaultLanguage)
+}
+
+// ForMessage is a shorthand for the language of a reply to a message.
+func ForMessage(db database.LanguageRepository, message *tgbotapi.Message) Localizer {
+	return For(db, message.Chat, message.From)
+}
+
+// SetPreference stores the language for a chat or user and updates the cache.
+func SetPreference(db database.LanguageRepository, id int64, lang string) error {
+	if err := db.SetLanguage(context.Background(), id, lang); err != nil {
+		return err
+	}
+	mu.Lock()
+	preferences[id] = lang
+	mu.Unlock()
+	return nil
+}
+
+// preference returns the stored language for a chat or user, loading it from the database once.
+func preference(db database.LanguageRepository, id int64) string {
+	mu.Lock()
+	lang, ok := preferences[id]
+	mu.Unlock()
+	if ok {
+		return lang
+	}
+
+	lang, err := db.GetLanguage(context.Background(), id)
+	if err != nil {
+		// Don't cache failures, the next message will retry.
+		log.Printf("Failed to load language preference for %d: %v", id, err)
+		return ""
+	}
+	lang = Normalize(lang)
+
+	mu.Lock()
+	preferences[id] = lang
+	mu.Unlock()
+	return lang
+}
+
+// T translates a key and formats it with fmt.Sprintf-style arguments.
+func (l Localizer) T(key string, args ...interface{}) string {
+	text := lookup(l.Lang, key, "other")
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// N translates a pluralized key, choosing the form for count. The count is not passed
+// to the format automatically; include it in args where the message needs it.
+func (l Localizer) N(key string, count int, args ...interface{}) string {
+	text := lookup(l.Lang, key, pluralForm(l.Lang, count))
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,363 @@
+package inline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/notes"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+const (
+	// resultTTL is how long we reuse results for the same query before asking the APIs again.
+	resultTTL = 60 * time.Second
+	// telegramCacheTime lets Telegram's servers cache answers too, so repeated queries don't reach us at all.
+	telegramCacheTime = 30
+	// maxNoteResults keeps the result list short enough to scan on a phone.
+	maxNoteResults = 10
+	// membershipTTL is how long we trust that a user is, or isn't, in a chat with notes.
+	membershipTTL = 5 * time.Minute
+	// maxMembershipLookups caps the membership checks one query can ask Telegram for, so
+	// typing a query can't run into the Bot API's rate limits.
+	maxMembershipLookups = 3
+	// seenTTL is how long the notes of a group stay searchable for a user after they were
+	// last active in it.
+	seenTTL = 30 * 24 * time.Hour
+	// maxSeenChats bounds the groups remembered per user. The least recently active go first.
+	maxSeenChats = 20
+	// debounceDelay is how long a query that needs fresh lookups waits for the user to stop
+	// typing. Telegram sends a query per keystroke, and only the last one is worth answering.
+	debounceDelay = 400 * time.Millisecond
+)
+
+type cachedResults struct {
+	results []interface{}
+	expires time.Time
+}
+
+type cachedMembership struct {
+	member  bool
+	expires time.Time
+}
+
+type seenChat struct {
+	chatID int64
+	at     time.Time
+}
+
+var (
+	resultCache     = make(map[string]cachedResults)    // Keyed by language and normalized query
+	membershipCache = make(map[string]cachedMembership) // Keyed by chat and user ID
+	latestQuery     = make(map[int64]string)            // Newest query ID per user
+	seenChats       = make(map[int64][]seenChat)        // Groups each user was active in, most recent first
+	lastSeenSweep   time.Time                           // When expired seenChats entries were last dropped
+	mu              sync.Mutex
+)
+
+// Seen records that a user was active in a group. Inline queries only search the notes of
+// groups the user was seen in, so they check a few memberships instead of one per group.
+func Seen(chatID, userID int64) {
+	now := time.Now()
+	expired := func(c seenChat) bool { return now.Sub(c.at) > seenTTL }
+
+	mu.Lock()
+	defer mu.Unlock()
+	chats := slices.DeleteFunc(seenChats[userID], func(c seenChat) bool { return c.chatID == chatID || expired(c) })
+	chats = slices.Insert(chats, 0, seenChat{chatID: chatID, at: now})
+	seenChats[userID] = chats[:min(len(chats), maxSeenChats)]
+
+	// Users who are never seen again would otherwise keep their entries forever.
+	if now.Sub(lastSeenSweep) > time.Hour {
+		lastSeenSweep = now
+		for id, chats := range seenChats {
+			if chats = slices.DeleteFunc(chats, expired); len(chats) == 0 {
+				delete(seenChats, id)
+			} else {
+				seenChats[id] = chats
+			}
+		}
+	}
+}
+
+// seenIn returns the groups a user was active in within seenTTL, most recent first.
+func seenIn(userID int64) []int64 {
+	now := time.Now()
+	mu.Lock()
+	defer mu.Unlock()
+	var chats []int64
+	for _, c := range seenChats[userID] {
+		if now.Sub(c.at) <= seenTTL {
+			chats = append(chats, c.chatID)
+		}
+	}
+	return chats
+}
+
+// HandleInlineQuery answers "@bot <query>" from any chat with price cards, gas fees and the
+// notes of the groups the user is active in and still a member of.
+//
+//	@bot            -> gas fees and the BTC and ETH price cards
+//	@bot eth        -> the ETH price card, plus notes whose name starts with "eth"
+//	@bot eth eur    -> the ETH price card in euros
+//	@bot gas        -> gas fees
+//	@bot note faq   -> notes whose name starts with "faq"
+func HandleInlineQuery(bot *tgbotapi.BotAPI, db database.Store, query *tgbotapi.InlineQuery) {
+	l := i18n.For(db, nil, query.From)
+	text := strings.ToLower(strings.TrimSpace(query.Query))
+	key := l.Lang + ":" + text
+
+	// Prices and gas are the same for everyone and cached together; notes depend on who asks,
+	// so they are added afterwards and make the answer personal.
+	results, ok := cached(key)
+	if !ok {
+		if !awaitTyping(query) {
+			return
+		}
+		results = buildResults(l, text)
+		store(key, results)
+	}
+	prefix, withNotes := notePrefix(text)
+	if withNotes {
+		results = appendNotes(slices.Clip(results), bot, db, l, query.From.ID, prefix)
+	}
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     telegramCacheTime,
+		IsPersonal:    withNotes,
+	}
+	if _, err := bot.Request(answer); err != nil {
+		log.Printf("Failed to answer inline query %q: %v", query.Query, err)
+	}
+}
+
+// awaitTyping waits debounceDelay and reports whether query is still the user's newest one.
+// Superseded queries are dropped unanswered: the user's app already moved on to the next one.
+func awaitTyping(query *tgbotapi.InlineQuery) bool {
+	userID := query.From.ID
+	mu.Lock()
+	latestQuery[userID] = query.ID
+	mu.Unlock()
+
+	time.Sleep(debounceDelay)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if latestQuery[userID] != query.ID {
+		return false
+	}
+	delete(latestQuery, userID)
+	return true
+}
+
+// buildResults looks up the prices and gas fees that match a query. Lookups that fail are
+// left out rather than failing the whole answer, so a price API outage doesn't hide notes.
+func buildResults(l i18n.Localizer, text string) []interface{} {
+	var results []interface{}
+
+	switch {
+	case text == "":
+		results = appendGas(results, l)
+		results = appendPrice(results, l, "btc")
+		results = appendPrice(results, l, "eth")
+	case text == "gas":
+		results = appendGas(results, l)
+	case isNoteQuery(text):
+	default:
+		results = appendPrice(results, l, text)
+	}
+	return results
+}
+
+func isNoteQuery(text string) bool {
+	return text == "note" || text == "notes" || strings.HasPrefix(text, "note ")
+}
+
+// notePrefix returns the note name prefix a query searches for, if it searches notes at all.
+func notePrefix(text string) (string, bool) {
+	switch {
+	case text == "" || text == "gas":
+		return "", false
+	case isNoteQuery(text):
+		return strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(text, "notes"), "note")), true
+	default:
+		return text, true
+	}
+}
+
+// appendPrice adds the price card of a coin. If the name is ambiguous or misspelled,
+// the cards of the suggested coins are offered instead.
+func appendPrice(results []interface{}, l i18n.Localizer, coinName string) []interface{} {
+	coinName, currency := web3.SplitCurrency(coinName)
+	if currency == "" {
+		currency = web3.DefaultCurrency
+	}
+
+	res := web3.Coins.Resolve(coinName)
+	if res.ID == "" {
+		for _, c := range res.Suggestions {
+			results = appendCoin(results, l, c.ID, currency)
+		}
+		return results
+	}
+	return appendCoin(results, l, res.ID, currency)
+}
+
+func appendCoin(results []interface{}, l i18n.Localizer, coinID, currency string) []interface{} {
+	coin, err := web3.Prices.Coin(context.Background(), coinID)
+	if err != nil {
+		log.Printf("Inline price lookup for %q failed: %v", coinID, err)
+		return results
+	}
+
+	article := tgbotapi.NewInlineQueryResultArticleMarkdown("price:"+coin.ID+":"+currency, fmt.Sprintf("%s (%s)", coin.Name, strings.ToUpper(coin.Symbol)), web3.PriceCaption(l, coin, currency))
+	quote, used := coin.Quote(currency)
+	article.Description = web3.FormatMoney(used, quote.Price) + " " + strings.ToUpper(used)
+	article.ThumbURL = coin.ImageURL
+	return append(results, article)
+}
+
+func appendGas(results []interface{}, l i18n.Localizer) []interface{} {
+	ctx := context.Background()
+	gas, err := web3.FetchGasPrices(ctx, web3.Chains[0])
+	if err != nil {
+		log.Printf("Inline gas lookup failed: %v", err)
+		return results
+	}
+	native, err := web3.Prices.Coin(ctx, gas.Chain.NativeCoinID)
+	if err != nil {
+		native = nil
+	}
+
+	article := tgbotapi.NewInlineQueryResultArticleMarkdown("gas", l.T("inline.gas_title"), web3.GasText(l, gas, native))
+	article.Description = l.T("inline.gas_description", web3.FormatGwei(gas.Slow), web3.FormatGwei(gas.Standard), web3.FormatGwei(gas.Fast))
+	return append(results, article)
+}
+
+// appendNotes adds the notes whose name starts with prefix from the groups the user was seen
+// in and is still a member of. At most maxMembershipLookups memberships are checked per query;
+// the rest of the groups are searched once their membership is cached by later queries.
+func appendNotes(results []interface{}, bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, userID int64, prefix string) []interface{} {
+	seen := seenIn(userID)
+	if len(seen) == 0 {
+		return results
+	}
+	noteChats, err := db.GetNoteChats(context.Background())
+	if err != nil {
+		log.Printf("Inline note search for %q failed: %v", prefix, err)
+		return results
+	}
+
+	added, lookups := 0, 0
+	for _, chatID := range seen {
+		if added == maxNoteResults {
+			break
+		}
+		if !slices.Contains(noteChats, chatID) {
+			continue
+		}
+		member, known := cachedMember(chatID, userID)
+		if !known {
+			if lookups == maxMembershipLookups {
+				continue
+			}
+			lookups++
+			member = lookupMember(bot, chatID, userID)
+		}
+		if !member {
+			continue
+		}
+		found, err := notes.Search(db, chatID, prefix, maxNoteResults-added)
+		if err != nil {
+			log.Printf("Inline note search for %q in chat %d failed: %v", prefix, chatID, err)
+			continue
+		}
+		for _, n := range found {
+			id := fmt.Sprintf("note:%d:%s", chatID, n.Name)
+			article := tgbotapi.NewInlineQueryResultArticle(id, l.T("inline.note_title", n.Name), n.Text)
+			article.Description = truncate(n.Text, 80)
+			results = append(results, article)
+		}
+		added += len(found)
+	}
+	return results
+}
+
+// cachedMember returns whether a user is in a chat, if Telegram told us within membershipTTL.
+func cachedMember(chatID, userID int64) (member, known bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	entry, ok := membershipCache[fmt.Sprintf("%d:%d", chatID, userID)]
+	if !ok || time.Now().After(entry.expires) {
+		return false, false
+	}
+	return entry.member, true
+}
+
+// lookupMember asks Telegram whether a user is in a chat and caches the answer for membershipTTL.
+// If Telegram can't tell, the user is treated as not a member this time, so notes never leak.
+func lookupMember(bot *tgbotapi.BotAPI, chatID, userID int64) bool {
+	key := fmt.Sprintf("%d:%d", chatID, userID)
+	now := time.Now()
+	chatMember, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		log.Printf("Failed to get member %d of chat %d: %v", userID, chatID, err)
+		return false
+	}
+	member := !chatMember.HasLeft() && !chatMember.WasKicked()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for k, e := range membershipCache {
+		if now.After(e.expires) {
+			delete(membershipCache, k)
+		}
+	}
+	membershipCache[key] = cachedMembership{member: member, expires: now.Add(membershipTTL)}
+	return member
+}
+
+func cached(key string) ([]interface{}, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	entry, ok := resultCache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.results, true
+}
+
+func store(key string, results []interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Drop expired entries while we hold the lock so the cache can't grow without bound.
+	now := time.Now()
+	for k, entry := range resultCache {
+		if now.After(entry.expires) {
+			delete(resultCache, k)
+		}
+	}
+	resultCache[key] = cachedResults{results: results, expires: now.Add(resultTTL)}
+}
+
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
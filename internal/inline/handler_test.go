@@ -0,0 +1,156 @@
+package inline
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+func TestAwaitTypingDropsSupersededQueries(t *testing.T) {
+	user := &tgbotapi.User{ID: 42}
+	first := make(chan bool)
+	go func() { first <- awaitTyping(&tgbotapi.InlineQuery{ID: "1", From: user, Query: "et"}) }()
+	time.Sleep(debounceDelay / 4)
+
+	if !awaitTyping(&tgbotapi.InlineQuery{ID: "2", From: user, Query: "eth"}) {
+		t.Error("the newest query was dropped")
+	}
+	if <-first {
+		t.Error("a superseded query was answered")
+	}
+
+	other := &tgbotapi.User{ID: 43}
+	if !awaitTyping(&tgbotapi.InlineQuery{ID: "3", From: other, Query: "btc"}) {
+		t.Error("a query of another user was dropped")
+	}
+	if len(latestQuery) != 0 {
+		t.Errorf("latestQuery kept %d entries", len(latestQuery))
+	}
+}
+
+func TestNotePrefix(t *testing.T) {
+	tests := []struct {
+		text   string
+		prefix string
+		ok     bool
+	}{
+		{text: "", ok: false},
+		{text: "gas", ok: false},
+		{text: "note", prefix: "", ok: true},
+		{text: "notes", prefix: "", ok: true},
+		{text: "note faq", prefix: "faq", ok: true},
+		{text: "eth", prefix: "eth", ok: true},
+	}
+	for _, tt := range tests {
+		prefix, ok := notePrefix(tt.text)
+		if prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("notePrefix(%q) = %q, %v, want %q, %v", tt.text, prefix, ok, tt.prefix, tt.ok)
+		}
+	}
+}
+
+func TestAppendNotes(t *testing.T) {
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	ctx := context.Background()
+	user := int64(42)
+	t.Cleanup(func() {
+		mu.Lock()
+		clear(seenChats)
+		clear(membershipCache)
+		mu.Unlock()
+	})
+
+	// Eight groups have notes. The user was active in six of them and left -106.
+	for chat := int64(-101); chat >= -108; chat-- {
+		note := &models.Note{ChatID: chat, Name: fmt.Sprintf("faq%d", -chat), Text: "Read the pins", CreatedAt: time.Now()}
+		if err := db.SaveNote(ctx, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for chat := int64(-106); chat <= -101; chat++ {
+		Seen(chat, user)
+	}
+	Seen(-999, user) // A group without notes.
+	server.SetStatus(-106, user, "left")
+
+	titles := func() []string {
+		var out []string
+		for _, r := range appendNotes(nil, bot, db, i18n.New("en"), user, "faq") {
+			out = append(out, r.(tgbotapi.InlineQueryResultArticle).Title)
+		}
+		return out
+	}
+	lookups := func() []string {
+		var chats []string
+		for _, r := range server.Requests("getChatMember") {
+			chats = append(chats, r.Params.Get("chat_id"))
+		}
+		server.Reset()
+		return chats
+	}
+
+	// The most recently active groups are checked first, and only maxMembershipLookups of them.
+	if got, want := titles(), []string{"📝 Note: faq101", "📝 Note: faq102", "📝 Note: faq103"}; !slices.Equal(got, want) {
+		t.Errorf("first query found %v, want %v", got, want)
+	}
+	if got := lookups(); !slices.Equal(got, []string{"-101", "-102", "-103"}) {
+		t.Errorf("first query checked chats %v", got)
+	}
+	// The next query reuses those answers and checks the next groups, finding the user left -106.
+	if got := titles(); len(got) != 5 || slices.Contains(got, "📝 Note: faq106") {
+		t.Errorf("second query found %v", got)
+	}
+	if got := lookups(); !slices.Equal(got, []string{"-104", "-105", "-106"}) {
+		t.Errorf("second query checked chats %v", got)
+	}
+	// Groups the user was never seen in aren't searched at all.
+	if got := titles(); len(got) != 5 {
+		t.Errorf("third query found %v", got)
+	}
+	if got := lookups(); len(got) != 0 {
+		t.Errorf("third query checked chats %v", got)
+	}
+	if got := appendNotes(nil, bot, db, i18n.New("en"), 43, "faq"); len(got) != 0 || len(lookups()) != 0 {
+		t.Errorf("a user never seen anywhere got %d notes", len(got))
+	}
+}
+
+func TestSeenForgetsOldAndExtraChats(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		clear(seenChats)
+		mu.Unlock()
+	})
+	for chat := int64(1); chat <= maxSeenChats+5; chat++ {
+		Seen(-chat, 42)
+	}
+	Seen(-3, 42)
+	chats := seenIn(42)
+	if len(chats) != maxSeenChats || chats[0] != -3 || chats[1] != -(maxSeenChats+5) {
+		t.Errorf("seenIn = %v", chats)
+	}
+
+	mu.Lock()
+	seenChats[43] = []seenChat{{chatID: -1, at: time.Now().Add(-seenTTL - time.Minute)}}
+	lastSeenSweep = time.Time{}
+	mu.Unlock()
+	if chats := seenIn(43); len(chats) != 0 {
+		t.Errorf("seenIn returned expired chats %v", chats)
+	}
+	Seen(-1, 42)
+	mu.Lock()
+	_, kept := seenChats[43]
+	mu.Unlock()
+	if kept {
+		t.Error("the sweep kept a user whose chats all expired")
+	}
+}
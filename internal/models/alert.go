@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PriceAlert notifies a chat once when a coin's price crosses a threshold or moves by a percentage.
+// Alerts created in a group are delivered to the group, alerts created in private chat to the user.
+type PriceAlert struct {
+	ID         int64  `json:"id,omitempty"`
+	ChatID     int64  `json:"chat_id"`
+	CreatedBy  int64  `json:"created_by"`
+	CoinID     string `json:"coin_id"`
+	CoinSymbol string `json:"coin_symbol"`
+	Currency   string `json:"currency"`
+	Condition  string `json:"condition"` // above, below, up, down or move
+	// Target is the price for above and below, or the percentage for up, down and move.
+	Target float64 `json:"target"`
+	// ReferencePrice is the price moves are measured from, and tells which side of a threshold the price was on.
+	ReferencePrice float64    `json:"reference_price"`
+	Active         bool       `json:"active"`
+	SnoozedUntil   *time.Time `json:"snoozed_until"`
+	TriggeredAt    *time.Time `json:"triggered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Filter match types supported by the keyword auto-reply system.
+const (
+	FilterMatchWord     = "word"
+	FilterMatchContains = "contains"
+	FilterMatchRegex    = "regex"
+)
+
+// Filter is a keyword trigger that makes the bot reply with a stored response in a chat.
+type Filter struct {
+	ID              int64     `json:"id,omitempty"`
+	ChatID          int64     `json:"chat_id"`
+	Trigger         string    `json:"trigger"`
+	MatchType       string    `json:"match_type"`
+	Response        string    `json:"response"`
+	CooldownSeconds int       `json:"cooldown_seconds"`
+	CreatedBy       int64     `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// GasAlert notifies a chat once when the standard gas fee of a chain drops to or below a threshold.
+type GasAlert struct {
+	ID        int64  `json:"id,omitempty"`
+	ChatID    int64  `json:"chat_id"`
+	CreatedBy int64  `json:"created_by"`
+	Chain     string `json:"chain"`
+	// Threshold is in Gwei.
+	Threshold float64 `json:"threshold"`
+	// ReferenceGwei is the fee when the alert was (re-)armed or last seen above the threshold.
+	// The alert only fires when the fee drops from above the threshold.
+	ReferenceGwei float64    `json:"reference_gwei"`
+	Active        bool       `json:"active"`
+	TriggeredAt   *time.Time `json:"triggered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
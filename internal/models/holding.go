@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Holding is a purchase in a member's private portfolio. Amounts are decimal strings, so they stay exact.
+type Holding struct {
+	ID         int64  `json:"id,omitempty"`
+	TelegramID int64  `json:"telegram_id"`
+	CoinID     string `json:"coin_id"`
+	Symbol     string `json:"symbol"`
+	// Amount is the number of coins bought.
+	Amount string `json:"amount"`
+	// Price is what one coin cost, in Currency, a lowercase fiat code.
+	Price     string    `json:"price"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// LanguagePreference stores the reply language chosen for a chat or a user.
+// Group chat IDs are negative and never collide with user IDs, and a user's private
+// chat shares their user ID, so one table keyed by Telegram ID holds both.
+type LanguagePreference struct {
+	ID       int64  `json:"id"`
+	Language string `json:"language"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Note is a note a group admin saved, which members of that group can recall by name, also
+// from inline mode. Notes belong to their group: two groups can each have a note "faq".
+type Note struct {
+	ID        int64     `json:"id,omitempty"`
+	Name      string    `json:"name"`
+	Text      string    `json:"text"`
+	ChatID    int64     `json:"chat_id"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
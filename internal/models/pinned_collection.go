@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PinnedCollection is an NFT collection a chat follows, shown in its /nfts summary.
+type PinnedCollection struct {
+	ID        int64     `json:"id,omitempty"`
+	ChatID    int64     `json:"chat_id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	AddedBy   int64     `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Referral attributes a member who opened an invite deep link to the member who shared it.
+type Referral struct {
+	ChatID     int64     `json:"chat_id"`
+	ReferrerID int64     `json:"referrer_id"`
+	ReferredID int64     `json:"referred_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
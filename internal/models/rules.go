@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RulesVersion is one revision of a chat's community rules. Versions start at 1;
+// version 0 stands for the built-in default rules of chats that never set their own.
+type RulesVersion struct {
+	ID        int64     `json:"id,omitempty"`
+	ChatID    int64     `json:"chat_id"`
+	Version   int       `json:"version"`
+	Text      string    `json:"text"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RulesAcceptance records the latest rules version a member accepted in a chat.
+type RulesAcceptance struct {
+	ChatID     int64     `json:"chat_id"`
+	TelegramID int64     `json:"telegram_id"`
+	Version    int       `json:"version"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
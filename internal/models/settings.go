@@ -0,0 +1,8 @@
+package models
+
+// ChatSettings holds per-chat options that admins can change.
+type ChatSettings struct {
+	ChatID                 int64  `json:"chat_id"`
+	RequireRulesAcceptance bool   `json:"require_rules_acceptance"`
+	Currency               string `json:"currency"` // Lowercase fiat code for price cards; "" means USD
+}
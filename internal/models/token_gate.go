@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// TokenGate limits a chat to members whose linked wallets hold enough of a token or NFT collection.
+type TokenGate struct {
+	ChatID   int64  `json:"chat_id"`
+	Chain    string `json:"chain"`
+	Contract string `json:"contract"`
+	Symbol   string `json:"symbol"`
+	// Decimals is 0 for NFT collections, which are counted in whole tokens.
+	Decimals int `json:"decimals"`
+	// MinBalance is the balance required, in the token's smallest unit, as a decimal string
+	// since it can exceed 64 bits.
+	MinBalance string    `json:"min_balance"`
+	CreatedBy  int64     `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TokenGateMember is a member admitted to a gated chat, whose holdings are checked again periodically.
+type TokenGateMember struct {
+	ChatID     int64     `json:"chat_id"`
+	TelegramID int64     `json:"telegram_id"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LinkedWallet is an address a Telegram user proved they control by signing a message with it.
+// The same address is linked separately on every chain it is used on.
+type LinkedWallet struct {
+	ID         int64     `json:"id,omitempty"`
+	TelegramID int64     `json:"telegram_id"`
+	Chain      string    `json:"chain"`   // Chain key, like "ethereum"
+	Address    string    `json:"address"` // Checksummed
+	LinkedAt   time.Time `json:"linked_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// WatchlistCoin is a coin on a chat's watchlist, covered by its market digest.
+type WatchlistCoin struct {
+	ID        int64     `json:"id,omitempty"`
+	ChatID    int64     `json:"chat_id"`
+	CoinID    string    `json:"coin_id"`
+	Symbol    string    `json:"symbol"`
+	AddedBy   int64     `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DigestSchedule is when a chat gets its daily market digest.
+type DigestSchedule struct {
+	ChatID int64 `json:"chat_id"`
+	// Time is the local time of day as "15:04", in Timezone, an IANA name such as "Europe/Paris".
+	Time     string `json:"time"`
+	Timezone string `json:"timezone"`
+	// Chain is the key of the chain whose gas fees the digest shows.
+	Chain string `json:"chain"`
+	// LastSentOn is the local date, as "2006-01-02", of the last digest, so each day gets one.
+	LastSentOn string    `json:"last_sent_on"`
+	CreatedBy  int64     `json:"created_by"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -1,7 +1,6 @@
 package moderation
 
 import (
-	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -9,10 +8,11 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/philip-857.bit/byb-bot/internal/botsetup"
 	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
 )
 
-// isUserAdmin checks if a given user is an administrator or creator of the chat.
-func isUserAdmin(bot *tgbotapi.BotAPI, chatID int64, userID int64) bool {
+// IsUserAdmin checks if a given user is an administrator or creator of the chat.
+func IsUserAdmin(bot *tgbotapi.BotAPI, chatID int64, userID int64) bool {
 	chatMember, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{
 		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
 			ChatID: chatID,
@@ -27,24 +27,25 @@ func isUserAdmin(bot *tgbotapi.BotAPI, chatID int64, userID int64) bool {
 }
 
 // HandleWarnCommand allows an admin to warn a user by replying to their message.
-func HandleWarnCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
-	if !isUserAdmin(bot, message.Chat.ID, message.From.ID) {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "This command is for admins only."))
+func HandleWarnCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
 		return
 	}
 	if message.ReplyToMessage == nil {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Usage: Reply to a user's message with `/warn [optional reason]`."))
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("moderation.warn_usage")))
 		return
 	}
 
 	reason := strings.TrimSpace(message.CommandArguments())
 	if reason == "" {
-		reason = "No reason provided."
+		reason = l.T("moderation.no_reason")
 	}
 	userToWarn := message.ReplyToMessage.From
 	adminName := message.From.FirstName
 
-	warningText := fmt.Sprintf("⚠️ *Warning Issued* ⚠️\n\n*To User*: %s\n*Reason*: %s\n*By Admin*: %s", userToWarn.FirstName, reason, adminName)
+	warningText := l.T("moderation.warning_issued", userToWarn.FirstName, reason, adminName)
 	msg := tgbotapi.NewMessage(message.Chat.ID, warningText)
 	msg.ParseMode = "Markdown"
 	bot.Send(msg)
@@ -52,13 +53,14 @@ func HandleWarnCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbot
 }
 
 // HandleMuteCommand allows an admin to mute a user for a specified duration.
-func HandleMuteCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
-	if !isUserAdmin(bot, message.Chat.ID, message.From.ID) {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "This command is for admins only."))
+func HandleMuteCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
 		return
 	}
 	if message.ReplyToMessage == nil {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Usage: Reply to a user's message with `/mute [duration]` (e.g., 1h, 2d, 30m). Default is 1 hour."))
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("moderation.mute_usage")))
 		return
 	}
 
@@ -84,25 +86,26 @@ func HandleMuteCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbot
 	_, err = bot.Request(restrictConfig)
 	if err != nil {
 		log.Printf("Failed to mute user: %v", err)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "An error occurred while trying to mute the user."))
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("moderation.mute_error")))
 		return
 	}
 
-	muteText := fmt.Sprintf("🔇 %s has been muted for %s.", userToMute.FirstName, duration.String())
+	muteText := l.T("moderation.muted", userToMute.FirstName, duration.String())
 	bot.Send(tgbotapi.NewMessage(message.Chat.ID, muteText))
 	log.Printf("Admin %s muted user %s for %s", message.From.FirstName, userToMute.FirstName, duration.String())
 }
-func HandleSetupCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
-	if !isUserAdmin(bot, message.Chat.ID, message.From.ID) {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "This command is for admins only."))
+func HandleSetupCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
 		return
 	}
 
 	if message.Chat.IsPrivate() {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "This command can only be used in a group chat."))
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
 		return
 	}
 
 	botsetup.SetGroupCommands(bot, message.Chat.ID)
-	bot.Send(tgbotapi.NewMessage(message.Chat.ID, "✅ Bot commands have been updated for this group's members and admins."))
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("moderation.setup_done")))
 }
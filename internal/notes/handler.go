@@ -0,0 +1,172 @@
+package notes
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+)
+
+// validName restricts note names to what is easy to type after /note and in inline queries.
+var validName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Search returns up to limit notes of a chat whose name starts with prefix, sorted by name.
+func Search(db database.Store, chatID int64, prefix string, limit int) ([]models.Note, error) {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	found, err := db.SearchNotes(context.Background(), chatID, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// LIKE treats '_' as a wildcard, so double check the prefix.
+	notes := found[:0]
+	for _, n := range found {
+		if strings.HasPrefix(n.Name, prefix) {
+			notes = append(notes, n)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Name < notes[j].Name })
+	return notes, nil
+}
+
+// HandleSaveCommand lets a group admin save a note: /save <name> <text>, or reply to a message with /save <name>.
+func HandleSaveCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+	if !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	args := strings.TrimSpace(message.CommandArguments())
+	name, text := args, ""
+	if i := strings.IndexAny(args, " \t\n"); i >= 0 {
+		name, text = args[:i], strings.TrimSpace(args[i:])
+	}
+	name = strings.ToLower(name)
+	if text == "" && message.ReplyToMessage != nil {
+		text = message.ReplyToMessage.Text
+	}
+	if !validName.MatchString(name) || text == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.save_usage")))
+		return
+	}
+
+	note := &models.Note{
+		Name:      name,
+		Text:      text,
+		ChatID:    message.Chat.ID,
+		CreatedBy: message.From.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := db.SaveNote(context.Background(), note); err != nil {
+		log.Printf("Failed to save note: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.save_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.saved", name)))
+}
+
+// HandleNoteCommand shows a note of the group by name.
+func HandleNoteCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if name == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.note_usage")))
+		return
+	}
+
+	note, err := db.GetNote(context.Background(), message.Chat.ID, name)
+	if err != nil {
+		log.Printf("Failed to load note %q of chat %d: %v", name, message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.load_error")))
+		return
+	}
+	if note == nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.not_found", name)))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, note.Text)
+	if message.ReplyToMessage != nil {
+		// Answer the question that was replied to, like "read this: /note gas".
+		msg.ReplyToMessageID = message.ReplyToMessage.MessageID
+	}
+	bot.Send(msg)
+}
+
+// HandleNotesCommand lists the notes saved in the group.
+func HandleNotesCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+
+	notes, err := Search(db, message.Chat.ID, "", 100)
+	if err != nil {
+		log.Printf("Failed to list notes of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.load_error")))
+		return
+	}
+	if len(notes) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.none")))
+		return
+	}
+
+	names := make([]string, len(notes))
+	for i, n := range notes {
+		names[i] = n.Name
+	}
+	text := l.N("notes.list_header", len(notes), len(notes)) + "\n\n" + strings.Join(names, ", ") + "\n\n" + l.T("notes.note_usage")
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// HandleDelNoteCommand lets a group admin delete a note.
+func HandleDelNoteCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+	if !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if name == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.delete_usage")))
+		return
+	}
+
+	note, err := db.GetNote(context.Background(), message.Chat.ID, name)
+	if err == nil && note != nil {
+		err = db.RemoveNote(context.Background(), message.Chat.ID, name)
+	}
+	if err != nil {
+		log.Printf("Failed to delete note %q of chat %d: %v", name, message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.save_error")))
+		return
+	}
+	if note == nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.not_found", name)))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("notes.deleted", name)))
+}
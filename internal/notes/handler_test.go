@@ -0,0 +1,58 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+func TestNoteCommands(t *testing.T) {
+	_, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	group, other := tgfake.Group(-100), tgfake.Group(-200)
+	admin := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	member := &tgbotapi.User{ID: 43, FirstName: "Bob"}
+	server.SetStatus(group.ID, admin.ID, "administrator")
+	server.SetStatus(other.ID, admin.ID, "creator")
+
+	if got := server.Answer(t, HandleSaveCommand, db, tgfake.Command(group, member, "/save faq Read the pins")); !strings.Contains(got, "admins only") {
+		t.Errorf("a member saving a note got %q", got)
+	}
+	if got := server.Answer(t, HandleSaveCommand, db, tgfake.Command(group, admin, "/save FAQ Read the pins")); !strings.Contains(got, `Saved note "faq"`) {
+		t.Errorf("saving a note answered %q", got)
+	}
+	server.Answer(t, HandleSaveCommand, db, tgfake.Command(other, admin, "/save faq Ask in #help"))
+
+	// Each group sees its own note under the same name.
+	if got := server.Answer(t, HandleNoteCommand, db, tgfake.Command(group, member, "/note faq")); got != "Read the pins" {
+		t.Errorf("/note faq = %q", got)
+	}
+	if got := server.Answer(t, HandleNoteCommand, db, tgfake.Command(other, member, "/note FAQ")); got != "Ask in #help" {
+		t.Errorf("/note faq in the other group = %q", got)
+	}
+	if got := server.Answer(t, HandleNoteCommand, db, tgfake.Command(group, member, "/note rules")); !strings.Contains(got, `no note called "rules"`) {
+		t.Errorf("/note of a missing note answered %q", got)
+	}
+	if got := server.Answer(t, HandleNoteCommand, db, tgfake.Command(tgfake.Private(member), member, "/note faq")); !strings.Contains(got, "group chat") {
+		t.Errorf("/note in private answered %q", got)
+	}
+
+	server.Answer(t, HandleSaveCommand, db, tgfake.Command(group, admin, "/save gas Check /gas"))
+	if got := server.Answer(t, HandleNotesCommand, db, tgfake.Command(group, member, "/notes")); !strings.Contains(got, "faq, gas") {
+		t.Errorf("/notes answered %q", got)
+	}
+
+	if got := server.Answer(t, HandleDelNoteCommand, db, tgfake.Command(group, member, "/delnote faq")); !strings.Contains(got, "admins only") {
+		t.Errorf("a member deleting a note got %q", got)
+	}
+	server.Answer(t, HandleDelNoteCommand, db, tgfake.Command(group, admin, "/delnote faq"))
+	if got := server.Answer(t, HandleNoteCommand, db, tgfake.Command(group, member, "/note faq")); !strings.Contains(got, "no note called") {
+		t.Errorf("/note of a deleted note answered %q", got)
+	}
+	if got := server.Answer(t, HandleNoteCommand, db, tgfake.Command(other, member, "/note faq")); got != "Ask in #help" {
+		t.Errorf("deleting a note removed the other group's: %q", got)
+	}
+}
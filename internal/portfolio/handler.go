@@ -0,0 +1,277 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+const (
+	// maxHoldings keeps a portfolio within one message.
+	maxHoldings = 50
+	// storedDecimals is how many decimals amounts and prices are stored with, enough for a single wei.
+	storedDecimals = 18
+)
+
+// HandlePortfolioCommand manages the user's private portfolio:
+//
+//	/portfolio                                  show its value and P&L
+//	/portfolio add 1.2 eth @ 2500 [currency]    record a purchase, at today's price without "@"
+//	/portfolio edit <id> 1.5 [@ 2400]           change the amount or price of a purchase
+//	/portfolio remove <id>                      delete a purchase
+//
+// Holdings are nobody else's business, so the command only works in private chat.
+func HandlePortfolioCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	args := strings.TrimSpace(message.CommandArguments())
+	if !message.Chat.IsPrivate() {
+		if args != "" {
+			// The holdings are already out, but they don't have to stay in the group's history.
+			bot.Request(tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID))
+		}
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.private_only")))
+		return
+	}
+
+	action, rest, _ := strings.Cut(args, " ")
+	rest = strings.TrimSpace(rest)
+	switch strings.ToLower(action) {
+	case "":
+		showPortfolio(bot, db, l, message)
+	case "add":
+		addHolding(bot, db, l, message, rest)
+	case "edit":
+		editHolding(bot, db, l, message, rest)
+	case "remove":
+		removeHolding(bot, db, l, message, rest)
+	default:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.usage")))
+	}
+}
+
+func showPortfolio(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message) {
+	ctx := context.Background()
+	holdings, err := db.GetHoldings(ctx, message.From.ID)
+	if err != nil {
+		log.Printf("Failed to load portfolio of user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.load_error")))
+		return
+	}
+	if len(holdings) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.empty")+"\n\n"+l.T("portfolio.usage")))
+		return
+	}
+
+	bot.Request(tgbotapi.NewChatAction(message.Chat.ID, tgbotapi.ChatTyping))
+	coins := make(map[string]*web3.Coin)
+	for _, holding := range holdings {
+		if _, ok := coins[holding.CoinID]; ok {
+			continue
+		}
+		coin, err := web3.Prices.Coin(ctx, holding.CoinID)
+		if err != nil {
+			log.Printf("Failed to fetch price of %q for portfolio: %v", holding.CoinID, err)
+		}
+		coins[holding.CoinID] = coin
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, PortfolioText(l, Value(holdings, coins)))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+func addHolding(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, args string) {
+	purchase, at, _ := strings.Cut(args, "@")
+	fields := strings.Fields(purchase)
+	if len(fields) != 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.usage")))
+		return
+	}
+	amount, ok := web3.ParseAmount(fields[0])
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.usage")))
+		return
+	}
+	price, currency, ok := parsePrice(at)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.usage")))
+		return
+	}
+	if currency == "" {
+		currency = web3.ChatCurrency(db, message.Chat.ID)
+	}
+
+	id := web3.Coins.Resolve(fields[1]).ID
+	if id == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.unknown_coin", fields[1])))
+		return
+	}
+	ctx := context.Background()
+	symbol := strings.ToUpper(fields[1])
+	coin, err := web3.Prices.Coin(ctx, id)
+	if err == nil && coin.Symbol != "" {
+		symbol = strings.ToUpper(coin.Symbol)
+	}
+	if price == nil {
+		// Without "@", the purchase is recorded at today's price.
+		if err != nil {
+			log.Printf("Failed to fetch price of %q for portfolio: %v", id, err)
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.price_needed", symbol)))
+			return
+		}
+		var quote web3.Quote
+		quote, currency = coin.Quote(currency)
+		// The shortest decimal that round-trips is the price the provider meant, not its binary expansion.
+		price, _ = new(big.Rat).SetString(strconv.FormatFloat(quote.Price, 'f', -1, 64))
+	}
+
+	holdings, err := db.GetHoldings(ctx, message.From.ID)
+	if err != nil {
+		log.Printf("Failed to load portfolio of user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.load_error")))
+		return
+	}
+	if len(holdings) >= maxHoldings {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.limit", maxHoldings)))
+		return
+	}
+
+	now := time.Now()
+	holding := &models.Holding{
+		TelegramID: message.From.ID,
+		CoinID:     id,
+		Symbol:     symbol,
+		Amount:     decimalString(amount),
+		Price:      decimalString(price),
+		Currency:   currency,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := db.AddHolding(ctx, holding); err != nil {
+		log.Printf("Failed to save holding of user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.save_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.added", describe(holding))))
+}
+
+func editHolding(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, args string) {
+	purchase, at, _ := strings.Cut(args, "@")
+	fields := strings.Fields(purchase)
+	if len(fields) != 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.usage")))
+		return
+	}
+	id, idOK := parseID(fields[0])
+	amount, amountOK := web3.ParseAmount(fields[1])
+	price, currency, priceOK := parsePrice(at)
+	if !idOK || !amountOK || !priceOK {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.usage")))
+		return
+	}
+
+	ctx := context.Background()
+	holding, ok := findHolding(bot, db, l, message, id)
+	if !ok {
+		return
+	}
+	holding.Amount = decimalString(amount)
+	if price != nil {
+		holding.Price = decimalString(price)
+		if currency != "" {
+			holding.Currency = currency
+		}
+	}
+	holding.UpdatedAt = time.Now()
+	if err := db.UpdateHolding(ctx, holding); err != nil {
+		log.Printf("Failed to update holding %d: %v", id, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.save_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.updated", describe(holding))))
+}
+
+func removeHolding(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, args string) {
+	id, ok := parseID(args)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.usage")))
+		return
+	}
+	removed, err := db.RemoveHolding(context.Background(), message.From.ID, id)
+	switch {
+	case err != nil:
+		log.Printf("Failed to remove holding %d: %v", id, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.save_error")))
+	case !removed:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.not_found", id)))
+	default:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.removed", id)))
+	}
+}
+
+// findHolding loads one of the user's purchases, answering the user if it can't.
+func findHolding(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, id int64) (*models.Holding, bool) {
+	holdings, err := db.GetHoldings(context.Background(), message.From.ID)
+	if err != nil {
+		log.Printf("Failed to load portfolio of user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.load_error")))
+		return nil, false
+	}
+	for i := range holdings {
+		if holdings[i].ID == id {
+			return &holdings[i], true
+		}
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("portfolio.not_found", id)))
+	return nil, false
+}
+
+// parsePrice reads what follows "@": a price and an optional currency. An empty input means
+// no price was given, which is fine; the price is nil then.
+func parsePrice(input string) (*big.Rat, string, bool) {
+	fields := strings.Fields(strings.ToLower(input))
+	if len(fields) == 0 {
+		return nil, "", true
+	}
+	if len(fields) > 2 || (len(fields) == 2 && !web3.IsCurrency(fields[1])) {
+		return nil, "", false
+	}
+	price, ok := web3.ParseAmount(fields[0])
+	if !ok {
+		return nil, "", false
+	}
+	currency := ""
+	if len(fields) == 2 {
+		currency = fields[1]
+	}
+	return price, currency, true
+}
+
+// parseID reads a holding ID, with or without its "#".
+func parseID(input string) (int64, bool) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(input), "#"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// decimalString formats an amount for storage: plain digits, without trailing zeros. Anything
+// beyond storedDecimals is rounded away.
+func decimalString(r *big.Rat) string {
+	number := r.FloatString(storedDecimals)
+	return strings.TrimRight(strings.TrimRight(number, "0"), ".")
+}
+
+// describe formats a purchase as "#3: 1.2 ETH @ $2,500.00".
+func describe(holding *models.Holding) string {
+	amount, price := parseStored(holding.Amount), parseStored(holding.Price)
+	return fmt.Sprintf("#%d: %s %s @ %s", holding.ID, web3.FormatDecimal(amount), holding.Symbol, formatMoney(holding.Currency, price))
+}
@@ -0,0 +1,67 @@
+package portfolio
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+	"github.com/philip-857.bit/byb-bot/internal/web3/pricefake"
+)
+
+func TestPortfolioCommand(t *testing.T) {
+	pricefake.Serve(t, pricefake.Ether)
+	_, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	user := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	chat := tgfake.Private(user)
+
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio")); !strings.Contains(got, "portfolio is empty") {
+		t.Errorf("empty /portfolio answered %q", got)
+	}
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio add 1.5 eth @ 1500")); got != "✅ Added #1: 1.5 ETH @ $1,500.00" {
+		t.Errorf("adding at a price answered %q", got)
+	}
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio add 0.5 eth")); got != "✅ Added #2: 0.5 ETH @ $2,000.00" {
+		t.Errorf("adding at today's price answered %q", got)
+	}
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio add 1 notacoin @ 3")); !strings.Contains(got, "couldn't find the coin") {
+		t.Errorf("adding an unknown coin answered %q", got)
+	}
+
+	// 2 ETH worth $4,000, bought for $3,250.
+	got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio"))
+	for _, want := range []string{"$4,000.00", "$3,250.00", "+$750.00"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("/portfolio lacks %q:\n%s", want, got)
+		}
+	}
+
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio edit 1 2 @ 1000")); got != "✅ Updated #1: 2 ETH @ $1,000.00" {
+		t.Errorf("editing answered %q", got)
+	}
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio remove #2")); !strings.Contains(got, "Removed #2") {
+		t.Errorf("removing answered %q", got)
+	}
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(chat, user, "/portfolio remove 2")); !strings.Contains(got, "no purchase #2") {
+		t.Errorf("removing twice answered %q", got)
+	}
+	holdings, err := db.GetHoldings(context.Background(), user.ID)
+	if err != nil || len(holdings) != 1 || holdings[0].Amount != "2" || holdings[0].Price != "1000" {
+		t.Errorf("stored holdings = %+v, %v", holdings, err)
+	}
+
+	// In a group the command is refused, and a message giving holdings away is deleted.
+	group := tgfake.Group(-100)
+	if got := server.Answer(t, HandlePortfolioCommand, db, tgfake.Command(group, user, "/portfolio add 1 eth @ 1")); !strings.Contains(got, "private chat") {
+		t.Errorf("/portfolio in a group answered %q", got)
+	}
+	if len(server.Requests("deleteMessage")) != 1 {
+		t.Error("the group message with holdings wasn't deleted")
+	}
+	if holdings, _ := db.GetHoldings(context.Background(), user.ID); len(holdings) != 1 {
+		t.Errorf("a group message changed the portfolio to %d holdings", len(holdings))
+	}
+}
@@ -0,0 +1,160 @@
+package portfolio
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+// Position is what a user holds of one coin, bought in one currency. All amounts are exact.
+type Position struct {
+	CoinID   string
+	Symbol   string
+	Currency string
+	Amount   *big.Rat
+	// Cost is what the coins cost in total.
+	Cost *big.Rat
+	// Value is what the coins are worth now, or nil if the coin has no price in Currency.
+	Value    *big.Rat
+	Holdings []models.Holding
+}
+
+// Total sums the positions of a portfolio bought in one currency. Positions without a price
+// are left out of both sums, so the P&L compares like with like.
+type Total struct {
+	Currency string
+	Cost     *big.Rat
+	Value    *big.Rat
+	// Unpriced counts the positions left out.
+	Unpriced int
+}
+
+// Valuation is a portfolio valued at current prices.
+type Valuation struct {
+	Positions []Position
+	Totals    []Total
+}
+
+// Value groups holdings into positions, in the order their coins were first bought, and
+// values them with the given coins. A nil coin means its price couldn't be fetched.
+func Value(holdings []models.Holding, coins map[string]*web3.Coin) Valuation {
+	var v Valuation
+	index := make(map[string]int)
+	for _, holding := range holdings {
+		key := holding.CoinID + " " + holding.Currency
+		i, ok := index[key]
+		if !ok {
+			i = len(v.Positions)
+			index[key] = i
+			v.Positions = append(v.Positions, Position{
+				CoinID:   holding.CoinID,
+				Symbol:   holding.Symbol,
+				Currency: holding.Currency,
+				Amount:   new(big.Rat),
+				Cost:     new(big.Rat),
+			})
+		}
+		p := &v.Positions[i]
+		amount := parseStored(holding.Amount)
+		p.Amount.Add(p.Amount, amount)
+		p.Cost.Add(p.Cost, new(big.Rat).Mul(amount, parseStored(holding.Price)))
+		p.Holdings = append(p.Holdings, holding)
+	}
+
+	totals := make(map[string]*Total)
+	for i := range v.Positions {
+		p := &v.Positions[i]
+		total, ok := totals[p.Currency]
+		if !ok {
+			total = &Total{Currency: p.Currency, Cost: new(big.Rat), Value: new(big.Rat)}
+			totals[p.Currency] = total
+		}
+
+		// A coin quoted in another currency than it was bought in can't be compared to its cost.
+		if coin := coins[p.CoinID]; coin != nil {
+			if quote, currency := coin.Quote(p.Currency); currency == p.Currency {
+				p.Value = new(big.Rat).Mul(p.Amount, new(big.Rat).SetFloat64(quote.Price))
+			}
+		}
+		if p.Value == nil {
+			total.Unpriced++
+			continue
+		}
+		total.Cost.Add(total.Cost, p.Cost)
+		total.Value.Add(total.Value, p.Value)
+	}
+	for _, total := range totals {
+		v.Totals = append(v.Totals, *total)
+	}
+	sort.Slice(v.Totals, func(i, j int) bool { return v.Totals[i].Currency < v.Totals[j].Currency })
+	return v
+}
+
+// PortfolioText formats the Markdown summary of a valued portfolio: each position with its
+// P&L and the purchases it is made of, then the totals.
+func PortfolioText(l i18n.Localizer, v Valuation) string {
+	lines := []string{l.T("portfolio.title")}
+	for _, p := range v.Positions {
+		symbol := tgbotapi.EscapeText(tgbotapi.ModeMarkdown, p.Symbol)
+		lines = append(lines, "")
+		if p.Value == nil {
+			lines = append(lines, l.T("portfolio.position_unpriced", symbol, web3.FormatDecimal(p.Amount)))
+			lines = append(lines, l.T("portfolio.cost", formatMoney(p.Currency, p.Cost)))
+		} else {
+			lines = append(lines, l.T("portfolio.position", symbol, web3.FormatDecimal(p.Amount), formatMoney(p.Currency, p.Value)))
+			lines = append(lines, l.T("portfolio.pnl", formatMoney(p.Currency, p.Cost), formatPnL(p.Currency, p.Cost, p.Value)))
+		}
+
+		purchases := make([]string, len(p.Holdings))
+		for i, holding := range p.Holdings {
+			purchases[i] = l.T("portfolio.purchase", holding.ID, web3.FormatDecimal(parseStored(holding.Amount)), formatMoney(p.Currency, parseStored(holding.Price)))
+		}
+		lines = append(lines, strings.Join(purchases, " · "))
+	}
+
+	lines = append(lines, "")
+	for _, total := range v.Totals {
+		if total.Value.Sign() > 0 || total.Cost.Sign() > 0 {
+			lines = append(lines, l.T("portfolio.total", formatMoney(total.Currency, total.Value), formatMoney(total.Currency, total.Cost), formatPnL(total.Currency, total.Cost, total.Value)))
+		}
+		if total.Unpriced > 0 {
+			lines = append(lines, l.N("portfolio.unpriced", total.Unpriced, total.Unpriced, strings.ToUpper(total.Currency)))
+		}
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// formatPnL formats the profit or loss of a cost turned into a value, with its percentage.
+func formatPnL(currency string, cost, value *big.Rat) string {
+	pnl := new(big.Rat).Sub(value, cost)
+	text := formatMoney(currency, pnl)
+	if pnl.Sign() > 0 {
+		text = "+" + text
+	}
+	if cost.Sign() > 0 {
+		percent, _ := new(big.Rat).Quo(new(big.Rat).Mul(pnl, big.NewRat(100, 1)), cost).Float64()
+		text += " " + web3.FormatChange(percent)
+	}
+	return text
+}
+
+// formatMoney formats an exact amount of money. Only the display is rounded.
+func formatMoney(currency string, amount *big.Rat) string {
+	f, _ := amount.Float64()
+	return web3.FormatMoney(currency, f)
+}
+
+// parseStored parses an amount saved by decimalString. Rows are only written by this package,
+// so a bad one can only come from editing the table by hand, and counts as 0.
+func parseStored(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
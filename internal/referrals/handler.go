@@ -0,0 +1,105 @@
+package referrals
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/deeplink"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// inviteLinkLifetime is how long the single-use group invite handed to a referred user stays valid.
+const inviteLinkLifetime = 24 * time.Hour
+
+// HandleInviteCommand gives a member their personal referral link for the group.
+func HandleInviteCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+
+	link, err := deeplink.URL(bot, deeplink.ActionReferral, message.Chat.ID, message.From.ID)
+	if err != nil {
+		log.Printf("Failed to build referral link: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("referrals.error")))
+		return
+	}
+
+	referrals, err := db.GetReferralsBy(context.Background(), message.Chat.ID, message.From.ID)
+	if err != nil {
+		log.Printf("Failed to load referrals of user %d: %v", message.From.ID, err)
+	}
+
+	text := l.T("referrals.link", message.From.FirstName, link) + "\n" + l.N("referrals.count", len(referrals), len(referrals))
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
+
+// HandleReferralDeepLink records who invited a user and hands them a single-use invite to the group.
+func HandleReferralDeepLink(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message, args []int64) {
+	l := i18n.ForMessage(db, message)
+	if len(args) != 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("deeplink.invalid")))
+		return
+	}
+	chatID, referrerID := args[0], args[1]
+
+	// Only the first referral counts, and members can't refer themselves.
+	if referrerID != message.From.ID {
+		existing, err := db.GetReferral(context.Background(), chatID, message.From.ID)
+		if err != nil {
+			log.Printf("Failed to check referral of user %d: %v", message.From.ID, err)
+		} else if existing == nil {
+			referral := &models.Referral{
+				ChatID:     chatID,
+				ReferrerID: referrerID,
+				ReferredID: message.From.ID,
+				CreatedAt:  time.Now(),
+			}
+			if err := db.AddReferral(context.Background(), referral); err != nil {
+				log.Printf("Failed to record referral: %v", err)
+			}
+		}
+	}
+
+	chat, err := bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+	if err != nil {
+		log.Printf("Failed to get chat %d for referral: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("referrals.error")))
+		return
+	}
+
+	invite, err := createInviteLink(bot, chatID)
+	if err != nil {
+		// The bot needs the "invite users" admin right for this; without it we can still greet the user.
+		log.Printf("Failed to create invite link for chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("referrals.welcome_no_link", chat.Title)))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("referrals.welcome", chat.Title, invite)))
+}
+
+// createInviteLink creates a single-use invite link to a chat that expires after a day.
+func createInviteLink(bot *tgbotapi.BotAPI, chatID int64) (string, error) {
+	resp, err := bot.Request(tgbotapi.CreateChatInviteLinkConfig{
+		ChatConfig:  tgbotapi.ChatConfig{ChatID: chatID},
+		ExpireDate:  int(time.Now().Add(inviteLinkLifetime).Unix()),
+		MemberLimit: 1,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var link tgbotapi.ChatInviteLink
+	if err := json.Unmarshal(resp.Result, &link); err != nil {
+		return "", err
+	}
+	return link.InviteLink, nil
+}
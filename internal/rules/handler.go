@@ -0,0 +1,299 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+)
+
+// maxRulesLength leaves room for the header and captcha text within Telegram's 4096 character limit.
+const maxRulesLength = 3500
+
+// acceptPrefix is the callback data prefix of the "I accept" buttons.
+const acceptPrefix = "rules_accept_"
+
+// Current returns the latest rules of a chat. Chats that never set their own rules get
+// version 0 with an empty text, which stands for the built-in default rules.
+func Current(db database.Store, chatID int64) (*models.RulesVersion, error) {
+	rules, err := db.GetLatestRules(context.Background(), chatID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		return &models.RulesVersion{ChatID: chatID}, nil
+	}
+	return rules, nil
+}
+
+// PlainText returns the rules as plain text, for places where Markdown can't be used.
+func PlainText(l i18n.Localizer, rules *models.RulesVersion) string {
+	if rules.Version == 0 {
+		return strings.ReplaceAll(l.T("rules.text"), "*", "")
+	}
+	return rules.Text
+}
+
+// Accept records that a member accepted a rules version.
+func Accept(db database.Store, chatID, userID int64, version int) error {
+	return db.RecordRulesAcceptance(context.Background(), &models.RulesAcceptance{
+		ChatID:     chatID,
+		TelegramID: userID,
+		Version:    version,
+		AcceptedAt: time.Now(),
+	})
+}
+
+// HandleRulesCommand shows the chat's current rules.
+func HandleRulesCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	rules, err := Current(db, message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load rules for chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.load_error")))
+		return
+	}
+
+	if rules.Version == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, l.T("rules.text"))
+		msg.ParseMode = "Markdown"
+		bot.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, formatRules(l, rules))
+	if !message.Chat.IsPrivate() {
+		msg.ReplyMarkup = acceptKeyboard(l, rules.Version)
+	}
+	bot.Send(msg)
+}
+
+// HandleSetRulesCommand lets an admin publish a new version of the chat's rules.
+// The text comes from the command arguments or from the message being replied to.
+func HandleSetRulesCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+
+	text := strings.TrimSpace(message.CommandArguments())
+	if text == "" && message.ReplyToMessage != nil {
+		text = strings.TrimSpace(message.ReplyToMessage.Text)
+	}
+	if text == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.set_usage")))
+		return
+	}
+	if utf8.RuneCountInString(text) > maxRulesLength {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.too_long", maxRulesLength)))
+		return
+	}
+
+	// The store picks the version, so admins saving at the same time can't both get the same one.
+	rules := &models.RulesVersion{
+		ChatID:    message.Chat.ID,
+		Text:      text,
+		CreatedBy: message.From.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := db.AddRulesVersion(context.Background(), rules); err != nil {
+		log.Printf("Failed to save rules for chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.save_error")))
+		return
+	}
+
+	announcement := l.T("rules.changed", rules.Version) + "\n\n" + rules.Text
+	msg := tgbotapi.NewMessage(message.Chat.ID, announcement)
+	msg.ReplyMarkup = acceptKeyboard(l, rules.Version)
+	bot.Send(msg)
+}
+
+// HandleRulesHistoryCommand lists the rules versions of the chat, or shows one with /rulehistory <version>.
+func HandleRulesHistoryCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	history, err := db.GetRulesHistory(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load rules history for chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.load_error")))
+		return
+	}
+	if len(history) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.no_history")))
+		return
+	}
+
+	if arg := strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "v"); arg != "" {
+		version, err := strconv.Atoi(arg)
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.history_usage")))
+			return
+		}
+		for i := range history {
+			if history[i].Version == version {
+				bot.Send(tgbotapi.NewMessage(message.Chat.ID, formatRules(l, &history[i])))
+				return
+			}
+		}
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.version_not_found", version)))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(l.N("rules.history_header", len(history), len(history)))
+	sb.WriteString("\n")
+	for _, r := range history {
+		sb.WriteString("\n")
+		sb.WriteString(l.T("rules.history_item", r.Version, r.CreatedAt.Format("2006-01-02 15:04"), r.CreatedBy))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(l.T("rules.history_usage"))
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, sb.String()))
+}
+
+// HandleRequireRulesCommand lets an admin require new members to accept the rules as part of verification.
+func HandleRequireRulesCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+
+	var require bool
+	switch strings.ToLower(strings.TrimSpace(message.CommandArguments())) {
+	case "on":
+		require = true
+	case "off":
+		require = false
+	default:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.require_usage")))
+		return
+	}
+
+	settings, err := db.GetChatSettings(context.Background(), message.Chat.ID)
+	if err == nil {
+		settings.RequireRulesAcceptance = require
+		err = db.SaveChatSettings(context.Background(), settings)
+	}
+	if err != nil {
+		log.Printf("Failed to update rules requirement for chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.save_error")))
+		return
+	}
+
+	if require {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.require_on")))
+	} else {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.require_off")))
+	}
+}
+
+// IsCallback reports whether callback data belongs to this package.
+func IsCallback(data string) bool {
+	return strings.HasPrefix(data, acceptPrefix)
+}
+
+// HandleCallbackQuery records a member's click on an "I accept" button.
+// Buttons shown in private chat carry the group's chat ID after the version.
+func HandleCallbackQuery(bot *tgbotapi.BotAPI, db database.Store, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(query.Data, acceptPrefix), "_")
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return
+	}
+	chatID := query.Message.Chat.ID
+	if len(parts) == 2 {
+		if chatID, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return
+		}
+	}
+	l := i18n.For(db, query.Message.Chat, query.From)
+
+	current, err := Current(db, chatID)
+	if err != nil {
+		log.Printf("Failed to load rules for chat %d: %v", chatID, err)
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("rules.save_error")))
+		return
+	}
+	if version != current.Version {
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("rules.outdated", current.Version)))
+		return
+	}
+
+	if err := Accept(db, chatID, query.From.ID, version); err != nil {
+		log.Printf("Failed to record rules acceptance: %v", err)
+		bot.Request(tgbotapi.NewCallback(query.ID, l.T("rules.save_error")))
+		return
+	}
+	bot.Request(tgbotapi.NewCallback(query.ID, l.T("rules.accepted", version)))
+}
+
+// HandleRulesDeepLink shows a group's rules in private chat.
+func HandleRulesDeepLink(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message, args []int64) {
+	l := i18n.ForMessage(db, message)
+	if len(args) != 1 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("deeplink.invalid")))
+		return
+	}
+	chatID := args[0]
+
+	rules, err := Current(db, chatID)
+	if err != nil {
+		log.Printf("Failed to load rules for chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("rules.load_error")))
+		return
+	}
+
+	title := ""
+	if chat, err := bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}}); err == nil {
+		title = chat.Title
+	}
+
+	text := PlainText(l, rules)
+	if rules.Version > 0 {
+		text = formatRules(l, rules)
+	}
+	if title != "" {
+		text = l.T("rules.private_header", title) + "\n\n" + text
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	if rules.Version > 0 {
+		button := tgbotapi.NewInlineKeyboardButtonData(l.T("rules.accept_button"), fmt.Sprintf("%s%d_%d", acceptPrefix, rules.Version, chatID))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button))
+	}
+	bot.Send(msg)
+}
+
+// formatRules renders a custom rules version with its version header.
+func formatRules(l i18n.Localizer, rules *models.RulesVersion) string {
+	header := l.T("rules.header", rules.Version, rules.CreatedAt.Format("2006-01-02"))
+	return fmt.Sprintf("%s\n\n%s", header, rules.Text)
+}
+
+func acceptKeyboard(l i18n.Localizer, version int) tgbotapi.InlineKeyboardMarkup {
+	button := tgbotapi.NewInlineKeyboardButtonData(l.T("rules.accept_button"), fmt.Sprintf("%s%d", acceptPrefix, version))
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button))
+}
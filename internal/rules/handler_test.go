@@ -0,0 +1,144 @@
+package rules
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+func TestRulesCommands(t *testing.T) {
+	_, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	group := tgfake.Group(-100)
+	admin := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	member := &tgbotapi.User{ID: 43, FirstName: "Bob"}
+	server.SetStatus(group.ID, admin.ID, "administrator")
+
+	if got := server.Answer(t, HandleRulesCommand, db, tgfake.Command(group, member, "/rules")); !strings.Contains(got, "COMMUNITY RULES") {
+		t.Errorf("/rules without custom rules answered %q", got)
+	}
+	if got := server.Answer(t, HandleRulesHistoryCommand, db, tgfake.Command(group, member, "/rulehistory")); !strings.Contains(got, "default rules") {
+		t.Errorf("/rulehistory without custom rules answered %q", got)
+	}
+	if got := server.Answer(t, HandleSetRulesCommand, db, tgfake.Command(group, member, "/setrules No rules")); !strings.Contains(got, "admins only") {
+		t.Errorf("a member setting rules got %q", got)
+	}
+	if got := server.Answer(t, HandleSetRulesCommand, db, tgfake.Command(group, admin, "/setrules")); !strings.Contains(got, "Usage") {
+		t.Errorf("/setrules without text answered %q", got)
+	}
+
+	if got := server.Answer(t, HandleSetRulesCommand, db, tgfake.Command(group, admin, "/setrules Be nice")); !strings.Contains(got, "(version 1)") {
+		t.Errorf("the first /setrules answered %q", got)
+	}
+	// The text can also come from the message the command replies to.
+	reply := tgfake.Command(group, admin, "/setrules")
+	reply.ReplyToMessage = &tgbotapi.Message{Text: "Be nice. No spam"}
+	if got := server.Answer(t, HandleSetRulesCommand, db, reply); !strings.Contains(got, "(version 2)") || !strings.Contains(got, "No spam") {
+		t.Errorf("/setrules in a reply answered %q", got)
+	}
+	keyboard := server.Requests("sendMessage")[0].Params.Get("reply_markup")
+	if !strings.Contains(keyboard, "rules_accept_2") {
+		t.Errorf("the announcement's button is %s", keyboard)
+	}
+
+	if got := server.Answer(t, HandleRulesCommand, db, tgfake.Command(group, member, "/rules")); !strings.Contains(got, "version 2") || !strings.Contains(got, "No spam") {
+		t.Errorf("/rules answered %q", got)
+	}
+	if got := server.Answer(t, HandleRulesHistoryCommand, db, tgfake.Command(group, member, "/rulehistory")); !strings.Contains(got, "2 versions") ||
+		strings.Index(got, "v2") > strings.Index(got, "v1") {
+		t.Errorf("/rulehistory answered %q", got)
+	}
+	if got := server.Answer(t, HandleRulesHistoryCommand, db, tgfake.Command(group, member, "/rulehistory v1")); !strings.HasSuffix(got, "\n\nBe nice") {
+		t.Errorf("/rulehistory v1 answered %q", got)
+	}
+	if got := server.Answer(t, HandleRulesHistoryCommand, db, tgfake.Command(group, member, "/rulehistory 9")); !strings.Contains(got, "no version 9") {
+		t.Errorf("/rulehistory of a missing version answered %q", got)
+	}
+}
+
+func TestConcurrentSetRules(t *testing.T) {
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	group := tgfake.Group(-100)
+	admins := []*tgbotapi.User{{ID: 42, FirstName: "Ada"}, {ID: 44, FirstName: "Cy"}, {ID: 45, FirstName: "Di"}}
+
+	var wg sync.WaitGroup
+	for _, admin := range admins {
+		server.SetStatus(group.ID, admin.ID, "administrator")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			HandleSetRulesCommand(bot, db, tgfake.Command(group, admin, "/setrules Rules by "+admin.FirstName))
+		}()
+	}
+	wg.Wait()
+
+	history, err := db.GetRulesHistory(context.Background(), group.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != len(admins) {
+		t.Fatalf("%d saves stored %d versions", len(admins), len(history))
+	}
+	for i, r := range history {
+		if r.Version != len(admins)-i {
+			t.Fatalf("admins saving at once got versions %+v", history)
+		}
+	}
+}
+
+func TestAcceptRules(t *testing.T) {
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	group := tgfake.Group(-100)
+	admin := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	member := &tgbotapi.User{ID: 43, FirstName: "Bob"}
+	server.SetStatus(group.ID, admin.ID, "administrator")
+	server.Answer(t, HandleSetRulesCommand, db, tgfake.Command(group, admin, "/setrules Be nice"))
+	server.Answer(t, HandleSetRulesCommand, db, tgfake.Command(group, admin, "/setrules Be nice. No spam"))
+
+	// click taps an accept button in a chat and returns the toast shown.
+	click := func(chat *tgbotapi.Chat, data string) string {
+		t.Helper()
+		server.Reset()
+		HandleCallbackQuery(bot, db, &tgbotapi.CallbackQuery{ID: "1", From: member, Message: &tgbotapi.Message{Chat: chat}, Data: data})
+		answers := server.Requests("answerCallbackQuery")
+		if len(answers) != 1 {
+			t.Fatalf("%s got %d answers", data, len(answers))
+		}
+		return answers[0].Params.Get("text")
+	}
+
+	if got := click(group, "rules_accept_1"); !strings.Contains(got, "out of date") {
+		t.Errorf("accepting an old version answered %q", got)
+	}
+	if a, _ := db.GetRulesAcceptance(context.Background(), group.ID, member.ID); a != nil {
+		t.Errorf("an old version was accepted: %+v", a)
+	}
+	if got := click(group, "rules_accept_2"); !strings.Contains(got, "accepted version 2") {
+		t.Errorf("accepting answered %q", got)
+	}
+
+	// In private, the button names the group the rules belong to.
+	private := tgfake.Private(member)
+	got := server.Answer(t, func(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+		HandleRulesDeepLink(bot, db, message, []int64{group.ID})
+	}, db, tgfake.Command(private, member, "/start"))
+	if !strings.Contains(got, "Rules of Test group") || !strings.Contains(got, "No spam") {
+		t.Errorf("the rules deep link answered %q", got)
+	}
+	if keyboard := server.Requests("sendMessage")[0].Params.Get("reply_markup"); !strings.Contains(keyboard, "rules_accept_2_-100") {
+		t.Errorf("the private button is %s", keyboard)
+	}
+	if got := click(private, "rules_accept_2_-100"); !strings.Contains(got, "accepted version 2") {
+		t.Errorf("accepting in private answered %q", got)
+	}
+	if a, _ := db.GetRulesAcceptance(context.Background(), group.ID, member.ID); a == nil || a.Version != 2 {
+		t.Errorf("stored acceptance = %+v", a)
+	}
+}
@@ -0,0 +1,244 @@
+// Package tgfake is an in-process Telegram Bot API for tests. Bot returns a client talking to
+// it, so handlers run unchanged while the test reads back what they sent, instead of depending
+// on Telegram.
+package tgfake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+)
+
+// Handler answers one call with its result. Returning an *Error fails the call.
+type Handler func(params url.Values) (interface{}, error)
+
+// Error is a Bot API error answered by the fake.
+type Error struct {
+	Code        int
+	Description string
+}
+
+func (e *Error) Error() string { return e.Description }
+
+// Request is a call the bot made.
+type Request struct {
+	Method string
+	Params url.Values
+}
+
+// Server is a fake Bot API. Methods without a handler succeed: send methods answer with a
+// message in the chat they were sent to, getChat with a group, and everything else with true.
+// Chat members are plain members unless SetStatus says otherwise.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	statuses map[[2]int64]string // Chat member statuses keyed by chat and user ID
+	requests []Request
+	nextID   int
+	bot      *tgbotapi.BotAPI // The bot made by Bot, which Answer runs handlers with
+}
+
+// New starts a fake Bot API. Close it when done.
+func New() *Server {
+	s := &Server{handlers: make(map[string]Handler), statuses: make(map[[2]int64]string)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.Handle("getMe", func(url.Values) (interface{}, error) {
+		return tgbotapi.User{ID: 1, IsBot: true, FirstName: "Test", UserName: "test_bot"}, nil
+	})
+	s.Handle("getChatMember", s.chatMember)
+	s.Handle("getChat", func(params url.Values) (interface{}, error) {
+		chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+		return Group(chatID), nil
+	})
+	return s
+}
+
+// Bot starts a fake Bot API and returns a bot talking to it. Both go away when the test ends.
+func Bot(t testing.TB) (*tgbotapi.BotAPI, *Server) {
+	t.Helper()
+	s := New()
+	t.Cleanup(s.Close)
+	bot, err := tgbotapi.NewBotAPIWithClient("test", s.URL+"/bot%s/%s", s.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.bot = bot
+	return bot, s
+}
+
+// Handle sets the handler of a method, replacing any previous one.
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+}
+
+// SetStatus sets what getChatMember answers for a user, like "administrator" or "left".
+func (s *Server) SetStatus(chatID, userID int64, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[[2]int64{chatID, userID}] = status
+}
+
+// Requests returns the calls of a method made so far, in order, or all calls if method is "".
+func (s *Server) Requests(method string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Request
+	for _, r := range s.requests {
+		if method == "" || r.Method == method {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Texts returns the texts and captions sent so far, in order.
+func (s *Server) Texts() []string {
+	var texts []string
+	for _, r := range s.Requests("") {
+		switch r.Method {
+		case "sendMessage", "editMessageText":
+			texts = append(texts, r.Params.Get("text"))
+		case "sendPhoto", "editMessageCaption":
+			texts = append(texts, r.Params.Get("caption"))
+		}
+	}
+	return texts
+}
+
+// LastText returns the last text or caption sent, failing the test if nothing was sent.
+func (s *Server) LastText(t testing.TB) string {
+	t.Helper()
+	texts := s.Texts()
+	if len(texts) == 0 {
+		t.Fatal("the bot sent nothing")
+	}
+	return texts[len(texts)-1]
+}
+
+// Answer forgets the calls made so far, runs a command handler on message with the bot made
+// by Bot, and returns the one text it sent. The test fails if it sent none or several.
+func (s *Server) Answer(t testing.TB, handle func(*tgbotapi.BotAPI, database.Store, *tgbotapi.Message),
+	db database.Store, message *tgbotapi.Message) string {
+	t.Helper()
+	if s.bot == nil {
+		t.Fatal("Answer needs a server made by Bot")
+	}
+	s.Reset()
+	handle(s.bot, db, message)
+	texts := s.Texts()
+	if len(texts) != 1 {
+		t.Fatalf("%s sent %d messages: %q", message.Text, len(texts), texts)
+	}
+	return texts[0]
+}
+
+// Reset forgets the calls made so far.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	s.mu.Lock()
+	if method != "getMe" {
+		s.requests = append(s.requests, Request{Method: method, Params: r.PostForm})
+	}
+	h, ok := s.handlers[method]
+	s.mu.Unlock()
+
+	var result interface{} = true
+	var err error
+	switch {
+	case ok:
+		result, err = h(r.PostForm)
+	case strings.HasPrefix(method, "send"):
+		result = s.message(r.PostForm)
+	}
+
+	answer := map[string]interface{}{"ok": err == nil}
+	if err != nil {
+		apiErr, ok := err.(*Error)
+		if !ok {
+			apiErr = &Error{Code: http.StatusInternalServerError, Description: err.Error()}
+		}
+		answer["error_code"] = apiErr.Code
+		answer["description"] = apiErr.Description
+	} else {
+		answer["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answer)
+}
+
+// message is the default answer of send methods: a new message in the chat.
+func (s *Server) message(params url.Values) tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.mu.Unlock()
+	return tgbotapi.Message{
+		MessageID: id,
+		Chat:      &tgbotapi.Chat{ID: chatID},
+		Date:      int(time.Now().Unix()),
+		Text:      params.Get("text"),
+	}
+}
+
+func (s *Server) chatMember(params url.Values) (interface{}, error) {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	userID, _ := strconv.ParseInt(params.Get("user_id"), 10, 64)
+	s.mu.Lock()
+	status, ok := s.statuses[[2]int64{chatID, userID}]
+	s.mu.Unlock()
+	if !ok {
+		status = "member"
+	}
+	return tgbotapi.ChatMember{User: &tgbotapi.User{ID: userID, FirstName: "User"}, Status: status}, nil
+}
+
+// Command returns a message sending a command, like "/nft pudgypenguins", from user in chat.
+func Command(chat *tgbotapi.Chat, user *tgbotapi.User, text string) *tgbotapi.Message {
+	length := len(text)
+	if i := strings.IndexByte(text, ' '); i >= 0 {
+		length = i
+	}
+	return &tgbotapi.Message{
+		MessageID: 1,
+		From:      user,
+		Chat:      chat,
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+		Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}},
+	}
+}
+
+// Private returns the private chat of a user.
+func Private(user *tgbotapi.User) *tgbotapi.Chat {
+	return &tgbotapi.Chat{ID: user.ID, Type: "private", FirstName: user.FirstName}
+}
+
+// Group returns a supergroup.
+func Group(id int64) *tgbotapi.Chat {
+	return &tgbotapi.Chat{ID: id, Type: "supergroup", Title: "Test group"}
+}
@@ -0,0 +1,286 @@
+package tokengate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/deeplink"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+	"github.com/philip-857.bit/byb-bot/internal/wallets"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+// HandleTokenGateCommand lets an admin limit a group to holders of a token or NFT collection:
+// /tokengate <contract> [min balance] [chain], /tokengate off, or /tokengate to show the gate.
+func HandleTokenGateCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.group_only")))
+		return
+	}
+	if !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	ctx := context.Background()
+	fields := strings.Fields(message.CommandArguments())
+	switch {
+	case len(fields) == 0:
+		gate, err := db.GetTokenGate(ctx, message.Chat.ID)
+		if err != nil {
+			log.Printf("Failed to load token gate of chat %d: %v", message.Chat.ID, err)
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("tokengate.error")))
+			return
+		}
+		if gate == nil {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("tokengate.none")))
+			return
+		}
+		sendMarkdown(bot, message.Chat.ID, l.T("tokengate.status", describe(gate)))
+		return
+
+	case len(fields) == 1 && strings.EqualFold(fields[0], "off"):
+		if err := db.RemoveTokenGate(ctx, message.Chat.ID); err != nil {
+			log.Printf("Failed to remove token gate of chat %d: %v", message.Chat.ID, err)
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("tokengate.error")))
+			return
+		}
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("tokengate.off")))
+		return
+
+	case len(fields) > 3:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("tokengate.usage")))
+		return
+	}
+
+	contract, err := web3.ParseAddress(fields[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, web3.WalletErrorText(l, fields[0], err)))
+		return
+	}
+	amount, chainName := "1", ""
+	if len(fields) > 1 {
+		amount = fields[1]
+	}
+	if len(fields) > 2 {
+		chainName = fields[2]
+	}
+	chain, ok := web3.LookupChain(chainName)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", chainName, strings.Join(web3.ChainKeys(), ", "))))
+		return
+	}
+
+	symbol, decimals, err := web3.ReadGateToken(ctx, chain, contract)
+	if err != nil {
+		log.Printf("Failed to read gate token %s on %s: %v", contract, chain.Name, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, web3.TokenErrorText(l, chain, err)))
+		return
+	}
+	minBalance, ok := web3.ParseUnits(amount, decimals)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("tokengate.invalid_amount", amount, decimals)))
+		return
+	}
+
+	gate := &models.TokenGate{
+		ChatID:     message.Chat.ID,
+		Chain:      chain.Key,
+		Contract:   contract,
+		Symbol:     symbol,
+		Decimals:   decimals,
+		MinBalance: minBalance.String(),
+		CreatedBy:  message.From.ID,
+		CreatedAt:  time.Now(),
+	}
+	if err := db.SaveTokenGate(ctx, gate); err != nil {
+		log.Printf("Failed to save token gate of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("tokengate.error")))
+		return
+	}
+	sendMarkdown(bot, message.Chat.ID, l.T("tokengate.set", describe(gate)))
+}
+
+// describe formats the requirement of a gate: amount and symbol, chain and contract.
+func describe(gate *models.TokenGate) string {
+	chainName := gate.Chain
+	if chain, ok := web3.LookupChain(gate.Chain); ok {
+		chainName = chain.Name
+	}
+	return fmt.Sprintf("`%s %s` · %s · `%s`", minBalanceText(gate), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, gate.Symbol), chainName, gate.Contract)
+}
+
+func minBalanceText(gate *models.TokenGate) string {
+	minBalance, _ := new(big.Int).SetString(gate.MinBalance, 10)
+	if minBalance == nil {
+		return gate.MinBalance
+	}
+	return web3.FormatTokenAmount(minBalance, gate.Decimals)
+}
+
+// Admit checks a user against the token gate of a chat and, when they hold enough, records them
+// as a member so their holdings are checked again later. Chats without a gate admit everyone
+// and return a nil gate, as does a gate that couldn't be loaded, along with the error.
+func Admit(ctx context.Context, db database.Store, chatID, userID int64) (*models.TokenGate, bool, error) {
+	gate, err := db.GetTokenGate(ctx, chatID)
+	if err != nil || gate == nil {
+		return nil, err == nil, err
+	}
+	ok, err := holds(ctx, db, gate, userID)
+	if err != nil || !ok {
+		return gate, false, err
+	}
+	member := &models.TokenGateMember{ChatID: chatID, TelegramID: userID, CheckedAt: time.Now()}
+	if err := db.SaveTokenGateMember(ctx, member); err != nil {
+		log.Printf("Failed to record token gate member %d of chat %d: %v", userID, chatID, err)
+	}
+	return gate, true, nil
+}
+
+// errNoMinimum is returned for gates whose stored minimum can't be read.
+var errNoMinimum = errors.New("invalid token gate minimum")
+
+// holds reports whether the wallets a user linked together hold at least the gate's minimum.
+func holds(ctx context.Context, db database.Store, gate *models.TokenGate, userID int64) (bool, error) {
+	minBalance, ok := new(big.Int).SetString(gate.MinBalance, 10)
+	if !ok {
+		return false, errNoMinimum
+	}
+	chain, ok := web3.LookupChain(gate.Chain)
+	if !ok {
+		return false, fmt.Errorf("unknown chain %q", gate.Chain)
+	}
+	addresses, err := wallets.Addresses(ctx, db, userID, gate.Chain)
+	if err != nil {
+		return false, err
+	}
+
+	rpc := chain.RPC()
+	total := new(big.Int)
+	for _, addr := range addresses {
+		balance, err := web3.TokenBalance(ctx, rpc, gate.Contract, addr)
+		if err != nil {
+			return false, err
+		}
+		if total.Add(total, balance).Cmp(minBalance) >= 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LinkURL returns the deep link that starts linking a wallet for a gated chat.
+func LinkURL(bot *tgbotapi.BotAPI, chatID int64) (string, error) {
+	return deeplink.URL(bot, deeplink.ActionWallet, chatID)
+}
+
+// RequirementText explains what a user needs to join a gated chat.
+func RequirementText(l i18n.Localizer, gate *models.TokenGate, chatTitle string) string {
+	return l.T("tokengate.required", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, chatTitle), describe(gate))
+}
+
+// HandleJoinRequest approves requests to join a gated chat from users whose linked wallets hold
+// enough, and sends everyone else a link to link a wallet. Their request stays pending meanwhile.
+// Requests to chats without a gate are left to the admins.
+func HandleJoinRequest(bot *tgbotapi.BotAPI, db database.Store, request *tgbotapi.ChatJoinRequest) {
+	ctx := context.Background()
+	gate, ok, err := Admit(ctx, db, request.Chat.ID, request.From.ID)
+	if gate == nil {
+		if err != nil {
+			log.Printf("Failed to load token gate of chat %d: %v", request.Chat.ID, err)
+		}
+		return
+	}
+
+	l := i18n.For(db, nil, &request.From)
+	if err != nil {
+		log.Printf("Failed to check token gate of chat %d for user %d: %v", request.Chat.ID, request.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(request.From.ID, l.T("tokengate.check_error")))
+		return
+	}
+	if ok {
+		approve(bot, l, request.Chat.ID, request.Chat.Title, request.From.ID)
+		return
+	}
+	sendLinkPrompt(bot, l, gate, request.Chat.ID, request.Chat.Title, request.From.ID)
+}
+
+// HandleWalletLinked approves the pending join request of a user who just linked a wallet for a gated chat.
+func HandleWalletLinked(bot *tgbotapi.BotAPI, db database.Store, user *tgbotapi.User, chatID int64) {
+	if chatID == 0 {
+		return
+	}
+	ctx := context.Background()
+	gate, ok, err := Admit(ctx, db, chatID, user.ID)
+	if gate == nil {
+		return
+	}
+
+	l := i18n.For(db, nil, user)
+	title := chatTitle(bot, chatID)
+	switch {
+	case err != nil:
+		log.Printf("Failed to check token gate of chat %d for user %d: %v", chatID, user.ID, err)
+		bot.Send(tgbotapi.NewMessage(user.ID, l.T("tokengate.check_error")))
+	case ok:
+		approve(bot, l, chatID, title, user.ID)
+	default:
+		sendMarkdown(bot, user.ID, l.T("tokengate.insufficient", describe(gate)))
+	}
+}
+
+// approve approves a join request and tells the user. Users who joined some other way, and are
+// waiting on the captcha instead, have no request to approve, which is fine.
+func approve(bot *tgbotapi.BotAPI, l i18n.Localizer, chatID int64, title string, userID int64) {
+	_, err := bot.Request(tgbotapi.ApproveChatJoinRequestConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+		UserID:     userID,
+	})
+	if err != nil {
+		log.Printf("Failed to approve join request of user %d to chat %d: %v", userID, chatID, err)
+		return
+	}
+	log.Printf("Approved join request of token holder %d to chat %d", userID, chatID)
+	bot.Send(tgbotapi.NewMessage(userID, l.T("tokengate.approved", title)))
+}
+
+// sendLinkPrompt tells a user what a gated chat requires, with a button to link a wallet.
+func sendLinkPrompt(bot *tgbotapi.BotAPI, l i18n.Localizer, gate *models.TokenGate, chatID int64, title string, userID int64) {
+	msg := tgbotapi.NewMessage(userID, RequirementText(l, gate, title))
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	if link, err := LinkURL(bot, chatID); err == nil {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(l.T("tokengate.link_button"), link)))
+	}
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("Failed to send token gate requirements to user %d: %v", userID, err)
+	}
+}
+
+// chatTitle returns the title of a chat, or "" if it can't be read.
+func chatTitle(bot *tgbotapi.BotAPI, chatID int64) string {
+	chat, err := bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+	if err != nil {
+		log.Printf("Failed to get chat %d: %v", chatID, err)
+		return ""
+	}
+	return chat.Title
+}
+
+func sendMarkdown(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
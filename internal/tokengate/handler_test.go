@@ -0,0 +1,158 @@
+package tokengate
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/config"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+	"github.com/philip-857.bit/byb-bot/internal/web3/rpcfake"
+)
+
+const (
+	token  = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+	wallet = "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"
+)
+
+// Function selectors of the calls a gate makes.
+const (
+	balanceOf = "70a08231"
+	decimals  = "313ce567"
+	symbol    = "95d89b41"
+)
+
+// word ABI-encodes a uint256 or a left-aligned bytes32.
+func word(b []byte, leftAligned bool) []byte {
+	out := make([]byte, 32)
+	if leftAligned {
+		copy(out, b)
+	} else {
+		copy(out[32-len(b):], b)
+	}
+	return out
+}
+
+// balanceCall is the calldata of balanceOf(owner), hex encoded.
+func balanceCall(owner string) string {
+	return balanceOf + strings.Repeat("0", 24) + strings.ToLower(owner[2:])
+}
+
+// tokens returns n whole tokens of 18 decimals.
+func tokens(n int64) []byte {
+	return new(big.Int).Mul(big.NewInt(n), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)).Bytes()
+}
+
+// fakeToken serves an 18-decimal GATE token on Base from a fake node until the test ends.
+func fakeToken(t *testing.T) *rpcfake.Server {
+	t.Helper()
+	node := rpcfake.New()
+	t.Cleanup(node.Close)
+	previous := web3.Cfg
+	web3.Cfg = &config.Config{RPCURLs: map[string]string{"base": node.URL}}
+	t.Cleanup(func() { web3.Cfg = previous })
+
+	node.Result("eth_getCode", "0x6080604052")
+	node.Call(token, balanceCall(token), word(nil, false))
+	node.Call(token, decimals, word([]byte{18}, false))
+	node.Call(token, symbol, word([]byte("GATE"), true))
+	return node
+}
+
+func TestTokenGate(t *testing.T) {
+	node := fakeToken(t)
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	ctx := context.Background()
+	group := tgfake.Group(-100)
+	admin := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	holder := &tgbotapi.User{ID: 50, FirstName: "Bob"}
+	newcomer := &tgbotapi.User{ID: 51, FirstName: "Cy"}
+	server.SetStatus(group.ID, admin.ID, "administrator")
+
+	HandleTokenGateCommand(bot, db, tgfake.Command(group, holder, "/tokengate "+token+" 100 base"))
+	if gate, _ := db.GetTokenGate(ctx, group.ID); gate != nil {
+		t.Fatal("a member set a token gate")
+	}
+	HandleTokenGateCommand(bot, db, tgfake.Command(group, admin, "/tokengate "+token+" 100 base"))
+	gate, err := db.GetTokenGate(ctx, group.ID)
+	if err != nil || gate == nil {
+		t.Fatalf("GetTokenGate = %v, %v", gate, err)
+	}
+	if gate.Symbol != "GATE" || gate.Decimals != 18 || gate.MinBalance != new(big.Int).SetBytes(tokens(100)).String() {
+		t.Errorf("stored gate = %+v", gate)
+	}
+
+	// Without a linked wallet, a join request waits while the user is asked to link one.
+	server.Reset()
+	HandleJoinRequest(bot, db, &tgbotapi.ChatJoinRequest{Chat: *group, From: *newcomer})
+	if len(server.Requests("approveChatJoinRequest")) != 0 {
+		t.Error("a user without a wallet was let in")
+	}
+	if texts := server.Texts(); len(texts) != 1 || !strings.Contains(texts[0], "only for holders") {
+		t.Errorf("a user without a wallet was told %q", texts)
+	}
+
+	// Linking a wallet holding enough lets the user in.
+	node.Call(token, balanceCall(wallet), word(tokens(150), false))
+	db.AddLinkedWallet(ctx, &models.LinkedWallet{TelegramID: holder.ID, Chain: "base", Address: wallet, LinkedAt: time.Now()})
+	server.Reset()
+	HandleWalletLinked(bot, db, holder, group.ID)
+	if approvals := server.Requests("approveChatJoinRequest"); len(approvals) != 1 || approvals[0].Params.Get("user_id") != "50" {
+		t.Errorf("approvals = %v", approvals)
+	}
+	if members, _ := db.GetTokenGateMembers(ctx, group.ID); len(members) != 1 || members[0].TelegramID != holder.ID {
+		t.Errorf("gate members = %+v", members)
+	}
+
+	// Holding enough keeps the member in.
+	server.Reset()
+	recheck(bot, db)
+	if len(server.Requests("banChatMember")) != 0 {
+		t.Error("a holder was removed")
+	}
+
+	// Selling removes them without keeping them out.
+	node.Call(token, balanceCall(wallet), word(tokens(10), false))
+	server.Reset()
+	recheck(bot, db)
+	bans, unbans := server.Requests("banChatMember"), server.Requests("unbanChatMember")
+	if len(bans) != 1 || len(unbans) != 1 || unbans[0].Params.Get("only_if_banned") != "true" {
+		t.Errorf("removal made bans %v and unbans %v", bans, unbans)
+	}
+	if texts := server.Texts(); len(texts) != 1 || !strings.Contains(texts[0], "ask to join again") {
+		t.Errorf("a removed member was told %q", texts)
+	}
+	if members, _ := db.GetTokenGateMembers(ctx, group.ID); len(members) != 0 {
+		t.Errorf("a removed member is still recorded: %+v", members)
+	}
+}
+
+func TestRecheckSkipsAdminsAndForgetsLeavers(t *testing.T) {
+	fakeToken(t)
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	ctx := context.Background()
+	gate := &models.TokenGate{ChatID: -100, Chain: "base", Contract: token, Symbol: "GATE", Decimals: 18, MinBalance: "1", CreatedAt: time.Now()}
+	db.SaveTokenGate(ctx, gate)
+	for _, id := range []int64{42, 43} {
+		db.SaveTokenGateMember(ctx, &models.TokenGateMember{ChatID: gate.ChatID, TelegramID: id, CheckedAt: time.Now()})
+	}
+	server.SetStatus(gate.ChatID, 42, "administrator")
+	server.SetStatus(gate.ChatID, 43, "left")
+
+	recheck(bot, db)
+	if len(server.Requests("banChatMember")) != 0 {
+		t.Error("recheck removed an admin or a member who had left")
+	}
+	members, _ := db.GetTokenGateMembers(ctx, gate.ChatID)
+	if len(members) != 1 || members[0].TelegramID != 42 {
+		t.Errorf("gate members = %+v, want only the admin", members)
+	}
+}
@@ -0,0 +1,101 @@
+package tokengate
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+// recheckInterval is how often the holdings of gated chat members are checked again.
+const recheckInterval = 6 * time.Hour
+
+// RunRechecker removes members of gated chats whose wallets no longer hold enough, every
+// recheckInterval, forever. Start it once on startup.
+func RunRechecker(bot *tgbotapi.BotAPI, db database.Store) {
+	ticker := time.NewTicker(recheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		recheck(bot, db)
+	}
+}
+
+// recheck goes through the admitted members of every gated chat. Only members the gate let in
+// are known: the Bot API can't list a chat's members, so those who joined before the gate was
+// set are never checked.
+func recheck(bot *tgbotapi.BotAPI, db database.Store) {
+	ctx := context.Background()
+	gates, err := db.GetTokenGates(ctx)
+	if err != nil {
+		log.Printf("Failed to load token gates: %v", err)
+		return
+	}
+	for i := range gates {
+		gate := &gates[i]
+		members, err := db.GetTokenGateMembers(ctx, gate.ChatID)
+		if err != nil {
+			log.Printf("Failed to load token gate members of chat %d: %v", gate.ChatID, err)
+			continue
+		}
+		for _, member := range members {
+			recheckMember(bot, db, gate, member)
+		}
+	}
+}
+
+// recheckMember removes a member who sold below the gate's minimum. Members who left are
+// forgotten, and admins are never removed. A failed balance check keeps the member for now.
+func recheckMember(bot *tgbotapi.BotAPI, db database.Store, gate *models.TokenGate, member models.TokenGateMember) {
+	ctx := context.Background()
+	chatMember, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: gate.ChatID, UserID: member.TelegramID},
+	})
+	if err != nil {
+		log.Printf("Failed to get member %d of chat %d: %v", member.TelegramID, gate.ChatID, err)
+		return
+	}
+	switch {
+	case chatMember.HasLeft() || chatMember.WasKicked():
+		if err := db.RemoveTokenGateMember(ctx, gate.ChatID, member.TelegramID); err != nil {
+			log.Printf("Failed to forget token gate member %d of chat %d: %v", member.TelegramID, gate.ChatID, err)
+		}
+		return
+	case chatMember.IsCreator() || chatMember.IsAdministrator():
+		return
+	}
+
+	ok, err := holds(ctx, db, gate, member.TelegramID)
+	if err != nil {
+		log.Printf("Failed to recheck holdings of member %d of chat %d: %v", member.TelegramID, gate.ChatID, err)
+		return
+	}
+	if ok {
+		member.CheckedAt = time.Now()
+		if err := db.SaveTokenGateMember(ctx, &member); err != nil {
+			log.Printf("Failed to update token gate member %d of chat %d: %v", member.TelegramID, gate.ChatID, err)
+		}
+		return
+	}
+
+	log.Printf("Removing member %d from chat %d, their wallets no longer hold %s", member.TelegramID, gate.ChatID, gate.Symbol)
+	// Banning and unbanning right away removes the member without keeping them out, so they can
+	// ask to join again once they hold enough.
+	chatMemberConfig := tgbotapi.ChatMemberConfig{ChatID: gate.ChatID, UserID: member.TelegramID}
+	if _, err := bot.Request(tgbotapi.KickChatMemberConfig{ChatMemberConfig: chatMemberConfig}); err != nil {
+		log.Printf("Failed to remove member %d from chat %d: %v", member.TelegramID, gate.ChatID, err)
+		return
+	}
+	if _, err := bot.Request(tgbotapi.UnbanChatMemberConfig{ChatMemberConfig: chatMemberConfig, OnlyIfBanned: true}); err != nil {
+		log.Printf("Failed to unban removed member %d of chat %d: %v", member.TelegramID, gate.ChatID, err)
+	}
+	if err := db.RemoveTokenGateMember(ctx, gate.ChatID, member.TelegramID); err != nil {
+		log.Printf("Failed to forget token gate member %d of chat %d: %v", member.TelegramID, gate.ChatID, err)
+	}
+
+	l := i18n.For(db, nil, chatMember.User)
+	sendMarkdown(bot, member.TelegramID, l.T("tokengate.removed", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, chatTitle(bot, gate.ChatID)), describe(gate)))
+}
@@ -0,0 +1,249 @@
+package wallets
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+const (
+	// challengeTimeout is how long a sign-in message can be signed after /linkwallet asked for it.
+	challengeTimeout = 15 * time.Minute
+	// linkingTimeout is how long a group's wallet link is remembered for the user who followed it.
+	linkingTimeout = time.Hour
+)
+
+// challenge is a sign-in message waiting for its signature.
+type challenge struct {
+	address string
+	chain   *web3.Chain
+	message string
+	expires time.Time
+}
+
+// linking is the chat a user followed a wallet link from.
+type linking struct {
+	chatID  int64
+	expires time.Time
+}
+
+// LinkedHook runs after a user linked a wallet. chatID is the chat whose wallet link they
+// followed, or 0 if they started linking on their own.
+type LinkedHook func(bot *tgbotapi.BotAPI, db database.Store, user *tgbotapi.User, chatID int64)
+
+var (
+	challenges  = make(map[int64]challenge) // Keyed by Telegram user ID
+	linkingFor  = make(map[int64]linking)   // Chat a user is linking a wallet for, keyed by user ID
+	linkedHooks []LinkedHook
+	mu          sync.Mutex
+)
+
+// OnLinked registers a hook to run whenever a user links a wallet.
+func OnLinked(hook LinkedHook) {
+	linkedHooks = append(linkedHooks, hook)
+}
+
+// HandleWalletDeepLink explains how to link a wallet. Args: none, or the chat the user needs
+// the wallet for, which is told once the wallet is linked.
+func HandleWalletDeepLink(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message, args []int64) {
+	l := i18n.ForMessage(db, message)
+	if len(args) > 1 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("deeplink.invalid")))
+		return
+	}
+	if len(args) == 1 {
+		now := time.Now()
+		mu.Lock()
+		pruneExpired(now)
+		linkingFor[message.From.ID] = linking{chatID: args[0], expires: now.Add(linkingTimeout)}
+		mu.Unlock()
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.link_usage")))
+}
+
+// HandleLinkWalletCommand starts linking a wallet: /linkwallet <address|ens name> [chain]. It
+// answers with a Sign-In with Ethereum message for the user to sign with that wallet. Without a
+// chain, the wallet is linked on the chain of the gated group the user is joining, or Ethereum.
+func HandleLinkWalletCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !message.Chat.IsPrivate() {
+		// Signatures are harmless to share, but the flow is personal and would clutter the group.
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.private_only")))
+		return
+	}
+
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 || len(fields) > 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.link_usage")))
+		return
+	}
+	chainName := gateChain(db, message.From.ID)
+	if len(fields) == 2 {
+		chainName = fields[1]
+	}
+	chain, ok := web3.LookupChain(chainName)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", chainName, strings.Join(web3.ChainKeys(), ", "))))
+		return
+	}
+
+	input := fields[0]
+	address, _, err := web3.ResolveAddressInput(context.Background(), input)
+	if err != nil {
+		log.Printf("Failed to resolve wallet %q to link: %v", input, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, web3.WalletErrorText(l, input, err)))
+		return
+	}
+
+	nonce, err := web3.NewNonce()
+	if err != nil {
+		log.Printf("Failed to generate sign-in nonce: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.error")))
+		return
+	}
+	now := time.Now()
+	signIn := &web3.SignInMessage{
+		Domain:         "t.me",
+		Address:        address,
+		Statement:      "Link this wallet to Telegram user " + strconv.FormatInt(message.From.ID, 10) + " on @" + bot.Self.UserName + ".",
+		URI:            "https://t.me/" + bot.Self.UserName,
+		ChainID:        chain.ChainID,
+		Nonce:          nonce,
+		IssuedAt:       now,
+		ExpirationTime: now.Add(challengeTimeout),
+	}
+	text := signIn.String()
+
+	mu.Lock()
+	pruneExpired(now)
+	challenges[message.From.ID] = challenge{address: address, chain: chain, message: text, expires: signIn.ExpirationTime}
+	mu.Unlock()
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.sign", int(challengeTimeout/time.Minute))+"\n\n```\n"+text+"\n```")
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// HandleVerifyWalletCommand finishes linking a wallet: /verifywallet <signature>. The signature
+// must be of the message /linkwallet sent, by the wallet being linked.
+func HandleVerifyWalletCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.private_only")))
+		return
+	}
+
+	signature := strings.TrimSpace(message.CommandArguments())
+	if signature == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.verify_usage")))
+		return
+	}
+
+	mu.Lock()
+	pending, exists := challenges[message.From.ID]
+	mu.Unlock()
+	if !exists || time.Now().After(pending.expires) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.no_challenge")))
+		return
+	}
+
+	if err := web3.VerifyPersonalSign(pending.address, pending.message, signature); err != nil {
+		log.Printf("Rejected wallet signature from user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.bad_signature", pending.address)))
+		return
+	}
+
+	// Each message can only be used once, so a leaked signature can't link the wallet again.
+	mu.Lock()
+	delete(challenges, message.From.ID)
+	chatID, _ := linkingChat(message.From.ID, time.Now())
+	delete(linkingFor, message.From.ID)
+	mu.Unlock()
+
+	// An address someone else linked moves to this user, who just proved they control it.
+	wallet := &models.LinkedWallet{
+		TelegramID: message.From.ID,
+		Chain:      pending.chain.Key,
+		Address:    pending.address,
+		LinkedAt:   time.Now(),
+	}
+	if err := db.AddLinkedWallet(context.Background(), wallet); err != nil {
+		log.Printf("Failed to save linked wallet of user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.error")))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.linked", pending.address, pending.chain.Name))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+
+	for _, hook := range linkedHooks {
+		hook(bot, db, message.From, chatID)
+	}
+}
+
+// linkingChat returns the chat a user is linking a wallet for, unless the link has expired.
+// Callers hold mu.
+func linkingChat(userID int64, now time.Time) (int64, bool) {
+	l, ok := linkingFor[userID]
+	if !ok || now.After(l.expires) {
+		return 0, false
+	}
+	return l.chatID, true
+}
+
+// pruneExpired forgets the challenges and wallet links that have expired, so users who never
+// finish linking don't stay in memory. Callers hold mu.
+func pruneExpired(now time.Time) {
+	for userID, c := range challenges {
+		if now.After(c.expires) {
+			delete(challenges, userID)
+		}
+	}
+	for userID, l := range linkingFor {
+		if now.After(l.expires) {
+			delete(linkingFor, userID)
+		}
+	}
+}
+
+// gateChain returns the chain of the token-gated chat a user is linking a wallet for, or "".
+func gateChain(db database.Store, userID int64) string {
+	mu.Lock()
+	chatID, ok := linkingChat(userID, time.Now())
+	mu.Unlock()
+	if !ok {
+		return ""
+	}
+	gate, err := db.GetTokenGate(context.Background(), chatID)
+	if err != nil || gate == nil {
+		return ""
+	}
+	return gate.Chain
+}
+
+// Addresses returns the wallets a user has linked on a chain, for features like token gates
+// and airdrops that act on the user's holdings there. Wallets stored without a chain count
+// for every chain, since an address is the same on all of them.
+func Addresses(ctx context.Context, db database.Store, telegramID int64, chain string) ([]string, error) {
+	linked, err := db.GetLinkedWallets(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, w := range linked {
+		if w.Chain == chain || w.Chain == "" {
+			addresses = append(addresses, w.Address)
+		}
+	}
+	return addresses, nil
+}
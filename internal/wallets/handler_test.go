@@ -0,0 +1,64 @@
+package wallets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+func TestLinkingStateExpires(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		clear(challenges)
+		clear(linkingFor)
+	})
+	bot, _ := tgfake.Bot(t)
+	db := database.NewMemory()
+	if err := db.SaveTokenGate(context.Background(), &models.TokenGate{ChatID: -100, Chain: "base"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	mu.Lock()
+	challenges[1] = challenge{address: vitalik, expires: now.Add(-time.Second)}
+	challenges[2] = challenge{address: other, expires: now.Add(time.Minute)}
+	linkingFor[1] = linking{chatID: -100, expires: now.Add(-time.Second)}
+	linkingFor[2] = linking{chatID: -100, expires: now.Add(time.Minute)}
+	mu.Unlock()
+
+	// An expired link no longer picks the group's chain, even before it's pruned.
+	if got := gateChain(db, 1); got != "" {
+		t.Errorf("gateChain of an expired link = %q", got)
+	}
+	if got := gateChain(db, 2); got != "base" {
+		t.Errorf("gateChain = %q, want base", got)
+	}
+
+	// Following a wallet link clears out what has expired.
+	user := &tgbotapi.User{ID: 3, FirstName: "Cy"}
+	HandleWalletDeepLink(bot, db, tgfake.Command(tgfake.Private(user), user, "/start"), []int64{-100})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := challenges[1]; ok {
+		t.Error("an expired challenge was kept")
+	}
+	if _, ok := linkingFor[1]; ok {
+		t.Error("an expired wallet link was kept")
+	}
+	if _, ok := challenges[2]; !ok {
+		t.Error("a pending challenge was dropped")
+	}
+	if l, ok := linkingFor[3]; !ok || l.chatID != -100 || l.expires.Before(now.Add(linkingTimeout)) {
+		t.Errorf("the followed link = %+v, %v", l, ok)
+	}
+	if len(linkingFor) != 2 {
+		t.Errorf("%d wallet links remembered, want 2", len(linkingFor))
+	}
+}
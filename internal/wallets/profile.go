@@ -0,0 +1,133 @@
+package wallets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+// HandleProfileCommand shows a user their profile and linked wallets. Groups only see
+// shortened addresses without ENS names, so a profile shown there doesn't identify the wallets.
+func HandleProfileCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	ctx := context.Background()
+
+	linked, err := db.GetLinkedWallets(ctx, message.From.ID)
+	if err != nil {
+		log.Printf("Failed to load linked wallets of user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.profile_error")))
+		return
+	}
+	member, err := db.GetUser(ctx, message.From.ID)
+	if err != nil {
+		// The join date is a detail; the wallets are what the profile is for.
+		log.Printf("Failed to load member %d: %v", message.From.ID, err)
+	}
+
+	var names map[string]string
+	if message.Chat.IsPrivate() {
+		addresses := make([]string, 0, len(linked))
+		for _, w := range linked {
+			addresses = append(addresses, w.Address)
+		}
+		names = web3.AddressNames(ctx, addresses...)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, ProfileText(l, message.From, member, linked, names, message.Chat.IsPrivate()))
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
+
+// ProfileText formats the Markdown profile of a user. member is nil for users the bot never
+// verified in a group. Full addresses and ENS names are only shown when full is set, since a
+// name identifies a wallet as well as its address does.
+func ProfileText(l i18n.Localizer, user *tgbotapi.User, member *models.User, linked []models.LinkedWallet, names map[string]string, full bool) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	title := l.T("wallets.profile_title", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, name))
+	if user.UserName != "" {
+		title += " (@" + tgbotapi.EscapeText(tgbotapi.ModeMarkdown, user.UserName) + ")"
+	}
+	lines := []string{title, l.T("wallets.profile_id", user.ID)}
+	if member != nil && !member.JoinedAt.IsZero() {
+		lines = append(lines, l.T("wallets.profile_joined", member.JoinedAt.UTC().Format("2006-01-02")))
+	}
+
+	lines = append(lines, "")
+	if len(linked) == 0 {
+		lines = append(lines, l.T("wallets.profile_no_wallets"))
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, l.N("wallets.profile_wallets", len(linked), len(linked)))
+	for _, w := range linked {
+		chainName := w.Chain
+		if chain, ok := web3.LookupChain(w.Chain); ok {
+			chainName = chain.Name
+		}
+		address := "`" + web3.ShortAddress(w.Address) + "`"
+		if full {
+			address = "`" + w.Address + "`"
+			if name := names[w.Address]; name != "" {
+				address += " · " + tgbotapi.EscapeText(tgbotapi.ModeMarkdown, name)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %s", chainName, address))
+	}
+	lines = append(lines, "", l.T("wallets.profile_hint"))
+	return strings.Join(lines, "\n")
+}
+
+// HandleUnlinkCommand removes a linked wallet: /unlink <address|ens name> [chain]. Without a
+// chain the address is unlinked everywhere.
+func HandleUnlinkCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if !message.Chat.IsPrivate() {
+		// Like linking, unlinking names the wallet, which a group shouldn't learn.
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.private_only")))
+		return
+	}
+
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 || len(fields) > 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.unlink_usage")))
+		return
+	}
+	chainKey := ""
+	if len(fields) == 2 {
+		chain, ok := web3.LookupChain(fields[1])
+		if !ok {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", fields[1], strings.Join(web3.ChainKeys(), ", "))))
+			return
+		}
+		chainKey = chain.Key
+	}
+
+	ctx := context.Background()
+	address, _, err := web3.ResolveAddressInput(ctx, fields[0])
+	if err != nil {
+		log.Printf("Failed to resolve wallet %q to unlink: %v", fields[0], err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, web3.WalletErrorText(l, fields[0], err)))
+		return
+	}
+
+	removed, err := db.RemoveLinkedWallet(ctx, message.From.ID, address, chainKey)
+	if err != nil {
+		log.Printf("Failed to unlink wallet of user %d: %v", message.From.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.profile_error")))
+		return
+	}
+	if removed == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.unlink_not_linked", fields[0])))
+		return
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, l.T("wallets.unlinked", web3.ShortAddress(address)))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
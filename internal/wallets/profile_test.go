@@ -0,0 +1,61 @@
+package wallets
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+)
+
+const (
+	vitalik = "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"
+	other   = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+)
+
+func TestProfileText(t *testing.T) {
+	l := i18n.New("en")
+	user := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	linked := []models.LinkedWallet{{TelegramID: 42, Chain: "ethereum", Address: vitalik}}
+	names := map[string]string{vitalik: "vitalik.eth"}
+
+	private := ProfileText(l, user, nil, linked, names, true)
+	if !strings.Contains(private, vitalik) || !strings.Contains(private, "vitalik.eth") {
+		t.Errorf("private profile lacks the address or its name:\n%s", private)
+	}
+	group := ProfileText(l, user, nil, linked, names, false)
+	if strings.Contains(group, vitalik) || strings.Contains(group, "vitalik.eth") {
+		t.Errorf("group profile identifies the wallet:\n%s", group)
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	db := database.NewMemory()
+	ctx := context.Background()
+	for _, w := range []models.LinkedWallet{
+		{TelegramID: 42, Chain: "ethereum", Address: vitalik},
+		{TelegramID: 42, Chain: "", Address: other},
+		{TelegramID: 43, Chain: "base", Address: other},
+	} {
+		w.LinkedAt = time.Now()
+		if err := db.AddLinkedWallet(ctx, &w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Addresses(ctx, db, 42, "ethereum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{vitalik, other}; !slices.Equal(got, want) {
+		t.Errorf("Addresses on ethereum = %v, want %v", got, want)
+	}
+	if got, _ := Addresses(ctx, db, 42, "base"); !slices.Equal(got, []string{other}) {
+		t.Errorf("Addresses on base = %v, want only the wallet without a chain", got)
+	}
+}
@@ -0,0 +1,203 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+const (
+	// scheduleInterval is how often the scheduler looks for digests that are due. Digests are
+	// scheduled to the minute, so this is also how late one can be.
+	scheduleInterval = time.Minute
+	// digestGrace is how late a digest may still go out, after the bot was down at its time.
+	// A morning digest posted in the evening would only be noise, so later ones skip the day.
+	digestGrace = time.Hour
+	// maxMovers is how many of the biggest 24h moves the digest highlights.
+	maxMovers = 3
+)
+
+// RunScheduler posts the digests that are due every scheduleInterval, forever. Start it once on startup.
+func RunScheduler(bot *tgbotapi.BotAPI, db database.Store) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		postDueDigests(bot, db)
+	}
+}
+
+// postDueDigests posts every digest that is due. Market data is shared between the chats, so
+// a coin or chain watched by many chats is looked up once.
+func postDueDigests(bot *tgbotapi.BotAPI, db database.Store) {
+	ctx := context.Background()
+	schedules, err := db.GetDigestSchedules(ctx)
+	if err != nil {
+		log.Printf("Failed to load digest schedules: %v", err)
+		return
+	}
+
+	market := newMarketData()
+	now := time.Now()
+	for i := range schedules {
+		schedule := &schedules[i]
+		date, ok := due(schedule, now)
+		if !ok {
+			continue
+		}
+
+		// The schedule is saved first, so a failed save can't turn into the same digest every minute.
+		schedule.LastSentOn = date
+		if err := db.SaveDigestSchedule(ctx, schedule); err != nil {
+			log.Printf("Failed to mark digest of chat %d as sent: %v", schedule.ChatID, err)
+			continue
+		}
+
+		watchlist, err := db.GetWatchlist(ctx, schedule.ChatID)
+		if err != nil {
+			log.Printf("Failed to load watchlist of chat %d for its digest: %v", schedule.ChatID, err)
+			continue
+		}
+		if len(watchlist) == 0 {
+			continue
+		}
+
+		l := i18n.ForGroup(db, schedule.ChatID, nil)
+		text := digestText(l, market, watchlist, web3.ChatCurrency(db, schedule.ChatID), scheduleChain(schedule), now.In(scheduleLocation(schedule)))
+		msg := tgbotapi.NewMessage(schedule.ChatID, text)
+		msg.ParseMode = "Markdown"
+		if _, err := bot.Send(msg); err != nil {
+			log.Printf("Failed to deliver digest to chat %d: %v", schedule.ChatID, err)
+		}
+	}
+}
+
+// due reports whether a schedule's digest should be posted at now, and the local date it is for.
+func due(schedule *models.DigestSchedule, now time.Time) (string, bool) {
+	at, err := time.Parse("15:04", schedule.Time)
+	if err != nil {
+		return "", false
+	}
+	local := now.In(scheduleLocation(schedule))
+	date := local.Format("2006-01-02")
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, local.Location())
+	late := local.Sub(scheduled)
+	return date, schedule.LastSentOn != date && late >= 0 && late < digestGrace
+}
+
+// loadLocation loads a time zone by its IANA name, such as "Europe/Paris", or "UTC".
+func loadLocation(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "utc") {
+		return time.UTC, nil
+	}
+	if name == "" || name == "Local" {
+		// The host's zone means nothing to the chat.
+		return nil, errors.New("not a time zone name")
+	}
+	return time.LoadLocation(name)
+}
+
+// scheduleLocation returns the time zone of a schedule, or UTC if it is no longer known.
+func scheduleLocation(schedule *models.DigestSchedule) *time.Location {
+	loc, err := loadLocation(schedule.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// scheduleChain returns the chain whose gas a schedule's digest shows, or Ethereum.
+func scheduleChain(schedule *models.DigestSchedule) *web3.Chain {
+	if chain, ok := web3.LookupChain(schedule.Chain); ok {
+		return chain
+	}
+	return web3.Chains[0]
+}
+
+// marketData looks every coin and chain up once through the shared price and gas caches.
+// Failures are remembered too, so one bad coin costs one lookup per round of digests.
+type marketData struct {
+	coins map[string]*web3.Coin
+	gas   map[string]*web3.GasPrices
+}
+
+func newMarketData() *marketData {
+	return &marketData{
+		coins: make(map[string]*web3.Coin),
+		gas:   make(map[string]*web3.GasPrices),
+	}
+}
+
+// coin returns the market data of a coin, or nil if it couldn't be fetched.
+func (m *marketData) coin(ctx context.Context, coinID string) *web3.Coin {
+	coin, ok := m.coins[coinID]
+	if !ok {
+		var err error
+		if coin, err = web3.Prices.Coin(ctx, coinID); err != nil {
+			log.Printf("Failed to fetch price of %q for digest: %v", coinID, err)
+		}
+		m.coins[coinID] = coin
+	}
+	return coin
+}
+
+// gasPrices returns the gas fees of a chain, or nil if they couldn't be fetched.
+func (m *marketData) gasPrices(ctx context.Context, chain *web3.Chain) *web3.GasPrices {
+	gas, ok := m.gas[chain.Key]
+	if !ok {
+		var err error
+		if gas, err = web3.FetchGasPrices(ctx, chain); err != nil {
+			log.Printf("Failed to fetch %s gas fees for digest: %v", chain.Name, err)
+		}
+		m.gas[chain.Key] = gas
+	}
+	return gas
+}
+
+// digestText formats the Markdown market digest of a watchlist: each coin's price and 24h
+// move, the biggest movers and the gas fee of a chain. Coins that can't be fetched are
+// still listed, so the digest always shows what the chat watches.
+func digestText(l i18n.Localizer, market *marketData, watchlist []models.WatchlistCoin, currency string, chain *web3.Chain, now time.Time) string {
+	ctx := context.Background()
+	lines := []string{l.T("watchlist.digest_title", now.Format("2006-01-02")), ""}
+
+	var fetched []*web3.Coin
+	for _, item := range watchlist {
+		symbol := tgbotapi.EscapeText(tgbotapi.ModeMarkdown, item.Symbol)
+		coin := market.coin(ctx, item.CoinID)
+		if coin == nil {
+			lines = append(lines, l.T("watchlist.digest_unavailable", symbol))
+			continue
+		}
+		fetched = append(fetched, coin)
+		quote, quoteCurrency := coin.Quote(currency)
+		lines = append(lines, l.T("watchlist.digest_line", symbol, web3.FormatMoney(quoteCurrency, quote.Price), web3.FormatChange(coin.Change24h)))
+	}
+
+	var footer []string
+	if len(fetched) > 1 {
+		sort.SliceStable(fetched, func(i, j int) bool { return math.Abs(fetched[i].Change24h) > math.Abs(fetched[j].Change24h) })
+		var movers []string
+		for _, coin := range fetched[:min(len(fetched), maxMovers)] {
+			movers = append(movers, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, strings.ToUpper(coin.Symbol))+" "+web3.FormatChange(coin.Change24h))
+		}
+		footer = append(footer, l.T("watchlist.digest_movers", strings.Join(movers, ", ")))
+	}
+
+	if gas := market.gasPrices(ctx, chain); gas != nil {
+		footer = append(footer, l.T("watchlist.digest_gas", chain.Name, web3.FormatGwei(gas.Standard)))
+	}
+	if len(footer) > 0 {
+		lines = append(append(lines, ""), footer...)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,107 @@
+package watchlist
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+	"github.com/philip-857.bit/byb-bot/internal/web3/pricefake"
+)
+
+func TestDue(t *testing.T) {
+	utc := func(s string) time.Time {
+		t.Helper()
+		at, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return at
+	}
+	tests := []struct {
+		name       string
+		at, zone   string
+		lastSentOn string
+		now        time.Time
+		date       string // "" if the digest isn't due
+	}{
+		{"before the time", "08:00", "UTC", "", utc("2024-05-01T07:59:59Z"), ""},
+		{"on the minute", "08:00", "UTC", "", utc("2024-05-01T08:00:00Z"), "2024-05-01"},
+		{"within the grace", "08:00", "UTC", "2024-04-30", utc("2024-05-01T08:59:00Z"), "2024-05-01"},
+		{"after the grace", "08:00", "UTC", "", utc("2024-05-01T09:00:00Z"), ""},
+		{"already sent today", "08:00", "UTC", "2024-05-01", utc("2024-05-01T08:30:00Z"), ""},
+		{"unparsable time", "8am", "UTC", "", utc("2024-05-01T08:00:00Z"), ""},
+		{"unknown zone counts as UTC", "08:00", "Mars/Olympus", "", utc("2024-05-01T08:10:00Z"), "2024-05-01"},
+		// Tokyo is a day ahead of UTC here, so the digest is for the next day there.
+		{"zone ahead across midnight", "08:00", "Asia/Tokyo", "2024-05-01", utc("2024-05-01T23:30:00Z"), "2024-05-02"},
+		{"zone ahead, not yet", "08:00", "Asia/Tokyo", "2024-05-01", utc("2024-05-01T22:30:00Z"), ""},
+		// Los Angeles is still on the previous day.
+		{"zone behind across midnight", "23:30", "America/Los_Angeles", "2024-04-30", utc("2024-05-02T06:45:00Z"), "2024-05-01"},
+		// On the day Paris moves to summer time, 08:00 is 06:00 UTC rather than 07:00.
+		{"DST starts", "08:00", "Europe/Paris", "", utc("2024-03-31T06:00:00Z"), "2024-03-31"},
+		{"DST starts, not yet", "08:00", "Europe/Paris", "", utc("2024-03-31T05:59:00Z"), ""},
+		// 02:30 doesn't exist that day, so the digest goes out when the clock reaches 03:30.
+		{"skipped time, not yet", "02:30", "Europe/Paris", "", utc("2024-03-31T01:00:00Z"), ""},
+		{"skipped time", "02:30", "Europe/Paris", "", utc("2024-03-31T01:30:00Z"), "2024-03-31"},
+		// 02:30 happens twice when summer time ends; the digest goes out the second time.
+		{"repeated time, first", "02:30", "Europe/Paris", "", utc("2024-10-27T00:30:00Z"), ""},
+		{"repeated time, second", "02:30", "Europe/Paris", "", utc("2024-10-27T01:30:00Z"), "2024-10-27"},
+		{"DST ends", "08:00", "Europe/Paris", "", utc("2024-10-27T07:00:00Z"), "2024-10-27"},
+	}
+	for _, tt := range tests {
+		schedule := &models.DigestSchedule{Time: tt.at, Timezone: tt.zone, LastSentOn: tt.lastSentOn}
+		date, ok := due(schedule, tt.now)
+		if ok != (tt.date != "") || (ok && date != tt.date) {
+			t.Errorf("%s: due at %s = %q, %v, want %q", tt.name, tt.now.Format(time.RFC3339), date, ok, tt.date)
+		}
+	}
+}
+
+func TestDigestText(t *testing.T) {
+	pricefake.Serve(t,
+		web3.Coin{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Change24h: 1.5, Quotes: map[string]web3.Quote{"usd": {Price: 60000}, "eur": {Price: 50000}}},
+		web3.Coin{ID: "ethereum", Symbol: "eth", Name: "Ethereum", Change24h: -4, Quotes: map[string]web3.Quote{"usd": {Price: 2000}}},
+		web3.Coin{ID: "wrapped_coin", Symbol: "w_coin", Name: "Wrapped", Change24h: 0.1, Quotes: map[string]web3.Quote{"usd": {Price: 1}}},
+	)
+	ethereum := web3.Chains[0]
+	market := newMarketData()
+	// Gas comes from the market data, so the digest doesn't need a node.
+	market.gas[ethereum.Key] = &web3.GasPrices{Chain: ethereum, Standard: 12.5}
+
+	watchlist := []models.WatchlistCoin{
+		{CoinID: "bitcoin", Symbol: "btc"},
+		{CoinID: "delisted", Symbol: "gone"},
+		{CoinID: "ethereum", Symbol: "eth"},
+		{CoinID: "wrapped_coin", Symbol: "w_coin"},
+	}
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	got := digestText(i18n.New("en"), market, watchlist, "eur", ethereum, now)
+
+	lines := strings.Split(got, "\n")
+	want := []string{
+		"📊 *Market digest* · 2024-05-01",
+		"",
+		"• *btc* €50,000.00 🔺 +1.50%",
+		// A coin that failed to load is still listed, in its place.
+		"• *gone* price unavailable",
+		// Without a euro quote, the price is shown in dollars.
+		"• *eth* $2,000.00 🔻 -4.00%",
+		`• *w\_coin* $1.00 🔺 +0.10%`,
+		"",
+		`🚀 Top movers: ETH 🔻 -4.00%, BTC 🔺 +1.50%, W\_COIN 🔺 +0.10%`,
+		"⛽ Ethereum gas: 12.5 Gwei",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("digest:\n%s", got)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+	if _, ok := market.coins["delisted"]; !ok {
+		t.Error("the failed lookup wasn't remembered for the other digests")
+	}
+}
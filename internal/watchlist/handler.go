@@ -0,0 +1,295 @@
+package watchlist
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	// Time zone names must work on hosts without a zoneinfo database.
+	_ "time/tzdata"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+// maxWatchlistCoins keeps digests short enough to read at a glance.
+const maxWatchlistCoins = 20
+
+// HandleWatchCommand shows or changes the chat's watchlist:
+//
+//	/watch                  show the watchlist and when the digest is posted
+//	/watch add sol arb op   add coins
+//	/watch remove op        remove coins
+//
+// Anyone can change the watchlist in private chat; in groups it takes an admin.
+func HandleWatchCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	fields := strings.Fields(strings.ToLower(message.CommandArguments()))
+	if len(fields) == 0 {
+		showWatchlist(bot, db, l, message.Chat.ID)
+		return
+	}
+	if (fields[0] != "add" && fields[0] != "remove") || len(fields) < 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.usage")))
+		return
+	}
+	if !message.Chat.IsPrivate() && !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	watchlist, err := db.GetWatchlist(context.Background(), message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load watchlist of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.load_error")))
+		return
+	}
+	if fields[0] == "add" {
+		addCoins(bot, db, l, message, watchlist, fields[1:])
+	} else {
+		removeCoins(bot, db, l, message, watchlist, fields[1:])
+	}
+}
+
+func showWatchlist(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, chatID int64) {
+	ctx := context.Background()
+	watchlist, err := db.GetWatchlist(ctx, chatID)
+	if err != nil {
+		log.Printf("Failed to load watchlist of chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("watchlist.load_error")))
+		return
+	}
+	if len(watchlist) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("watchlist.empty")+"\n\n"+l.T("watchlist.usage")))
+		return
+	}
+
+	lines := []string{l.N("watchlist.list", len(watchlist), len(watchlist), symbols(watchlist))}
+	schedule, err := db.GetDigestSchedule(ctx, chatID)
+	switch {
+	case err != nil:
+		log.Printf("Failed to load digest schedule of chat %d: %v", chatID, err)
+	case schedule == nil:
+		lines = append(lines, l.T("watchlist.no_schedule"))
+	default:
+		lines = append(lines, describeSchedule(l, schedule))
+	}
+	lines = append(lines, "", l.T("watchlist.usage"))
+	bot.Send(tgbotapi.NewMessage(chatID, strings.Join(lines, "\n")))
+}
+
+// addCoins adds the coins the user named, reporting the ones it doesn't know or that were already watched.
+func addCoins(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, watchlist []models.WatchlistCoin, inputs []string) {
+	ctx := context.Background()
+	watched := make(map[string]bool, len(watchlist))
+	for _, coin := range watchlist {
+		watched[coin.CoinID] = true
+	}
+
+	var added []models.WatchlistCoin
+	var already, unknown []string
+	now := time.Now()
+	for _, input := range inputs {
+		id := web3.Coins.Resolve(input).ID
+		switch {
+		case id == "":
+			unknown = append(unknown, input)
+			continue
+		case watched[id]:
+			already = append(already, strings.ToUpper(input))
+			continue
+		}
+		watched[id] = true
+
+		// The symbol is only for display; the digest looks coins up by ID.
+		symbol := strings.ToUpper(input)
+		if coin, err := web3.Prices.Coin(ctx, id); err == nil && coin.Symbol != "" {
+			symbol = strings.ToUpper(coin.Symbol)
+		}
+		added = append(added, models.WatchlistCoin{
+			ChatID:    message.Chat.ID,
+			CoinID:    id,
+			Symbol:    symbol,
+			AddedBy:   message.From.ID,
+			CreatedAt: now,
+		})
+	}
+
+	if len(watchlist)+len(added) > maxWatchlistCoins {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.limit", maxWatchlistCoins)))
+		return
+	}
+	if len(added) > 0 {
+		if err := db.AddWatchlistCoins(ctx, added); err != nil {
+			log.Printf("Failed to add coins to watchlist of chat %d: %v", message.Chat.ID, err)
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.save_error")))
+			return
+		}
+	}
+
+	var lines []string
+	if len(added) > 0 {
+		lines = append(lines, l.T("watchlist.added", symbols(added)))
+	}
+	if len(already) > 0 {
+		lines = append(lines, l.T("watchlist.already", strings.Join(already, ", ")))
+	}
+	if len(unknown) > 0 {
+		lines = append(lines, l.T("watchlist.unknown", strings.Join(unknown, ", ")))
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, strings.Join(lines, "\n")))
+}
+
+// removeCoins removes the coins the user named. Inputs are matched against the symbols and
+// IDs on the watchlist first, so coins can be removed even if the resolver would pick another.
+func removeCoins(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, message *tgbotapi.Message, watchlist []models.WatchlistCoin, inputs []string) {
+	var ids []string
+	for _, input := range inputs {
+		id := ""
+		for _, coin := range watchlist {
+			if strings.EqualFold(coin.Symbol, input) || coin.CoinID == input {
+				id = coin.CoinID
+				break
+			}
+		}
+		if id == "" {
+			id = web3.Coins.Resolve(input).ID
+		}
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.not_watched")))
+		return
+	}
+
+	removed, err := db.RemoveWatchlistCoins(context.Background(), message.Chat.ID, ids)
+	switch {
+	case err != nil:
+		log.Printf("Failed to remove coins from watchlist of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.save_error")))
+	case removed == 0:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.not_watched")))
+	default:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.N("watchlist.removed", removed, removed)))
+	}
+}
+
+// HandleDigestCommand posts the market digest of the chat's watchlist, or schedules it:
+//
+//	/digest                                 post the digest now
+//	/digest 08:00 [timezone] [chain]        post it every day at 08:00, UTC and Ethereum gas by default
+//	/digest off                             stop the daily digest
+//
+// Anyone can post the digest; scheduling it in a group takes an admin.
+func HandleDigestCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 {
+		postDigestNow(bot, db, l, message.Chat.ID)
+		return
+	}
+	if len(fields) > 3 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.digest_usage")))
+		return
+	}
+	if !message.Chat.IsPrivate() && !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	ctx := context.Background()
+	if strings.EqualFold(fields[0], "off") && len(fields) == 1 {
+		if err := db.RemoveDigestSchedule(ctx, message.Chat.ID); err != nil {
+			log.Printf("Failed to remove digest schedule of chat %d: %v", message.Chat.ID, err)
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.save_error")))
+			return
+		}
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.digest_off")))
+		return
+	}
+
+	at, err := time.Parse("15:04", fields[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.digest_usage")))
+		return
+	}
+	schedule := &models.DigestSchedule{
+		ChatID:    message.Chat.ID,
+		Time:      at.Format("15:04"),
+		Timezone:  "UTC",
+		Chain:     web3.Chains[0].Key,
+		CreatedBy: message.From.ID,
+		UpdatedAt: time.Now(),
+	}
+	// Chain keys never contain a slash, so whatever isn't a chain is taken as a time zone.
+	for _, field := range fields[1:] {
+		if chain, ok := web3.LookupChain(field); ok {
+			schedule.Chain = chain.Key
+			continue
+		}
+		loc, err := loadLocation(field)
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.digest_bad_timezone", field)))
+			return
+		}
+		schedule.Timezone = loc.String()
+	}
+	// A digest set for a time that just passed starts tomorrow rather than right away.
+	if date, ok := due(schedule, time.Now()); ok {
+		schedule.LastSentOn = date
+	}
+
+	if err := db.SaveDigestSchedule(ctx, schedule); err != nil {
+		log.Printf("Failed to save digest schedule of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("watchlist.save_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, describeSchedule(l, schedule)))
+}
+
+// postDigestNow answers /digest with the chat's digest.
+func postDigestNow(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, chatID int64) {
+	watchlist, err := db.GetWatchlist(context.Background(), chatID)
+	if err != nil {
+		log.Printf("Failed to load watchlist of chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("watchlist.load_error")))
+		return
+	}
+	if len(watchlist) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("watchlist.empty")+"\n\n"+l.T("watchlist.usage")))
+		return
+	}
+
+	// Without a schedule, the digest shows Ethereum gas and today's date in UTC.
+	chain := web3.Chains[0]
+	loc := time.UTC
+	if schedule, err := db.GetDigestSchedule(context.Background(), chatID); err == nil && schedule != nil {
+		chain, loc = scheduleChain(schedule), scheduleLocation(schedule)
+	}
+
+	bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	text := digestText(l, newMarketData(), watchlist, web3.ChatCurrency(db, chatID), chain, time.Now().In(loc))
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// describeSchedule tells when a chat's digest is posted.
+func describeSchedule(l i18n.Localizer, schedule *models.DigestSchedule) string {
+	return l.T("watchlist.schedule", schedule.Time, schedule.Timezone, scheduleChain(schedule).Name)
+}
+
+// symbols lists the symbols of watchlist coins.
+func symbols(coins []models.WatchlistCoin) string {
+	names := make([]string, len(coins))
+	for i, coin := range coins {
+		names[i] = coin.Symbol
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,92 @@
+package web3
+
+import (
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrInvalidAddress is returned for input that isn't a 20-byte hex address.
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrBadChecksum is returned for a mixed-case address whose EIP-55 checksum doesn't match,
+	// which usually means it was mistyped.
+	ErrBadChecksum = errors.New("address checksum mismatch")
+)
+
+var addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// ParseAddress validates an address and returns it in its checksummed form. All-lowercase and
+// all-uppercase addresses carry no checksum and are accepted as they are; mixed-case ones must match.
+func ParseAddress(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !addressPattern.MatchString(s) {
+		return "", ErrInvalidAddress
+	}
+	checksummed := ChecksumAddress(s)
+	hexPart := s[2:]
+	if hexPart != strings.ToLower(hexPart) && hexPart != strings.ToUpper(hexPart) && s != checksummed {
+		return "", ErrBadChecksum
+	}
+	return checksummed, nil
+}
+
+// IsAddress reports whether s looks like an address, without checking its checksum.
+func IsAddress(s string) bool {
+	return addressPattern.MatchString(s)
+}
+
+// ChecksumAddress formats an address with the EIP-55 mixed-case checksum.
+func ChecksumAddress(addr string) string {
+	lower := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X"))
+	hash := hex.EncodeToString(keccak256([]byte(lower)))
+
+	out := []byte("0x" + lower)
+	for i := range lower {
+		if lower[i] >= 'a' && hash[i] >= '8' {
+			out[i+2] = lower[i] - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+// ShortAddress abbreviates an address for display, such as 0x1234…abcd.
+func ShortAddress(addr string) string {
+	if len(addr) < 12 {
+		return addr
+	}
+	return addr[:6] + "…" + addr[len(addr)-4:]
+}
+
+// keccak256 hashes data the way Ethereum does.
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// selector returns the 4-byte function selector of a Solidity signature such as "balanceOf(address)".
+func selector(signature string) []byte {
+	return keccak256([]byte(signature))[:4]
+}
+
+// encodeAddress ABI-encodes an address as a 32-byte word.
+func encodeAddress(addr string) []byte {
+	word := make([]byte, 32)
+	b, _ := hex.DecodeString(strings.TrimPrefix(strings.ToLower(addr), "0x"))
+	copy(word[32-len(b):], b)
+	return word
+}
+
+// decodeAddress reads an address from the last 20 bytes of an ABI word.
+func decodeAddress(word []byte) string {
+	if len(word) < 32 {
+		return ""
+	}
+	return ChecksumAddress(hex.EncodeToString(word[12:32]))
+}
@@ -0,0 +1,146 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// priceTTL is how long a price is served from memory before we ask the provider again.
+	priceTTL = time.Minute
+	// priceMaxStale is how old a cached price may get and still stand in for a failed lookup.
+	priceMaxStale = 30 * time.Minute
+)
+
+// Cache is a PriceProvider that remembers answers for a while, so a burst of "/p eth"
+// costs one upstream call. Concurrent lookups of the same coin share one call, and if
+// the upstream fails, the last known price is served as long as it isn't too old.
+type Cache struct {
+	provider PriceProvider
+	ttl      time.Duration
+	maxStale time.Duration
+
+	mu          sync.Mutex
+	entries     map[string]cacheEntry
+	inflight    map[string]*lookup
+	histories   map[string]historyEntry
+	candles     map[string]candleEntry
+	marketLists map[string]marketsEntry
+	marketCalls map[string]*marketsLookup
+}
+
+type cacheEntry struct {
+	coin      Coin
+	fetchedAt time.Time
+}
+
+type historyEntry struct {
+	points    []PricePoint
+	fetchedAt time.Time
+}
+
+type candleEntry struct {
+	candles   []PriceCandle
+	fetchedAt time.Time
+}
+
+// lookup is an upstream call that other callers can wait on.
+type lookup struct {
+	done chan struct{}
+	coin *Coin
+	err  error
+}
+
+// NewCache wraps a provider with a cache that keeps answers for ttl and falls back to
+// answers up to maxStale old when the provider fails.
+func NewCache(provider PriceProvider, ttl, maxStale time.Duration) *Cache {
+	return &Cache{
+		provider:    provider,
+		ttl:         ttl,
+		maxStale:    maxStale,
+		entries:     make(map[string]cacheEntry),
+		inflight:    make(map[string]*lookup),
+		histories:   make(map[string]historyEntry),
+		candles:     make(map[string]candleEntry),
+		marketLists: make(map[string]marketsEntry),
+		marketCalls: make(map[string]*marketsLookup),
+	}
+}
+
+// Name implements PriceProvider.
+func (c *Cache) Name() string { return "cache(" + c.provider.Name() + ")" }
+
+// Coin implements PriceProvider.
+func (c *Cache) Coin(ctx context.Context, coinID string) (*Coin, error) {
+	key := strings.ToLower(coinID)
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && time.Since(entry.fetchedAt) < c.ttl {
+		c.mu.Unlock()
+		coin := entry.coin
+		return &coin, nil
+	}
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &lookup{done: make(chan struct{})}
+		c.inflight[key] = call
+		// The upstream call outlives the caller that started it, since others may be waiting on it.
+		go c.fetch(context.WithoutCancel(ctx), key, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	coin := *call.coin
+	return &coin, nil
+}
+
+// fetch asks the provider for a coin and stores the answer, or falls back to the last known one.
+func (c *Cache) fetch(ctx context.Context, key string, call *lookup) {
+	coin, err := c.provider.Coin(ctx, key)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(call.done)
+	delete(c.inflight, key)
+
+	if err == nil {
+		if coin.UpdatedAt.IsZero() {
+			coin.UpdatedAt = now
+		}
+		c.prune(now)
+		c.entries[key] = cacheEntry{coin: *coin, fetchedAt: now}
+		call.coin = coin
+		return
+	}
+
+	// A coin that doesn't exist stays that way, but any other failure is worth papering over.
+	entry, ok := c.entries[key]
+	if ok && !errors.Is(err, ErrCoinNotFound) && now.Sub(entry.fetchedAt) < c.maxStale {
+		log.Printf("Serving cached price of %q from %s after lookup failed: %v", key, entry.coin.UpdatedAt.Format(time.RFC3339), err)
+		stale := entry.coin
+		call.coin = &stale
+		return
+	}
+	call.err = err
+}
+
+// prune drops entries too old to be served, so the cache can't grow without bound. Callers hold c.mu.
+func (c *Cache) prune(now time.Time) {
+	for k, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.maxStale {
+			delete(c.entries, k)
+		}
+	}
+}
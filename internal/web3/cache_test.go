@@ -0,0 +1,170 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testEther = Coin{ID: "ethereum", Symbol: "eth", Name: "Ethereum", Quotes: map[string]Quote{"usd": {Price: 2000}}}
+
+// blockingProvider counts its lookups and holds each one until released, then answers
+// with its coin or fails with its error.
+type blockingProvider struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+
+	mu   sync.Mutex
+	coin Coin
+	err  error
+}
+
+func newBlockingProvider(coin Coin) *blockingProvider {
+	return &blockingProvider{started: make(chan struct{}, 100), release: make(chan struct{}), coin: coin}
+}
+
+func (p *blockingProvider) Name() string { return "blocking" }
+
+func (p *blockingProvider) Coin(ctx context.Context, coinID string) (*Coin, error) {
+	p.calls.Add(1)
+	p.started <- struct{}{}
+	<-p.release
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	coin := p.coin
+	return &coin, nil
+}
+
+// failWith makes the lookups after this one fail with err.
+func (p *blockingProvider) failWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// age makes the cached answer for a coin look as if it was fetched d ago.
+func (c *Cache) age(coinID string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[coinID]
+	entry.fetchedAt = time.Now().Add(-d)
+	c.entries[coinID] = entry
+}
+
+func TestCacheCoalescesLookups(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		p := newBlockingProvider(testEther)
+		if fail {
+			p.failWith(errors.New("upstream down"))
+		}
+		c := NewCache(p, priceTTL, priceMaxStale)
+
+		const callers = 20
+		var wg, asking sync.WaitGroup
+		results := make(chan error, callers)
+		for range callers {
+			wg.Add(1)
+			asking.Add(1)
+			go func() {
+				defer wg.Done()
+				asking.Done()
+				coin, err := c.Coin(context.Background(), "ethereum")
+				if err == nil && coin.Quotes["usd"].Price != 2000 {
+					err = errors.New("wrong price")
+				}
+				results <- err
+			}()
+		}
+		// Every caller joins the held lookup, so even a failure is fetched once. asking only
+		// says they're about to ask, so give them a moment to get there.
+		<-p.started
+		asking.Wait()
+		time.Sleep(20 * time.Millisecond)
+		close(p.release)
+		wg.Wait()
+		close(results)
+
+		if n := p.calls.Load(); n != 1 {
+			t.Errorf("%d callers made %d upstream calls, want 1", callers, n)
+		}
+		for err := range results {
+			if (err != nil) != fail {
+				t.Errorf("fail=%v: a caller got %v", fail, err)
+			}
+		}
+	}
+}
+
+func TestCacheCallerGivesUp(t *testing.T) {
+	p := newBlockingProvider(testEther)
+	c := NewCache(p, priceTTL, priceMaxStale)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := c.Coin(ctx, "ethereum")
+		done <- err
+	}()
+	<-p.started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("a cancelled caller got %v", err)
+	}
+
+	// The lookup carries on for whoever asks next.
+	close(p.release)
+	if coin, err := c.Coin(context.Background(), "ethereum"); err != nil || coin.Quotes["usd"].Price != 2000 || p.calls.Load() != 1 {
+		t.Errorf("Coin after the cancel = %+v, %v after %d calls", coin, err, p.calls.Load())
+	}
+}
+
+func TestCacheServesStale(t *testing.T) {
+	p := newBlockingProvider(testEther)
+	close(p.release)
+	c := NewCache(p, priceTTL, priceMaxStale)
+	ctx := context.Background()
+
+	if _, err := c.Coin(ctx, "ethereum"); err != nil {
+		t.Fatal(err)
+	}
+	p.failWith(errors.New("upstream down"))
+
+	// Within the TTL the upstream isn't asked.
+	if _, err := c.Coin(ctx, "ethereum"); err != nil || p.calls.Load() != 1 {
+		t.Fatalf("a fresh lookup = %v after %d calls", err, p.calls.Load())
+	}
+
+	// After it, the failed lookup is papered over with the last price...
+	c.age("ethereum", priceTTL+time.Second)
+	coin, err := c.Coin(ctx, "ethereum")
+	if err != nil || coin.Quotes["usd"].Price != 2000 || p.calls.Load() != 2 {
+		t.Fatalf("a stale lookup = %+v, %v after %d calls", coin, err, p.calls.Load())
+	}
+	// ...right up to priceMaxStale...
+	c.age("ethereum", priceMaxStale-time.Second)
+	if _, err := c.Coin(ctx, "ethereum"); err != nil {
+		t.Errorf("a price just short of priceMaxStale wasn't served: %v", err)
+	}
+	// ...but not past it.
+	c.age("ethereum", priceMaxStale)
+	if _, err := c.Coin(ctx, "ethereum"); err == nil {
+		t.Error("a price priceMaxStale old was served")
+	}
+
+	// A coin that's gone isn't served from the cache, however fresh.
+	c = NewCache(p, priceTTL, priceMaxStale)
+	p.failWith(nil)
+	c.Coin(ctx, "ethereum")
+	p.failWith(ErrCoinNotFound)
+	c.age("ethereum", priceTTL+time.Second)
+	if _, err := c.Coin(ctx, "ethereum"); !errors.Is(err, ErrCoinNotFound) {
+		t.Errorf("a delisted coin: err = %v, want ErrCoinNotFound", err)
+	}
+}
@@ -0,0 +1,76 @@
+package web3
+
+import "strings"
+
+// Chain is an EVM network the bot can read gas fees and on-chain data from.
+type Chain struct {
+	// Key is how users name the chain in commands, such as "base".
+	Key     string
+	Name    string
+	ChainID int64
+	// NativeCoinID is the price provider ID of the coin gas is paid in.
+	NativeCoinID string
+	NativeSymbol string
+	// DefaultRPC is a public endpoint, used unless RPC_URLS configures another one.
+	DefaultRPC string
+	// Explorer is the block explorer, for links to addresses and transactions.
+	Explorer string
+	// Aliases are other names users type for the chain.
+	Aliases []string
+}
+
+// Chains lists the supported networks. Ethereum comes first and is the default.
+var Chains = []*Chain{
+	{Key: "ethereum", Name: "Ethereum", ChainID: 1, NativeCoinID: "ethereum", NativeSymbol: "ETH",
+		DefaultRPC: "https://ethereum-rpc.publicnode.com", Explorer: "https://etherscan.io", Aliases: []string{"eth", "mainnet"}},
+	{Key: "polygon", Name: "Polygon", ChainID: 137, NativeCoinID: "polygon-ecosystem-token", NativeSymbol: "POL",
+		DefaultRPC: "https://polygon-bor-rpc.publicnode.com", Explorer: "https://polygonscan.com", Aliases: []string{"matic", "pol"}},
+	{Key: "arbitrum", Name: "Arbitrum One", ChainID: 42161, NativeCoinID: "ethereum", NativeSymbol: "ETH",
+		DefaultRPC: "https://arbitrum-one-rpc.publicnode.com", Explorer: "https://arbiscan.io", Aliases: []string{"arb"}},
+	{Key: "optimism", Name: "OP Mainnet", ChainID: 10, NativeCoinID: "ethereum", NativeSymbol: "ETH",
+		DefaultRPC: "https://optimism-rpc.publicnode.com", Explorer: "https://optimistic.etherscan.io", Aliases: []string{"op"}},
+	{Key: "base", Name: "Base", ChainID: 8453, NativeCoinID: "ethereum", NativeSymbol: "ETH",
+		DefaultRPC: "https://base-rpc.publicnode.com", Explorer: "https://basescan.org"},
+	{Key: "bsc", Name: "BNB Smart Chain", ChainID: 56, NativeCoinID: "binancecoin", NativeSymbol: "BNB",
+		DefaultRPC: "https://bsc-rpc.publicnode.com", Explorer: "https://bscscan.com", Aliases: []string{"bnb", "binance"}},
+	{Key: "avalanche", Name: "Avalanche C-Chain", ChainID: 43114, NativeCoinID: "avalanche-2", NativeSymbol: "AVAX",
+		DefaultRPC: "https://avalanche-c-chain-rpc.publicnode.com", Explorer: "https://snowtrace.io", Aliases: []string{"avax"}},
+}
+
+// LookupChain finds a chain by key or alias. An empty name means Ethereum.
+func LookupChain(name string) (*Chain, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return Chains[0], true
+	}
+	for _, c := range Chains {
+		if c.Key == name {
+			return c, true
+		}
+		for _, alias := range c.Aliases {
+			if alias == name {
+				return c, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ChainKeys lists the keys of the supported chains, for usage messages.
+func ChainKeys() []string {
+	keys := make([]string, len(Chains))
+	for i, c := range Chains {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// RPC returns a client for the chain's JSON-RPC endpoint, honouring the RPC_URLS config.
+func (c *Chain) RPC() *RPCClient {
+	if Cfg != nil {
+		if url, ok := Cfg.RPCURLs[c.Key]; ok && url != "" {
+			return NewRPCClient(url)
+		}
+	}
+	return NewRPCClient(c.DefaultRPC)
+}
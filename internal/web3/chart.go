@@ -0,0 +1,196 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/chart"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+)
+
+// chartPrefix is the callback data prefix of the chart "did you mean" buttons, followed by "<period>_<currency>_<coin ID>".
+// Buttons for candlestick charts use candlePrefix instead.
+const (
+	chartPrefix  = "chart_"
+	candlePrefix = "candles_"
+)
+
+// defaultPeriod is charted when /chart is given no period.
+const defaultPeriod = "7d"
+
+// chartPeriods maps the periods /chart accepts to days of history.
+var chartPeriods = map[string]int{
+	"1d":  1,
+	"7d":  7,
+	"30d": 30,
+	"1y":  365,
+}
+
+// chartStyles maps the styles /chart accepts to whether they draw candlesticks.
+var chartStyles = map[string]bool{
+	"line":    false,
+	"candle":  true,
+	"candles": true,
+}
+
+// HandleChartCommand replies with a price chart: /chart <coin> [1d|7d|30d|1y] [currency] [line|candles].
+func HandleChartCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	// The period, currency and style can come in any order after the coin.
+	fields := strings.Fields(strings.ToLower(message.CommandArguments()))
+	period, currency, style := "", "", ""
+	for len(fields) > 1 {
+		last := fields[len(fields)-1]
+		if _, ok := chartPeriods[last]; ok && period == "" {
+			period = last
+		} else if IsCurrency(last) && currency == "" {
+			currency = last
+		} else if _, ok := chartStyles[last]; ok && style == "" {
+			style = last
+		} else {
+			break
+		}
+		fields = fields[:len(fields)-1]
+	}
+	candles := chartStyles[style]
+	coinName := strings.Join(fields, " ")
+	if coinName == "" {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.chart_usage")))
+		return
+	}
+	if period == "" {
+		period = defaultPeriod
+	}
+	if currency == "" {
+		currency = ChatCurrency(db, message.Chat.ID)
+	}
+
+	res := Coins.Resolve(coinName)
+	switch {
+	case res.ID != "":
+		sendChart(bot, l, message.Chat.ID, res.ID, coinName, currency, period, candles)
+	case len(res.Suggestions) > 0:
+		prefix := chartPrefix
+		if candles {
+			prefix = candlePrefix
+		}
+		msg := tgbotapi.NewMessage(message.Chat.ID, l.T("web3.did_you_mean", coinName))
+		msg.ReplyMarkup = suggestionKeyboard(res.Suggestions, prefix+period+"_"+currency+"_")
+		bot.Send(msg)
+	default:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.price_not_found", coinName)))
+	}
+}
+
+// sendChart renders and sends the line or candlestick chart of a coin. coinName is what the
+// user typed, for error messages.
+func sendChart(bot *tgbotapi.BotAPI, l i18n.Localizer, chatID int64, coinID, coinName, currency, period string, candles bool) {
+	days, ok := chartPeriods[period]
+	if !ok {
+		return
+	}
+	// Rendering takes a moment, so show that something is happening.
+	bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatUploadPhoto))
+
+	ctx := context.Background()
+	coin, err := Prices.Coin(ctx, coinID)
+	if err != nil {
+		log.Printf("Failed to fetch coin %q for chart: %v", coinID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, PriceErrorText(l, coinName, err)))
+		return
+	}
+
+	c, err := loadChart(ctx, coin.Symbol, coinID, currency, period, days, candles)
+	if err != nil {
+		log.Printf("Failed to fetch %s history of %q: %v", period, coinID, err)
+		if errors.Is(err, ErrNoHistory) {
+			bot.Send(tgbotapi.NewMessage(chatID, l.T("web3.chart_unavailable")))
+		} else {
+			bot.Send(tgbotapi.NewMessage(chatID, PriceErrorText(l, coinName, err)))
+		}
+		return
+	}
+
+	png, err := chart.Render(c)
+	if err != nil {
+		log.Printf("Failed to render chart of %q: %v", coinID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("web3.chart_no_data", coinName)))
+		return
+	}
+
+	low, high := c.Range()
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: coinID + ".png", Bytes: png})
+	photo.Caption = l.T("web3.chart_caption",
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, coin.Name), tgbotapi.EscapeText(tgbotapi.ModeMarkdown, strings.ToUpper(coin.Symbol)), period,
+		FormatMoney(currency, high), FormatMoney(currency, low), FormatChange(c.Change()))
+	photo.ParseMode = "Markdown"
+	if _, err := bot.Send(photo); err != nil {
+		log.Printf("Failed to send chart: %v", err)
+	}
+}
+
+// loadChart fetches the price history or the candles of a coin and describes its chart.
+func loadChart(ctx context.Context, symbol, coinID, currency, period string, days int, candles bool) (chart.Chart, error) {
+	if candles {
+		provider, ok := Prices.(OHLCProvider)
+		if !ok {
+			return chart.Chart{}, ErrNoHistory
+		}
+		history, err := provider.OHLC(ctx, coinID, currency, days)
+		if err != nil {
+			return chart.Chart{}, err
+		}
+		return CandleChart(symbol, currency, period, history), nil
+	}
+
+	provider, ok := Prices.(HistoryProvider)
+	if !ok {
+		return chart.Chart{}, ErrNoHistory
+	}
+	points, err := provider.History(ctx, coinID, currency, days)
+	if err != nil {
+		return chart.Chart{}, err
+	}
+	return PriceChart(symbol, currency, period, points), nil
+}
+
+// PriceChart describes the line chart of a price history.
+func PriceChart(symbol, currency, period string, points []PricePoint) chart.Chart {
+	c := newChart(symbol, currency, period)
+	for _, p := range points {
+		c.Points = append(c.Points, chart.Point{Time: p.Time, Value: p.Price})
+	}
+	return c
+}
+
+// CandleChart describes the candlestick chart of a price history.
+func CandleChart(symbol, currency, period string, candles []PriceCandle) chart.Chart {
+	c := newChart(symbol, currency, period)
+	for _, k := range candles {
+		c.Candles = append(c.Candles, chart.Candle{Time: k.Time, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close})
+	}
+	return c
+}
+
+// newChart describes a chart without data. Axis labels are plain numbers because the chart
+// font lacks some currency symbols; the currency is in the title instead.
+func newChart(symbol, currency, period string) chart.Chart {
+	c := chart.Chart{
+		Title:       fmt.Sprintf("%s/%s · %s", strings.ToUpper(symbol), strings.ToUpper(currency), period),
+		FormatValue: formatAmount,
+		TimeLayout:  "Jan 2",
+	}
+	switch period {
+	case "1d":
+		c.TimeLayout = "15:04"
+	case "1y":
+		c.TimeLayout = "Jan 2006"
+	}
+	return c
+}
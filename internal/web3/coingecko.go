@@ -0,0 +1,174 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// httpTimeout bounds every call to a price API so a slow upstream can't pile up goroutines.
+const httpTimeout = 10 * time.Second
+
+// CoinGecko API roots. Pro keys only work on the pro root, and demo keys only on the public one.
+const (
+	coinGeckoURL    = "https://api.coingecko.com/api/v3"
+	coinGeckoProURL = "https://pro-api.coingecko.com/api/v3"
+)
+
+// CoinGecko fetches prices from the CoinGecko API.
+type CoinGecko struct {
+	// BaseURL is the API root. Tests point it at an httptest server.
+	BaseURL string
+	// APIKey is an optional demo or pro key that raises the rate limit.
+	APIKey string
+	// Pro sends APIKey as a key of a paid plan.
+	Pro    bool
+	Client *http.Client
+}
+
+// NewCoinGecko returns a CoinGecko provider for the public API, or for the pro API if the
+// key belongs to a paid plan.
+func NewCoinGecko(apiKey string, pro bool) *CoinGecko {
+	baseURL := coinGeckoURL
+	if pro {
+		baseURL = coinGeckoProURL
+	}
+	return &CoinGecko{
+		BaseURL: baseURL,
+		APIKey:  apiKey,
+		Pro:     pro,
+		Client:  &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// headers returns the headers every request sends, which carry the API key if there is one.
+func (c *CoinGecko) headers() map[string]string {
+	switch {
+	case c.APIKey == "":
+		return nil
+	case c.Pro:
+		return map[string]string{"x-cg-pro-api-key": c.APIKey}
+	default:
+		return map[string]string{"x-cg-demo-api-key": c.APIKey}
+	}
+}
+
+// Name implements PriceProvider.
+func (c *CoinGecko) Name() string { return "coingecko" }
+
+// Coin implements PriceProvider using the detailed 'coins' endpoint, which includes the image and market data.
+func (c *CoinGecko) Coin(ctx context.Context, coinID string) (*Coin, error) {
+	apiURL := fmt.Sprintf("%s/coins/%s?localization=false&tickers=false&community_data=false&developer_data=false",
+		c.BaseURL, url.PathEscape(coinID))
+
+	// Define a struct to capture the detailed response from CoinGecko.
+	var result struct {
+		ID          string    `json:"id"`
+		Symbol      string    `json:"symbol"`
+		Name        string    `json:"name"`
+		LastUpdated time.Time `json:"last_updated"`
+		Image       struct {
+			Large string `json:"large"`
+		} `json:"image"`
+		MarketData struct {
+			CurrentPrice        map[string]float64 `json:"current_price"`
+			MarketCap           map[string]float64 `json:"market_cap"`
+			TotalVolume         map[string]float64 `json:"total_volume"`
+			ATH                 map[string]float64 `json:"ath"`
+			ATHChangePercentage map[string]float64 `json:"ath_change_percentage"`
+			PriceChange24h      float64            `json:"price_change_percentage_24h"`
+			PriceChange7d       float64            `json:"price_change_percentage_7d"`
+			MarketCapRank       int                `json:"market_cap_rank"`
+		} `json:"market_data"`
+	}
+	if err := getJSON(ctx, c.Client, apiURL, c.headers(), &result); err != nil {
+		return nil, fmt.Errorf("coingecko %q: %w", coinID, err)
+	}
+
+	md := result.MarketData
+	if _, ok := md.CurrentPrice[DefaultCurrency]; !ok {
+		return nil, fmt.Errorf("coingecko %q: no %s price: %w", coinID, DefaultCurrency, ErrBadResponse)
+	}
+	quotes := make(map[string]Quote)
+	for _, currency := range Currencies {
+		price, ok := md.CurrentPrice[currency]
+		if !ok {
+			continue
+		}
+		quotes[currency] = Quote{
+			Price:     price,
+			MarketCap: md.MarketCap[currency],
+			Volume24h: md.TotalVolume[currency],
+			ATH:       md.ATH[currency],
+			ATHChange: md.ATHChangePercentage[currency],
+		}
+	}
+
+	return &Coin{
+		ID:        result.ID,
+		Symbol:    result.Symbol,
+		Name:      result.Name,
+		ImageURL:  result.Image.Large,
+		Rank:      md.MarketCapRank,
+		Change24h: md.PriceChange24h,
+		Change7d:  md.PriceChange7d,
+		Quotes:    quotes,
+		UpdatedAt: result.LastUpdated,
+	}, nil
+}
+
+// maxErrorBody is how much of an error response getJSON keeps.
+const maxErrorBody = 4 << 10
+
+// statusError is returned by getJSON for HTTP status codes that have no package error.
+type statusError struct {
+	code int
+	// body is the start of the response, for APIs that explain the error in it.
+	body []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.code)
+}
+
+// getJSON performs a GET request and decodes the JSON body into dst.
+// Status codes are mapped to the package errors so providers report failures the same way.
+func getJSON(ctx context.Context, client *http.Client, apiURL string, headers map[string]string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrCoinNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &statusError{code: resp.StatusCode, body: body}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if err := json.Unmarshal(body, dst); err != nil {
+		return fmt.Errorf("failed to parse JSON: %v: %w", err, ErrBadResponse)
+	}
+	return nil
+}
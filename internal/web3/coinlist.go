@@ -0,0 +1,146 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// CoinListing is one entry of a provider's full coin list, used to resolve what members type into coin IDs.
+type CoinListing struct {
+	ID     string
+	Symbol string
+	Name   string
+	// Rank is the market cap rank, or 0 if the provider doesn't rank the coin.
+	Rank int
+	// Contracts maps a platform such as "ethereum" to the token's contract address, lowercased.
+	Contracts map[string]string
+}
+
+// CoinLister is implemented by price providers that can list every coin they know.
+type CoinLister interface {
+	CoinList(ctx context.Context) ([]CoinListing, error)
+}
+
+// errNoCoinList is returned by Failover when none of its providers can list coins.
+var errNoCoinList = errors.New("no price provider can list coins")
+
+// rankedPages is how many pages of the market cap ranking CoinGecko loads. Coins below
+// the top 1000 stay unranked, which is enough to settle every realistic symbol collision.
+const rankedPages = 4
+
+// CoinList implements CoinLister. It combines the full list, which includes contract
+// addresses, with the first pages of the market cap ranking.
+func (c *CoinGecko) CoinList(ctx context.Context) ([]CoinListing, error) {
+	var list []struct {
+		ID        string            `json:"id"`
+		Symbol    string            `json:"symbol"`
+		Name      string            `json:"name"`
+		Platforms map[string]string `json:"platforms"`
+	}
+	if err := getJSON(ctx, c.Client, c.BaseURL+"/coins/list?include_platform=true", c.headers(), &list); err != nil {
+		return nil, fmt.Errorf("coingecko coin list: %w", err)
+	}
+
+	ranks := make(map[string]int)
+	for page := 1; page <= rankedPages; page++ {
+		var markets []struct {
+			ID   string `json:"id"`
+			Rank int    `json:"market_cap_rank"`
+		}
+		apiURL := fmt.Sprintf("%s/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=250&page=%d", c.BaseURL, page)
+		if err := getJSON(ctx, c.Client, apiURL, c.headers(), &markets); err != nil {
+			// The list is still useful without ranks, collisions just become "did you mean" questions.
+			log.Printf("Failed to load CoinGecko market cap ranking page %d: %v", page, err)
+			break
+		}
+		for _, m := range markets {
+			ranks[m.ID] = m.Rank
+		}
+	}
+
+	listings := make([]CoinListing, 0, len(list))
+	for _, coin := range list {
+		listing := CoinListing{ID: coin.ID, Symbol: coin.Symbol, Name: coin.Name, Rank: ranks[coin.ID]}
+		for platform, address := range coin.Platforms {
+			if address == "" {
+				continue
+			}
+			if listing.Contracts == nil {
+				listing.Contracts = make(map[string]string)
+			}
+			listing.Contracts[platform] = strings.ToLower(address)
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// CoinList implements CoinLister using the ID map, which includes the rank and token contract.
+// Slugs are used as IDs to match what Coin expects.
+func (c *CoinMarketCap) CoinList(ctx context.Context) ([]CoinListing, error) {
+	headers := map[string]string{"X-CMC_PRO_API_KEY": c.APIKey}
+
+	var result struct {
+		Data []struct {
+			Name     string `json:"name"`
+			Symbol   string `json:"symbol"`
+			Slug     string `json:"slug"`
+			Rank     int    `json:"rank"`
+			Platform *struct {
+				Slug         string `json:"slug"`
+				TokenAddress string `json:"token_address"`
+			} `json:"platform"`
+		} `json:"data"`
+	}
+	apiURL := c.BaseURL + "/v1/cryptocurrency/map?listing_status=active&sort=cmc_rank&" + url.Values{"aux": {"platform"}}.Encode()
+	if err := getJSON(ctx, c.Client, apiURL, headers, &result); err != nil {
+		return nil, fmt.Errorf("coinmarketcap coin list: %w", err)
+	}
+
+	listings := make([]CoinListing, 0, len(result.Data))
+	for _, coin := range result.Data {
+		listing := CoinListing{ID: coin.Slug, Symbol: strings.ToLower(coin.Symbol), Name: coin.Name, Rank: coin.Rank}
+		if coin.Platform != nil && coin.Platform.TokenAddress != "" {
+			listing.Contracts = map[string]string{coin.Platform.Slug: strings.ToLower(coin.Platform.TokenAddress)}
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// CoinList implements CoinLister with the coins the provider was given.
+func (p *StaticProvider) CoinList(ctx context.Context) ([]CoinListing, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	listings := make([]CoinListing, 0, len(p.coins))
+	for _, coin := range p.coins {
+		listings = append(listings, CoinListing{ID: coin.ID, Symbol: coin.Symbol, Name: coin.Name})
+	}
+	return listings, nil
+}
+
+// CoinList implements CoinLister with the list of the first provider that can produce one.
+func (f *Failover) CoinList(ctx context.Context) ([]CoinListing, error) {
+	lastErr := errNoCoinList
+	for _, p := range f.providers {
+		lister, ok := p.(CoinLister)
+		if !ok || f.backingOff(p) {
+			continue
+		}
+		listings, err := lister.CoinList(ctx)
+		if err == nil {
+			return listings, nil
+		}
+		if errors.Is(err, ErrRateLimited) {
+			f.backOff(p)
+		}
+		log.Printf("Price provider %s failed to list coins: %v", p.Name(), err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,257 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CoinMarketCap fetches prices from the CoinMarketCap Pro API.
+// Coins are asked for by CoinGecko ID, which CoinMarketCap doesn't know, so they are matched
+// to CoinMarketCap's own IDs through its ID map: by symbol and name when the coin list has
+// the coin, and by slug otherwise.
+type CoinMarketCap struct {
+	// BaseURL is the API root. Tests point it at an httptest server.
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+	// Coins describes CoinGecko IDs by symbol and name. Without it, only coins whose
+	// CoinGecko ID is also their CoinMarketCap slug are found.
+	Coins *Resolver
+
+	mu       sync.Mutex
+	ids      *cmcIndex
+	loadedAt time.Time
+}
+
+// NewCoinMarketCap returns a CoinMarketCap provider for the Pro API.
+func NewCoinMarketCap(apiKey string) *CoinMarketCap {
+	return &CoinMarketCap{
+		BaseURL: "https://pro-api.coinmarketcap.com",
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Name implements PriceProvider.
+func (c *CoinMarketCap) Name() string { return "coinmarketcap" }
+
+// cmcQuote is a coin's market data in one currency.
+type cmcQuote struct {
+	Price            float64   `json:"price"`
+	MarketCap        float64   `json:"market_cap"`
+	Volume24h        float64   `json:"volume_24h"`
+	PercentChange24h float64   `json:"percent_change_24h"`
+	PercentChange7d  float64   `json:"percent_change_7d"`
+	LastUpdated      time.Time `json:"last_updated"`
+}
+
+// cmcCoin is a coin as the quotes endpoint returns it.
+type cmcCoin struct {
+	ID      int                 `json:"id"`
+	Name    string              `json:"name"`
+	Symbol  string              `json:"symbol"`
+	Slug    string              `json:"slug"`
+	CMCRank int                 `json:"cmc_rank"`
+	Quote   map[string]cmcQuote `json:"quote"`
+}
+
+// cmcStatus is the status block of every CoinMarketCap answer.
+type cmcStatus struct {
+	ErrorCode    int    `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// Coin implements PriceProvider using the quotes endpoint. The basic plan converts to one
+// currency per call, so the coin is fetched in the default currency and then priced in each
+// of the others. A currency that fails is left out, and shown in the default currency.
+func (c *CoinMarketCap) Coin(ctx context.Context, coinID string) (*Coin, error) {
+	id, err := c.cmcID(ctx, coinID)
+	if err != nil {
+		return nil, fmt.Errorf("coinmarketcap %q: %w", coinID, err)
+	}
+
+	entry, err := c.quote(ctx, id, DefaultCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("coinmarketcap %q: %w", coinID, err)
+	}
+	usd, ok := entry.Quote[strings.ToUpper(DefaultCurrency)]
+	if !ok {
+		return nil, fmt.Errorf("coinmarketcap %q: no %s quote: %w", coinID, DefaultCurrency, ErrBadResponse)
+	}
+	// CoinMarketCap's basic plan has no all-time high, so the ATH fields stay empty.
+	quotes := map[string]Quote{DefaultCurrency: {Price: usd.Price, MarketCap: usd.MarketCap, Volume24h: usd.Volume24h}}
+
+	for _, currency := range Currencies {
+		if currency == DefaultCurrency {
+			continue
+		}
+		other, err := c.quote(ctx, id, currency)
+		if errors.Is(err, ErrRateLimited) {
+			return nil, fmt.Errorf("coinmarketcap %q in %s: %w", coinID, currency, err)
+		}
+		if err != nil {
+			log.Printf("Failed to fetch coinmarketcap %q in %s: %v", coinID, currency, err)
+			continue
+		}
+		if q, ok := other.Quote[strings.ToUpper(currency)]; ok {
+			quotes[currency] = Quote{Price: q.Price, MarketCap: q.MarketCap, Volume24h: q.Volume24h}
+		}
+	}
+
+	return &Coin{
+		ID:        coinID,
+		Symbol:    strings.ToLower(entry.Symbol),
+		Name:      entry.Name,
+		ImageURL:  fmt.Sprintf("https://s2.coinmarketcap.com/static/img/coins/200x200/%d.png", entry.ID),
+		Rank:      entry.CMCRank,
+		Change24h: usd.PercentChange24h,
+		Change7d:  usd.PercentChange7d,
+		Quotes:    quotes,
+		UpdatedAt: usd.LastUpdated,
+	}, nil
+}
+
+// quote fetches a coin by CoinMarketCap ID, converted to one currency.
+func (c *CoinMarketCap) quote(ctx context.Context, id int, currency string) (*cmcCoin, error) {
+	apiURL := fmt.Sprintf("%s/v2/cryptocurrency/quotes/latest?id=%d&convert=%s", c.BaseURL, id, strings.ToUpper(currency))
+
+	var result struct {
+		Status cmcStatus          `json:"status"`
+		Data   map[string]cmcCoin `json:"data"`
+	}
+	if err := getJSON(ctx, c.Client, apiURL, c.headers(), &result); err != nil {
+		// CoinMarketCap answers every bad request with 400 and says in the body what was wrong.
+		var se *statusError
+		if errors.As(err, &se) && se.code == http.StatusBadRequest {
+			var body struct {
+				Status cmcStatus `json:"status"`
+			}
+			if json.Unmarshal(se.body, &body) == nil && body.Status.ErrorMessage != "" {
+				if strings.HasPrefix(body.Status.ErrorMessage, `Invalid value for "id"`) {
+					return nil, ErrCoinNotFound
+				}
+				return nil, fmt.Errorf("%w: %s", err, body.Status.ErrorMessage)
+			}
+		}
+		return nil, err
+	}
+	if result.Status.ErrorCode != 0 {
+		return nil, errors.New(result.Status.ErrorMessage)
+	}
+	if entry, ok := result.Data[fmt.Sprint(id)]; ok {
+		return &entry, nil
+	}
+	return nil, ErrCoinNotFound
+}
+
+func (c *CoinMarketCap) headers() map[string]string {
+	return map[string]string{"X-CMC_PRO_API_KEY": c.APIKey}
+}
+
+// cmcListing is a coin in CoinMarketCap's ID map.
+type cmcListing struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+	Slug   string `json:"slug"`
+	Rank   int    `json:"rank"`
+}
+
+// cmcIndex finds CoinMarketCap IDs by slug and by symbol.
+type cmcIndex struct {
+	bySlug   map[string]int
+	bySymbol map[string][]cmcListing // Ranked coins first, biggest market cap first
+}
+
+func newCMCIndex(listings []cmcListing) *cmcIndex {
+	sort.SliceStable(listings, func(i, j int) bool {
+		a, b := listings[i].Rank, listings[j].Rank
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+	idx := &cmcIndex{bySlug: make(map[string]int, len(listings)), bySymbol: make(map[string][]cmcListing)}
+	for _, l := range listings {
+		idx.bySlug[l.Slug] = l.ID
+		symbol := strings.ToLower(l.Symbol)
+		idx.bySymbol[symbol] = append(idx.bySymbol[symbol], l)
+	}
+	return idx
+}
+
+// find matches a CoinGecko coin to a CoinMarketCap ID. A coin with the same symbol and name
+// wins, then one whose slug is the CoinGecko ID, then the biggest ranked coin with the symbol.
+// listing is nil when the coin list doesn't have the coin, leaving only the slug to go by.
+func (idx *cmcIndex) find(coinID string, listing *CoinListing) (int, bool) {
+	var sameSymbol []cmcListing
+	if listing != nil {
+		sameSymbol = idx.bySymbol[strings.ToLower(listing.Symbol)]
+		for _, l := range sameSymbol {
+			if strings.EqualFold(l.Name, listing.Name) {
+				return l.ID, true
+			}
+		}
+	}
+	if id, ok := idx.bySlug[coinID]; ok {
+		return id, true
+	}
+	if len(sameSymbol) > 0 && sameSymbol[0].Rank > 0 {
+		return sameSymbol[0].ID, true
+	}
+	return 0, false
+}
+
+// cmcID translates a CoinGecko ID to a CoinMarketCap ID, loading the ID map when it's
+// missing or as old as the coin list refresh interval.
+func (c *CoinMarketCap) cmcID(ctx context.Context, coinID string) (int, error) {
+	idx, err := c.index(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var listing *CoinListing
+	if c.Coins != nil {
+		if l, ok := c.Coins.Listing(coinID); ok {
+			listing = &l
+		}
+	}
+	id, ok := idx.find(coinID, listing)
+	if !ok {
+		return 0, ErrCoinNotFound
+	}
+	return id, nil
+}
+
+// index returns the ID map, loading it if needed. A map that fails to refresh is kept in use
+// and retried after coinListRetry.
+func (c *CoinMarketCap) index(ctx context.Context) (*cmcIndex, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ids != nil && time.Since(c.loadedAt) < coinListRefresh {
+		return c.ids, nil
+	}
+
+	var result struct {
+		Data []cmcListing `json:"data"`
+	}
+	err := getJSON(ctx, c.Client, c.BaseURL+"/v1/cryptocurrency/map?listing_status=active", c.headers(), &result)
+	if err != nil {
+		if c.ids == nil {
+			return nil, fmt.Errorf("loading ID map: %w", err)
+		}
+		log.Printf("Failed to refresh the coinmarketcap ID map: %v", err)
+		c.loadedAt = time.Now().Add(coinListRetry - coinListRefresh)
+		return c.ids, nil
+	}
+	c.ids = newCMCIndex(result.Data)
+	c.loadedAt = time.Now()
+	return c.ids, nil
+}
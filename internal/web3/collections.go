@@ -0,0 +1,279 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/models"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+)
+
+// maxPinnedCollections keeps /nfts summaries short enough to read and cheap to build.
+const maxPinnedCollections = 10
+
+// HandleNFTCommand shows the floor price and stats of an NFT collection:
+// /nft <collection slug|contract> [chain].
+func HandleNFTCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if NFTs == nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nft_unavailable")))
+		return
+	}
+
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 || len(fields) > 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nft_usage")))
+		return
+	}
+	chain, ok := nftChain(bot, l, message.Chat.ID, fields)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	collection, err := FindCollection(ctx, NFTs, fields[0], chain)
+	if err != nil {
+		log.Printf("Failed to look up collection %q: %v", fields[0], err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, NFTErrorText(l, fields[0], err)))
+		return
+	}
+
+	caption := CollectionText(l, collection, floorUSD(ctx, collection))
+	if collection.ImageURL != "" {
+		photo := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FileURL(collection.ImageURL))
+		photo.Caption = caption
+		photo.ParseMode = "Markdown"
+		if _, err := bot.Send(photo); err == nil {
+			return
+		}
+		// Telegram can't show every image format collections use; the stats matter more.
+		log.Printf("Failed to send thumbnail of collection %s, sending text instead: %v", collection.Slug, err)
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, caption)
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
+
+// HandleNFTsCommand summarises the community collections pinned in a chat: /nfts, or for
+// admins /nfts add <slug|contract> [chain] and /nfts remove <slug>.
+func HandleNFTsCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	if NFTs == nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nft_unavailable")))
+		return
+	}
+
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 {
+		sendPinnedSummary(bot, db, l, message.Chat.ID)
+		return
+	}
+
+	action := strings.ToLower(fields[0])
+	if (action != "add" && action != "remove") || len(fields) < 2 || len(fields) > 3 || (action == "remove" && len(fields) > 2) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_usage")))
+		return
+	}
+	if !message.Chat.IsPrivate() && !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	ctx := context.Background()
+	if action == "remove" {
+		removed, err := db.UnpinCollection(ctx, message.Chat.ID, strings.ToLower(fields[1]))
+		switch {
+		case err != nil:
+			log.Printf("Failed to unpin collection in chat %d: %v", message.Chat.ID, err)
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_error")))
+		case !removed:
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_not_pinned", fields[1])))
+		default:
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_removed", fields[1])))
+		}
+		return
+	}
+
+	chain, ok := nftChain(bot, l, message.Chat.ID, fields[1:])
+	if !ok {
+		return
+	}
+	pins, err := db.GetPinnedCollections(ctx, message.Chat.ID)
+	if err != nil {
+		log.Printf("Failed to load pinned collections of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_error")))
+		return
+	}
+	if len(pins) >= maxPinnedCollections {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_limit", maxPinnedCollections)))
+		return
+	}
+
+	collection, err := FindCollection(ctx, NFTs, fields[1], chain)
+	if err != nil {
+		log.Printf("Failed to look up collection %q to pin: %v", fields[1], err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, NFTErrorText(l, fields[1], err)))
+		return
+	}
+	pin := &models.PinnedCollection{
+		ChatID:    message.Chat.ID,
+		Slug:      collection.Slug,
+		Name:      collection.Name,
+		AddedBy:   message.From.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := db.PinCollection(ctx, pin); err != nil {
+		log.Printf("Failed to pin collection %s in chat %d: %v", collection.Slug, message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_error")))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.nfts_added", collection.Name, collection.Slug)))
+}
+
+// nftChain reads the optional chain after a collection, answering the user if it is unknown.
+func nftChain(bot *tgbotapi.BotAPI, l i18n.Localizer, chatID int64, fields []string) (*Chain, bool) {
+	chainName := ""
+	if len(fields) == 2 {
+		chainName = fields[1]
+	}
+	chain, ok := LookupChain(chainName)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("web3.gas_unknown_chain", chainName, strings.Join(ChainKeys(), ", "))))
+	}
+	return chain, ok
+}
+
+// sendPinnedSummary sends one line per pinned collection. A collection that fails to load
+// is still listed, so the summary always shows what the chat follows.
+func sendPinnedSummary(bot *tgbotapi.BotAPI, db database.Store, l i18n.Localizer, chatID int64) {
+	ctx := context.Background()
+	pins, err := db.GetPinnedCollections(ctx, chatID)
+	if err != nil {
+		log.Printf("Failed to load pinned collections of chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("web3.nfts_error")))
+		return
+	}
+	if len(pins) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, l.T("web3.nfts_empty")))
+		return
+	}
+
+	bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	lines := []string{l.T("web3.nfts_title"), ""}
+	for _, pin := range pins {
+		collection, err := NFTs.Collection(ctx, pin.Slug)
+		if err != nil {
+			log.Printf("Failed to look up pinned collection %s: %v", pin.Slug, err)
+			lines = append(lines, l.T("web3.nfts_line_unavailable", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, pin.Name)))
+			continue
+		}
+		lines = append(lines, collectionLine(l, collection, floorUSD(ctx, collection)))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, strings.Join(lines, "\n"))
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
+
+// floorUSD values the floor price of a collection, or returns 0 if it has no floor or its coin has no price.
+func floorUSD(ctx context.Context, c *Collection) float64 {
+	if c.FloorPrice <= 0 {
+		return 0
+	}
+	return valueUSD(ctx, nativeCoinID(c.FloorSymbol), new(big.Rat).SetFloat64(c.FloorPrice))
+}
+
+// nativeCoinID returns the price provider ID of a chain's native coin from its symbol, or "".
+// Wrapped ether counts as ether, which is what collections are usually priced in.
+func nativeCoinID(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if symbol == "WETH" {
+		symbol = "ETH"
+	}
+	for _, chain := range Chains {
+		if chain.NativeSymbol == symbol {
+			return chain.NativeCoinID
+		}
+	}
+	return ""
+}
+
+// formatCoinAmount formats an amount of coins reported as a float by an API.
+func formatCoinAmount(amount float64) string {
+	return formatUnits(new(big.Rat).SetFloat64(amount))
+}
+
+// CollectionText formats the Markdown caption of an NFT collection.
+func CollectionText(l i18n.Localizer, c *Collection, floorUSD float64) string {
+	lines := []string{l.T("web3.nft_title", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, c.Name))}
+	if c.FloorPrice > 0 {
+		lines = append(lines, l.T("web3.nft_floor", formatCoinAmount(c.FloorPrice), c.FloorSymbol)+usdSuffix(floorUSD))
+	} else {
+		lines = append(lines, l.T("web3.nft_no_floor"))
+	}
+	volume := fmt.Sprintf("%s %s", formatCoinAmount(c.Volume24h), volumeSymbol(c))
+	lines = append(lines, l.T("web3.nft_volume", volume)+" · "+l.N("web3.nft_sales", c.Sales24h, c.Sales24h))
+	if c.Holders > 0 {
+		holders := groupThousands(strconv.Itoa(c.Holders))
+		if c.Supply > 0 {
+			lines = append(lines, l.T("web3.nft_holders_supply", holders, groupThousands(strconv.Itoa(c.Supply))))
+		} else {
+			lines = append(lines, l.T("web3.nft_holders", holders))
+		}
+	}
+	if c.Contract != "" {
+		lines = append(lines, "`"+c.Contract+"`")
+	}
+	if c.URL != "" {
+		lines = append(lines, "", l.T("web3.nft_link", c.URL))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collectionLine formats a collection in a /nfts summary.
+func collectionLine(l i18n.Localizer, c *Collection, floorUSD float64) string {
+	name := tgbotapi.EscapeText(tgbotapi.ModeMarkdown, c.Name)
+	if c.URL != "" {
+		name = fmt.Sprintf("[%s](%s)", name, c.URL)
+	}
+	floor := l.T("web3.nfts_no_floor")
+	if c.FloorPrice > 0 {
+		floor = fmt.Sprintf("`%s %s`%s", formatCoinAmount(c.FloorPrice), c.FloorSymbol, usdSuffix(floorUSD))
+	}
+	volume := fmt.Sprintf("%s %s", formatCoinAmount(c.Volume24h), volumeSymbol(c))
+	return l.T("web3.nfts_line", name, floor, volume)
+}
+
+// volumeSymbol is the coin volume is counted in: the native coin of the collection's chain,
+// or the floor's coin when the chain isn't known.
+func volumeSymbol(c *Collection) string {
+	if chain, ok := LookupChain(c.Chain); ok && c.Chain != "" {
+		return chain.NativeSymbol
+	}
+	return c.FloorSymbol
+}
+
+// NFTErrorText picks the message for a failed collection lookup.
+func NFTErrorText(l i18n.Localizer, query string, err error) string {
+	switch {
+	case errors.Is(err, ErrCollectionNotFound):
+		return l.T("web3.nft_not_found", query)
+	case errors.Is(err, ErrBadChecksum):
+		return l.T("web3.wallet_bad_checksum", query)
+	case errors.Is(err, ErrRateLimited):
+		return l.T("web3.price_rate_limited")
+	default:
+		return l.T("web3.nft_error")
+	}
+}
@@ -0,0 +1,116 @@
+package web3
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+var pudgy = Collection{
+	Slug:        "pudgypenguins",
+	Name:        "Pudgy Penguins",
+	URL:         "https://opensea.io/collection/pudgypenguins",
+	Chain:       "ethereum",
+	Contract:    "0xBd3531dA5CF5857e7CfAA92426877b022e612cf8",
+	FloorPrice:  10.5,
+	FloorSymbol: "ETH",
+	Volume24h:   120,
+	Sales24h:    14,
+	Holders:     4_800,
+	Supply:      8_888,
+}
+
+var milady = Collection{Slug: "milady", Name: "Milady Maker", Chain: "ethereum", FloorSymbol: "ETH"}
+
+// fakeNFTs serves the given collections, and ether at $2,000, until the test ends.
+func fakeNFTs(t *testing.T, collections ...Collection) *StaticNFTProvider {
+	t.Helper()
+	provider := NewStaticNFTProvider(collections...)
+	previousNFTs, previousPrices := NFTs, Prices
+	NFTs = provider
+	Prices = NewStaticProvider(Coin{ID: "ethereum", Symbol: "eth", Name: "Ethereum", Quotes: map[string]Quote{DefaultCurrency: {Price: 2000}}})
+	t.Cleanup(func() { NFTs, Prices = previousNFTs, previousPrices })
+	return provider
+}
+
+func TestHandleNFTCommand(t *testing.T) {
+	fakeNFTs(t, pudgy)
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	user := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	chat := tgfake.Private(user)
+
+	for _, query := range []string{"pudgypenguins", "PudgyPenguins", strings.ToLower(pudgy.Contract)} {
+		server.Reset()
+		HandleNFTCommand(bot, db, tgfake.Command(chat, user, "/nft "+query))
+		text := server.LastText(t)
+		for _, want := range []string{"Pudgy Penguins", "10.5 ETH", "$21,000", "4,800"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("/nft %s lacks %q:\n%s", query, want, text)
+			}
+		}
+	}
+
+	server.Reset()
+	HandleNFTCommand(bot, db, tgfake.Command(chat, user, "/nft boredapes"))
+	if text := server.LastText(t); !strings.Contains(text, "couldn't find") {
+		t.Errorf("/nft of an unknown collection answered:\n%s", text)
+	}
+	server.Reset()
+	HandleNFTCommand(bot, db, tgfake.Command(chat, user, "/nft "+pudgy.Contract+" base"))
+	if text := server.LastText(t); !strings.Contains(text, "couldn't find") {
+		t.Errorf("/nft of a contract on another chain answered:\n%s", text)
+	}
+}
+
+func TestHandleNFTsCommand(t *testing.T) {
+	provider := fakeNFTs(t, pudgy, milady)
+	bot, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	admin := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	member := &tgbotapi.User{ID: 43, FirstName: "Bob"}
+	group := tgfake.Group(-100)
+	server.SetStatus(group.ID, admin.ID, "administrator")
+
+	HandleNFTsCommand(bot, db, tgfake.Command(group, admin, "/nfts"))
+	if text := server.LastText(t); !strings.Contains(text, "No collections are pinned") {
+		t.Errorf("empty /nfts answered:\n%s", text)
+	}
+
+	HandleNFTsCommand(bot, db, tgfake.Command(group, member, "/nfts add pudgypenguins"))
+	if text := server.LastText(t); !strings.Contains(text, "admin") {
+		t.Errorf("a member pinning a collection got:\n%s", text)
+	}
+	HandleNFTsCommand(bot, db, tgfake.Command(group, admin, "/nfts add "+pudgy.Contract))
+	HandleNFTsCommand(bot, db, tgfake.Command(group, admin, "/nfts add milady"))
+	if text := server.LastText(t); !strings.Contains(text, "Pinned Milady Maker") {
+		t.Errorf("pinning answered:\n%s", text)
+	}
+
+	// A collection that stops loading is still listed.
+	provider.mu.Lock()
+	delete(provider.collections, "milady")
+	provider.mu.Unlock()
+	server.Reset()
+	HandleNFTsCommand(bot, db, tgfake.Command(group, member, "/nfts"))
+	text := server.LastText(t)
+	for _, want := range []string{"Pudgy Penguins", "10.5 ETH", "Milady Maker", "stats unavailable"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("/nfts lacks %q:\n%s", want, text)
+		}
+	}
+
+	HandleNFTsCommand(bot, db, tgfake.Command(group, admin, "/nfts remove milady"))
+	HandleNFTsCommand(bot, db, tgfake.Command(group, admin, "/nfts remove milady"))
+	if text := server.LastText(t); !strings.Contains(text, "isn't pinned") {
+		t.Errorf("removing twice answered:\n%s", text)
+	}
+	server.Reset()
+	HandleNFTsCommand(bot, db, tgfake.Command(group, member, "/nfts"))
+	if text := server.LastText(t); strings.Contains(text, "Milady") {
+		t.Errorf("/nfts still lists a removed collection:\n%s", text)
+	}
+}
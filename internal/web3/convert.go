@@ -0,0 +1,299 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+)
+
+// fiatReference is the coin whose quotes give the exchange rates between fiat currencies.
+const fiatReference = "bitcoin"
+
+// convertDecimals caps the decimals of a converted amount, enough to show a single wei in ether.
+const convertDecimals = 18
+
+// convertSignificant is how many significant digits price-based conversions keep. Exact unit
+// conversions keep every digit instead, since they don't depend on a price.
+const convertSignificant = 8
+
+// unit is a denomination of a coin, worth scale of the coin.
+type unit struct {
+	coinID string
+	symbol string
+	scale  *big.Rat
+}
+
+// units lists the denominations /convert knows besides whole coins and fiat currencies.
+var units = map[string]unit{
+	"wei":     {coinID: "ethereum", symbol: "wei", scale: pow10(-18)},
+	"gwei":    {coinID: "ethereum", symbol: "Gwei", scale: pow10(-9)},
+	"ether":   {coinID: "ethereum", symbol: "ETH", scale: pow10(0)},
+	"sat":     {coinID: "bitcoin", symbol: "sats", scale: pow10(-8)},
+	"sats":    {coinID: "bitcoin", symbol: "sats", scale: pow10(-8)},
+	"satoshi": {coinID: "bitcoin", symbol: "sats", scale: pow10(-8)},
+}
+
+// asset is one side of a conversion: a fiat currency, or a coin in some denomination.
+type asset struct {
+	fiat   string // currency code when the asset is fiat
+	coinID string
+	symbol string
+	scale  *big.Rat
+}
+
+// errUnknownAsset is returned by resolveAsset when the input names nothing convertible.
+var errUnknownAsset = errors.New("unknown asset")
+
+// HandleConvertCommand converts between coins, fiat currencies and units:
+//
+//	/convert 0.5 eth usdc
+//	/convert 100 usd to btc
+//	/convert 21 gwei eth
+//
+// Amounts are exact decimals, so a wei is never lost converting between denominations.
+func HandleConvertCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	fields := strings.Fields(strings.ToLower(message.CommandArguments()))
+	if len(fields) == 4 && (fields[2] == "to" || fields[2] == "in") {
+		fields = append(fields[:2], fields[3])
+	}
+	if len(fields) != 2 && len(fields) != 3 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.convert_usage")))
+		return
+	}
+
+	amount, ok := ParseAmount(fields[0])
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.convert_invalid_amount", fields[0])))
+		return
+	}
+	to := ChatCurrency(db, message.Chat.ID)
+	if len(fields) == 3 {
+		to = fields[2]
+	}
+
+	from, err := resolveAsset(fields[1])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.price_not_found", fields[1])))
+		return
+	}
+	target, err := resolveAsset(to)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.price_not_found", to)))
+		return
+	}
+
+	result, exact, err := Convert(context.Background(), amount, from, target)
+	if err != nil {
+		log.Printf("Failed to convert %s %s to %s: %v", fields[0], fields[1], to, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, PriceErrorText(l, fields[1], err)))
+		return
+	}
+
+	text := l.T("web3.convert_result", formatRat(amount, convertDecimals), from.symbol, formatRat(result, resultDecimals(result, exact, target)), target.symbol)
+	if !exact {
+		text += "\n" + l.T("web3.convert_price_note")
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// Convert converts amount of from into to. Between denominations of the same coin, or the same
+// currency, the result is exact; otherwise it goes through the current prices and exact is false.
+func Convert(ctx context.Context, amount *big.Rat, from, to asset) (result *big.Rat, exact bool, err error) {
+	base := new(big.Rat).Mul(amount, from.scale)
+	if from.coinID == to.coinID && from.fiat == to.fiat {
+		return base.Quo(base, to.scale), true, nil
+	}
+
+	// Value both sides in the target currency when it is fiat, and in USD otherwise.
+	currency := to.fiat
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	fromPrice, err := priceIn(ctx, from, currency)
+	if err != nil {
+		return nil, false, err
+	}
+	toPrice, err := priceIn(ctx, to, currency)
+	if err != nil {
+		return nil, false, err
+	}
+	if toPrice.Sign() == 0 {
+		return nil, false, ErrBadResponse
+	}
+
+	result = base.Mul(base, fromPrice)
+	result.Quo(result, toPrice)
+	return result.Quo(result, to.scale), false, nil
+}
+
+// priceIn returns what one whole unit of an asset is worth in a fiat currency. Coins use their
+// own quote in that currency when the provider has one, and go through USD otherwise.
+func priceIn(ctx context.Context, a asset, currency string) (*big.Rat, error) {
+	if a.fiat == currency {
+		return big.NewRat(1, 1), nil
+	}
+
+	if a.coinID != "" {
+		coin, err := Prices.Coin(ctx, a.coinID)
+		if err != nil {
+			return nil, err
+		}
+		if quote, got := coin.Quote(currency); got == currency {
+			return ratFromFloat(quote.Price)
+		}
+		quote, _ := coin.Quote(DefaultCurrency)
+		price, err := ratFromFloat(quote.Price)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := fiatRate(ctx, DefaultCurrency, currency)
+		if err != nil {
+			return nil, err
+		}
+		return price.Mul(price, rate), nil
+	}
+	return fiatRate(ctx, a.fiat, currency)
+}
+
+// fiatRate returns how much of currency one unit of fiat buys, from the quotes of fiatReference.
+func fiatRate(ctx context.Context, fiat, currency string) (*big.Rat, error) {
+	ref, err := Prices.Coin(ctx, fiatReference)
+	if err != nil {
+		return nil, err
+	}
+	from, gotFrom := ref.Quote(fiat)
+	to, gotTo := ref.Quote(currency)
+	if gotFrom != fiat || gotTo != currency || from.Price == 0 {
+		return nil, ErrBadResponse
+	}
+	rate, err := ratFromFloat(to.Price)
+	if err != nil {
+		return nil, err
+	}
+	divisor, err := ratFromFloat(from.Price)
+	if err != nil {
+		return nil, err
+	}
+	return rate.Quo(rate, divisor), nil
+}
+
+// resolveAsset turns a unit, currency code or coin name into an asset.
+func resolveAsset(input string) (asset, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if u, ok := units[input]; ok {
+		return asset{coinID: u.coinID, symbol: u.symbol, scale: u.scale}, nil
+	}
+	if IsCurrency(input) {
+		return asset{fiat: input, symbol: strings.ToUpper(input), scale: pow10(0)}, nil
+	}
+	res := Coins.Resolve(input)
+	if res.ID == "" {
+		return asset{}, errUnknownAsset
+	}
+	return asset{coinID: res.ID, symbol: strings.ToUpper(input), scale: pow10(0)}, nil
+}
+
+// amountPattern accepts plain decimals with an optional short exponent. It keeps out the fractions
+// and huge exponents big.Rat would otherwise parse.
+var amountPattern = regexp.MustCompile(`^(\d+\.?\d*|\.\d+)(e[+-]?\d{1,2})?$`)
+
+// groupedPattern accepts numbers with commas between groups of three digits.
+var groupedPattern = regexp.MustCompile(`^\d{1,3}(,\d{3})+(\.\d*)?$`)
+
+// ParseAmount parses a positive decimal amount exactly, allowing exponents such as 1e18 and
+// commas as thousands separators. Many locales write decimals with a comma, so commas are only
+// taken as separators when nothing else fits: "1,000,000" and "1,500.5" parse, but "0,5" and
+// "1,500", which could be 1.5, are rejected rather than read a thousand times too large.
+func ParseAmount(s string) (*big.Rat, bool) {
+	if strings.Contains(s, ",") {
+		if !groupedPattern.MatchString(s) || (strings.Count(s, ",") == 1 && !strings.Contains(s, ".")) {
+			return nil, false
+		}
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	if !amountPattern.MatchString(s) {
+		return nil, false
+	}
+	amount, ok := new(big.Rat).SetString(s)
+	if !ok || amount.Sign() <= 0 {
+		return nil, false
+	}
+	return amount, true
+}
+
+// ratFromFloat converts a price to an exact rational. Prices come from the provider as floats,
+// so they carry whatever precision it returned; the arithmetic on them loses none.
+func ratFromFloat(f float64) (*big.Rat, error) {
+	r := new(big.Rat)
+	if r.SetFloat64(f) == nil {
+		return nil, ErrBadResponse
+	}
+	return r, nil
+}
+
+// pow10 returns 10^exp as an exact rational.
+func pow10(exp int) *big.Rat {
+	if exp < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-exp)), nil))
+	}
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+}
+
+// formatRat formats an amount with thousands separators and at most the given decimals,
+// dropping trailing zeros.
+func formatRat(r *big.Rat, decimals int) string {
+	number := r.FloatString(decimals)
+	if strings.Contains(number, ".") {
+		number = strings.TrimRight(strings.TrimRight(number, "0"), ".")
+	}
+	return groupThousands(number)
+}
+
+// FormatDecimal formats an exact amount with every digit up to convertDecimals, such as a
+// holding the user typed in.
+func FormatDecimal(r *big.Rat) string {
+	return formatRat(r, convertDecimals)
+}
+
+// resultDecimals picks the decimals to show a conversion result with. Exact amounts keep every
+// digit up to convertDecimals; price-based ones keep convertSignificant significant digits,
+// or cents for fiat amounts of at least 1.
+func resultDecimals(r *big.Rat, exact bool, to asset) int {
+	if exact {
+		return convertDecimals
+	}
+	digits := integerDigits(r)
+	if to.fiat != "" && digits > 0 {
+		return 2
+	}
+	return min(max(convertSignificant-digits, 2), convertDecimals)
+}
+
+// integerDigits returns the number of digits before the decimal point, or minus the number of
+// zeros right after it for amounts below 1, so 123.4 gives 3 and 0.0012 gives -2.
+func integerDigits(r *big.Rat) int {
+	x := new(big.Rat).Abs(r)
+	if x.Sign() == 0 {
+		return 0
+	}
+	whole := new(big.Int).Quo(x.Num(), x.Denom())
+	if whole.Sign() > 0 {
+		return len(whole.String())
+	}
+	digits := 0
+	ten, tenth := big.NewRat(10, 1), big.NewRat(1, 10)
+	for x.Cmp(tenth) < 0 {
+		x.Mul(x, ten)
+		digits--
+	}
+	return digits
+}
@@ -0,0 +1,160 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // as big.Rat.RatString, or "" if the amount is rejected
+	}{
+		{in: "1", want: "1"},
+		{in: "0.5", want: "1/2"},
+		{in: ".25", want: "1/4"},
+		{in: "1e18", want: "1000000000000000000"},
+		{in: "2.5e-1", want: "1/4"},
+		{in: "1,000,000", want: "1000000"},
+		{in: "1,500.5", want: "3001/2"},
+		{in: "12,345,678.9", want: "123456789/10"},
+		{in: "0,5"},
+		{in: "1,5"},
+		{in: "1,500"},
+		{in: "12,34"},
+		{in: "1,0000"},
+		{in: ",5"},
+		{in: "1,000,00"},
+		{in: "0"},
+		{in: "-1"},
+		{in: "1/3"},
+		{in: "1e999"},
+		{in: ""},
+	}
+	for _, tt := range tests {
+		got, ok := ParseAmount(tt.in)
+		switch {
+		case tt.want == "" && ok:
+			t.Errorf("ParseAmount(%q) = %s, want it rejected", tt.in, got.RatString())
+		case tt.want != "" && !ok:
+			t.Errorf("ParseAmount(%q) was rejected, want %s", tt.in, tt.want)
+		case ok && got.RatString() != tt.want:
+			t.Errorf("ParseAmount(%q) = %s, want %s", tt.in, got.RatString(), tt.want)
+		}
+	}
+}
+
+// useConvertPrices serves fixed prices for conversions until the test ends. Bitcoin's quotes
+// give the fiat rates, and ether only has a dollar price, so euros go through dollars.
+func useConvertPrices(t *testing.T) {
+	t.Helper()
+	usePrices(t, []CoinListing{
+		{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Rank: 1},
+		{ID: "ethereum", Symbol: "eth", Name: "Ethereum", Rank: 2},
+		{ID: "usd-coin", Symbol: "usdc", Name: "USDC", Rank: 6},
+		{ID: "worthless", Symbol: "zero", Name: "Worthless"},
+		{ID: "delisted", Symbol: "gone", Name: "Delisted"},
+	}, NewStaticProvider(
+		Coin{ID: "bitcoin", Quotes: map[string]Quote{"usd": {Price: 60000}, "eur": {Price: 50000}}},
+		Coin{ID: "ethereum", Quotes: map[string]Quote{"usd": {Price: 2000}}},
+		Coin{ID: "usd-coin", Quotes: map[string]Quote{"usd": {Price: 1}}},
+		Coin{ID: "worthless", Quotes: map[string]Quote{"usd": {Price: 0}}},
+	))
+}
+
+func TestConvert(t *testing.T) {
+	useConvertPrices(t)
+	tests := []struct {
+		amount, from, to string
+		want             string // as big.Rat.RatString
+		exact            bool
+	}{
+		// Denominations of a coin convert exactly, without asking for prices.
+		{"1", "ether", "wei", "1000000000000000000", true},
+		{"1", "eth", "gwei", "1000000000", true},
+		{"21", "gwei", "eth", "21/1000000000", true},
+		{"1", "wei", "gwei", "1/1000000000", true},
+		{"123456789012345678", "wei", "ether", "61728394506172839/500000000000000000", true},
+		{"150000000", "sats", "btc", "3/2", true},
+		{"1", "btc", "satoshi", "100000000", true},
+		{"12.5", "eur", "eur", "25/2", true},
+		// Anything else goes through the current prices.
+		{"0.5", "eth", "usd", "1000", false},
+		{"1", "gwei", "usd", "1/500000", false},
+		{"100", "usd", "btc", "1/600", false},
+		{"1", "btc", "eth", "30", false},
+		{"1", "eth", "usdc", "2000", false},
+		// Ether has no euro quote, so it's valued in dollars and converted at bitcoin's rate.
+		{"0.5", "eth", "eur", "2500/3", false},
+		{"3000", "eur", "btc", "3/50", false},
+		{"10", "usd", "eur", "25/3", false},
+	}
+	for _, tt := range tests {
+		amount, _ := ParseAmount(tt.amount)
+		from, err := resolveAsset(tt.from)
+		if err != nil {
+			t.Fatalf("resolveAsset(%q): %v", tt.from, err)
+		}
+		to, err := resolveAsset(tt.to)
+		if err != nil {
+			t.Fatalf("resolveAsset(%q): %v", tt.to, err)
+		}
+		got, exact, err := Convert(context.Background(), amount, from, to)
+		if err != nil || got.RatString() != tt.want || exact != tt.exact {
+			t.Errorf("Convert(%s %s to %s) = %v, %v, %v, want %s, %v", tt.amount, tt.from, tt.to, got, exact, err, tt.want, tt.exact)
+		}
+	}
+}
+
+func TestConvertFails(t *testing.T) {
+	useConvertPrices(t)
+	convert := func(from, to string) error {
+		a, _ := resolveAsset(from)
+		b, _ := resolveAsset(to)
+		_, _, err := Convert(context.Background(), big.NewRat(1, 1), a, b)
+		return err
+	}
+	if err := convert("eth", "zero"); !errors.Is(err, ErrBadResponse) {
+		t.Errorf("converting to a coin without a price: err = %v, want ErrBadResponse", err)
+	}
+	if err := convert("gone", "usd"); !errors.Is(err, ErrCoinNotFound) {
+		t.Errorf("converting a coin no provider has: err = %v, want ErrCoinNotFound", err)
+	}
+	if _, err := resolveAsset("nothing like it"); !errors.Is(err, errUnknownAsset) {
+		t.Errorf("resolveAsset of nonsense: err = %v", err)
+	}
+}
+
+func TestHandleConvertCommand(t *testing.T) {
+	useConvertPrices(t)
+	_, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	user := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	chat := tgfake.Private(user)
+
+	tests := []struct {
+		command string
+		want    string
+		note    bool
+	}{
+		{"/convert 21 gwei eth", "🔁 21 Gwei = 0.000000021 ETH", false},
+		{"/convert 1,000,000 usd to btc", "🔁 1,000,000 USD = 16.666667 BTC", true},
+		// Without a target, the chat's currency is used.
+		{"/convert 2 eth", "🔁 2 ETH = 4,000 USD", true},
+		{"/convert 0,5 eth usd", "0,5", false},
+		{"/convert 1 eth doge", "doge", false},
+	}
+	for _, tt := range tests {
+		got := server.Answer(t, HandleConvertCommand, db, tgfake.Command(chat, user, tt.command))
+		if !strings.Contains(got, tt.want) || strings.Contains(got, "market prices") != tt.note {
+			t.Errorf("%s answered %q", tt.command, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/moderation"
+)
+
+// DefaultCurrency is used by chats that haven't picked a currency.
+const DefaultCurrency = "usd"
+
+// Currencies are the fiat currencies price cards can be shown in.
+var Currencies = []string{"usd", "eur", "gbp", "ngn"}
+
+var currencySymbols = map[string]string{
+	"usd": "$",
+	"eur": "€",
+	"gbp": "£",
+	"ngn": "₦",
+}
+
+var (
+	chatCurrencies = make(map[int64]string) // Cached default currency per chat ID
+	currencyMu     sync.Mutex
+)
+
+// IsCurrency reports whether a currency code, in any case, is supported.
+func IsCurrency(code string) bool {
+	_, ok := currencySymbols[strings.ToLower(code)]
+	return ok
+}
+
+// SplitCurrency splits a trailing currency code off command arguments such as "eth eur".
+// The currency is "" if the arguments don't end with a supported one.
+func SplitCurrency(args string) (rest, currency string) {
+	i := strings.LastIndexAny(args, " \t")
+	if i < 0 || !IsCurrency(args[i+1:]) {
+		return args, ""
+	}
+	return strings.TrimSpace(args[:i]), strings.ToLower(args[i+1:])
+}
+
+// ChatCurrency returns the default currency of a chat, loading it from the database once.
+func ChatCurrency(db database.SettingsRepository, chatID int64) string {
+	currencyMu.Lock()
+	currency, ok := chatCurrencies[chatID]
+	currencyMu.Unlock()
+	if ok {
+		return currency
+	}
+
+	settings, err := db.GetChatSettings(context.Background(), chatID)
+	if err != nil {
+		// Don't cache failures, the next lookup will retry.
+		log.Printf("Failed to load currency of chat %d: %v", chatID, err)
+		return DefaultCurrency
+	}
+	currency = DefaultCurrency
+	if IsCurrency(settings.Currency) {
+		currency = strings.ToLower(settings.Currency)
+	}
+
+	currencyMu.Lock()
+	chatCurrencies[chatID] = currency
+	currencyMu.Unlock()
+	return currency
+}
+
+// HandleCurrencyCommand shows or changes the currency price cards use in a chat.
+// Anyone can change it in private chat; in groups it takes an admin.
+func HandleCurrencyCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	supported := strings.ToUpper(strings.Join(Currencies, ", "))
+
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if arg == "" {
+		current := strings.ToUpper(ChatCurrency(db, message.Chat.ID))
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.currency_current", current)+"\n"+l.T("web3.currency_usage", supported)))
+		return
+	}
+	if !IsCurrency(arg) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.currency_usage", supported)))
+		return
+	}
+	if !message.Chat.IsPrivate() && !moderation.IsUserAdmin(bot, message.Chat.ID, message.From.ID) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("common.admin_only")))
+		return
+	}
+
+	settings, err := db.GetChatSettings(context.Background(), message.Chat.ID)
+	if err == nil {
+		settings.Currency = arg
+		err = db.SaveChatSettings(context.Background(), settings)
+	}
+	if err != nil {
+		log.Printf("Failed to save currency of chat %d: %v", message.Chat.ID, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.currency_save_error")))
+		return
+	}
+
+	currencyMu.Lock()
+	chatCurrencies[message.Chat.ID] = arg
+	currencyMu.Unlock()
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.currency_set", strings.ToUpper(arg))))
+}
+
+// FormatMoney formats an amount with its currency symbol and thousands separators.
+func FormatMoney(currency string, amount float64) string {
+	if amount < 0 {
+		return "-" + currencySymbol(currency) + formatAmount(-amount)
+	}
+	return currencySymbol(currency) + formatAmount(amount)
+}
+
+// formatAmount formats a number with thousands separators. Amounts below 1 keep four
+// significant digits, so cheap tokens don't all show up as 0.00.
+func formatAmount(amount float64) string {
+	decimals := 2
+	if abs := math.Abs(amount); abs > 0 && abs < 1 {
+		decimals = min(int(-math.Floor(math.Log10(abs)))+3, 12)
+	}
+	number := strconv.FormatFloat(math.Abs(amount), 'f', decimals, 64)
+	if amount < 0 {
+		return "-" + groupThousands(number)
+	}
+	return groupThousands(number)
+}
+
+// formatCompact formats large amounts such as market caps as "$1.23B".
+func formatCompact(currency string, amount float64) string {
+	units := []struct {
+		size   float64
+		suffix string
+	}{{1e12, "T"}, {1e9, "B"}, {1e6, "M"}, {1e3, "K"}}
+	for _, u := range units {
+		if math.Abs(amount) >= u.size {
+			return fmt.Sprintf("%s%.2f%s", currencySymbol(currency), amount/u.size, u.suffix)
+		}
+	}
+	return FormatMoney(currency, amount)
+}
+
+// FormatChange formats a percentage change with an arrow showing its direction.
+func FormatChange(percent float64) string {
+	arrow := "▪️"
+	switch {
+	case percent > 0:
+		arrow = "🔺"
+	case percent < 0:
+		arrow = "🔻"
+	}
+	return fmt.Sprintf("%s %+.2f%%", arrow, percent)
+}
+
+func currencySymbol(currency string) string {
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol
+	}
+	return strings.ToUpper(currency) + " "
+}
+
+// groupThousands inserts commas into the integer part of a formatted non-negative number.
+func groupThousands(number string) string {
+	intPart, frac, hasFrac := strings.Cut(number, ".")
+
+	var sb strings.Builder
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(digit)
+	}
+	if hasFrac {
+		sb.WriteString("." + frac)
+	}
+	return sb.String()
+}
@@ -0,0 +1,51 @@
+package web3
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCoinNotFound is returned when a price provider doesn't know a coin.
+	ErrCoinNotFound = errors.New("coin not found")
+	// ErrGasAPI is returned when the Etherscan gas oracle answers with an error status.
+	ErrGasAPI = errors.New("gas oracle error")
+	// ErrBadResponse is returned when an API answers with something we can't parse.
+	ErrBadResponse = errors.New("unexpected API response")
+)
+
+// Coin is the market data of a cryptocurrency as shown in price cards.
+type Coin struct {
+	ID       string
+	Symbol   string
+	Name     string
+	ImageURL string
+	// Rank is the market cap rank, or 0 if the provider doesn't rank the coin.
+	Rank int
+	// Change24h and Change7d are the price changes in percent, measured in USD.
+	Change24h float64
+	Change7d  float64
+	// Quotes holds the market data per lowercase currency code. Every provider fills in at least "usd".
+	Quotes map[string]Quote
+	// UpdatedAt is when the provider last refreshed the data.
+	UpdatedAt time.Time
+}
+
+// Quote is the market data of a coin in one currency. Fields a provider doesn't report are 0.
+type Quote struct {
+	Price     float64
+	MarketCap float64
+	Volume24h float64
+	ATH       float64
+	// ATHChange is how far the price is from the all-time high, in percent (0 or negative).
+	ATHChange float64
+}
+
+// Quote returns the market data in a currency, falling back to USD if the provider didn't
+// report that currency. The second value is the currency actually used.
+func (c *Coin) Quote(currency string) (Quote, string) {
+	if q, ok := c.Quotes[currency]; ok {
+		return q, currency
+	}
+	return c.Quotes[DefaultCurrency], DefaultCurrency
+}
@@ -0,0 +1,279 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ensRegistry is the ENS registry on Ethereum mainnet, which names every domain's resolver.
+const ensRegistry = "0x00000000000C2E074eC69A0bFB2997ba6c7d2e1E"
+
+const (
+	// ensTTL is how long resolved names and addresses are reused. Names rarely move.
+	ensTTL = time.Hour
+	// ensMissTTL is how long a name or address without a record is remembered, shorter so
+	// a freshly set record shows up soon.
+	ensMissTTL = 10 * time.Minute
+	// ensMaxEntries caps each cache, since every address a wallet traded with ends up in it.
+	ensMaxEntries = 10_000
+	// ensLookupWorkers bounds how many reverse lookups AddressNames runs at once.
+	ensLookupWorkers = 8
+)
+
+// ErrENSNotFound is returned when an ENS name has no resolver or no address set.
+var ErrENSNotFound = errors.New("ENS name not found")
+
+// zeroAddress is what ENS contracts return for names that aren't set.
+var zeroAddress = ChecksumAddress(strings.Repeat("0", 40))
+
+type ensEntry struct {
+	value     string // The address of a name, or the primary name of an address; "" for none
+	fetchedAt time.Time
+}
+
+var (
+	ensForward = make(map[string]ensEntry) // Keyed by lowercase name
+	ensReverse = make(map[string]ensEntry) // Keyed by checksummed address
+	ensMu      sync.Mutex
+)
+
+// ensCached returns a cached lookup that hasn't expired yet.
+func ensCached(cache map[string]ensEntry, key string) (string, bool) {
+	ensMu.Lock()
+	defer ensMu.Unlock()
+	entry, ok := cache[key]
+	if !ok {
+		return "", false
+	}
+	if entry.expired(time.Now()) {
+		delete(cache, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+func (e ensEntry) expired(now time.Time) bool {
+	ttl := ensTTL
+	if e.value == "" {
+		ttl = ensMissTTL
+	}
+	return now.Sub(e.fetchedAt) >= ttl
+}
+
+// ensStore caches a lookup. A full cache first drops its expired entries, then its oldest one.
+func ensStore(cache map[string]ensEntry, key, value string) {
+	ensMu.Lock()
+	defer ensMu.Unlock()
+	now := time.Now()
+	if _, ok := cache[key]; !ok && len(cache) >= ensMaxEntries {
+		var oldest string
+		for k, entry := range cache {
+			if entry.expired(now) {
+				delete(cache, k)
+			} else if oldest == "" || entry.fetchedAt.Before(cache[oldest].fetchedAt) {
+				oldest = k
+			}
+		}
+		if len(cache) >= ensMaxEntries {
+			delete(cache, oldest)
+		}
+	}
+	cache[key] = ensEntry{value: value, fetchedAt: now}
+}
+
+// IsENSName reports whether input looks like an ENS name rather than an address.
+func IsENSName(input string) bool {
+	input = strings.ToLower(strings.TrimSpace(input))
+	return strings.HasSuffix(input, ".eth") && len(input) > len(".eth") && !strings.ContainsAny(input, " /")
+}
+
+// ResolveAddressInput turns what a user typed, an address or an ENS name, into a checksummed
+// address. Commands that take an address use it so both forms work everywhere.
+func ResolveAddressInput(ctx context.Context, input string) (address, ensName string, err error) {
+	input = strings.TrimSpace(input)
+	if IsENSName(input) {
+		ensName = strings.ToLower(input)
+		address, err = ResolveENS(ctx, ensName)
+		return address, ensName, err
+	}
+	address, err = ParseAddress(input)
+	return address, "", err
+}
+
+// ResolveENS looks up the address an ENS name points to, on Ethereum mainnet.
+func ResolveENS(ctx context.Context, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if addr, ok := ensCached(ensForward, name); ok {
+		if addr == "" {
+			return "", ErrENSNotFound
+		}
+		return addr, nil
+	}
+
+	addr, err := resolveENS(ctx, name)
+	if errors.Is(err, ErrENSNotFound) {
+		ensStore(ensForward, name, "")
+	}
+	if err != nil {
+		return "", err
+	}
+	ensStore(ensForward, name, addr)
+	return addr, nil
+}
+
+func resolveENS(ctx context.Context, name string) (string, error) {
+	rpc := Chains[0].RPC()
+	node := namehash(name)
+
+	resolver, err := ensResolver(ctx, rpc, node)
+	if err != nil {
+		return "", err
+	}
+	out, err := rpc.EthCall(ctx, resolver, append(selector("addr(bytes32)"), node...))
+	if err != nil {
+		return "", err
+	}
+	addr := decodeAddress(out)
+	if addr == "" || addr == zeroAddress {
+		return "", ErrENSNotFound
+	}
+	return addr, nil
+}
+
+// LookupENSName returns the primary ENS name of an address, or "" if it has none. The reverse
+// record is only trusted when the name resolves back to the same address, as ENS requires,
+// since anyone can point the reverse record of their own address at any name.
+func LookupENSName(ctx context.Context, address string) (string, error) {
+	address = ChecksumAddress(address)
+	if name, ok := ensCached(ensReverse, address); ok {
+		return name, nil
+	}
+
+	name, err := lookupENSName(ctx, address)
+	if err != nil {
+		return "", err
+	}
+	ensStore(ensReverse, address, name)
+	return name, nil
+}
+
+func lookupENSName(ctx context.Context, address string) (string, error) {
+	rpc := Chains[0].RPC()
+	node := namehash(strings.ToLower(address[2:]) + ".addr.reverse")
+
+	resolver, err := ensResolver(ctx, rpc, node)
+	if errors.Is(err, ErrENSNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	out, err := rpc.EthCall(ctx, resolver, append(selector("name(bytes32)"), node...))
+	if err != nil {
+		return "", err
+	}
+	name := strings.ToLower(decodeString(out))
+	if name == "" {
+		return "", nil
+	}
+
+	forward, err := ResolveENS(ctx, name)
+	if errors.Is(err, ErrENSNotFound) || (err == nil && forward != address) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ensResolver asks the registry which resolver contract serves a node.
+func ensResolver(ctx context.Context, rpc *RPCClient, node []byte) (string, error) {
+	out, err := rpc.EthCall(ctx, ensRegistry, append(selector("resolver(bytes32)"), node...))
+	if err != nil {
+		return "", err
+	}
+	resolver := decodeAddress(out)
+	if resolver == "" || resolver == zeroAddress {
+		return "", ErrENSNotFound
+	}
+	return resolver, nil
+}
+
+// namehash computes the ENS node of a name, hashing its labels from the top level down.
+// Names are only lowercased; full UTS-46 normalisation isn't needed for the names people paste.
+func namehash(name string) []byte {
+	node := make([]byte, 32)
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = keccak256(node, keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// decodeString reads an ABI-encoded string return value, or "" if it is malformed.
+func decodeString(out []byte) string {
+	if len(out) < 64 {
+		return ""
+	}
+	offset := new(big.Int).SetBytes(out[:32])
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(out)) {
+		return ""
+	}
+	start := offset.Int64() + 32
+	length := new(big.Int).SetBytes(out[start-32 : start])
+	if !length.IsInt64() || start+length.Int64() > int64(len(out)) {
+		return ""
+	}
+	return string(out[start : start+length.Int64()])
+}
+
+// AddressNames looks up the primary names of several addresses, leaving out those without one.
+// Failures only cost the name, so addresses are still shown. Lookups run a few at a time.
+func AddressNames(ctx context.Context, addresses ...string) map[string]string {
+	var (
+		names = make(map[string]string)
+		seen  = make(map[string]bool)
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, ensLookupWorkers)
+	)
+	for _, addr := range addresses {
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			name, err := LookupENSName(ctx, addr)
+			if err != nil || name == "" {
+				return
+			}
+			mu.Lock()
+			names[addr] = name
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return names
+}
+
+// DisplayAddress formats an address for Markdown messages, led by its primary name when it has one.
+func DisplayAddress(addr, name string) string {
+	if name == "" {
+		return "`" + addr + "`"
+	}
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, name) + " (`" + ShortAddress(addr) + "`)"
+}
@@ -0,0 +1,59 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// etherscanAPI is the Etherscan v2 multichain API; the chainid parameter picks the network.
+const etherscanAPI = "https://api.etherscan.io/v2/api"
+
+// ErrExplorerAPI is returned when Etherscan answers with an error status, usually because of a
+// bad API key or a plan that doesn't include the endpoint.
+var ErrExplorerAPI = errors.New("etherscan API error")
+
+// etherscan calls the explorer API for a chain and returns the result of a successful answer.
+// Empty answers such as "No transactions found" count as success, with an empty result.
+func etherscan(ctx context.Context, chain *Chain, apiKey string, params url.Values) (json.RawMessage, error) {
+	params.Set("chainid", strconv.FormatInt(chain.ChainID, 10))
+	params.Set("apikey", apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, etherscanAPI+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rpcHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Etherscan %s API: %w", params.Get("module"), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Etherscan API response body: %w", err)
+	}
+
+	var apiResponse struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON from Etherscan: %v: %w", err, ErrBadResponse)
+	}
+	if apiResponse.Status != "1" {
+		if strings.HasPrefix(apiResponse.Message, "No ") {
+			return nil, nil
+		}
+		// On errors the result is a string explaining what went wrong.
+		return nil, fmt.Errorf("Etherscan API returned an error: %s %s: %w", apiResponse.Message, apiResponse.Result, ErrExplorerAPI)
+	}
+	return apiResponse.Result, nil
+}
@@ -0,0 +1,216 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// gasTTL is how long gas fees are reused. Blocks come every few seconds, but a burst of
+	// /gas commands shouldn't each cost an upstream call.
+	gasTTL = 15 * time.Second
+	// feeHistoryBlocks is how many recent blocks the RPC backend looks at for priority fees.
+	feeHistoryBlocks = 20
+)
+
+// GasPrices are fee suggestions for a chain, in Gwei. Each level is the total fee per gas,
+// that is the base fee plus the priority fee paid to get included that fast.
+type GasPrices struct {
+	Chain    *Chain
+	BaseFee  float64
+	Slow     float64
+	Standard float64
+	Fast     float64
+	// Source names the backend the fees came from, for logs.
+	Source string
+}
+
+// Priority returns the priority part of a fee level.
+func (g *GasPrices) Priority(total float64) float64 {
+	return max(total-g.BaseFee, 0)
+}
+
+// GasAction is a common transaction whose cost we estimate from its typical gas usage.
+type GasAction struct {
+	Key string
+	Gas int64
+}
+
+// GasActions are the estimates shown with gas fees. L2 estimates leave out the L1 data
+// fee, which depends on the transaction size rather than its gas.
+var GasActions = []GasAction{
+	{Key: "transfer", Gas: 21000},
+	{Key: "swap", Gas: 150000},
+	{Key: "nft_mint", Gas: 120000},
+}
+
+type gasCacheEntry struct {
+	gas       *GasPrices
+	fetchedAt time.Time
+}
+
+var (
+	gasCache = make(map[string]gasCacheEntry) // Keyed by chain key
+	gasMu    sync.Mutex
+)
+
+// FetchGasPrices gets the current gas fees of a chain. Etherscan's gas oracle is asked
+// first when an API key is configured; the chain's JSON-RPC endpoint is the fallback.
+func FetchGasPrices(ctx context.Context, chain *Chain) (*GasPrices, error) {
+	gasMu.Lock()
+	entry, ok := gasCache[chain.Key]
+	gasMu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < gasTTL {
+		return entry.gas, nil
+	}
+
+	var gas *GasPrices
+	var err error
+	if Cfg != nil && Cfg.EtherscanAPIKey != "" {
+		gas, err = etherscanGas(ctx, chain, Cfg.EtherscanAPIKey)
+		if err != nil {
+			log.Printf("Etherscan gas oracle failed for %s, trying RPC: %v", chain.Name, err)
+		}
+	}
+	if gas == nil {
+		if gas, err = rpcGas(ctx, chain); err != nil {
+			return nil, err
+		}
+	}
+
+	gasMu.Lock()
+	gasCache[chain.Key] = gasCacheEntry{gas: gas, fetchedAt: time.Now()}
+	gasMu.Unlock()
+	return gas, nil
+}
+
+// etherscanGas reads the gas oracle of the Etherscan v2 multichain API.
+func etherscanGas(ctx context.Context, chain *Chain, apiKey string) (*GasPrices, error) {
+	raw, err := etherscan(ctx, chain, apiKey, url.Values{"module": {"gastracker"}, "action": {"gasoracle"}})
+	if errors.Is(err, ErrExplorerAPI) {
+		return nil, fmt.Errorf("%w: %w", ErrGasAPI, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		SafeGasPrice    string `json:"SafeGasPrice"`
+		ProposeGasPrice string `json:"ProposeGasPrice"`
+		FastGasPrice    string `json:"FastGasPrice"`
+		SuggestBaseFee  string `json:"suggestBaseFee"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse Etherscan gas oracle: %v: %w", err, ErrBadResponse)
+	}
+
+	gas := &GasPrices{Chain: chain, Source: "etherscan"}
+	for _, f := range []struct {
+		dst *float64
+		src string
+	}{{&gas.Slow, result.SafeGasPrice}, {&gas.Standard, result.ProposeGasPrice}, {&gas.Fast, result.FastGasPrice}} {
+		v, err := strconv.ParseFloat(f.src, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gas price %q from Etherscan: %w", f.src, ErrBadResponse)
+		}
+		*f.dst = v
+	}
+	// Chains without EIP-1559 report no base fee; their whole fee counts as priority.
+	gas.BaseFee, _ = strconv.ParseFloat(result.SuggestBaseFee, 64)
+	return gas, nil
+}
+
+// rpcGas estimates fees from eth_feeHistory: the base fee of the next block plus the median
+// 10th, 50th and 90th percentile priority fees of recent blocks. Nodes without fee history
+// fall back to eth_gasPrice, which gives a single level.
+func rpcGas(ctx context.Context, chain *Chain) (*GasPrices, error) {
+	rpc := chain.RPC()
+
+	var history struct {
+		BaseFeePerGas []string   `json:"baseFeePerGas"`
+		Reward        [][]string `json:"reward"`
+	}
+	err := rpc.Call(ctx, &history, "eth_feeHistory", toHex(feeHistoryBlocks), "latest", []int{10, 50, 90})
+	if err != nil || len(history.BaseFeePerGas) == 0 {
+		log.Printf("eth_feeHistory failed on %s, falling back to eth_gasPrice: %v", chain.Name, err)
+		var price string
+		if err := rpc.Call(ctx, &price, "eth_gasPrice"); err != nil {
+			return nil, err
+		}
+		wei, err := parseHexBig(price)
+		if err != nil {
+			return nil, err
+		}
+		gwei := weiToGwei(wei)
+		return &GasPrices{Chain: chain, Slow: gwei, Standard: gwei, Fast: gwei, Source: "rpc"}, nil
+	}
+
+	// The last base fee is the one of the next, still unmined block.
+	next, err := parseHexBig(history.BaseFeePerGas[len(history.BaseFeePerGas)-1])
+	if err != nil {
+		return nil, err
+	}
+	gas := &GasPrices{Chain: chain, BaseFee: weiToGwei(next), Source: "rpc"}
+
+	levels := []*float64{&gas.Slow, &gas.Standard, &gas.Fast}
+	for i, level := range levels {
+		var rewards []float64
+		for _, block := range history.Reward {
+			if i >= len(block) {
+				continue
+			}
+			wei, err := parseHexBig(block[i])
+			if err != nil {
+				return nil, err
+			}
+			rewards = append(rewards, weiToGwei(wei))
+		}
+		*level = gas.BaseFee + median(rewards)
+	}
+	return gas, nil
+}
+
+// GasCostUSD estimates the cost of an action at a fee level, given the price of the native coin.
+func GasCostUSD(gwei float64, action GasAction, nativePriceUSD float64) float64 {
+	return gwei * float64(action.Gas) / 1e9 * nativePriceUSD
+}
+
+func weiToGwei(wei *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return f
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// FormatGwei formats a fee with more precision for the sub-Gwei fees of L2s.
+func FormatGwei(gwei float64) string {
+	switch {
+	case gwei >= 10:
+		return strconv.FormatFloat(gwei, 'f', 1, 64)
+	case gwei >= 1:
+		return strconv.FormatFloat(gwei, 'f', 2, 64)
+	case gwei == 0:
+		return "0"
+	default:
+		return strconv.FormatFloat(gwei, 'g', 3, 64)
+	}
+}
@@ -1,84 +1,101 @@
 package web3
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/philip-857.bit/byb-bot/internal/config"
 	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
 )
 
-// Cfg will be populated by main.go on startup.
+// Cfg will be populated by Configure on startup.
 var Cfg *config.Config
 
-// HandlePriceCommand fetches the price and image of a cryptocurrency.
-func HandlePriceCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
+// pricePrefix is the callback data prefix of the "did you mean" buttons, followed by "<currency>_<coin ID>".
+const pricePrefix = "price_"
+
+// HandlePriceCommand fetches the price and image of a cryptocurrency, in the chat's
+// currency or the one given after the coin, as in "/price eth eur".
+// Ambiguous or misspelled names are answered with "did you mean" buttons.
+func HandlePriceCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
 	coinName := strings.TrimSpace(message.CommandArguments())
 	if coinName == "" {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Please specify a cryptocurrency. Usage: `/price bitcoin` or `/p eth`"))
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.price_usage")))
 		return
 	}
 
-	coinID := strings.ToLower(coinName)
-	switch coinID {
-	case "btc":
-		coinID = "bitcoin"
-	case "eth":
-		coinID = "ethereum"
+	coinName, currency := SplitCurrency(coinName)
+	if currency == "" {
+		currency = ChatCurrency(db, message.Chat.ID)
 	}
 
-	// Use the more detailed 'coins' endpoint to get image and market data.
-	apiURL := fmt.Sprintf("https://api.coingecko.com/api/v3/coins/%s", coinID)
-
-	resp, err := http.Get(apiURL)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		log.Printf("Failed to call CoinGecko API or coin not found: %v", err)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Sorry, could not find data for '%s'.", coinName)))
-		return
+	res := Coins.Resolve(coinName)
+	switch {
+	case res.ID != "":
+		sendPriceCard(bot, l, message.Chat.ID, res.ID, coinName, currency)
+	case len(res.Suggestions) > 0:
+		msg := tgbotapi.NewMessage(message.Chat.ID, l.T("web3.did_you_mean", coinName))
+		msg.ReplyMarkup = suggestionKeyboard(res.Suggestions, pricePrefix+currency+"_")
+		bot.Send(msg)
+	default:
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.price_not_found", coinName)))
 	}
-	defer resp.Body.Close()
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Failed to read API response body: %v", err)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Sorry, an error occurred while processing the price data."))
+// IsCallback reports whether callback data belongs to this package.
+func IsCallback(data string) bool {
+	return strings.HasPrefix(data, pricePrefix) || strings.HasPrefix(data, chartPrefix) || strings.HasPrefix(data, candlePrefix)
+}
+
+// HandleCallbackQuery answers a click on a "did you mean" button with the chosen coin's price card or chart.
+func HandleCallbackQuery(bot *tgbotapi.BotAPI, db database.Store, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
 		return
 	}
-
-	// Define a struct to capture the detailed response from CoinGecko.
-	var result struct {
-		Symbol string `json:"symbol"`
-		Image  struct {
-			Large string `json:"large"`
-		} `json:"image"`
-		MarketData struct {
-			CurrentPrice struct {
-				USD float64 `json:"usd"`
-			} `json:"current_price"`
-		} `json:"market_data"`
+	l := i18n.For(db, query.Message.Chat, query.From)
+	chatID := query.Message.Chat.ID
+
+	var send func()
+	data, chartData := strings.CutPrefix(query.Data, chartPrefix)
+	data, candleData := strings.CutPrefix(data, candlePrefix)
+	if chartData || candleData {
+		parts := strings.SplitN(data, "_", 3)
+		if len(parts) != 3 {
+			return
+		}
+		send = func() { sendChart(bot, l, chatID, parts[2], parts[2], parts[1], parts[0], candleData) }
+	} else {
+		currency, coinID, ok := strings.Cut(strings.TrimPrefix(query.Data, pricePrefix), "_")
+		if !ok {
+			return
+		}
+		send = func() { sendPriceCard(bot, l, chatID, coinID, coinID, currency) }
 	}
 
-	if err := json.Unmarshal(body, &result); err != nil {
-		log.Printf("Failed to parse JSON from CoinGecko: %v", err)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Sorry, an error occurred while parsing the price data."))
+	bot.Request(tgbotapi.NewCallback(query.ID, ""))
+	// The question is answered, so the buttons go away.
+	bot.Request(tgbotapi.NewDeleteMessage(chatID, query.Message.MessageID))
+	send()
+}
+
+// sendPriceCard sends the price card of a coin. coinName is what the user typed, for error messages.
+func sendPriceCard(bot *tgbotapi.BotAPI, l i18n.Localizer, chatID int64, coinID, coinName, currency string) {
+	coin, err := Prices.Coin(context.Background(), coinID)
+	if err != nil {
+		log.Printf("Failed to fetch price for %q: %v", coinID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, PriceErrorText(l, coinName, err)))
 		return
 	}
 
-	// Create the caption for the photo.
-	caption := fmt.Sprintf("📈 **%s (%s) Price:**\n`$%.2f USD`",
-		strings.ToUpper(coinID),
-		strings.ToUpper(result.Symbol),
-		result.MarketData.CurrentPrice.USD,
-	)
-
 	// Send a photo with the price as the caption.
-	photoMsg := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FileURL(result.Image.Large))
-	photoMsg.Caption = caption
+	photoMsg := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(coin.ImageURL))
+	photoMsg.Caption = PriceCaption(l, coin, currency)
 	photoMsg.ParseMode = "Markdown"
 
 	if _, err := bot.Send(photoMsg); err != nil {
@@ -86,64 +103,133 @@ func HandlePriceCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbo
 	}
 }
 
-// HandleGasCommand remains the same.
-func HandleGasCommand(bot *tgbotapi.BotAPI, db *database.Client, message *tgbotapi.Message) {
-	apiKey := Cfg.EtherscanAPIKey
-	if apiKey == "" {
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Sorry, the gas command is not configured by the administrator."))
-		return
+// suggestionKeyboard has one button per suggested coin, whose callback data is the prefix
+// followed by the coin ID. Coins whose ID doesn't fit Telegram's 64 byte limit are left out.
+func suggestionKeyboard(suggestions []CoinListing, prefix string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, c := range suggestions {
+		data := prefix + c.ID
+		if len(data) > 64 {
+			continue
+		}
+		label := fmt.Sprintf("%s (%s)", c.Name, strings.ToUpper(c.Symbol))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, data)))
 	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
 
-	apiURL := fmt.Sprintf("https://api.etherscan.io/v2/api?chainid=1&module=gastracker&action=gasoracle&apikey=%s", apiKey)
+// HandleGasCommand replies with the current gas fees of a chain: /gas [chain], Ethereum by default.
+func HandleGasCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
 
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		log.Printf("Failed to call Etherscan Gas API: %v", err)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Sorry, an error occurred while fetching gas fees."))
+	name := strings.TrimSpace(message.CommandArguments())
+	chain, ok := LookupChain(name)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", name, strings.Join(ChainKeys(), ", "))))
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	ctx := context.Background()
+	gas, err := FetchGasPrices(ctx, chain)
 	if err != nil {
-		log.Printf("Failed to read Etherscan API response body: %v", err)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Sorry, an error occurred while processing gas fee data."))
+		log.Printf("Failed to fetch %s gas fees: %v", chain.Name, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, GasErrorText(l, err)))
 		return
 	}
 
-	var apiResponse struct {
-		Status  string `json:"status"`
-		Message string `json:"message"`
-		Result  struct {
-			SafeGasPrice    string `json:"SafeGasPrice"`
-			ProposeGasPrice string `json:"ProposeGasPrice"`
-			FastGasPrice    string `json:"FastGasPrice"`
-		} `json:"result"`
+	// Cost estimates are a bonus; without the native coin's price we still show the fees.
+	native, err := Prices.Coin(ctx, chain.NativeCoinID)
+	if err != nil {
+		log.Printf("Failed to fetch %s price for gas estimates: %v", chain.NativeSymbol, err)
+		native = nil
 	}
 
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		log.Printf("Failed to parse JSON from Etherscan: %v", err)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Sorry, an error occurred while parsing gas fee data."))
-		return
+	msg := tgbotapi.NewMessage(message.Chat.ID, GasText(l, gas, native))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// PriceCaption formats the Markdown caption of a price card in a currency, ending with when the data is from.
+// Lines for market data the provider didn't report are left out.
+func PriceCaption(l i18n.Localizer, coin *Coin, currency string) string {
+	quote, currency := coin.Quote(currency)
+
+	title := l.T("web3.price_title", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, coin.Name),
+		tgbotapi.EscapeText(tgbotapi.ModeMarkdown, strings.ToUpper(coin.Symbol)))
+	if coin.Rank > 0 {
+		title += l.T("web3.price_rank", coin.Rank)
+	}
+	lines := []string{
+		title,
+		l.T("web3.price_line", FormatMoney(currency, quote.Price)),
+		l.T("web3.price_change", FormatChange(coin.Change24h), FormatChange(coin.Change7d)),
+	}
+	if quote.MarketCap > 0 {
+		lines = append(lines, l.T("web3.price_market_cap", formatCompact(currency, quote.MarketCap)))
 	}
+	if quote.Volume24h > 0 {
+		lines = append(lines, l.T("web3.price_volume", formatCompact(currency, quote.Volume24h)))
+	}
+	if quote.ATH > 0 {
+		lines = append(lines, l.T("web3.price_ath", FormatMoney(currency, quote.ATH), quote.ATHChange))
+	}
+	if !coin.UpdatedAt.IsZero() {
+		lines = append(lines, l.T("web3.price_as_of", coin.UpdatedAt.UTC().Format("2006-01-02 15:04 UTC")))
+	}
+	return strings.Join(lines, "\n")
+}
 
-	if apiResponse.Status != "1" {
-		log.Printf("Etherscan API returned an error: %s", apiResponse.Message)
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "The gas fee API returned an error. Please check your API key."))
-		return
+// PriceErrorText explains a price provider error to the user.
+func PriceErrorText(l i18n.Localizer, coinName string, err error) string {
+	switch {
+	case errors.Is(err, ErrCoinNotFound):
+		return l.T("web3.price_not_found", coinName)
+	case errors.Is(err, ErrRateLimited):
+		return l.T("web3.price_rate_limited")
+	case errors.Is(err, ErrBadResponse):
+		return l.T("web3.price_parse_error")
+	default:
+		return l.T("web3.price_read_error")
 	}
+}
 
-	gasText := fmt.Sprintf(
-		"⛽️ *Current Ethereum Gas Fees:*\n\n"+
-			"🐢 *Slow (Safe):* `%s Gwei`\n"+
-			"🚗 *Standard (Propose):* `%s Gwei`\n"+
-			"🚀 *Fast:* `%s Gwei`",
-		apiResponse.Result.SafeGasPrice,
-		apiResponse.Result.ProposeGasPrice,
-		apiResponse.Result.FastGasPrice,
-	)
+// GasText formats the Markdown gas fee summary. With the price of the chain's native coin,
+// it adds what common actions cost at the standard fee.
+func GasText(l i18n.Localizer, gas *GasPrices, native *Coin) string {
+	lines := []string{l.T("web3.gas_title", gas.Chain.Name), ""}
+	if gas.BaseFee > 0 {
+		lines = append(lines, l.T("web3.gas_base_fee", FormatGwei(gas.BaseFee)))
+	}
+	for _, level := range []struct {
+		key  string
+		gwei float64
+	}{{"web3.gas_slow", gas.Slow}, {"web3.gas_standard", gas.Standard}, {"web3.gas_fast", gas.Fast}} {
+		line := l.T(level.key, FormatGwei(level.gwei))
+		if gas.BaseFee > 0 {
+			line += " " + l.T("web3.gas_priority", FormatGwei(gas.Priority(level.gwei)))
+		}
+		lines = append(lines, line)
+	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, gasText)
-	msg.ParseMode = "Markdown"
-	bot.Send(msg)
+	if native != nil {
+		quote, _ := native.Quote(DefaultCurrency)
+		lines = append(lines, "", l.T("web3.gas_costs_header"))
+		for _, action := range GasActions {
+			cost := GasCostUSD(gas.Standard, action, quote.Price)
+			lines = append(lines, l.T("web3.gas_action_"+action.Key, FormatMoney(DefaultCurrency, cost)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GasErrorText explains a FetchGasPrices error to the user.
+func GasErrorText(l i18n.Localizer, err error) string {
+	switch {
+	case errors.Is(err, ErrGasAPI):
+		return l.T("web3.gas_api_error")
+	case errors.Is(err, ErrBadResponse):
+		return l.T("web3.gas_parse_error")
+	default:
+		return l.T("web3.gas_fetch_error")
+	}
 }
@@ -0,0 +1,77 @@
+package web3
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+	"github.com/philip-857.bit/byb-bot/internal/tgfake"
+)
+
+func TestPriceCaptionEscapesMarkdown(t *testing.T) {
+	coin := &Coin{
+		ID:     "wrapped-steth",
+		Symbol: "w_steth*",
+		Name:   "Wrapped [stETH]",
+		Quotes: map[string]Quote{"usd": {Price: 4000}},
+	}
+	caption := PriceCaption(i18n.New("en"), coin, "usd")
+	title, _, _ := strings.Cut(caption, "\n")
+	for _, want := range []string{`W\_STETH\*`, `Wrapped \[stETH]`} {
+		if !strings.Contains(title, want) {
+			t.Errorf("title %q doesn't contain %q", title, want)
+		}
+	}
+}
+
+// usePrices makes providers, behind the usual cache, the ones the handlers ask until the test ends.
+func usePrices(t *testing.T, listings []CoinListing, providers ...PriceProvider) {
+	t.Helper()
+	previousPrices, previousCoins := Prices, Coins
+	t.Cleanup(func() { Prices, Coins = previousPrices, previousCoins })
+	Prices = NewCache(NewFailover(providers...), priceTTL, priceMaxStale)
+	Coins = NewResolver(nil)
+	Coins.SetListings(listings)
+}
+
+func TestHandlePriceCommandFailover(t *testing.T) {
+	_, server := tgfake.Bot(t)
+	db := database.NewMemory()
+	group := tgfake.Group(-100)
+	user := &tgbotapi.User{ID: 42, FirstName: "Ada"}
+	listings := []CoinListing{
+		{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Rank: 1},
+		{ID: "binancecoin", Symbol: "bnb", Name: "BNB", Rank: 4},
+	}
+
+	var geckoHits, cmcLookups atomic.Int32
+	gecko := testCoinGecko(serve(t, http.StatusOK, coinGeckoBitcoin, &geckoHits))
+	cmc := testCoinMarketCap(serveCoinMarketCap(t, &cmcLookups))
+	usePrices(t, listings, gecko, cmc)
+	cmc.Coins = Coins
+
+	got := server.Answer(t, HandlePriceCommand, db, tgfake.Command(group, user, "/price btc"))
+	if !strings.Contains(got, "$60,000.00") || geckoHits.Load() != 1 || cmcLookups.Load() != 0 {
+		t.Errorf("with CoinGecko up, /price btc answered %q after %d CoinGecko and %d CoinMarketCap lookups",
+			got, geckoHits.Load(), cmcLookups.Load())
+	}
+
+	// CoinGecko goes down, and CoinMarketCap answers under its own name for the coin.
+	gecko.BaseURL = serve(t, http.StatusInternalServerError, "", &geckoHits).URL
+	got = server.Answer(t, HandlePriceCommand, db, tgfake.Command(group, user, "/price bnb eur"))
+	if !strings.Contains(got, "BNB") || !strings.Contains(got, "€560.00") || cmcLookups.Load() != 1 {
+		t.Errorf("with CoinGecko down, /price bnb eur answered %q after %d CoinMarketCap lookups", got, cmcLookups.Load())
+	}
+
+	// A coin neither provider has is reported as unknown rather than as an outage.
+	Coins.SetListings(append(listings, CoinListing{ID: "delisted", Symbol: "gone", Name: "Delisted"}))
+	gecko.BaseURL = serve(t, http.StatusNotFound, `{"error": "coin not found"}`, nil).URL
+	got = server.Answer(t, HandlePriceCommand, db, tgfake.Command(group, user, "/price gone"))
+	if want := PriceErrorText(i18n.New("en"), "gone", ErrCoinNotFound); got != want {
+		t.Errorf("/price of a coin no provider has answered %q, want %q", got, want)
+	}
+}
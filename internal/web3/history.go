@@ -0,0 +1,193 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNoHistory is returned when no configured provider serves historical prices.
+var ErrNoHistory = errors.New("no price provider serves historical prices")
+
+// historyTTL is how long a price history is reused. Charts cover at least a day, so a few
+// minutes of delay don't show, and chart requests cost far more upstream than prices.
+const historyTTL = 5 * time.Minute
+
+// PricePoint is one sample of a coin's price history.
+type PricePoint struct {
+	Time  time.Time
+	Price float64
+}
+
+// PriceCandle is the open, high, low and close price of a coin over the interval starting at Time.
+type PriceCandle struct {
+	Time                   time.Time
+	Open, High, Low, Close float64
+}
+
+// HistoryProvider is implemented by price providers that serve historical prices.
+type HistoryProvider interface {
+	// History returns the prices of a coin over the last days, oldest first.
+	History(ctx context.Context, coinID, currency string, days int) ([]PricePoint, error)
+}
+
+// OHLCProvider is implemented by price providers that serve historical candles.
+type OHLCProvider interface {
+	// OHLC returns the candles of a coin over the last days, oldest first.
+	OHLC(ctx context.Context, coinID, currency string, days int) ([]PriceCandle, error)
+}
+
+// History implements HistoryProvider using the market chart endpoint. CoinGecko picks the
+// sample interval from the period: five minutes for a day, hourly up to 90 days, daily beyond.
+func (c *CoinGecko) History(ctx context.Context, coinID, currency string, days int) ([]PricePoint, error) {
+	apiURL := fmt.Sprintf("%s/coins/%s/market_chart?vs_currency=%s&days=%d",
+		c.BaseURL, url.PathEscape(coinID), url.QueryEscape(currency), days)
+
+	var result struct {
+		Prices [][2]float64 `json:"prices"` // [unix milliseconds, price]
+	}
+	if err := getJSON(ctx, c.Client, apiURL, c.headers(), &result); err != nil {
+		return nil, fmt.Errorf("coingecko history %q: %w", coinID, err)
+	}
+
+	points := make([]PricePoint, 0, len(result.Prices))
+	for _, p := range result.Prices {
+		points = append(points, PricePoint{Time: time.UnixMilli(int64(p[0])), Price: p[1]})
+	}
+	return points, nil
+}
+
+// OHLC implements OHLCProvider using the OHLC endpoint, which only takes 1, 7, 14, 30, 90,
+// 180 and 365 days. Candles span 30 minutes for up to two days, four hours up to 30 days and
+// four days beyond.
+func (c *CoinGecko) OHLC(ctx context.Context, coinID, currency string, days int) ([]PriceCandle, error) {
+	apiURL := fmt.Sprintf("%s/coins/%s/ohlc?vs_currency=%s&days=%d",
+		c.BaseURL, url.PathEscape(coinID), url.QueryEscape(currency), days)
+
+	var result [][5]float64 // [unix milliseconds, open, high, low, close]
+	if err := getJSON(ctx, c.Client, apiURL, c.headers(), &result); err != nil {
+		return nil, fmt.Errorf("coingecko ohlc %q: %w", coinID, err)
+	}
+
+	candles := make([]PriceCandle, 0, len(result))
+	for _, k := range result {
+		candles = append(candles, PriceCandle{Time: time.UnixMilli(int64(k[0])), Open: k[1], High: k[2], Low: k[3], Close: k[4]})
+	}
+	return candles, nil
+}
+
+// History implements HistoryProvider with the first provider that serves the history.
+func (f *Failover) History(ctx context.Context, coinID, currency string, days int) ([]PricePoint, error) {
+	lastErr := ErrNoHistory
+	for _, p := range f.providers {
+		hp, ok := p.(HistoryProvider)
+		if !ok || f.backingOff(p) {
+			continue
+		}
+		points, err := hp.History(ctx, coinID, currency, days)
+		if err == nil {
+			return points, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrRateLimited) {
+			f.backOff(p)
+		}
+		log.Printf("Price provider %s failed to load history of %q: %v", p.Name(), coinID, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// OHLC implements OHLCProvider with the first provider that serves candles.
+func (f *Failover) OHLC(ctx context.Context, coinID, currency string, days int) ([]PriceCandle, error) {
+	lastErr := ErrNoHistory
+	for _, p := range f.providers {
+		op, ok := p.(OHLCProvider)
+		if !ok || f.backingOff(p) {
+			continue
+		}
+		candles, err := op.OHLC(ctx, coinID, currency, days)
+		if err == nil {
+			return candles, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrRateLimited) {
+			f.backOff(p)
+		}
+		log.Printf("Price provider %s failed to load candles of %q: %v", p.Name(), coinID, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// History implements HistoryProvider, keeping each history for historyTTL.
+func (c *Cache) History(ctx context.Context, coinID, currency string, days int) ([]PricePoint, error) {
+	hp, ok := c.provider.(HistoryProvider)
+	if !ok {
+		return nil, ErrNoHistory
+	}
+	key := fmt.Sprintf("%s:%s:%d", strings.ToLower(coinID), currency, days)
+
+	c.mu.Lock()
+	entry, ok := c.histories[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < historyTTL {
+		return entry.points, nil
+	}
+
+	points, err := hp.History(ctx, coinID, currency, days)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.histories {
+		if now.Sub(e.fetchedAt) >= historyTTL {
+			delete(c.histories, k)
+		}
+	}
+	c.histories[key] = historyEntry{points: points, fetchedAt: now}
+	return points, nil
+}
+
+// OHLC implements OHLCProvider, keeping each set of candles for historyTTL.
+func (c *Cache) OHLC(ctx context.Context, coinID, currency string, days int) ([]PriceCandle, error) {
+	op, ok := c.provider.(OHLCProvider)
+	if !ok {
+		return nil, ErrNoHistory
+	}
+	key := fmt.Sprintf("%s:%s:%d", strings.ToLower(coinID), currency, days)
+
+	c.mu.Lock()
+	entry, ok := c.candles[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < historyTTL {
+		return entry.candles, nil
+	}
+
+	candles, err := op.OHLC(ctx, coinID, currency, days)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.candles {
+		if now.Sub(e.fetchedAt) >= historyTTL {
+			delete(c.candles, k)
+		}
+	}
+	c.candles[key] = candleEntry{candles: candles, fetchedAt: now}
+	return candles, nil
+}
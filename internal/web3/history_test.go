@@ -0,0 +1,75 @@
+package web3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// serveFixture answers requests for path with a file from testdata.
+func serveFixture(t *testing.T, path, file string) *CoinGecko {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return testCoinGecko(srv)
+}
+
+func TestCoinGeckoHistory(t *testing.T) {
+	c := serveFixture(t, "/coins/bitcoin/market_chart", "market_chart.json")
+	points, err := c.History(context.Background(), "bitcoin", "usd", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 6 {
+		t.Fatalf("got %d points, want 6", len(points))
+	}
+	first, last := points[0], points[len(points)-1]
+	if !first.Time.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || first.Price != 60321.52 {
+		t.Errorf("first point = %+v", first)
+	}
+	if !last.Time.Equal(time.Date(2024, 5, 1, 5, 0, 0, 0, time.UTC)) || last.Price != 59102.46 {
+		t.Errorf("last point = %+v", last)
+	}
+
+	chart := PriceChart("btc", "usd", "1d", points)
+	if chart.Title != "BTC/USD · 1d" || chart.TimeLayout != "15:04" || len(chart.Points) != 6 {
+		t.Errorf("chart = %+v", chart)
+	}
+	if low, high := chart.Range(); low != 58010.33 || high != 60321.52 {
+		t.Errorf("range = %v-%v", low, high)
+	}
+}
+
+func TestCoinGeckoOHLC(t *testing.T) {
+	c := serveFixture(t, "/coins/bitcoin/ohlc", "ohlc.json")
+	candles, err := c.OHLC(context.Background(), "bitcoin", "usd", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PriceCandle{Time: time.Date(2024, 5, 1, 4, 0, 0, 0, time.UTC), Open: 60112.08, High: 60200.4, Low: 57900, Close: 58010.33}
+	if len(candles) != 4 || !candles[1].Time.Equal(want.Time) || candles[1].Open != want.Open ||
+		candles[1].High != want.High || candles[1].Low != want.Low || candles[1].Close != want.Close {
+		t.Fatalf("candles = %+v, want the second to be %+v", candles, want)
+	}
+
+	chart := CandleChart("btc", "usd", "7d", candles)
+	if low, high := chart.Range(); low != 57900 || high != 60410 {
+		t.Errorf("range = %v-%v", low, high)
+	}
+	if change := chart.Change(); change > -2.32 || change < -2.33 {
+		t.Errorf("change = %v, want the first open to last close change of -2.32%%", change)
+	}
+}
@@ -0,0 +1,299 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoMarkets is returned when no configured provider ranks coins by market cap.
+	ErrNoMarkets = errors.New("no price provider ranks coins by market cap")
+	// ErrNoTrending is returned when no configured provider has a trending list.
+	ErrNoTrending = errors.New("no price provider has a trending list")
+)
+
+// marketsTTL is how long market cap rankings and trending lists are reused. They cover the
+// whole market, cost a lot more upstream than one price and barely change within minutes.
+const marketsTTL = 5 * time.Minute
+
+// MarketLister is implemented by price providers that rank coins by market cap.
+type MarketLister interface {
+	// TopCoins returns the n biggest coins by market cap, biggest first, with USD quotes and changes.
+	TopCoins(ctx context.Context, n int) ([]Coin, error)
+}
+
+// TrendingProvider is implemented by price providers that know which coins are trending.
+type TrendingProvider interface {
+	// Trending returns the provider's trending coins, most trending first. Quotes are in USD
+	// and may be missing for coins the provider has no price for.
+	Trending(ctx context.Context) ([]Coin, error)
+}
+
+type marketsEntry struct {
+	coins     []Coin
+	fetchedAt time.Time
+}
+
+// marketsLookup is an upstream list call that other callers can wait on.
+type marketsLookup struct {
+	done  chan struct{}
+	coins []Coin
+	err   error
+}
+
+// TopCoins implements MarketLister using the markets endpoint, which pages by 250.
+func (c *CoinGecko) TopCoins(ctx context.Context, n int) ([]Coin, error) {
+	apiURL := fmt.Sprintf("%s/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=%d&page=1&price_change_percentage=24h,7d",
+		c.BaseURL, min(n, 250))
+
+	var markets []struct {
+		ID          string    `json:"id"`
+		Symbol      string    `json:"symbol"`
+		Name        string    `json:"name"`
+		Image       string    `json:"image"`
+		Price       float64   `json:"current_price"`
+		MarketCap   float64   `json:"market_cap"`
+		Rank        int       `json:"market_cap_rank"`
+		Volume      float64   `json:"total_volume"`
+		Change24h   float64   `json:"price_change_percentage_24h_in_currency"`
+		Change7d    float64   `json:"price_change_percentage_7d_in_currency"`
+		LastUpdated time.Time `json:"last_updated"`
+	}
+	if err := getJSON(ctx, c.Client, apiURL, c.headers(), &markets); err != nil {
+		return nil, fmt.Errorf("coingecko markets: %w", err)
+	}
+
+	coins := make([]Coin, 0, len(markets))
+	for _, m := range markets {
+		coins = append(coins, Coin{
+			ID:        m.ID,
+			Symbol:    m.Symbol,
+			Name:      m.Name,
+			ImageURL:  m.Image,
+			Rank:      m.Rank,
+			Change24h: m.Change24h,
+			Change7d:  m.Change7d,
+			Quotes:    map[string]Quote{DefaultCurrency: {Price: m.Price, MarketCap: m.MarketCap, Volume24h: m.Volume}},
+			UpdatedAt: m.LastUpdated,
+		})
+	}
+	return coins, nil
+}
+
+// Trending implements TrendingProvider using the trending search list.
+func (c *CoinGecko) Trending(ctx context.Context) ([]Coin, error) {
+	var result struct {
+		Coins []struct {
+			Item struct {
+				ID     string `json:"id"`
+				Symbol string `json:"symbol"`
+				Name   string `json:"name"`
+				Thumb  string `json:"thumb"`
+				Rank   int    `json:"market_cap_rank"`
+				Data   struct {
+					Price     float64            `json:"price"`
+					Change24h map[string]float64 `json:"price_change_percentage_24h"`
+				} `json:"data"`
+			} `json:"item"`
+		} `json:"coins"`
+	}
+	if err := getJSON(ctx, c.Client, c.BaseURL+"/search/trending", c.headers(), &result); err != nil {
+		return nil, fmt.Errorf("coingecko trending: %w", err)
+	}
+
+	coins := make([]Coin, 0, len(result.Coins))
+	for _, entry := range result.Coins {
+		item := entry.Item
+		coin := Coin{
+			ID:        item.ID,
+			Symbol:    item.Symbol,
+			Name:      item.Name,
+			ImageURL:  item.Thumb,
+			Rank:      item.Rank,
+			Change24h: item.Data.Change24h[DefaultCurrency],
+		}
+		if item.Data.Price > 0 {
+			coin.Quotes = map[string]Quote{DefaultCurrency: {Price: item.Data.Price}}
+		}
+		coins = append(coins, coin)
+	}
+	return coins, nil
+}
+
+// TopCoins implements MarketLister using the listings endpoint, which is sorted by market cap.
+func (c *CoinMarketCap) TopCoins(ctx context.Context, n int) ([]Coin, error) {
+	apiURL := fmt.Sprintf("%s/v1/cryptocurrency/listings/latest?limit=%d&convert=USD", c.BaseURL, n)
+	headers := map[string]string{"X-CMC_PRO_API_KEY": c.APIKey}
+
+	var result struct {
+		Data []struct {
+			Name    string `json:"name"`
+			Symbol  string `json:"symbol"`
+			Slug    string `json:"slug"`
+			CMCRank int    `json:"cmc_rank"`
+			Quote   struct {
+				USD struct {
+					Price            float64   `json:"price"`
+					MarketCap        float64   `json:"market_cap"`
+					Volume24h        float64   `json:"volume_24h"`
+					PercentChange24h float64   `json:"percent_change_24h"`
+					PercentChange7d  float64   `json:"percent_change_7d"`
+					LastUpdated      time.Time `json:"last_updated"`
+				} `json:"USD"`
+			} `json:"quote"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, c.Client, apiURL, headers, &result); err != nil {
+		return nil, fmt.Errorf("coinmarketcap listings: %w", err)
+	}
+
+	coins := make([]Coin, 0, len(result.Data))
+	for _, entry := range result.Data {
+		usd := entry.Quote.USD
+		coins = append(coins, Coin{
+			ID:        entry.Slug,
+			Symbol:    strings.ToLower(entry.Symbol),
+			Name:      entry.Name,
+			Rank:      entry.CMCRank,
+			Change24h: usd.PercentChange24h,
+			Change7d:  usd.PercentChange7d,
+			Quotes:    map[string]Quote{DefaultCurrency: {Price: usd.Price, MarketCap: usd.MarketCap, Volume24h: usd.Volume24h}},
+			UpdatedAt: usd.LastUpdated,
+		})
+	}
+	return coins, nil
+}
+
+// TopCoins implements MarketLister with the coins the provider was given, ranked by their USD market cap.
+func (p *StaticProvider) TopCoins(ctx context.Context, n int) ([]Coin, error) {
+	p.mu.RLock()
+	coins := make([]Coin, 0, len(p.coins))
+	for _, coin := range p.coins {
+		coins = append(coins, coin)
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(coins, func(i, j int) bool {
+		return coins[i].Quotes[DefaultCurrency].MarketCap > coins[j].Quotes[DefaultCurrency].MarketCap
+	})
+	return coins[:min(n, len(coins))], nil
+}
+
+// TopCoins implements MarketLister with the first provider that ranks coins.
+func (f *Failover) TopCoins(ctx context.Context, n int) ([]Coin, error) {
+	lastErr := ErrNoMarkets
+	for _, p := range f.providers {
+		lister, ok := p.(MarketLister)
+		if !ok || f.backingOff(p) {
+			continue
+		}
+		coins, err := lister.TopCoins(ctx, n)
+		if err == nil {
+			return coins, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrRateLimited) {
+			f.backOff(p)
+		}
+		log.Printf("Price provider %s failed to rank coins: %v", p.Name(), err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Trending implements TrendingProvider with the first provider that has a trending list.
+func (f *Failover) Trending(ctx context.Context) ([]Coin, error) {
+	lastErr := ErrNoTrending
+	for _, p := range f.providers {
+		tp, ok := p.(TrendingProvider)
+		if !ok || f.backingOff(p) {
+			continue
+		}
+		coins, err := tp.Trending(ctx)
+		if err == nil {
+			return coins, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrRateLimited) {
+			f.backOff(p)
+		}
+		log.Printf("Price provider %s failed to list trending coins: %v", p.Name(), err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// TopCoins implements MarketLister, keeping each ranking for marketsTTL.
+func (c *Cache) TopCoins(ctx context.Context, n int) ([]Coin, error) {
+	lister, ok := c.provider.(MarketLister)
+	if !ok {
+		return nil, ErrNoMarkets
+	}
+	return c.markets(ctx, fmt.Sprintf("top:%d", n), func(ctx context.Context) ([]Coin, error) { return lister.TopCoins(ctx, n) })
+}
+
+// Trending implements TrendingProvider, keeping the list for marketsTTL.
+func (c *Cache) Trending(ctx context.Context) ([]Coin, error) {
+	tp, ok := c.provider.(TrendingProvider)
+	if !ok {
+		return nil, ErrNoTrending
+	}
+	return c.markets(ctx, "trending", tp.Trending)
+}
+
+// markets serves a market-wide list from memory, or fetches and keeps it. Like Coin, concurrent
+// callers share one upstream call, and a failed call falls back to a list up to maxStale old.
+func (c *Cache) markets(ctx context.Context, key string, fetch func(context.Context) ([]Coin, error)) ([]Coin, error) {
+	c.mu.Lock()
+	if entry, ok := c.marketLists[key]; ok && time.Since(entry.fetchedAt) < marketsTTL {
+		c.mu.Unlock()
+		return entry.coins, nil
+	}
+	call, ok := c.marketCalls[key]
+	if !ok {
+		call = &marketsLookup{done: make(chan struct{})}
+		c.marketCalls[key] = call
+		go c.fetchMarkets(context.WithoutCancel(ctx), key, fetch, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return call.coins, call.err
+}
+
+// fetchMarkets asks the provider for a list and stores the answer, or falls back to the last known one.
+func (c *Cache) fetchMarkets(ctx context.Context, key string, fetch func(context.Context) ([]Coin, error), call *marketsLookup) {
+	coins, err := fetch(ctx)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(call.done)
+	delete(c.marketCalls, key)
+
+	if err == nil {
+		c.marketLists[key] = marketsEntry{coins: coins, fetchedAt: now}
+		call.coins = coins
+		return
+	}
+	entry, ok := c.marketLists[key]
+	if ok && now.Sub(entry.fetchedAt) < c.maxStale {
+		log.Printf("Serving cached %s list from %s after lookup failed: %v", key, entry.fetchedAt.Format(time.RFC3339), err)
+		call.coins = entry.coins
+		return
+	}
+	call.err = err
+}
@@ -0,0 +1,70 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowLister ranks coins after a delay, failing while fail is set.
+type slowLister struct {
+	*StaticProvider
+	calls atomic.Int32
+	fail  atomic.Bool
+}
+
+func (p *slowLister) TopCoins(ctx context.Context, n int) ([]Coin, error) {
+	p.calls.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	if p.fail.Load() {
+		return nil, ErrRateLimited
+	}
+	return []Coin{{ID: "bitcoin"}, {ID: "ethereum"}}[:n], nil
+}
+
+func TestCacheTopCoins(t *testing.T) {
+	lister := &slowLister{StaticProvider: NewStaticProvider()}
+	cache := NewCache(lister, time.Minute, time.Hour)
+	ctx := context.Background()
+
+	// Concurrent callers share one upstream call.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if coins, err := cache.TopCoins(ctx, 2); err != nil || len(coins) != 2 {
+				t.Errorf("TopCoins = %v, %v", coins, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls := lister.calls.Load(); calls != 1 {
+		t.Errorf("10 concurrent callers made %d upstream calls", calls)
+	}
+
+	// Once expired, a failed refresh serves the last list while it isn't too old.
+	lister.fail.Store(true)
+	cache.mu.Lock()
+	entry := cache.marketLists["top:2"]
+	entry.fetchedAt = time.Now().Add(-marketsTTL)
+	cache.marketLists["top:2"] = entry
+	cache.mu.Unlock()
+	if coins, err := cache.TopCoins(ctx, 2); err != nil || len(coins) != 2 {
+		t.Errorf("stale TopCoins = %v, %v", coins, err)
+	}
+
+	cache.mu.Lock()
+	entry.fetchedAt = time.Now().Add(-2 * time.Hour)
+	cache.marketLists["top:2"] = entry
+	cache.mu.Unlock()
+	if _, err := cache.TopCoins(ctx, 2); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("too stale TopCoins: err = %v, want ErrRateLimited", err)
+	}
+	if _, err := cache.TopCoins(ctx, 1); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("uncached TopCoins: err = %v, want ErrRateLimited", err)
+	}
+}
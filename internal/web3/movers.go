@@ -0,0 +1,174 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+)
+
+const (
+	// moversUniverse is how many of the biggest coins /movers ranks. Below that, thin markets
+	// would fill the list with coins nobody in the chat has heard of.
+	moversUniverse = 100
+	// moversShown is how many gainers and how many losers /movers lists.
+	moversShown = 5
+	// trendingShown caps the /trending table.
+	trendingShown = 10
+	// tableSymbolWidth is the width of the symbol column; longer symbols are cut.
+	tableSymbolWidth = 8
+)
+
+// moversPeriods maps what users type after /movers to the period it shows.
+var moversPeriods = map[string]string{
+	"":    "24h",
+	"24h": "24h",
+	"1d":  "24h",
+	"7d":  "7d",
+	"1w":  "7d",
+}
+
+// HandleTrendingCommand replies with the price provider's trending coins: /trending.
+func HandleTrendingCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	trending, ok := Prices.(TrendingProvider)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.markets_unavailable")))
+		return
+	}
+
+	coins, err := trending.Trending(context.Background())
+	if err == nil && len(coins) == 0 {
+		err = ErrBadResponse
+	}
+	if err != nil {
+		log.Printf("Failed to fetch trending coins: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, MarketsErrorText(l, err)))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, TrendingText(l, coins[:min(len(coins), trendingShown)]))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// HandleMoversCommand replies with the biggest gainers and losers among the top coins by
+// market cap: /movers [24h|7d].
+func HandleMoversCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+	period, ok := moversPeriods[strings.ToLower(strings.TrimSpace(message.CommandArguments()))]
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.movers_usage")))
+		return
+	}
+	lister, ok := Prices.(MarketLister)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.markets_unavailable")))
+		return
+	}
+
+	coins, err := lister.TopCoins(context.Background(), moversUniverse)
+	if err == nil && len(coins) == 0 {
+		err = ErrBadResponse
+	}
+	if err != nil {
+		log.Printf("Failed to fetch top coins for movers: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, MarketsErrorText(l, err)))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, MoversText(l, coins, period))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// TrendingText formats trending coins as a Markdown table of rank, price and 24h change.
+func TrendingText(l i18n.Localizer, coins []Coin) string {
+	rows := make([]string, len(coins))
+	for i := range coins {
+		rows[i] = fmt.Sprintf("%2d %s", i+1, tableRow(&coins[i], coins[i].Change24h))
+	}
+	return l.T("web3.trending_title") + "\n" + codeBlock(rows)
+}
+
+// MoversText formats the biggest gainers and losers of a period, "24h" or "7d", among coins
+// ranked by market cap. The gainers only list coins that went up, the losers coins that went down.
+func MoversText(l i18n.Localizer, coins []Coin, period string) string {
+	change := func(c *Coin) float64 { return c.Change24h }
+	if period == "7d" {
+		change = func(c *Coin) float64 { return c.Change7d }
+	}
+
+	sorted := make([]*Coin, len(coins))
+	for i := range coins {
+		sorted[i] = &coins[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return change(sorted[i]) > change(sorted[j]) })
+
+	var gainers, losers []string
+	for _, c := range sorted {
+		if len(gainers) == moversShown || change(c) <= 0 {
+			break
+		}
+		gainers = append(gainers, tableRow(c, change(c)))
+	}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		c := sorted[i]
+		if len(losers) == moversShown || change(c) >= 0 {
+			break
+		}
+		losers = append(losers, tableRow(c, change(c)))
+	}
+
+	lines := []string{l.T("web3.movers_title", period, len(coins)), ""}
+	lines = append(lines, l.T("web3.movers_gainers"))
+	if len(gainers) > 0 {
+		lines = append(lines, codeBlock(gainers))
+	} else {
+		lines = append(lines, l.T("web3.movers_none"))
+	}
+	lines = append(lines, l.T("web3.movers_losers"))
+	if len(losers) > 0 {
+		lines = append(lines, codeBlock(losers))
+	} else {
+		lines = append(lines, l.T("web3.movers_none"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tableRow formats a coin as a fixed-width row of symbol, USD price and change.
+func tableRow(c *Coin, change float64) string {
+	symbol := strings.ToUpper(c.Symbol)
+	if runes := []rune(symbol); len(runes) > tableSymbolWidth {
+		symbol = string(runes[:tableSymbolWidth])
+	}
+	price := "-"
+	if quote, ok := c.Quotes[DefaultCurrency]; ok && quote.Price > 0 {
+		price = FormatMoney(DefaultCurrency, quote.Price)
+	}
+	return fmt.Sprintf("%-*s %14s %+8.2f%%", tableSymbolWidth, symbol, price, change)
+}
+
+// codeBlock wraps rows in a Markdown code block, so their columns line up. Backticks would
+// end the block early, so they are dropped.
+func codeBlock(rows []string) string {
+	return "```\n" + strings.ReplaceAll(strings.Join(rows, "\n"), "`", "") + "\n```"
+}
+
+// MarketsErrorText explains a TopCoins or Trending error to the user.
+func MarketsErrorText(l i18n.Localizer, err error) string {
+	switch {
+	case errors.Is(err, ErrNoMarkets), errors.Is(err, ErrNoTrending):
+		return l.T("web3.markets_unavailable")
+	case errors.Is(err, ErrRateLimited):
+		return l.T("web3.price_rate_limited")
+	default:
+		return l.T("web3.markets_error")
+	}
+}
@@ -0,0 +1,185 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCollectionNotFound is returned when an NFT provider doesn't know a collection or contract.
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// nftTTL is how long collection stats are served from memory. Floors move slower than prices.
+const nftTTL = 5 * time.Minute
+
+// Collection is what /nft shows about an NFT collection.
+type Collection struct {
+	Slug     string
+	Name     string
+	ImageURL string
+	URL      string
+	// Chain and Contract are those of the collection's main contract, if the provider knows it.
+	Chain    string
+	Contract string
+	// FloorPrice is in FloorSymbol, usually the chain's native coin. 0 means no listings.
+	FloorPrice  float64
+	FloorSymbol string
+	// Volume24h is traded in the last day, in the same coin as the floor.
+	Volume24h float64
+	Sales24h  int
+	Holders   int
+	Supply    int
+}
+
+// NFTProvider looks up NFT collection stats. Handlers only depend on this interface, so the
+// OpenSea API, or a fake in tests, can stand behind them.
+type NFTProvider interface {
+	// Name identifies the provider in logs.
+	Name() string
+	// Collection returns the stats of a collection by its slug, like "pudgypenguins", or an
+	// error wrapping ErrCollectionNotFound.
+	Collection(ctx context.Context, slug string) (*Collection, error)
+	// CollectionSlug returns the slug of the collection at a contract address.
+	CollectionSlug(ctx context.Context, chain *Chain, contract string) (string, error)
+}
+
+// NFTs is the provider used by the NFT handlers, or nil when none is configured. Configure sets it on startup.
+var NFTs NFTProvider
+
+// FindCollection looks a collection up by what a user typed: a slug, or a contract address on a chain.
+func FindCollection(ctx context.Context, provider NFTProvider, query string, chain *Chain) (*Collection, error) {
+	slug := strings.ToLower(strings.TrimSpace(query))
+	if IsAddress(query) {
+		contract, err := ParseAddress(query)
+		if err != nil {
+			return nil, err
+		}
+		if slug, err = provider.CollectionSlug(ctx, chain, contract); err != nil {
+			return nil, err
+		}
+	}
+	return provider.Collection(ctx, slug)
+}
+
+// NFTCache is an NFTProvider that remembers collections for a while and contract slugs for good,
+// so a pinned summary asked for by several chats costs one upstream call per collection.
+type NFTCache struct {
+	provider NFTProvider
+	ttl      time.Duration
+
+	mu          sync.Mutex
+	collections map[string]collectionEntry
+	slugs       map[string]string // Keyed by chain key and lowercase contract
+}
+
+type collectionEntry struct {
+	collection Collection
+	fetchedAt  time.Time
+}
+
+// NewNFTCache wraps a provider with a cache that keeps collections for ttl.
+func NewNFTCache(provider NFTProvider, ttl time.Duration) *NFTCache {
+	return &NFTCache{
+		provider:    provider,
+		ttl:         ttl,
+		collections: make(map[string]collectionEntry),
+		slugs:       make(map[string]string),
+	}
+}
+
+// Name implements NFTProvider.
+func (c *NFTCache) Name() string { return "cache(" + c.provider.Name() + ")" }
+
+// Collection implements NFTProvider.
+func (c *NFTCache) Collection(ctx context.Context, slug string) (*Collection, error) {
+	key := strings.ToLower(slug)
+	c.mu.Lock()
+	entry, ok := c.collections[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < c.ttl {
+		collection := entry.collection
+		return &collection, nil
+	}
+
+	collection, err := c.provider.Collection(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.collections[key] = collectionEntry{collection: *collection, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return collection, nil
+}
+
+// CollectionSlug implements NFTProvider. A contract's collection never changes, so slugs don't expire.
+func (c *NFTCache) CollectionSlug(ctx context.Context, chain *Chain, contract string) (string, error) {
+	key := chain.Key + ":" + strings.ToLower(contract)
+	c.mu.Lock()
+	slug, ok := c.slugs[key]
+	c.mu.Unlock()
+	if ok {
+		return slug, nil
+	}
+
+	slug, err := c.provider.CollectionSlug(ctx, chain, contract)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.slugs[key] = slug
+	c.mu.Unlock()
+	return slug, nil
+}
+
+// StaticNFTProvider serves collections from memory. It is the fake NFT provider in tests.
+type StaticNFTProvider struct {
+	mu          sync.RWMutex
+	collections map[string]Collection
+}
+
+// NewStaticNFTProvider returns a provider that knows exactly the given collections.
+func NewStaticNFTProvider(collections ...Collection) *StaticNFTProvider {
+	p := &StaticNFTProvider{collections: make(map[string]Collection)}
+	for _, c := range collections {
+		p.Set(c)
+	}
+	return p
+}
+
+// Set adds or replaces a collection.
+func (p *StaticNFTProvider) Set(collection Collection) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.collections[strings.ToLower(collection.Slug)] = collection
+}
+
+// Name implements NFTProvider.
+func (p *StaticNFTProvider) Name() string { return "static" }
+
+// Collection implements NFTProvider.
+func (p *StaticNFTProvider) Collection(ctx context.Context, slug string) (*Collection, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	collection, ok := p.collections[strings.ToLower(slug)]
+	if !ok {
+		return nil, fmt.Errorf("static %q: %w", slug, ErrCollectionNotFound)
+	}
+	return &collection, nil
+}
+
+// CollectionSlug implements NFTProvider by matching the contracts of the known collections.
+func (p *StaticNFTProvider) CollectionSlug(ctx context.Context, chain *Chain, contract string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, c := range p.collections {
+		if c.Chain == chain.Key && strings.EqualFold(c.Contract, contract) {
+			return c.Slug, nil
+		}
+	}
+	return "", fmt.Errorf("static contract %s: %w", contract, ErrCollectionNotFound)
+}
@@ -0,0 +1,130 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// openSeaChains maps our chain keys to the chain names OpenSea uses in its API.
+var openSeaChains = map[string]string{
+	"ethereum":  "ethereum",
+	"polygon":   "matic",
+	"arbitrum":  "arbitrum",
+	"optimism":  "optimism",
+	"base":      "base",
+	"avalanche": "avalanche",
+	"bsc":       "bsc",
+}
+
+// OpenSea fetches NFT collection stats from the OpenSea v2 API, which needs an API key.
+type OpenSea struct {
+	// BaseURL is the API root. Tests point it at an httptest server.
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+// NewOpenSea returns an OpenSea provider for the public API.
+func NewOpenSea(apiKey string) *OpenSea {
+	return &OpenSea{
+		BaseURL: "https://api.opensea.io/api/v2",
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Name implements NFTProvider.
+func (o *OpenSea) Name() string { return "opensea" }
+
+// Collection implements NFTProvider. The collection and its stats are separate endpoints.
+func (o *OpenSea) Collection(ctx context.Context, slug string) (*Collection, error) {
+	var info struct {
+		Collection  string `json:"collection"`
+		Name        string `json:"name"`
+		ImageURL    string `json:"image_url"`
+		OpenSeaURL  string `json:"opensea_url"`
+		TotalSupply int    `json:"total_supply"`
+		Contracts   []struct {
+			Address string `json:"address"`
+			Chain   string `json:"chain"`
+		} `json:"contracts"`
+	}
+	if err := o.get(ctx, "/collections/"+url.PathEscape(slug), &info); err != nil {
+		return nil, fmt.Errorf("opensea collection %q: %w", slug, err)
+	}
+
+	var stats struct {
+		Total struct {
+			FloorPrice       float64 `json:"floor_price"`
+			FloorPriceSymbol string  `json:"floor_price_symbol"`
+			NumOwners        int     `json:"num_owners"`
+		} `json:"total"`
+		Intervals []struct {
+			Interval string  `json:"interval"`
+			Volume   float64 `json:"volume"`
+			Sales    int     `json:"sales"`
+		} `json:"intervals"`
+	}
+	if err := o.get(ctx, "/collections/"+url.PathEscape(slug)+"/stats", &stats); err != nil {
+		return nil, fmt.Errorf("opensea stats %q: %w", slug, err)
+	}
+
+	c := &Collection{
+		Slug:        info.Collection,
+		Name:        info.Name,
+		ImageURL:    info.ImageURL,
+		URL:         info.OpenSeaURL,
+		Supply:      info.TotalSupply,
+		Holders:     stats.Total.NumOwners,
+		FloorPrice:  stats.Total.FloorPrice,
+		FloorSymbol: stats.Total.FloorPriceSymbol,
+	}
+	if len(info.Contracts) > 0 {
+		c.Contract = ChecksumAddress(info.Contracts[0].Address)
+		for key, name := range openSeaChains {
+			if name == info.Contracts[0].Chain {
+				c.Chain = key
+			}
+		}
+	}
+	for _, interval := range stats.Intervals {
+		if interval.Interval == "one_day" {
+			c.Volume24h, c.Sales24h = interval.Volume, interval.Sales
+		}
+	}
+	return c, nil
+}
+
+// CollectionSlug implements NFTProvider.
+func (o *OpenSea) CollectionSlug(ctx context.Context, chain *Chain, contract string) (string, error) {
+	chainName, ok := openSeaChains[chain.Key]
+	if !ok {
+		return "", fmt.Errorf("opensea doesn't support %s: %w", chain.Name, ErrCollectionNotFound)
+	}
+	var result struct {
+		Collection string `json:"collection"`
+	}
+	path := fmt.Sprintf("/chain/%s/contract/%s", chainName, strings.ToLower(contract))
+	if err := o.get(ctx, path, &result); err != nil {
+		return "", fmt.Errorf("opensea contract %s: %w", contract, err)
+	}
+	if result.Collection == "" {
+		return "", fmt.Errorf("opensea contract %s has no collection: %w", contract, ErrCollectionNotFound)
+	}
+	return result.Collection, nil
+}
+
+// get calls an API path, reporting unknown collections and contracts as ErrCollectionNotFound.
+func (o *OpenSea) get(ctx context.Context, path string, dst interface{}) error {
+	err := getJSON(ctx, o.Client, o.BaseURL+path, map[string]string{"x-api-key": o.APIKey}, dst)
+	var status *statusError
+	if errors.Is(err, ErrCoinNotFound) || (errors.As(err, &status) && status.code == http.StatusBadRequest) {
+		// OpenSea answers malformed slugs and unknown contracts with 400 rather than 404.
+		return ErrCollectionNotFound
+	}
+	return err
+}
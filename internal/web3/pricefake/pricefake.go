@@ -0,0 +1,31 @@
+// Package pricefake serves fixed prices to the web3 price lookups in tests, instead of
+// depending on a price API.
+package pricefake
+
+import (
+	"testing"
+
+	"github.com/philip-857.bit/byb-bot/internal/web3"
+)
+
+// Ether is ether at $2,000.
+var Ether = web3.Coin{ID: "ethereum", Symbol: "eth", Name: "Ethereum",
+	Quotes: map[string]web3.Quote{web3.DefaultCurrency: {Price: 2000}}}
+
+// Serve makes coins the only ones web3.Prices and web3.Coins know, ranked in the order
+// given, until the test ends.
+func Serve(t testing.TB, coins ...web3.Coin) *web3.StaticProvider {
+	t.Helper()
+	previousPrices, previousCoins := web3.Prices, web3.Coins
+	t.Cleanup(func() { web3.Prices, web3.Coins = previousPrices, previousCoins })
+
+	provider := web3.NewStaticProvider(coins...)
+	listings := make([]web3.CoinListing, 0, len(coins))
+	for i, c := range coins {
+		listings = append(listings, web3.CoinListing{ID: c.ID, Symbol: c.Symbol, Name: c.Name, Rank: i + 1})
+	}
+	web3.Prices = provider
+	web3.Coins = web3.NewResolver(nil)
+	web3.Coins.SetListings(listings)
+	return provider
+}
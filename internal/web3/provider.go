@@ -0,0 +1,182 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/philip-857.bit/byb-bot/internal/config"
+)
+
+// ErrRateLimited is returned when a price API refuses a request because we sent too many.
+var ErrRateLimited = errors.New("rate limited by price API")
+
+// PriceProvider looks up market data for coins identified by CoinGecko-style IDs such as "bitcoin".
+// Handlers only depend on this interface, so any backend, or a fake in tests, can stand behind them.
+type PriceProvider interface {
+	// Name identifies the provider in logs.
+	Name() string
+	// Coin returns the market data of a coin, or an error wrapping ErrCoinNotFound if the provider doesn't list it.
+	Coin(ctx context.Context, coinID string) (*Coin, error)
+}
+
+// Prices is the provider used by the price handlers. Configure sets it on startup.
+var Prices PriceProvider
+
+// Configure stores the config, builds the cached price provider chain from it, starts
+// keeping the coin list used for symbol resolution fresh and sets up the NFT provider.
+func Configure(cfg *config.Config) {
+	Cfg = cfg
+
+	providers, err := NewProviders(cfg)
+	if err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+	if len(providers) == 0 {
+		log.Println("WARNING: No usable price providers configured, falling back to CoinGecko.")
+		providers = []PriceProvider{NewCoinGecko(cfg.CoinGeckoAPIKey, cfg.CoinGeckoPro)}
+	}
+	failover := NewFailover(providers...)
+	Prices = NewCache(failover, priceTTL, priceMaxStale)
+
+	Coins = NewResolver(failover)
+	for _, p := range providers {
+		// CoinMarketCap matches coins by the symbol and name the coin list gives them.
+		if cmc, ok := p.(*CoinMarketCap); ok {
+			cmc.Coins = Coins
+		}
+	}
+	go Coins.Run(context.Background())
+
+	if cfg.OpenSeaAPIKey == "" {
+		log.Println("WARNING: OPENSEA_API_KEY not set. NFT collection stats are disabled.")
+	} else {
+		NFTs = NewNFTCache(NewOpenSea(cfg.OpenSeaAPIKey), nftTTL)
+	}
+}
+
+// NewProviders builds the providers listed in the config, in order. Providers that are
+// missing their API key or data file are skipped and reported in the returned error.
+func NewProviders(cfg *config.Config) ([]PriceProvider, error) {
+	var providers []PriceProvider
+	var problems []string
+
+	for _, name := range cfg.PriceProviders {
+		switch name {
+		case "coingecko":
+			providers = append(providers, NewCoinGecko(cfg.CoinGeckoAPIKey, cfg.CoinGeckoPro))
+		case "coinmarketcap", "cmc":
+			if cfg.CoinMarketCapAPIKey == "" {
+				problems = append(problems, "COINMARKETCAP_API_KEY not set, skipping CoinMarketCap")
+				continue
+			}
+			providers = append(providers, NewCoinMarketCap(cfg.CoinMarketCapAPIKey))
+		case "static":
+			if cfg.PriceStaticFile == "" {
+				problems = append(problems, "PRICE_STATIC_FILE not set, skipping static prices")
+				continue
+			}
+			static, err := LoadStaticProvider(cfg.PriceStaticFile)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			providers = append(providers, static)
+		default:
+			problems = append(problems, fmt.Sprintf("unknown price provider %q", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return providers, errors.New(strings.Join(problems, "; "))
+	}
+	return providers, nil
+}
+
+// rateLimitBackoff is how long a provider is skipped after it rate limits us.
+const rateLimitBackoff = time.Minute
+
+// Failover asks its providers in order and returns the first answer. A provider that
+// rate limits us is skipped for a while, so we don't keep hammering it during a spike.
+type Failover struct {
+	providers []PriceProvider
+
+	mu          sync.Mutex
+	backoffTill map[string]time.Time
+}
+
+// NewFailover returns a provider that tries each of the given providers in order.
+func NewFailover(providers ...PriceProvider) *Failover {
+	return &Failover{
+		providers:   providers,
+		backoffTill: make(map[string]time.Time),
+	}
+}
+
+// Name lists the chained providers.
+func (f *Failover) Name() string {
+	names := make([]string, len(f.providers))
+	for i, p := range f.providers {
+		names[i] = p.Name()
+	}
+	return "failover(" + strings.Join(names, ",") + ")"
+}
+
+// Coin returns the answer of the first provider that has the coin. If every provider that
+// answered says the coin doesn't exist, the error wraps ErrCoinNotFound; otherwise the last
+// failure is returned.
+func (f *Failover) Coin(ctx context.Context, coinID string) (*Coin, error) {
+	var lastErr error
+	notFound := 0
+	tried := 0
+
+	for _, p := range f.providers {
+		if f.backingOff(p) {
+			continue
+		}
+		tried++
+
+		coin, err := p.Coin(ctx, coinID)
+		if err == nil {
+			return coin, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		switch {
+		case errors.Is(err, ErrCoinNotFound):
+			notFound++
+		case errors.Is(err, ErrRateLimited):
+			f.backOff(p)
+			log.Printf("Price provider %s rate limited us, skipping it for %s", p.Name(), rateLimitBackoff)
+		default:
+			log.Printf("Price provider %s failed for %q: %v", p.Name(), coinID, err)
+		}
+		lastErr = err
+	}
+
+	if tried == 0 {
+		return nil, fmt.Errorf("all price providers are backing off: %w", ErrRateLimited)
+	}
+	if notFound == tried {
+		return nil, fmt.Errorf("no provider lists %q: %w", coinID, ErrCoinNotFound)
+	}
+	return nil, lastErr
+}
+
+func (f *Failover) backingOff(p PriceProvider) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return time.Now().Before(f.backoffTill[p.Name()])
+}
+
+func (f *Failover) backOff(p PriceProvider) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backoffTill[p.Name()] = time.Now().Add(rateLimitBackoff)
+}
@@ -0,0 +1,400 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const coinGeckoBitcoin = `{
+	"id": "bitcoin",
+	"symbol": "btc",
+	"name": "Bitcoin",
+	"last_updated": "2024-05-01T12:00:00Z",
+	"image": {"large": "https://example.com/btc.png"},
+	"market_data": {
+		"current_price": {"usd": 60000, "eur": 56000, "jpy": 9300000},
+		"market_cap": {"usd": 1200000000000, "eur": 1100000000000},
+		"total_volume": {"usd": 30000000000},
+		"ath": {"usd": 73000},
+		"ath_change_percentage": {"usd": -17.8},
+		"price_change_percentage_24h": 2.5,
+		"price_change_percentage_7d": -4.1,
+		"market_cap_rank": 1
+	}
+}`
+
+// cmcCoinPrices is a coin the CoinMarketCap stand-in knows, with its prices by currency.
+type cmcCoinPrices struct {
+	cmcListing
+	prices map[string]float64
+}
+
+// cmcCoins are the coins in the stand-in's ID map. BNB's slug isn't its CoinGecko ID, and
+// other coins share its symbol or have that ID as their slug.
+var cmcCoins = []cmcCoinPrices{
+	{cmcListing{1, "Bitcoin", "BTC", "bitcoin", 1}, map[string]float64{"USD": 60010, "EUR": 56010, "GBP": 48010}},
+	{cmcListing{1839, "BNB", "BNB", "bnb", 4}, map[string]float64{"USD": 600, "EUR": 560, "GBP": 480}},
+	{cmcListing{9001, "BNB Classic", "BNB", "bnb-classic", 2500}, map[string]float64{"USD": 0.01}},
+	{cmcListing{9002, "Binance Coin Meme", "BCM", "binancecoin", 0}, map[string]float64{"USD": 0.0001}},
+}
+
+// cmcError answers like CoinMarketCap does to a bad request.
+func cmcError(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusBadRequest)
+	fmt.Fprintf(w, `{"status": {"error_code": 400, "error_message": %q}}`, message)
+}
+
+// serveCoinMarketCap is a CoinMarketCap stand-in with the coins in cmcMap. Like the basic
+// plan, it converts to one currency per call. It counts the coins looked up, which are the
+// quote calls in the default currency.
+func serveCoinMarketCap(t *testing.T, lookups *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-CMC_PRO_API_KEY") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/cryptocurrency/map":
+			var listings []cmcListing
+			for _, c := range cmcCoins {
+				listings = append(listings, c.cmcListing)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": listings})
+		case "/v2/cryptocurrency/quotes/latest":
+			id, convert := r.URL.Query().Get("id"), r.URL.Query().Get("convert")
+			if strings.Contains(convert, ",") {
+				cmcError(w, "Your plan is limited to 1 convert options")
+				return
+			}
+			i := slices.IndexFunc(cmcCoins, func(c cmcCoinPrices) bool { return fmt.Sprint(c.ID) == id })
+			if i < 0 {
+				cmcError(w, fmt.Sprintf(`Invalid value for "id": "%s"`, id))
+				return
+			}
+			coin := cmcCoins[i]
+			price, ok := coin.prices[convert]
+			if !ok {
+				cmcError(w, fmt.Sprintf(`Invalid value for "convert": "%s"`, convert))
+				return
+			}
+			if convert == "USD" && lookups != nil {
+				lookups.Add(1)
+			}
+			fmt.Fprintf(w, `{"status": {"error_code": 0, "error_message": null}, "data": {%q: {
+				"id": %d, "name": %q, "symbol": %q, "slug": %q, "cmc_rank": %d,
+				"quote": {%q: {"price": %v, "market_cap": %v, "volume_24h": 31000000000,
+					"percent_change_24h": 2.4, "percent_change_7d": -4, "last_updated": "2024-05-01T12:00:00Z"}}}}}`,
+				id, coin.ID, coin.Name, coin.Symbol, coin.Slug, coin.Rank, convert, price, price*20_000_000)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// serve answers every request with the given status and body, counting the requests.
+func serve(t *testing.T, status int, body string, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			hits.Add(1)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testCoinGecko(srv *httptest.Server) *CoinGecko {
+	c := NewCoinGecko("", false)
+	c.BaseURL, c.Client = srv.URL, srv.Client()
+	return c
+}
+
+func testCoinMarketCap(srv *httptest.Server) *CoinMarketCap {
+	c := NewCoinMarketCap("key")
+	c.BaseURL, c.Client = srv.URL, srv.Client()
+	return c
+}
+
+func TestCoinGeckoCoin(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(coinGeckoBitcoin))
+	}))
+	defer srv.Close()
+
+	coin, err := testCoinGecko(srv).Coin(context.Background(), "bitcoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/coins/bitcoin" {
+		t.Errorf("requested %s, want /coins/bitcoin", path)
+	}
+	if coin.ID != "bitcoin" || coin.Symbol != "btc" || coin.Name != "Bitcoin" || coin.Rank != 1 {
+		t.Errorf("coin = %+v", coin)
+	}
+	if coin.Change24h != 2.5 || coin.Change7d != -4.1 || coin.UpdatedAt.IsZero() {
+		t.Errorf("changes = %v, %v, updated %v", coin.Change24h, coin.Change7d, coin.UpdatedAt)
+	}
+	usd := coin.Quotes["usd"]
+	if usd.Price != 60000 || usd.MarketCap != 1.2e12 || usd.Volume24h != 3e10 || usd.ATH != 73000 || usd.ATHChange != -17.8 {
+		t.Errorf("usd quote = %+v", usd)
+	}
+	if coin.Quotes["eur"].Price != 56000 {
+		t.Errorf("eur quote = %+v", coin.Quotes["eur"])
+	}
+	if _, ok := coin.Quotes["jpy"]; ok {
+		t.Error("kept a quote in a currency we don't show")
+	}
+}
+
+func TestCoinGeckoHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiKey  string
+		pro     bool
+		header  string
+		baseURL string
+	}{
+		{name: "public", baseURL: coinGeckoURL},
+		{name: "demo", apiKey: "CG-demo", header: "x-cg-demo-api-key", baseURL: coinGeckoURL},
+		{name: "pro", apiKey: "CG-pro", pro: true, header: "x-cg-pro-api-key", baseURL: coinGeckoProURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				w.Write([]byte(coinGeckoBitcoin))
+			}))
+			defer srv.Close()
+
+			c := NewCoinGecko(tt.apiKey, tt.pro)
+			if c.BaseURL != tt.baseURL {
+				t.Errorf("BaseURL = %s, want %s", c.BaseURL, tt.baseURL)
+			}
+			c.BaseURL, c.Client = srv.URL, srv.Client()
+			if _, err := c.Coin(context.Background(), "bitcoin"); err != nil {
+				t.Fatal(err)
+			}
+			for _, h := range []string{"x-cg-demo-api-key", "x-cg-pro-api-key"} {
+				want := ""
+				if h == tt.header {
+					want = tt.apiKey
+				}
+				if got.Get(h) != want {
+					t.Errorf("%s = %q, want %q", h, got.Get(h), want)
+				}
+			}
+		})
+	}
+}
+
+func TestCoinMarketCapCoin(t *testing.T) {
+	c := testCoinMarketCap(serveCoinMarketCap(t, nil))
+	coin, err := c.Coin(context.Background(), "bitcoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coin.ID != "bitcoin" || coin.Symbol != "btc" || coin.Rank != 1 || coin.Change24h != 2.4 {
+		t.Errorf("coin = %+v", coin)
+	}
+	// The stand-in has no naira price, so that currency is left out rather than failing the coin.
+	want := map[string]float64{"usd": 60010, "eur": 56010, "gbp": 48010}
+	if len(coin.Quotes) != len(want) {
+		t.Errorf("quotes = %+v", coin.Quotes)
+	}
+	for currency, price := range want {
+		if coin.Quotes[currency].Price != price {
+			t.Errorf("%s price = %v, want %v", currency, coin.Quotes[currency].Price, price)
+		}
+	}
+}
+
+func TestCoinMarketCapMatchesCoinGeckoIDs(t *testing.T) {
+	c := testCoinMarketCap(serveCoinMarketCap(t, nil))
+	ctx := context.Background()
+
+	// Without the coin list, only the slug can be matched, and binancecoin is another coin's slug.
+	if coin, err := c.Coin(ctx, "binancecoin"); err != nil || coin.Name != "Binance Coin Meme" {
+		t.Errorf("binancecoin by slug = %+v, %v", coin, err)
+	}
+
+	c.Coins = NewResolver(nil)
+	c.Coins.SetListings([]CoinListing{
+		{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Rank: 1},
+		{ID: "binancecoin", Symbol: "bnb", Name: "BNB", Rank: 4},
+		{ID: "bnb-renamed", Symbol: "bnb", Name: "Build N Build", Rank: 3000},
+	})
+	coin, err := c.Coin(ctx, "binancecoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coin.ID != "binancecoin" || coin.Name != "BNB" || coin.Quotes["eur"].Price != 560 {
+		t.Errorf("binancecoin = %+v", coin)
+	}
+	// A coin no CoinMarketCap coin shares a name with goes to the biggest one with its symbol.
+	if coin, err := c.Coin(ctx, "bnb-renamed"); err != nil || coin.Name != "BNB" {
+		t.Errorf("bnb-renamed = %+v, %v", coin, err)
+	}
+	if _, err := c.Coin(ctx, "nope"); !errors.Is(err, ErrCoinNotFound) {
+		t.Errorf("unknown coin: err = %v, want ErrCoinNotFound", err)
+	}
+}
+
+func TestCoinMarketCapBadRequest(t *testing.T) {
+	c := testCoinMarketCap(serveCoinMarketCap(t, nil))
+	ctx := context.Background()
+	if _, err := c.quote(ctx, 424242, "usd"); !errors.Is(err, ErrCoinNotFound) {
+		t.Errorf("unknown ID: err = %v, want ErrCoinNotFound", err)
+	}
+	// Other bad requests are failures, so another provider gets to answer.
+	_, err := c.quote(ctx, 1, "usd,eur")
+	if err == nil || errors.Is(err, ErrCoinNotFound) || !strings.Contains(err.Error(), "limited to 1 convert") {
+		t.Errorf("two currencies: err = %v", err)
+	}
+	srv := serve(t, http.StatusBadRequest, `{}`, nil)
+	if _, err := testCoinMarketCap(srv).Coin(ctx, "bitcoin"); err == nil || errors.Is(err, ErrCoinNotFound) {
+		t.Errorf("unexplained 400: err = %v", err)
+	}
+}
+
+func TestOpenSeaCollection(t *testing.T) {
+	var key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("x-api-key")
+		switch r.URL.Path {
+		case "/collections/pudgypenguins":
+			w.Write([]byte(`{"collection": "pudgypenguins", "name": "Pudgy Penguins", "image_url": "https://example.com/p.png",
+				"opensea_url": "https://opensea.io/collection/pudgypenguins", "total_supply": 8888,
+				"contracts": [{"address": "0xbd3531da5cf5857e7cfaa92426877b022e612cf8", "chain": "ethereum"}]}`))
+		case "/collections/pudgypenguins/stats":
+			w.Write([]byte(`{"total": {"floor_price": 11.2, "floor_price_symbol": "ETH", "num_owners": 4700},
+				"intervals": [{"interval": "one_day", "volume": 150.5, "sales": 13}, {"interval": "seven_day", "volume": 900, "sales": 80}]}`))
+		case "/chain/ethereum/contract/0xbd3531da5cf5857e7cfaa92426877b022e612cf8":
+			w.Write([]byte(`{"collection": "pudgypenguins"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	o := NewOpenSea("key")
+	o.BaseURL, o.Client = srv.URL, srv.Client()
+	ctx := context.Background()
+
+	c, err := o.Collection(ctx, "pudgypenguins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "key" {
+		t.Errorf("sent API key %q", key)
+	}
+	want := Collection{
+		Slug: "pudgypenguins", Name: "Pudgy Penguins", ImageURL: "https://example.com/p.png",
+		URL: "https://opensea.io/collection/pudgypenguins", Chain: "ethereum",
+		Contract:   "0xBd3531dA5CF5857e7CfAA92426877b022e612cf8",
+		FloorPrice: 11.2, FloorSymbol: "ETH", Volume24h: 150.5, Sales24h: 13, Holders: 4700, Supply: 8888,
+	}
+	if *c != want {
+		t.Errorf("collection = %+v\nwant %+v", *c, want)
+	}
+
+	ethereum, _ := LookupChain("ethereum")
+	slug, err := o.CollectionSlug(ctx, ethereum, want.Contract)
+	if err != nil || slug != "pudgypenguins" {
+		t.Errorf("CollectionSlug = %q, %v", slug, err)
+	}
+	if _, err := o.Collection(ctx, "no such thing"); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("unknown slug: err = %v, want ErrCollectionNotFound", err)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	srv := serve(t, http.StatusTooManyRequests, `{"status": {"error_code": 429}}`, nil)
+	ctx := context.Background()
+
+	if _, err := testCoinGecko(srv).Coin(ctx, "bitcoin"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("coingecko: err = %v, want ErrRateLimited", err)
+	}
+	if _, err := testCoinMarketCap(srv).Coin(ctx, "bitcoin"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("coinmarketcap: err = %v, want ErrRateLimited", err)
+	}
+	o := NewOpenSea("key")
+	o.BaseURL, o.Client = srv.URL, srv.Client()
+	if _, err := o.Collection(ctx, "pudgypenguins"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("opensea: err = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestFailoverOrder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("first answer wins", func(t *testing.T) {
+		var cgHits, cmcHits atomic.Int32
+		f := NewFailover(
+			testCoinGecko(serve(t, http.StatusOK, coinGeckoBitcoin, &cgHits)),
+			testCoinMarketCap(serveCoinMarketCap(t, &cmcHits)),
+		)
+		coin, err := f.Coin(ctx, "bitcoin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if coin.Quotes["usd"].Price != 60000 || cgHits.Load() != 1 || cmcHits.Load() != 0 {
+			t.Errorf("price %v from %d coingecko and %d coinmarketcap calls", coin.Quotes["usd"].Price, cgHits.Load(), cmcHits.Load())
+		}
+	})
+
+	t.Run("rate limited provider is skipped", func(t *testing.T) {
+		var cgHits, cmcHits atomic.Int32
+		f := NewFailover(
+			testCoinGecko(serve(t, http.StatusTooManyRequests, "", &cgHits)),
+			testCoinMarketCap(serveCoinMarketCap(t, &cmcHits)),
+		)
+		for range 2 {
+			coin, err := f.Coin(ctx, "bitcoin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if coin.Quotes["usd"].Price != 60010 {
+				t.Errorf("price = %v, want the coinmarketcap price", coin.Quotes["usd"].Price)
+			}
+		}
+		if cgHits.Load() != 1 || cmcHits.Load() != 2 {
+			t.Errorf("%d coingecko and %d coinmarketcap calls, want 1 and 2", cgHits.Load(), cmcHits.Load())
+		}
+	})
+
+	t.Run("not found everywhere", func(t *testing.T) {
+		f := NewFailover(
+			testCoinGecko(serve(t, http.StatusNotFound, `{"error": "coin not found"}`, nil)),
+			testCoinMarketCap(serveCoinMarketCap(t, nil)),
+		)
+		if _, err := f.Coin(ctx, "nope"); !errors.Is(err, ErrCoinNotFound) {
+			t.Errorf("err = %v, want ErrCoinNotFound", err)
+		}
+	})
+
+	t.Run("failure falls through", func(t *testing.T) {
+		f := NewFailover(
+			testCoinGecko(serve(t, http.StatusInternalServerError, "", nil)),
+			testCoinMarketCap(serveCoinMarketCap(t, nil)),
+		)
+		if coin, err := f.Coin(ctx, "bitcoin"); err != nil || coin.Quotes["usd"].Price != 60010 {
+			t.Errorf("Coin = %+v, %v", coin, err)
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package web3
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// coinListRefresh is how often the coin list is reloaded to pick up new listings and rank changes.
+	coinListRefresh = 6 * time.Hour
+	// coinListRetry is how soon a failed load is retried.
+	coinListRetry = 5 * time.Minute
+	// maxSuggestions is how many "did you mean" buttons we offer.
+	maxSuggestions = 3
+	// maxTypoDistance is how many edits a typo can be away from a symbol or name and still be suggested.
+	maxTypoDistance = 2
+)
+
+// contractAddress matches an EVM contract address, which we look up on every platform.
+var contractAddress = regexp.MustCompile(`^0x[0-9a-f]{40}$`)
+
+// Resolution is the outcome of resolving user input. Either ID is set, or Suggestions
+// holds the coins the user most likely meant, best first. Both are empty if nothing matched.
+type Resolution struct {
+	ID          string
+	Suggestions []CoinListing
+}
+
+// Resolver maps symbols, names, IDs and contract addresses to coin IDs using a provider's full
+// coin list. When several coins share a symbol or name, the one with the biggest market cap wins.
+type Resolver struct {
+	lister CoinLister
+
+	mu         sync.RWMutex
+	byID       map[string]*CoinListing
+	bySymbol   map[string][]*CoinListing
+	byName     map[string][]*CoinListing
+	byContract map[string][]*CoinListing
+	listings   []CoinListing
+}
+
+// Coins resolves the coin names handlers receive. Configure sets it on startup.
+var Coins *Resolver
+
+// NewResolver returns a resolver backed by a coin list. It resolves only the common
+// tickers until Load or Run has loaded the list.
+func NewResolver(lister CoinLister) *Resolver {
+	return &Resolver{lister: lister}
+}
+
+// Run loads the coin list and keeps it fresh until ctx is cancelled.
+func (r *Resolver) Run(ctx context.Context) {
+	for {
+		wait := coinListRefresh
+		if err := r.Load(ctx); err != nil {
+			log.Printf("Failed to load coin list: %v", err)
+			wait = coinListRetry
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Load replaces the coin list with a fresh copy from the provider.
+func (r *Resolver) Load(ctx context.Context) error {
+	listings, err := r.lister.CoinList(ctx)
+	if err != nil {
+		return err
+	}
+	r.SetListings(listings)
+	log.Printf("Loaded %d coins for symbol resolution", len(listings))
+	return nil
+}
+
+// SetListings indexes a coin list, replacing the previous one.
+func (r *Resolver) SetListings(listings []CoinListing) {
+	// Rank order makes the first entry of every index the coin with the biggest market cap.
+	sort.SliceStable(listings, func(i, j int) bool { return rankLess(listings[i], listings[j]) })
+
+	byID := make(map[string]*CoinListing, len(listings))
+	bySymbol := make(map[string][]*CoinListing)
+	byName := make(map[string][]*CoinListing)
+	byContract := make(map[string][]*CoinListing)
+	for i := range listings {
+		c := &listings[i]
+		byID[strings.ToLower(c.ID)] = c
+		if symbol := strings.ToLower(c.Symbol); symbol != "" {
+			bySymbol[symbol] = append(bySymbol[symbol], c)
+		}
+		if name := strings.ToLower(c.Name); name != "" {
+			byName[name] = append(byName[name], c)
+		}
+		for _, address := range c.Contracts {
+			byContract[address] = append(byContract[address], c)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listings = listings
+	r.byID = byID
+	r.bySymbol = bySymbol
+	r.byName = byName
+	r.byContract = byContract
+}
+
+// Listing returns the listing of a coin ID, if the coin list has it.
+func (r *Resolver) Listing(id string) (CoinListing, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.byID[strings.ToLower(id)]
+	if !ok {
+		return CoinListing{}, false
+	}
+	return *c, true
+}
+
+// Resolve maps user input to a coin ID. A contract address is looked up as such. Otherwise
+// coins whose symbol or name is the input compete on market cap, and a coin whose ID is the
+// input only wins if none of them outranks it, since IDs like "binancecoin" are rarely typed
+// while symbols like "uni" are also the ID of some obscure coin. Anything else is treated as
+// a typo and answered with suggestions.
+func (r *Resolver) Resolve(input string) Resolution {
+	query := strings.ToLower(strings.TrimSpace(input))
+	if query == "" {
+		return Resolution{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.byID == nil {
+		// The list hasn't loaded yet; let the provider decide whether the input is an ID.
+		return Resolution{ID: commonTicker(query)}
+	}
+
+	if contractAddress.MatchString(query) {
+		return pick(r.byContract[query])
+	}
+	matches := mergeRanked(r.bySymbol[query], r.byName[query])
+	if c, ok := r.byID[query]; ok && (len(matches) == 0 || !rankLess(*matches[0], *c)) {
+		return Resolution{ID: c.ID}
+	}
+	if len(matches) > 0 {
+		return pick(matches)
+	}
+	return Resolution{Suggestions: r.suggest(query)}
+}
+
+// mergeRanked merges rank ordered lists of coins into one, leaving out repeats. Coins of the
+// same rank keep the order of the lists.
+func mergeRanked(lists ...[]*CoinListing) []*CoinListing {
+	var merged []*CoinListing
+	for _, list := range lists {
+		for _, c := range list {
+			if !slices.Contains(merged, c) {
+				merged = append(merged, c)
+			}
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return rankLess(*merged[i], *merged[j]) })
+	return merged
+}
+
+// pick chooses between coins sharing a symbol, name or contract. The ranked coin with the
+// biggest market cap wins; if none is ranked we can't tell them apart and ask the user.
+func pick(matches []*CoinListing) Resolution {
+	switch {
+	case len(matches) == 0:
+		return Resolution{}
+	case len(matches) == 1 || matches[0].Rank > 0:
+		return Resolution{ID: matches[0].ID}
+	}
+
+	res := Resolution{}
+	for _, c := range matches {
+		if len(res.Suggestions) == maxSuggestions {
+			break
+		}
+		res.Suggestions = append(res.Suggestions, *c)
+	}
+	return res
+}
+
+// suggest finds the coins whose symbol or name is a few edits away from the query,
+// closest first and then by market cap.
+func (r *Resolver) suggest(query string) []CoinListing {
+	type candidate struct {
+		listing  *CoinListing
+		distance int
+	}
+	var candidates []candidate
+
+	for i := range r.listings {
+		c := &r.listings[i]
+		distance := min(editDistance(query, strings.ToLower(c.Symbol)), editDistance(query, strings.ToLower(c.Name)))
+		// Short inputs are one or two edits away from hundreds of tickers, so be stricter with them.
+		if distance > maxTypoDistance || distance*3 > len(query) {
+			continue
+		}
+		candidates = append(candidates, candidate{c, distance})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return rankLess(*candidates[i].listing, *candidates[j].listing)
+	})
+
+	var suggestions []CoinListing
+	for _, c := range candidates {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, *c.listing)
+	}
+	return suggestions
+}
+
+// rankLess orders ranked coins by rank, ahead of unranked ones.
+func rankLess(a, b CoinListing) bool {
+	if a.Rank == 0 || b.Rank == 0 {
+		return a.Rank != 0 && b.Rank == 0
+	}
+	return a.Rank < b.Rank
+}
+
+// editDistance is the Levenshtein distance between two strings, counted in bytes.
+func editDistance(a, b string) int {
+	if a == b {
+		return 0
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// commonTicker expands the tickers members type most, for when the coin list isn't available.
+func commonTicker(input string) string {
+	switch input {
+	case "btc":
+		return "bitcoin"
+	case "eth":
+		return "ethereum"
+	}
+	return input
+}
@@ -0,0 +1,130 @@
+package web3
+
+import (
+	"slices"
+	"testing"
+)
+
+func testResolver() *Resolver {
+	r := NewResolver(nil)
+	r.SetListings([]CoinListing{
+		{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Rank: 1},
+		{ID: "ethereum", Symbol: "eth", Name: "Ethereum", Rank: 2},
+		{ID: "binancecoin", Symbol: "bnb", Name: "BNB", Rank: 4},
+		{ID: "tron", Symbol: "trx", Name: "TRON", Rank: 10},
+		{ID: "uniswap", Symbol: "uni", Name: "Uniswap", Rank: 20,
+			Contracts: map[string]string{"ethereum": "0x1f9840a85d5af5bf1d1762f925bdaddc4201f984"}},
+		// Obscure coins whose IDs, symbols or names are what members type for the big ones.
+		{ID: "uni", Symbol: "unicoin", Name: "Unicoin"},
+		{ID: "tron-bsc", Symbol: "tron", Name: "Tron BSC"},
+		{ID: "ethereum-wormhole", Symbol: "eth", Name: "Ethereum (Wormhole)", Rank: 900},
+		{ID: "bitcoin-2", Symbol: "btc2", Name: "Bitcoin 2", Rank: 3000},
+		{ID: "pepe-bsc", Symbol: "pepe", Name: "Pepe BSC"},
+		{ID: "pepe-sol", Symbol: "pepe", Name: "Pepe Sol"},
+		{ID: "uniswap-bsc", Symbol: "uni-bsc", Name: "Uniswap", Contracts: map[string]string{
+			"binance-smart-chain": "0x1f9840a85d5af5bf1d1762f925bdaddc4201f984"}},
+	})
+	return r
+}
+
+func TestResolve(t *testing.T) {
+	r := testResolver()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"btc", "bitcoin"},
+		{" BTC ", "bitcoin"},
+		{"Bitcoin", "bitcoin"},
+		{"bitcoin-2", "bitcoin-2"},
+		// The biggest coin with a symbol wins it.
+		{"eth", "ethereum"},
+		// A symbol beats an unranked coin with that ID...
+		{"uni", "uniswap"},
+		// ...but not a ranked one.
+		{"tron", "tron"},
+		// IDs nobody else uses are still found.
+		{"binancecoin", "binancecoin"},
+		{"pepe-bsc", "pepe-bsc"},
+		// A name shared by a ranked and an unranked coin goes to the ranked one.
+		{"uniswap", "uniswap"},
+		// The same contract address on two chains goes to the ranked coin.
+		{"0x1F9840a85d5aF5bf1D1762F925BDADdC4201F984", "uniswap"},
+	}
+	for _, tt := range tests {
+		if got := r.Resolve(tt.input); got.ID != tt.want || len(got.Suggestions) > 0 {
+			t.Errorf("Resolve(%q) = %+v, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	r := testResolver()
+	// Two unranked coins share the symbol, so the member is asked which one.
+	got := r.Resolve("pepe")
+	if got.ID != "" || len(got.Suggestions) != 2 {
+		t.Fatalf("Resolve(pepe) = %+v, want both pepes suggested", got)
+	}
+	if got := r.Resolve(""); got.ID != "" || got.Suggestions != nil {
+		t.Errorf("Resolve of nothing = %+v", got)
+	}
+}
+
+func TestResolveSuggestions(t *testing.T) {
+	r := testResolver()
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"etherium", []string{"ethereum"}},
+		{"bitcoim", []string{"bitcoin"}},
+		// Closest first, then by market cap.
+		{"btcc", []string{"bitcoin", "bitcoin-2"}},
+		{"unswap", []string{"uniswap", "uniswap-bsc"}},
+		// Short inputs only match with a single edit per three letters.
+		{"bt", nil},
+		{"btx", []string{"bitcoin"}},
+		{"nothing like it", nil},
+	}
+	for _, tt := range tests {
+		got := r.Resolve(tt.input)
+		var ids []string
+		for _, c := range got.Suggestions {
+			ids = append(ids, c.ID)
+		}
+		if got.ID != "" || !slices.Equal(ids, tt.want) {
+			t.Errorf("Resolve(%q) = %q, suggested %v, want %v", tt.input, got.ID, ids, tt.want)
+		}
+	}
+}
+
+func TestResolveBeforeLoad(t *testing.T) {
+	r := NewResolver(nil)
+	for input, want := range map[string]string{"BTC": "bitcoin", "eth": "ethereum", "solana": "solana"} {
+		if got := r.Resolve(input); got.ID != want {
+			t.Errorf("Resolve(%q) before the list loaded = %+v, want %s", input, got, want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"eth", "eth", 0},
+		{"", "eth", 3},
+		{"eth", "", 3},
+		{"eth", "etc", 1},
+		{"bitcoin", "bitcoim", 1},
+		{"ethereum", "etherium", 1},
+		{"kitten", "sitting", 3},
+		{"uni", "inu", 2},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
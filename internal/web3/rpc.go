@@ -0,0 +1,125 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// rpcHTTPClient is shared by all RPC clients so connections to the same node are reused.
+var rpcHTTPClient = &http.Client{Timeout: httpTimeout}
+
+// RPCClient calls an Ethereum-compatible JSON-RPC endpoint.
+type RPCClient struct {
+	URL    string
+	Client *http.Client
+
+	nextID atomic.Int64
+}
+
+// NewRPCClient returns a client for a JSON-RPC endpoint.
+func NewRPCClient(url string) *RPCClient {
+	return &RPCClient{URL: url, Client: rpcHTTPClient}
+}
+
+// RPCError is an error answered by the node itself, as opposed to a transport failure.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// Call invokes a method and decodes its result into result. A null result leaves result untouched.
+func (c *RPCClient) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%s: %w", method, ErrRateLimited)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s: %w", method, &statusError{code: resp.StatusCode})
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: failed to read response body: %w", method, err)
+	}
+	var answer struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return fmt.Errorf("%s: failed to parse JSON: %v: %w", method, err, ErrBadResponse)
+	}
+	if answer.Error != nil {
+		return fmt.Errorf("%s: %w", method, answer.Error)
+	}
+	if len(answer.Result) == 0 || string(answer.Result) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(answer.Result, result); err != nil {
+		return fmt.Errorf("%s: failed to parse result: %v: %w", method, err, ErrBadResponse)
+	}
+	return nil
+}
+
+// parseHexBig parses a 0x-prefixed hex quantity as returned by JSON-RPC.
+func parseHexBig(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(s, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity %q: %w", s, ErrBadResponse)
+	}
+	return n, nil
+}
+
+// toHex formats a quantity the way JSON-RPC expects it.
+func toHex(n int64) string {
+	return fmt.Sprintf("0x%x", n)
+}
+
+// EthCall runs a read-only contract call against the latest block and returns the raw result.
+func (c *RPCClient) EthCall(ctx context.Context, to string, data []byte) ([]byte, error) {
+	var result string
+	call := map[string]string{"to": to, "data": "0x" + hex.EncodeToString(data)}
+	if err := c.Call(ctx, &result, "eth_call", call, "latest"); err != nil {
+		return nil, err
+	}
+	out, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("eth_call: invalid result %q: %w", result, ErrBadResponse)
+	}
+	return out, nil
+}
@@ -0,0 +1,213 @@
+package web3
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/philip-857.bit/byb-bot/internal/config"
+	"github.com/philip-857.bit/byb-bot/internal/web3/rpcfake"
+)
+
+// fakeNode starts a fake JSON-RPC node and points the given chains at it until the test ends.
+func fakeNode(t *testing.T, chains ...string) *rpcfake.Server {
+	t.Helper()
+	node := rpcfake.New()
+	t.Cleanup(node.Close)
+
+	previous := Cfg
+	cfg := &config.Config{RPCURLs: make(map[string]string)}
+	for _, key := range chains {
+		cfg.RPCURLs[key] = node.URL
+	}
+	Cfg = cfg
+	t.Cleanup(func() { Cfg = previous })
+	return node
+}
+
+// calldata hex encodes a call of a function with already encoded arguments.
+func calldata(signature string, args ...[]byte) string {
+	data := selector(signature)
+	for _, a := range args {
+		data = append(data, a...)
+	}
+	return hex.EncodeToString(data)
+}
+
+// abiString ABI-encodes a string return value.
+func abiString(s string) []byte {
+	out := make([]byte, 64, 96+len(s))
+	out[31] = 32
+	big.NewInt(int64(len(s))).FillBytes(out[32:64])
+	padded := make([]byte, (len(s)+31)/32*32)
+	copy(padded, s)
+	return append(out, padded...)
+}
+
+// Test vectors from EIP-55.
+var eip55 = []string{
+	"0x52908400098527886E0F7030069857D2E4169EE7",
+	"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+	"0xde709f2102306220921060314715629080e2fb77",
+	"0x27b1fdb04752bbc536007a920d24acb045561c26",
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestChecksumAddress(t *testing.T) {
+	// Every vector is checksummed already, including the all-caps and all-lowercase ones.
+	for _, addr := range eip55 {
+		if got := ChecksumAddress(addr); got != addr {
+			t.Errorf("ChecksumAddress(%s) = %s", addr, got)
+		}
+	}
+	if got := ChecksumAddress("0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"); got != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Errorf("ChecksumAddress of an all-caps address = %s", got)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	for _, addr := range eip55 {
+		if _, err := ParseAddress(addr); err != nil {
+			t.Errorf("ParseAddress(%s): %v", addr, err)
+		}
+	}
+	if got, err := ParseAddress(" 0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed "); err != nil || got != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Errorf("ParseAddress of a lowercase address = %s, %v", got, err)
+	}
+	// The vector with its first letter's case flipped.
+	if _, err := ParseAddress("0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"); !errors.Is(err, ErrBadChecksum) {
+		t.Errorf("mistyped checksum: err = %v, want ErrBadChecksum", err)
+	}
+	for _, bad := range []string{"", "0x", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe", "0xZZAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"} {
+		if _, err := ParseAddress(bad); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("ParseAddress(%q): err = %v, want ErrInvalidAddress", bad, err)
+		}
+	}
+}
+
+func TestNamehash(t *testing.T) {
+	// Vectors from EIP-137 and the ENS documentation.
+	tests := map[string]string{
+		"":            "0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":         "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth":     "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+		"vitalik.eth": "ee6c4522aab0003e8d14cd40a6af439055fd2577951148c14b6cea9a53475835",
+		"Vitalik.ETH": "ee6c4522aab0003e8d14cd40a6af439055fd2577951148c14b6cea9a53475835",
+	}
+	for name, want := range tests {
+		if got := hex.EncodeToString(namehash(name)); got != want {
+			t.Errorf("namehash(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestENS(t *testing.T) {
+	node := fakeNode(t, "ethereum")
+	ctx := context.Background()
+	const (
+		resolver = "0x231b0Ee14048e9dCcD1d247744d114a4EB5E8E63"
+		owner    = "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"
+		squatter = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	)
+	// rpcfake-test.eth points at owner, whose reverse record names it back.
+	forward := namehash("rpcfake-test.eth")
+	node.Call(ensRegistry, calldata("resolver(bytes32)", forward), encodeAddress(resolver))
+	node.Call(resolver, calldata("addr(bytes32)", forward), encodeAddress(owner))
+	reverse := namehash(hex.EncodeToString(encodeAddress(owner)[12:]) + ".addr.reverse")
+	node.Call(ensRegistry, calldata("resolver(bytes32)", reverse), encodeAddress(resolver))
+	node.Call(resolver, calldata("name(bytes32)", reverse), abiString("rpcfake-test.eth"))
+	// The squatter's reverse record claims the same name, which doesn't resolve to it.
+	squatted := namehash(hex.EncodeToString(encodeAddress(squatter)[12:]) + ".addr.reverse")
+	node.Call(ensRegistry, calldata("resolver(bytes32)", squatted), encodeAddress(resolver))
+	node.Call(resolver, calldata("name(bytes32)", squatted), abiString("rpcfake-test.eth"))
+
+	// The registry answers names nobody registered with the zero resolver.
+	node.Call(ensRegistry, calldata("resolver(bytes32)", namehash("rpcfake-unset.eth")), make([]byte, 32))
+
+	addr, ensName, err := ResolveAddressInput(ctx, "RPCFake-Test.eth")
+	if err != nil || addr != owner || ensName != "rpcfake-test.eth" {
+		t.Errorf("ResolveAddressInput = %s, %s, %v", addr, ensName, err)
+	}
+	if _, err := ResolveENS(ctx, "rpcfake-unset.eth"); !errors.Is(err, ErrENSNotFound) {
+		t.Errorf("unset name: err = %v, want ErrENSNotFound", err)
+	}
+	if name, err := LookupENSName(ctx, owner); err != nil || name != "rpcfake-test.eth" {
+		t.Errorf("LookupENSName(owner) = %q, %v", name, err)
+	}
+	if name, err := LookupENSName(ctx, squatter); err != nil || name != "" {
+		t.Errorf("LookupENSName(squatter) = %q, %v, want no name", name, err)
+	}
+
+	// Lookups are cached, so asking again doesn't reach the node.
+	calls := len(node.Methods())
+	ResolveENS(ctx, "rpcfake-test.eth")
+	LookupENSName(ctx, owner)
+	if more := len(node.Methods()) - calls; more != 0 {
+		t.Errorf("cached lookups made %d calls", more)
+	}
+
+	// Addresses without a usable name are left out, and repeats are looked up once.
+	names := AddressNames(ctx, owner, squatter, "", owner, zeroAddress)
+	if len(names) != 1 || names[owner] != "rpcfake-test.eth" {
+		t.Errorf("AddressNames = %v", names)
+	}
+}
+
+func TestENSCacheCap(t *testing.T) {
+	cache := make(map[string]ensEntry)
+	start := time.Now().Add(-time.Minute)
+	for i := range ensMaxEntries {
+		cache[fmt.Sprint(i)] = ensEntry{value: "name.eth", fetchedAt: start.Add(time.Duration(i) * time.Millisecond)}
+	}
+	ensStore(cache, "new", "new.eth")
+	if len(cache) != ensMaxEntries {
+		t.Errorf("cache holds %d entries, want %d", len(cache), ensMaxEntries)
+	}
+	if _, ok := cache["0"]; ok {
+		t.Error("the oldest entry wasn't evicted")
+	}
+	if got, ok := ensCached(cache, "new"); !ok || got != "new.eth" {
+		t.Errorf("ensCached(new) = %q, %v", got, ok)
+	}
+
+	// Expired entries make room before live ones are evicted.
+	cache["1"] = ensEntry{fetchedAt: time.Now().Add(-ensMissTTL)}
+	ensStore(cache, "newer", "newer.eth")
+	if _, ok := cache["1"]; ok {
+		t.Error("an expired entry was kept")
+	}
+	if _, ok := cache["2"]; !ok {
+		t.Error("a live entry was evicted while an expired one was left")
+	}
+}
+
+func TestTokenBalance(t *testing.T) {
+	node := fakeNode(t, "base")
+	const (
+		token  = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+		holder = "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"
+	)
+	balance := new(big.Int).Mul(big.NewInt(1234567), big.NewInt(1_000_000_000_000))
+	node.Call(token, calldata("balanceOf(address)", encodeAddress(holder)), balance.FillBytes(make([]byte, 32)))
+
+	base, _ := LookupChain("base")
+	got, err := TokenBalance(context.Background(), base.RPC(), token, holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(balance) != 0 {
+		t.Errorf("TokenBalance = %s, want %s", got, balance)
+	}
+
+	// A contract that reverts balanceOf isn't a token.
+	if _, err := TokenBalance(context.Background(), base.RPC(), holder, holder); err == nil {
+		t.Error("reverted balanceOf returned a balance")
+	}
+}
@@ -0,0 +1,127 @@
+// Package rpcfake is an in-process Ethereum JSON-RPC node for tests. Point a chain at
+// Server.URL through the RPC URLs in the config and script the answers per method,
+// instead of depending on a public node.
+package rpcfake
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Handler answers one call. Returning an *Error sends it back as a JSON-RPC error;
+// any other error becomes an internal error (-32603).
+type Handler func(params []json.RawMessage) (interface{}, error)
+
+// Error is a JSON-RPC error answered by the fake node.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Server is a fake JSON-RPC node. Methods without a handler answer "method not found".
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	calls    map[string][]byte // eth_call results keyed by lowercase "to" address and hex calldata
+	log      []string
+}
+
+// New starts a fake node. Close it when done.
+func New() *Server {
+	s := &Server{handlers: make(map[string]Handler), calls: make(map[string][]byte)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.Handle("eth_call", s.ethCall)
+	return s
+}
+
+// Handle sets the handler of a method, replacing any previous one.
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+}
+
+// Result makes a method always answer with result.
+func (s *Server) Result(method string, result interface{}) {
+	s.Handle(method, func([]json.RawMessage) (interface{}, error) { return result, nil })
+}
+
+// Call scripts the answer of eth_call to a contract with the given calldata, both hex encoded.
+func (s *Server) Call(to, data string, result []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[callKey(to, data)] = result
+}
+
+// Methods returns the methods called so far, in order.
+func (s *Server) Methods() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.log...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.log = append(s.log, req.Method)
+	h, ok := s.handlers[req.Method]
+	s.mu.Unlock()
+
+	answer := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if !ok {
+		answer["error"] = &Error{Code: -32601, Message: "method not found"}
+	} else if result, err := h(req.Params); err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: -32603, Message: err.Error()}
+		}
+		answer["error"] = rpcErr
+	} else {
+		answer["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answer)
+}
+
+// ethCall answers eth_call from the results scripted with Call. Unknown calls revert.
+func (s *Server) ethCall(params []json.RawMessage) (interface{}, error) {
+	var call struct {
+		To   string `json:"to"`
+		Data string `json:"data"`
+	}
+	if len(params) == 0 {
+		return nil, &Error{Code: -32602, Message: "missing call object"}
+	}
+	if err := json.Unmarshal(params[0], &call); err != nil {
+		return nil, &Error{Code: -32602, Message: err.Error()}
+	}
+
+	s.mu.Lock()
+	result, ok := s.calls[callKey(call.To, call.Data)]
+	s.mu.Unlock()
+	if !ok {
+		return nil, &Error{Code: 3, Message: "execution reverted"}
+	}
+	return "0x" + hex.EncodeToString(result), nil
+}
+
+func callKey(to, data string) string {
+	return strings.ToLower(to) + ":" + strings.ToLower(strings.TrimPrefix(data, "0x"))
+}
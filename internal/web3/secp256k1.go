@@ -0,0 +1,44 @@
+package web3
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// ErrInvalidSignature is returned for signatures that are malformed or don't recover to a key.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// secpHalfN is half the order of secp256k1. Signatures with a larger s are rejected, as
+// Ethereum has since EIP-2, because (r, N-s) is just as valid and would let anyone change a
+// signature without the key.
+var secpHalfN = new(big.Int).Rsh(secp256k1.S256().N, 1)
+
+// recoverAddress returns the address whose key made a 65-byte [r || s || v] signature of a hash.
+// v may be 0/1 or 27/28, as wallets use both.
+func recoverAddress(hash, sig []byte) (string, error) {
+	if len(hash) != 32 || len(sig) != 65 {
+		return "", ErrInvalidSignature
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 || new(big.Int).SetBytes(sig[32:64]).Cmp(secpHalfN) > 0 {
+		return "", ErrInvalidSignature
+	}
+
+	// The library wants the recovery code first, offset by 27 as in Bitcoin's compact signatures.
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	// The address is the last 20 bytes of the hash of the key's x and y, without the 0x04 prefix.
+	return ChecksumAddress(hex.EncodeToString(keccak256(pub.SerializeUncompressed()[1:])[12:])), nil
+}
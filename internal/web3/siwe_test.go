@@ -0,0 +1,76 @@
+package web3
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// The personal_sign example of the web3.js documentation, signed with key 0x4c0883a6...3f362318.
+const (
+	vectorAddress   = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	vectorMessage   = "Some data"
+	vectorSignature = "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+)
+
+func TestPersonalMessageHash(t *testing.T) {
+	const want = "1da44b586eb0729ff70a73c326926f6ed5a25f5b056e7f47fbc6e58d86871655"
+	if got := hex.EncodeToString(personalMessageHash(vectorMessage)); got != want {
+		t.Errorf("personalMessageHash = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyPersonalSign(t *testing.T) {
+	sig, _ := hex.DecodeString(vectorSignature)
+	// withV returns the signature with another recovery byte.
+	withV := func(v byte) string {
+		out := append([]byte(nil), sig...)
+		out[64] = v
+		return hex.EncodeToString(out)
+	}
+	// The same signature with s mirrored to N-s and the parity flipped recovers the same key,
+	// which is exactly the malleability EIP-2 rules out.
+	n := secp256k1.S256().N
+	s := new(big.Int).SetBytes(sig[32:64])
+	highS := append([]byte(nil), sig...)
+	new(big.Int).Sub(n, s).FillBytes(highS[32:64])
+	highS[64] ^= 1
+
+	valid := []string{"0x" + vectorSignature, strings.ToUpper(vectorSignature), withV(sig[64] - 27)}
+	for _, signature := range valid {
+		if err := VerifyPersonalSign(vectorAddress, vectorMessage, signature); err != nil {
+			t.Errorf("VerifyPersonalSign(%s): %v", signature, err)
+		}
+	}
+
+	zeroR := append(make([]byte, 32), sig[32:]...)
+	bigR := append(n.FillBytes(make([]byte, 32)), sig[32:]...)
+	zeroS := append(append(append([]byte(nil), sig[:32]...), make([]byte, 32)...), sig[64])
+	invalid := map[string]string{
+		"high s":  hex.EncodeToString(highS),
+		"v of 2":  withV(2),
+		"v of 29": withV(29),
+		"zero r":  hex.EncodeToString(zeroR),
+		"r of N":  hex.EncodeToString(bigR),
+		"zero s":  hex.EncodeToString(zeroS),
+		"short":   vectorSignature[:128],
+		"not hex": "0x" + strings.Repeat("zz", 65),
+		"empty":   "",
+	}
+	for name, signature := range invalid {
+		if err := VerifyPersonalSign(vectorAddress, vectorMessage, signature); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v, want ErrInvalidSignature", name, err)
+		}
+	}
+
+	if err := VerifyPersonalSign(vectorAddress, "Some other data", "0x"+vectorSignature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("signature of another message: err = %v, want ErrInvalidSignature", err)
+	}
+	if err := VerifyPersonalSign("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", vectorMessage, "0x"+vectorSignature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("signature by another wallet: err = %v, want ErrInvalidSignature", err)
+	}
+}
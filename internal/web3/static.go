@@ -0,0 +1,80 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// StaticProvider serves prices from memory. It backs the "static" provider, which reads a
+// JSON file for offline or pinned prices, and doubles as a fake in tests.
+type StaticProvider struct {
+	mu    sync.RWMutex
+	coins map[string]Coin
+}
+
+// NewStaticProvider returns a provider that knows exactly the given coins.
+func NewStaticProvider(coins ...Coin) *StaticProvider {
+	p := &StaticProvider{coins: make(map[string]Coin)}
+	for _, c := range coins {
+		p.Set(c)
+	}
+	return p
+}
+
+// LoadStaticProvider reads coins from a JSON file holding an array of objects such as
+// {"id": "bitcoin", "symbol": "btc", "name": "Bitcoin", "image_url": "...", "price_usd": 65000}.
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static price file: %w", err)
+	}
+
+	var entries []struct {
+		ID       string  `json:"id"`
+		Symbol   string  `json:"symbol"`
+		Name     string  `json:"name"`
+		ImageURL string  `json:"image_url"`
+		PriceUSD float64 `json:"price_usd"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse static price file %s: %w", path, err)
+	}
+
+	p := NewStaticProvider()
+	for _, e := range entries {
+		p.Set(Coin{
+			ID:       e.ID,
+			Symbol:   e.Symbol,
+			Name:     e.Name,
+			ImageURL: e.ImageURL,
+			Quotes:   map[string]Quote{DefaultCurrency: {Price: e.PriceUSD}},
+		})
+	}
+	return p, nil
+}
+
+// Set adds or replaces a coin.
+func (p *StaticProvider) Set(coin Coin) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.coins[strings.ToLower(coin.ID)] = coin
+}
+
+// Name implements PriceProvider.
+func (p *StaticProvider) Name() string { return "static" }
+
+// Coin implements PriceProvider.
+func (p *StaticProvider) Coin(ctx context.Context, coinID string) (*Coin, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	coin, ok := p.coins[strings.ToLower(coinID)]
+	if !ok {
+		return nil, fmt.Errorf("static %q: %w", coinID, ErrCoinNotFound)
+	}
+	return &coin, nil
+}
@@ -0,0 +1,462 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+)
+
+var (
+	// ErrNotContract is returned for an address without code, such as a wallet.
+	ErrNotContract = errors.New("address is not a contract")
+	// ErrNotToken is returned for a contract that doesn't answer the ERC-20 metadata calls.
+	ErrNotToken = errors.New("contract is not an ERC-20 token")
+)
+
+// eip1967ImplementationSlot is where EIP-1967 proxies keep the address of their implementation.
+const eip1967ImplementationSlot = "0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc"
+
+const (
+	// topHolders is how many of the largest holders the concentration check adds up.
+	topHolders = 10
+	// concentratedShare is the share of supply, in percent, above which the owner, the creator
+	// or the top holders holding it is a red flag.
+	concentratedShare = 20.0
+	topHoldersShare   = 80.0
+)
+
+// Red flags /token can raise. Owner-controlled ones are dropped once ownership is renounced,
+// unless the contract also hands out admin roles.
+const (
+	FlagUnverified    = "unverified"
+	FlagMintable      = "mintable"
+	FlagBlacklist     = "blacklist"
+	FlagTradingSwitch = "trading_switch"
+	FlagFees          = "fees"
+	FlagTxLimits      = "tx_limits"
+	FlagPausable      = "pausable"
+	FlagProxy         = "proxy"
+	FlagConcentrated  = "concentrated"
+)
+
+// flagSignatures are the functions whose presence in a contract's bytecode raises a flag.
+// A selector showing up doesn't prove the function is dangerous, which is why these are
+// shown as things to check rather than verdicts.
+var flagSignatures = map[string][]string{
+	FlagMintable: {"mint(address,uint256)", "mint(uint256)", "mintTo(address,uint256)", "issue(uint256)"},
+	FlagBlacklist: {"blacklist(address)", "addToBlacklist(address)", "setBlacklist(address,bool)",
+		"blacklistAddress(address,bool)", "setBots(address[],bool)", "addBots(address[])"},
+	FlagTradingSwitch: {"enableTrading()", "openTrading()", "setTradingEnabled(bool)", "setTrading(bool)"},
+	FlagFees: {"setFee(uint256)", "setFees(uint256,uint256)", "setTaxFee(uint256)", "setBuyFee(uint256)",
+		"setSellFee(uint256)", "updateFees(uint256,uint256)"},
+	FlagTxLimits: {"setMaxTxAmount(uint256)", "setMaxWalletSize(uint256)", "setMaxTxPercent(uint256)"},
+	FlagPausable: {"pause()"},
+}
+
+// flagOrder is the order red flags are listed in, most serious first.
+var flagOrder = []string{FlagUnverified, FlagMintable, FlagBlacklist, FlagTradingSwitch, FlagFees,
+	FlagTxLimits, FlagPausable, FlagProxy, FlagConcentrated}
+
+// ownerFlags are the flags that only matter while the contract has an owner.
+var ownerFlags = map[string]bool{FlagMintable: true, FlagBlacklist: true, FlagTradingSwitch: true,
+	FlagFees: true, FlagTxLimits: true, FlagPausable: true}
+
+// roleAdminSignatures are the functions of role-based access control, such as OpenZeppelin's
+// AccessControl. A contract with them can keep admins after renouncing Ownable ownership.
+var roleAdminSignatures = []string{"grantRole(bytes32,address)", "revokeRole(bytes32,address)",
+	"grantRoles(address,uint256)", "setRole(address,uint8,bool)"}
+
+// TokenInfo is what /token shows about a token contract.
+type TokenInfo struct {
+	Address     string
+	Chain       *Chain
+	Name        string
+	Symbol      string
+	Decimals    int
+	TotalSupply *big.Rat // In whole tokens
+	// Owner is the Ownable owner, or "" when the contract has none or it can't be read.
+	Owner          string
+	OwnerRenounced bool
+	// Implementation is the logic contract of an EIP-1967 proxy, "" otherwise.
+	Implementation string
+	// Explorer data, only known with an API key.
+	HasExplorer  bool
+	Verified     bool
+	ContractName string
+	Creator      string
+	// Shares of the supply in percent. TopShare is -1 when the holder list isn't available.
+	OwnerShare   float64
+	CreatorShare float64
+	TopShare     float64
+	Flags        []string
+}
+
+// FetchTokenInfo reads a token's metadata and checks it for red flags, from on-chain reads
+// and, with an API key, from the explorer.
+func FetchTokenInfo(ctx context.Context, chain *Chain, address string) (*TokenInfo, error) {
+	rpc := chain.RPC()
+	info := &TokenInfo{Address: address, Chain: chain, TopShare: -1}
+
+	code, err := contractCode(ctx, rpc, address)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, ErrNotContract
+	}
+
+	if err := readTokenMetadata(ctx, rpc, info); err != nil {
+		return nil, err
+	}
+
+	var slot string
+	if err := rpc.Call(ctx, &slot, "eth_getStorageAt", address, eip1967ImplementationSlot, "latest"); err == nil {
+		if word, err := hex.DecodeString(strings.TrimPrefix(slot, "0x")); err == nil {
+			if impl := decodeAddress(word); impl != "" && impl != zeroAddress {
+				info.Implementation = impl
+				// The proxy's own code only forwards calls; the functions live in the implementation.
+				if implCode, err := contractCode(ctx, rpc, impl); err == nil {
+					code = append(code, implCode...)
+				}
+			}
+		}
+	}
+
+	if out, err := rpc.EthCall(ctx, address, selector("owner()")); err == nil && len(out) >= 32 {
+		info.Owner = decodeAddress(out)
+		if info.Owner == zeroAddress {
+			info.Owner, info.OwnerRenounced = "", true
+		}
+	}
+
+	if Cfg != nil && Cfg.EtherscanAPIKey != "" {
+		if err := readExplorerData(ctx, chain, Cfg.EtherscanAPIKey, info); err != nil {
+			log.Printf("Failed to read explorer data of %s on %s: %v", address, chain.Name, err)
+		} else {
+			info.HasExplorer = true
+		}
+	}
+	info.OwnerShare = holderShare(ctx, rpc, info, info.Owner)
+	if !strings.EqualFold(info.Creator, info.Owner) {
+		info.CreatorShare = holderShare(ctx, rpc, info, info.Creator)
+	}
+
+	info.Flags = redFlags(info, code)
+	return info, nil
+}
+
+// contractCode returns the bytecode at an address, empty for wallets.
+func contractCode(ctx context.Context, rpc *RPCClient, address string) ([]byte, error) {
+	var code string
+	if err := rpc.Call(ctx, &code, "eth_getCode", address, "latest"); err != nil {
+		return nil, err
+	}
+	out, err := hex.DecodeString(strings.TrimPrefix(code, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid code %q: %w", code, ErrBadResponse)
+	}
+	return out, nil
+}
+
+// readTokenMetadata reads the ERC-20 name, symbol, decimals and total supply.
+func readTokenMetadata(ctx context.Context, rpc *RPCClient, info *TokenInfo) error {
+	decimals, err := rpc.EthCall(ctx, info.Address, selector("decimals()"))
+	if err != nil || len(decimals) < 32 {
+		return ErrNotToken
+	}
+	supply, err := rpc.EthCall(ctx, info.Address, selector("totalSupply()"))
+	if err != nil || len(supply) < 32 {
+		return ErrNotToken
+	}
+	d := new(big.Int).SetBytes(decimals[:32])
+	if !d.IsInt64() || d.Int64() > 77 {
+		return ErrNotToken
+	}
+	info.Decimals = int(d.Int64())
+	info.TotalSupply = toUnits(new(big.Int).SetBytes(supply[:32]), info.Decimals)
+
+	// Name and symbol are optional in ERC-20, and some early tokens return them as bytes32.
+	if out, err := rpc.EthCall(ctx, info.Address, selector("name()")); err == nil {
+		info.Name = decodeStringOrBytes32(out)
+	}
+	if out, err := rpc.EthCall(ctx, info.Address, selector("symbol()")); err == nil {
+		info.Symbol = decodeStringOrBytes32(out)
+	}
+	return nil
+}
+
+// decodeStringOrBytes32 reads a string return value, falling back to a zero-padded bytes32.
+func decodeStringOrBytes32(out []byte) string {
+	if s := decodeString(out); s != "" {
+		return s
+	}
+	if len(out) == 32 {
+		return string(bytes.TrimRight(out, "\x00"))
+	}
+	return ""
+}
+
+// readExplorerData asks the explorer whether the source is verified, who created the contract
+// and, where the API plan allows it, how much the largest holders own.
+func readExplorerData(ctx context.Context, chain *Chain, apiKey string, info *TokenInfo) error {
+	raw, err := etherscan(ctx, chain, apiKey, url.Values{"module": {"contract"}, "action": {"getsourcecode"}, "address": {info.Address}})
+	if err != nil {
+		return err
+	}
+	var source []struct {
+		SourceCode   string `json:"SourceCode"`
+		ContractName string `json:"ContractName"`
+	}
+	if err := json.Unmarshal(raw, &source); err != nil || len(source) == 0 {
+		return fmt.Errorf("failed to parse Etherscan source code: %w", ErrBadResponse)
+	}
+	info.Verified = source[0].SourceCode != ""
+	info.ContractName = source[0].ContractName
+
+	raw, err = etherscan(ctx, chain, apiKey, url.Values{"module": {"contract"}, "action": {"getcontractcreation"}, "contractaddresses": {info.Address}})
+	if err != nil {
+		log.Printf("Failed to read creator of %s: %v", info.Address, err)
+	} else {
+		var creation []struct {
+			ContractCreator string `json:"contractCreator"`
+		}
+		if err := json.Unmarshal(raw, &creation); err == nil && len(creation) > 0 && IsAddress(creation[0].ContractCreator) {
+			info.Creator = ChecksumAddress(creation[0].ContractCreator)
+		}
+	}
+
+	// The holder list needs a paid plan; without it the owner and creator shares still tell a lot.
+	raw, err = etherscan(ctx, chain, apiKey, url.Values{"module": {"token"}, "action": {"tokenholderlist"},
+		"contractaddress": {info.Address}, "page": {"1"}, "offset": {fmt.Sprint(topHolders)}})
+	if err != nil {
+		return nil
+	}
+	var holders []struct {
+		Quantity string `json:"TokenHolderQuantity"`
+	}
+	if err := json.Unmarshal(raw, &holders); err != nil || info.TotalSupply.Sign() == 0 {
+		return nil
+	}
+	total := new(big.Int)
+	for _, h := range holders {
+		if q, ok := new(big.Int).SetString(h.Quantity, 10); ok {
+			total.Add(total, q)
+		}
+	}
+	info.TopShare = sharePercent(toUnits(total, info.Decimals), info.TotalSupply)
+	return nil
+}
+
+// holderShare returns the percentage of the supply an address holds, or 0 if unknown.
+func holderShare(ctx context.Context, rpc *RPCClient, info *TokenInfo, holder string) float64 {
+	if holder == "" || info.TotalSupply.Sign() == 0 {
+		return 0
+	}
+	balance, err := TokenBalance(ctx, rpc, info.Address, holder)
+	if err != nil {
+		return 0
+	}
+	return sharePercent(toUnits(balance, info.Decimals), info.TotalSupply)
+}
+
+// sharePercent returns part as a percentage of total.
+func sharePercent(part, total *big.Rat) float64 {
+	share := new(big.Rat).Quo(part, total)
+	percent, _ := share.Mul(share, big.NewRat(100, 1)).Float64()
+	return percent
+}
+
+// redFlags derives the red flags of a token from what was read and from the selectors in its bytecode.
+func redFlags(info *TokenInfo, code []byte) []string {
+	raised := make(map[string]bool)
+	if info.HasExplorer && !info.Verified {
+		raised[FlagUnverified] = true
+	}
+	if info.Implementation != "" {
+		raised[FlagProxy] = true
+	}
+	if info.OwnerShare > concentratedShare || info.CreatorShare > concentratedShare || info.TopShare > topHoldersShare {
+		raised[FlagConcentrated] = true
+	}
+	// Renouncing ownership only disarms the owner functions when no role can call them instead.
+	renounced := info.OwnerRenounced
+	for _, sig := range roleAdminSignatures {
+		if hasSelector(code, selector(sig)) {
+			renounced = false
+			break
+		}
+	}
+	for flag, signatures := range flagSignatures {
+		if renounced && ownerFlags[flag] {
+			continue
+		}
+		for _, sig := range signatures {
+			if hasSelector(code, selector(sig)) {
+				raised[flag] = true
+				break
+			}
+		}
+	}
+
+	var flags []string
+	for _, flag := range flagOrder {
+		if raised[flag] {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// hasSelector reports whether bytecode pushes a function selector, which is how Solidity
+// dispatches to the functions a contract exposes.
+func hasSelector(code, sel []byte) bool {
+	const push4 = 0x63
+	return bytes.Contains(code, append([]byte{push4}, sel...))
+}
+
+// HandleTokenCommand shows a token contract's details and red flags: /token <contract> [chain].
+func HandleTokenCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 || len(fields) > 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.token_usage")))
+		return
+	}
+	chainName := ""
+	if len(fields) == 2 {
+		chainName = fields[1]
+	}
+	chain, ok := LookupChain(chainName)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", chainName, strings.Join(ChainKeys(), ", "))))
+		return
+	}
+
+	ctx := context.Background()
+	address, _, err := ResolveAddressInput(ctx, fields[0])
+	if err != nil {
+		log.Printf("Failed to resolve token %q: %v", fields[0], err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, WalletErrorText(l, fields[0], err)))
+		return
+	}
+
+	bot.Request(tgbotapi.NewChatAction(message.Chat.ID, tgbotapi.ChatTyping))
+	info, err := FetchTokenInfo(ctx, chain, address)
+	if err != nil {
+		log.Printf("Failed to check token %s on %s: %v", address, chain.Name, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, TokenErrorText(l, chain, err)))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, TokenText(l, info, AddressNames(ctx, info.Owner, info.Creator)))
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
+
+// TokenText formats the Markdown report of a token. names holds primary ENS names to show
+// for the owner and creator.
+func TokenText(l i18n.Localizer, info *TokenInfo, names map[string]string) string {
+	name := info.Name
+	if name == "" {
+		name = info.ContractName
+	}
+	if name == "" {
+		name = ShortAddress(info.Address)
+	}
+	title := tgbotapi.EscapeText(tgbotapi.ModeMarkdown, name)
+	if info.Symbol != "" {
+		title += " (" + tgbotapi.EscapeText(tgbotapi.ModeMarkdown, info.Symbol) + ")"
+	}
+
+	lines := []string{
+		l.T("web3.token_title", title, info.Chain.Name),
+		"`" + info.Address + "`",
+		"",
+		l.T("web3.token_decimals", info.Decimals),
+		l.T("web3.token_supply", formatUnits(info.TotalSupply)),
+	}
+
+	switch {
+	case info.OwnerRenounced:
+		lines = append(lines, l.T("web3.token_owner", l.T("web3.token_owner_renounced")))
+	case info.Owner != "":
+		lines = append(lines, l.T("web3.token_owner", DisplayAddress(info.Owner, names[info.Owner])))
+	}
+	if info.Creator != "" {
+		lines = append(lines, l.T("web3.token_creator", DisplayAddress(info.Creator, names[info.Creator])))
+	}
+	if info.Implementation != "" {
+		lines = append(lines, l.T("web3.token_proxy", info.Implementation))
+	}
+	switch {
+	case !info.HasExplorer:
+		lines = append(lines, l.T("web3.token_verified", l.T("web3.token_verified_unknown")))
+	case info.Verified:
+		lines = append(lines, l.T("web3.token_verified", l.T("web3.token_verified_yes")))
+	default:
+		lines = append(lines, l.T("web3.token_verified", l.T("web3.token_verified_no")))
+	}
+
+	var holders []string
+	if info.TopShare >= 0 {
+		holders = append(holders, l.T("web3.token_top_holders", topHolders, formatShare(info.TopShare)))
+	}
+	if info.Owner != "" {
+		holders = append(holders, l.T("web3.token_owner_holds", formatShare(info.OwnerShare)))
+	}
+	if info.Creator != "" && !strings.EqualFold(info.Creator, info.Owner) {
+		holders = append(holders, l.T("web3.token_creator_holds", formatShare(info.CreatorShare)))
+	}
+	if len(holders) > 0 {
+		lines = append(lines, "", l.T("web3.token_holders_header"))
+		lines = append(lines, holders...)
+	}
+
+	lines = append(lines, "")
+	if len(info.Flags) == 0 {
+		lines = append(lines, l.T("web3.token_no_flags"))
+	} else {
+		lines = append(lines, l.T("web3.token_flags_header"))
+		for _, flag := range info.Flags {
+			lines = append(lines, "🚩 "+l.T("web3.token_flag_"+flag))
+		}
+	}
+	lines = append(lines, "", l.T("web3.token_disclaimer"),
+		l.T("web3.wallet_explorer", info.Chain.Explorer+"/token/"+info.Address))
+	return strings.Join(lines, "\n")
+}
+
+// formatShare formats a percentage of the supply.
+func formatShare(percent float64) string {
+	if percent > 0 && percent < 0.01 {
+		return "<0.01%"
+	}
+	return fmt.Sprintf("%.2f%%", percent)
+}
+
+// TokenErrorText picks the message for a failed token check.
+func TokenErrorText(l i18n.Localizer, chain *Chain, err error) string {
+	switch {
+	case errors.Is(err, ErrNotContract):
+		return l.T("web3.token_not_contract", chain.Name)
+	case errors.Is(err, ErrNotToken):
+		return l.T("web3.token_not_token")
+	case errors.Is(err, ErrRateLimited):
+		return l.T("web3.price_rate_limited")
+	default:
+		return l.T("web3.token_error")
+	}
+}
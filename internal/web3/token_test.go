@@ -0,0 +1,198 @@
+package web3
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/philip-857.bit/byb-bot/internal/web3/rpcfake"
+)
+
+// bytecode fakes contract code that dispatches to the given functions.
+func bytecode(signatures ...string) []byte {
+	var code []byte
+	for _, sig := range signatures {
+		code = append(code, 0x63)
+		code = append(code, selector(sig)...)
+		code = append(code, 0x14, 0x61) // EQ, PUSH2 of the jump target
+	}
+	return code
+}
+
+func TestRedFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		info  TokenInfo
+		code  []byte
+		flags []string
+	}{
+		{
+			name:  "owned",
+			info:  TokenInfo{Owner: "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"},
+			code:  bytecode("transfer(address,uint256)", "mint(address,uint256)", "pause()"),
+			flags: []string{FlagMintable, FlagPausable},
+		},
+		{
+			name: "renounced",
+			info: TokenInfo{OwnerRenounced: true},
+			code: bytecode("transfer(address,uint256)", "mint(address,uint256)", "pause()"),
+		},
+		{
+			name:  "renounced with roles",
+			info:  TokenInfo{OwnerRenounced: true},
+			code:  bytecode("mint(address,uint256)", "grantRole(bytes32,address)"),
+			flags: []string{FlagMintable},
+		},
+		{
+			name:  "unverified proxy",
+			info:  TokenInfo{HasExplorer: true, Implementation: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", TopShare: 95},
+			flags: []string{FlagUnverified, FlagProxy, FlagConcentrated},
+		},
+	}
+	for _, tt := range tests {
+		if got := redFlags(&tt.info, tt.code); !slices.Equal(got, tt.flags) {
+			t.Errorf("%s: redFlags = %v, want %v", tt.name, got, tt.flags)
+		}
+	}
+}
+
+// uint256 ABI-encodes an unsigned integer.
+func uint256(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, 32))
+}
+
+// fakeTokenContract serves an 18-decimal token at address from node: its code, metadata and
+// owner. code maps addresses to their bytecode; others are wallets.
+func fakeTokenContract(node *rpcfake.Server, address, owner string, code map[string][]byte) {
+	node.Handle("eth_getCode", func(params []json.RawMessage) (interface{}, error) {
+		var at string
+		json.Unmarshal(params[0], &at)
+		for a, c := range code {
+			if strings.EqualFold(a, at) {
+				return "0x" + hex.EncodeToString(c), nil
+			}
+		}
+		return "0x", nil
+	})
+	node.Result("eth_getStorageAt", "0x"+strings.Repeat("0", 64))
+	supply := new(big.Int).Mul(big.NewInt(1_000_000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+	node.Call(address, calldata("decimals()"), uint256(big.NewInt(18)))
+	node.Call(address, calldata("totalSupply()"), uint256(supply))
+	node.Call(address, calldata("name()"), abiString("Test Token"))
+	node.Call(address, calldata("symbol()"), abiString("TEST"))
+	node.Call(address, calldata("owner()"), encodeAddress(owner))
+	// The owner holds 30% of the supply.
+	node.Call(address, calldata("balanceOf(address)", encodeAddress(owner)), uint256(new(big.Int).Div(new(big.Int).Mul(supply, big.NewInt(3)), big.NewInt(10))))
+}
+
+func TestFetchTokenInfo(t *testing.T) {
+	const (
+		token          = "0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984"
+		implementation = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		owner          = "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"
+	)
+	ethereum, _ := LookupChain("ethereum")
+	ctx := context.Background()
+
+	t.Run("owned", func(t *testing.T) {
+		node := fakeNode(t, "ethereum")
+		fakeTokenContract(node, token, owner, map[string][]byte{token: bytecode("transfer(address,uint256)", "mint(address,uint256)")})
+		info, err := FetchTokenInfo(ctx, ethereum, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Name != "Test Token" || info.Symbol != "TEST" || info.Decimals != 18 || info.TotalSupply.Cmp(big.NewRat(1_000_000, 1)) != 0 {
+			t.Errorf("metadata = %+v", info)
+		}
+		if info.Owner != owner || info.OwnerRenounced || info.OwnerShare != 30 || info.Implementation != "" {
+			t.Errorf("ownership = %+v", info)
+		}
+		if want := []string{FlagMintable, FlagConcentrated}; !slices.Equal(info.Flags, want) {
+			t.Errorf("flags = %v, want %v", info.Flags, want)
+		}
+	})
+
+	t.Run("EIP-1967 proxy", func(t *testing.T) {
+		node := fakeNode(t, "ethereum")
+		// The proxy only forwards calls; minting lives in the implementation.
+		fakeTokenContract(node, token, owner, map[string][]byte{
+			token:          bytecode("upgradeTo(address)"),
+			implementation: bytecode("transfer(address,uint256)", "mint(address,uint256)"),
+		})
+		node.Handle("eth_getStorageAt", func(params []json.RawMessage) (interface{}, error) {
+			var slot string
+			json.Unmarshal(params[1], &slot)
+			if slot != eip1967ImplementationSlot {
+				return "0x" + strings.Repeat("0", 64), nil
+			}
+			return "0x" + hex.EncodeToString(encodeAddress(implementation)), nil
+		})
+		info, err := FetchTokenInfo(ctx, ethereum, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Implementation != implementation {
+			t.Errorf("Implementation = %q, want %q", info.Implementation, implementation)
+		}
+		if want := []string{FlagMintable, FlagProxy, FlagConcentrated}; !slices.Equal(info.Flags, want) {
+			t.Errorf("flags = %v, want %v", info.Flags, want)
+		}
+	})
+
+	t.Run("renounced owner", func(t *testing.T) {
+		node := fakeNode(t, "ethereum")
+		fakeTokenContract(node, token, zeroAddress, map[string][]byte{token: bytecode("mint(address,uint256)", "pause()")})
+		info, err := FetchTokenInfo(ctx, ethereum, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Owner != "" || !info.OwnerRenounced || info.OwnerShare != 0 || len(info.Flags) != 0 {
+			t.Errorf("renounced token = %+v", info)
+		}
+	})
+
+	t.Run("bytes32 symbol", func(t *testing.T) {
+		node := fakeNode(t, "ethereum")
+		fakeTokenContract(node, token, owner, map[string][]byte{token: bytecode("transfer(address,uint256)")})
+		// Early tokens such as MKR return their name and symbol as zero-padded bytes32.
+		node.Call(token, calldata("name()"), []byte("Maker"+strings.Repeat("\x00", 27)))
+		node.Call(token, calldata("symbol()"), []byte("MKR"+strings.Repeat("\x00", 29)))
+		info, err := FetchTokenInfo(ctx, ethereum, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Name != "Maker" || info.Symbol != "MKR" {
+			t.Errorf("name %q, symbol %q, want Maker and MKR", info.Name, info.Symbol)
+		}
+	})
+
+	t.Run("wallet", func(t *testing.T) {
+		node := fakeNode(t, "ethereum")
+		fakeTokenContract(node, token, owner, nil)
+		if _, err := FetchTokenInfo(ctx, ethereum, owner); !errors.Is(err, ErrNotContract) {
+			t.Errorf("a wallet: err = %v, want ErrNotContract", err)
+		}
+	})
+
+	t.Run("not a token", func(t *testing.T) {
+		node := fakeNode(t, "ethereum")
+		fakeTokenContract(node, token, owner, map[string][]byte{token: bytecode("transfer(address,uint256)"), implementation: bytecode("swap()")})
+		if _, err := FetchTokenInfo(ctx, ethereum, implementation); !errors.Is(err, ErrNotToken) {
+			t.Errorf("a contract without decimals: err = %v, want ErrNotToken", err)
+		}
+		// Decimals that don't fit an int64 would wrap around if read unchecked.
+		node.Call(token, calldata("decimals()"), uint256(new(big.Int).Lsh(big.NewInt(1), 64)))
+		if _, err := FetchTokenInfo(ctx, ethereum, token); !errors.Is(err, ErrNotToken) {
+			t.Errorf("2^64 decimals: err = %v, want ErrNotToken", err)
+		}
+		node.Call(token, calldata("decimals()"), uint256(big.NewInt(78)))
+		if _, err := FetchTokenInfo(ctx, ethereum, token); !errors.Is(err, ErrNotToken) {
+			t.Errorf("78 decimals: err = %v, want ErrNotToken", err)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+// Token is an ERC-20 token contract on a chain.
+type Token struct {
+	Symbol   string
+	Address  string
+	Decimals int
+	// CoinID is the price provider ID of the token, for valuing balances.
+	CoinID string
+}
+
+// knownTokens are the tokens whose balances /wallet checks, keyed by chain key. JSON-RPC can't
+// list the tokens an address holds, so we ask about the widely held ones.
+var knownTokens = map[string][]Token{
+	"ethereum": {
+		{Symbol: "USDT", Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Decimals: 6, CoinID: "tether"},
+		{Symbol: "USDC", Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Decimals: 6, CoinID: "usd-coin"},
+		{Symbol: "DAI", Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F", Decimals: 18, CoinID: "dai"},
+		{Symbol: "WETH", Address: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", Decimals: 18, CoinID: "weth"},
+		{Symbol: "WBTC", Address: "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599", Decimals: 8, CoinID: "wrapped-bitcoin"},
+		{Symbol: "LINK", Address: "0x514910771AF9Ca656af840dff83E8264EcF986CA", Decimals: 18, CoinID: "chainlink"},
+		{Symbol: "UNI", Address: "0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984", Decimals: 18, CoinID: "uniswap"},
+	},
+	"polygon": {
+		{Symbol: "USDC", Address: "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359", Decimals: 6, CoinID: "usd-coin"},
+		{Symbol: "USDT", Address: "0xc2132D05D31c914a87C6611C10748AEb04B58e8F", Decimals: 6, CoinID: "tether"},
+		{Symbol: "WETH", Address: "0x7ceB23fD6bC0adD59E62ac25578270cFf1b9f619", Decimals: 18, CoinID: "weth"},
+	},
+	"arbitrum": {
+		{Symbol: "USDC", Address: "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", Decimals: 6, CoinID: "usd-coin"},
+		{Symbol: "USDT", Address: "0xFd086bC7CD5C481DCC9C85ebE478A1C0b69FCbb9", Decimals: 6, CoinID: "tether"},
+		{Symbol: "ARB", Address: "0x912CE59144191C1204E64559FE8253a0e49E6548", Decimals: 18, CoinID: "arbitrum"},
+	},
+	"optimism": {
+		{Symbol: "USDC", Address: "0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85", Decimals: 6, CoinID: "usd-coin"},
+		{Symbol: "OP", Address: "0x4200000000000000000000000000000000000042", Decimals: 18, CoinID: "optimism"},
+	},
+	"base": {
+		{Symbol: "USDC", Address: "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", Decimals: 6, CoinID: "usd-coin"},
+	},
+	"bsc": {
+		{Symbol: "USDT", Address: "0x55d398326f99059fF775485246999027B3197955", Decimals: 18, CoinID: "tether"},
+		{Symbol: "USDC", Address: "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d", Decimals: 18, CoinID: "usd-coin"},
+	},
+	"avalanche": {
+		{Symbol: "USDC", Address: "0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E", Decimals: 6, CoinID: "usd-coin"},
+	},
+}
+
+// TokenBalance reads an address's balance of an ERC-20 token, in the token's smallest unit.
+func TokenBalance(ctx context.Context, rpc *RPCClient, token, owner string) (*big.Int, error) {
+	out, err := rpc.EthCall(ctx, token, append(selector("balanceOf(address)"), encodeAddress(owner)...))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(out), nil
+}
+
+// ReadGateToken reads the symbol and decimals of an ERC-20 token or ERC-721 collection, the two
+// kinds of contract whose balanceOf a token gate can count. Collections have no decimals, so
+// they are counted in whole NFTs.
+func ReadGateToken(ctx context.Context, chain *Chain, address string) (symbol string, decimals int, err error) {
+	rpc := chain.RPC()
+	code, err := contractCode(ctx, rpc, address)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(code) == 0 {
+		return "", 0, ErrNotContract
+	}
+	// ERC-721 reverts balanceOf for the zero address, so ask about the contract itself instead.
+	out, err := rpc.EthCall(ctx, address, append(selector("balanceOf(address)"), encodeAddress(address)...))
+	var rpcErr *RPCError
+	switch {
+	case errors.As(err, &rpcErr):
+		return "", 0, ErrNotToken
+	case err != nil:
+		return "", 0, err
+	case len(out) < 32:
+		return "", 0, ErrNotToken
+	}
+
+	if out, err := rpc.EthCall(ctx, address, selector("decimals()")); err == nil && len(out) >= 32 {
+		d := new(big.Int).SetBytes(out[:32])
+		if !d.IsInt64() || d.Int64() > 77 {
+			return "", 0, ErrNotToken
+		}
+		decimals = int(d.Int64())
+	}
+	if out, err := rpc.EthCall(ctx, address, selector("symbol()")); err == nil {
+		symbol = decodeStringOrBytes32(out)
+	}
+	if symbol == "" {
+		symbol = ShortAddress(address)
+	}
+	return symbol, decimals, nil
+}
+
+// ParseUnits converts an amount typed in whole tokens, like "1.5", to the token's smallest unit.
+// It reports false for invalid amounts and ones with more decimals than the token has.
+func ParseUnits(amount string, decimals int) (*big.Int, bool) {
+	r, ok := ParseAmount(amount)
+	if !ok {
+		return nil, false
+	}
+	r.Mul(r, pow10(decimals))
+	if !r.IsInt() {
+		return nil, false
+	}
+	return new(big.Int).Set(r.Num()), true
+}
+
+// FormatTokenAmount formats an amount in a token's smallest unit as whole tokens, exactly.
+func FormatTokenAmount(amount *big.Int, decimals int) string {
+	return formatRat(toUnits(amount, decimals), decimals)
+}
+
+// toUnits converts an amount in a token's smallest unit to whole tokens, exactly.
+func toUnits(amount *big.Int, decimals int) *big.Rat {
+	r := new(big.Rat).SetInt(amount)
+	return r.Mul(r, pow10(-decimals))
+}
+
+// formatUnits formats an amount of whole tokens with convertSignificant significant digits.
+func formatUnits(amount *big.Rat) string {
+	return formatRat(amount, resultDecimals(amount, false, asset{}))
+}
@@ -0,0 +1,337 @@
+package web3
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+)
+
+var (
+	// ErrInvalidTxHash is returned for input that isn't a 32-byte hex hash.
+	ErrInvalidTxHash = errors.New("invalid transaction hash")
+	// ErrTxNotFound is returned when the node doesn't know a transaction, often because
+	// it was sent on another chain.
+	ErrTxNotFound = errors.New("transaction not found")
+)
+
+var txHashPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// Transaction statuses.
+const (
+	TxPending  = "pending"
+	TxSuccess  = "success"
+	TxReverted = "reverted"
+)
+
+// knownMethods are the function signatures /tx can name, looked up by their selector.
+var knownMethods = func() map[string]string {
+	signatures := []string{
+		"transfer(address,uint256)",
+		"transferFrom(address,address,uint256)",
+		"approve(address,uint256)",
+		"safeTransferFrom(address,address,uint256)",
+		"safeTransferFrom(address,address,uint256,bytes)",
+		"safeTransferFrom(address,address,uint256,uint256,bytes)",
+		"setApprovalForAll(address,bool)",
+		"deposit()",
+		"withdraw(uint256)",
+		"mint(address,uint256)",
+		"mint(uint256)",
+		"claim()",
+		"stake(uint256)",
+		"multicall(bytes[])",
+		"multicall(uint256,bytes[])",
+		"execute(bytes,bytes[],uint256)",
+		"execute(bytes,bytes[])",
+		"swapExactTokensForTokens(uint256,uint256,address[],address,uint256)",
+		"swapTokensForExactTokens(uint256,uint256,address[],address,uint256)",
+		"swapExactETHForTokens(uint256,address[],address,uint256)",
+		"swapExactTokensForETH(uint256,uint256,address[],address,uint256)",
+		"exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))",
+		"exactInput((bytes,address,uint256,uint256,uint256))",
+	}
+	methods := make(map[string]string, len(signatures))
+	for _, sig := range signatures {
+		methods[hex.EncodeToString(selector(sig))] = sig[:strings.Index(sig, "(")]
+	}
+	return methods
+}()
+
+// Transaction is what /tx shows about a transaction.
+type Transaction struct {
+	Hash   string
+	Chain  *Chain
+	Status string
+	// Block and Time are only set once the transaction is mined.
+	Block uint64
+	Time  time.Time
+	From  string
+	// To is empty for contract creations, which set Contract instead.
+	To       string
+	Contract string
+	Value    *big.Rat // In whole native coins
+	// Method is the decoded function name, or "" for plain transfers and unknown calls.
+	Method   string
+	Selector string
+	// TokenTransfer is set for ERC-20 transfers of a known token.
+	TokenTransfer *TokenTransfer
+	// GasUsed and Fee are only set once the transaction is mined. Fee is in whole native coins and
+	// includes the L1 data fee on OP-stack rollups.
+	GasUsed uint64
+	Fee     *big.Rat
+	// Names are the primary ENS names of the addresses involved, for display.
+	Names map[string]string
+}
+
+// TokenTransfer is a decoded ERC-20 transfer call.
+type TokenTransfer struct {
+	Token  Token
+	To     string
+	Amount *big.Rat
+}
+
+// FetchTransaction looks a transaction and its receipt up over JSON-RPC.
+func FetchTransaction(ctx context.Context, chain *Chain, hash string) (*Transaction, error) {
+	if !txHashPattern.MatchString(hash) {
+		return nil, ErrInvalidTxHash
+	}
+	rpc := chain.RPC()
+
+	var raw *struct {
+		BlockNumber *string `json:"blockNumber"`
+		From        string  `json:"from"`
+		To          *string `json:"to"`
+		Value       string  `json:"value"`
+		Input       string  `json:"input"`
+	}
+	if err := rpc.Call(ctx, &raw, "eth_getTransactionByHash", hash); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, ErrTxNotFound
+	}
+
+	value, err := parseHexBig(raw.Value)
+	if err != nil {
+		return nil, err
+	}
+	tx := &Transaction{
+		Hash:   strings.ToLower(hash),
+		Chain:  chain,
+		Status: TxPending,
+		From:   ChecksumAddress(raw.From),
+		Value:  toUnits(value, 18),
+	}
+	if raw.To != nil {
+		tx.To = ChecksumAddress(*raw.To)
+	}
+	decodeInput(tx, raw.Input)
+
+	if raw.BlockNumber == nil {
+		return tx, nil
+	}
+
+	var receipt *struct {
+		BlockNumber       string  `json:"blockNumber"`
+		Status            string  `json:"status"`
+		GasUsed           string  `json:"gasUsed"`
+		EffectiveGasPrice string  `json:"effectiveGasPrice"`
+		ContractAddress   *string `json:"contractAddress"`
+		// L1Fee is what OP-stack rollups charge on top of L2 gas for posting the transaction to L1.
+		L1Fee *string `json:"l1Fee"`
+	}
+	if err := rpc.Call(ctx, &receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		// Mined but not indexed yet; report it as pending rather than guessing.
+		return tx, nil
+	}
+
+	block, err := parseHexBig(receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	gasUsed, err := parseHexBig(receipt.GasUsed)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := parseHexBig(receipt.EffectiveGasPrice)
+	if err != nil {
+		return nil, err
+	}
+	tx.Block = block.Uint64()
+	tx.GasUsed = gasUsed.Uint64()
+	fee := new(big.Int).Mul(gasUsed, gasPrice)
+	if receipt.L1Fee != nil {
+		l1Fee, err := parseHexBig(*receipt.L1Fee)
+		if err != nil {
+			return nil, err
+		}
+		fee.Add(fee, l1Fee)
+	}
+	tx.Fee = toUnits(fee, 18)
+	tx.Status = TxSuccess
+	if receipt.Status == "0x0" {
+		tx.Status = TxReverted
+	}
+	if receipt.ContractAddress != nil {
+		tx.Contract = ChecksumAddress(*receipt.ContractAddress)
+	}
+
+	// The block time is a nice to have; the receipt already answers what was asked.
+	var header *struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := rpc.Call(ctx, &header, "eth_getBlockByNumber", receipt.BlockNumber, false); err != nil {
+		log.Printf("Failed to read block %d on %s: %v", tx.Block, chain.Name, err)
+	} else if header != nil {
+		if ts, err := parseHexBig(header.Timestamp); err == nil {
+			tx.Time = time.Unix(ts.Int64(), 0)
+		}
+	}
+	return tx, nil
+}
+
+// decodeInput names the called function from its selector and decodes ERC-20 transfers of known tokens.
+func decodeInput(tx *Transaction, input string) {
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil || len(data) < 4 {
+		return
+	}
+	tx.Selector = "0x" + hex.EncodeToString(data[:4])
+	tx.Method = knownMethods[hex.EncodeToString(data[:4])]
+
+	if tx.Method != "transfer" || len(data) != 4+64 {
+		return
+	}
+	for _, token := range knownTokens[tx.Chain.Key] {
+		if strings.EqualFold(token.Address, tx.To) {
+			tx.TokenTransfer = &TokenTransfer{
+				Token:  token,
+				To:     decodeAddress(data[4:36]),
+				Amount: toUnits(new(big.Int).SetBytes(data[36:68]), token.Decimals),
+			}
+			return
+		}
+	}
+}
+
+// HandleTxCommand reports the status of a transaction: /tx <hash> [chain].
+func HandleTxCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 || len(fields) > 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.tx_usage")))
+		return
+	}
+	chainName := ""
+	if len(fields) == 2 {
+		chainName = fields[1]
+	}
+	chain, ok := LookupChain(chainName)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", chainName, strings.Join(ChainKeys(), ", "))))
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := FetchTransaction(ctx, chain, fields[0])
+	if err != nil {
+		log.Printf("Failed to look up transaction %s on %s: %v", fields[0], chain.Name, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, TxErrorText(l, chain, err)))
+		return
+	}
+
+	tx.Names = AddressNames(ctx, tx.From, tx.To, tokenRecipient(tx))
+	msg := tgbotapi.NewMessage(message.Chat.ID, TxText(l, tx, valueUSD(ctx, chain.NativeCoinID, tx.Value), feeUSD(ctx, tx)))
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
+
+// tokenRecipient returns who received a decoded token transfer, or "".
+func tokenRecipient(tx *Transaction) string {
+	if tx.TokenTransfer == nil {
+		return ""
+	}
+	return tx.TokenTransfer.To
+}
+
+// feeUSD values the fee of a mined transaction, or returns 0.
+func feeUSD(ctx context.Context, tx *Transaction) float64 {
+	if tx.Fee == nil {
+		return 0
+	}
+	return valueUSD(ctx, tx.Chain.NativeCoinID, tx.Fee)
+}
+
+// TxText formats the Markdown summary of a transaction.
+func TxText(l i18n.Localizer, tx *Transaction, valueUSD, feeUSD float64) string {
+	icon := map[string]string{TxPending: "⏳", TxSuccess: "✅", TxReverted: "❌"}[tx.Status]
+	lines := []string{
+		l.T("web3.tx_title", icon, tx.Chain.Name),
+		"`" + tx.Hash + "`",
+		"",
+		l.T("web3.tx_status", l.T("web3.tx_status_"+tx.Status)),
+	}
+	if tx.Block > 0 {
+		block := groupThousands(fmt.Sprint(tx.Block))
+		if !tx.Time.IsZero() {
+			block += " · " + tx.Time.UTC().Format("2006-01-02 15:04 UTC")
+		}
+		lines = append(lines, l.T("web3.tx_block", block))
+	}
+
+	lines = append(lines, l.T("web3.tx_from", DisplayAddress(tx.From, tx.Names[tx.From])))
+	switch {
+	case tx.To != "":
+		lines = append(lines, l.T("web3.tx_to", DisplayAddress(tx.To, tx.Names[tx.To])))
+	case tx.Contract != "":
+		lines = append(lines, l.T("web3.tx_created", tx.Contract))
+	}
+	lines = append(lines, l.T("web3.tx_value", formatUnits(tx.Value), tx.Chain.NativeSymbol)+usdSuffix(valueUSD))
+
+	switch {
+	case tx.TokenTransfer != nil:
+		t := tx.TokenTransfer
+		lines = append(lines, l.T("web3.tx_token_transfer", formatUnits(t.Amount), t.Token.Symbol, DisplayAddress(t.To, tx.Names[t.To])))
+	case tx.Method != "":
+		lines = append(lines, l.T("web3.tx_method", tx.Method))
+	case tx.Selector != "":
+		lines = append(lines, l.T("web3.tx_method", tx.Selector))
+	}
+
+	if tx.Fee != nil {
+		lines = append(lines, l.T("web3.tx_gas_used", groupThousands(fmt.Sprint(tx.GasUsed))))
+		lines = append(lines, l.T("web3.tx_fee", formatUnits(tx.Fee), tx.Chain.NativeSymbol)+usdSuffix(feeUSD))
+	}
+
+	lines = append(lines, "", l.T("web3.wallet_explorer", tx.Chain.Explorer+"/tx/"+tx.Hash))
+	return strings.Join(lines, "\n")
+}
+
+// TxErrorText picks the message for a failed transaction lookup.
+func TxErrorText(l i18n.Localizer, chain *Chain, err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidTxHash):
+		return l.T("web3.tx_invalid")
+	case errors.Is(err, ErrTxNotFound):
+		return l.T("web3.tx_not_found", chain.Name)
+	case errors.Is(err, ErrRateLimited):
+		return l.T("web3.price_rate_limited")
+	default:
+		return l.T("web3.tx_error")
+	}
+}
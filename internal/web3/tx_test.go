@@ -0,0 +1,191 @@
+package web3
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const txHash = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+
+func TestFetchTransactionFee(t *testing.T) {
+	tests := []struct {
+		name    string
+		chain   string
+		receipt map[string]interface{}
+		fee     *big.Rat
+	}{
+		{
+			name:  "L1",
+			chain: "ethereum",
+			receipt: map[string]interface{}{
+				"gasUsed":           "0x5208", // 21000
+				"effectiveGasPrice": "0x3b9aca00",
+			},
+			fee: big.NewRat(21_000, 1_000_000_000), // 21000 gwei
+		},
+		{
+			name:  "OP stack",
+			chain: "base",
+			receipt: map[string]interface{}{
+				"gasUsed":           "0x5208",
+				"effectiveGasPrice": "0x3b9aca00",
+				"l1Fee":             "0x2386f26fc10000", // 0.01 ETH
+			},
+			fee: new(big.Rat).Add(big.NewRat(21_000, 1_000_000_000), big.NewRat(1, 100)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := fakeNode(t, tt.chain)
+			node.Result("eth_getTransactionByHash", map[string]interface{}{
+				"blockNumber": "0x10",
+				"from":        "0xd8da6bf26964af9d7eed9e03e53415d37aa96045",
+				"to":          "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+				"value":       "0x0",
+				"input":       "0x",
+			})
+			receipt := map[string]interface{}{"blockNumber": "0x10", "status": "0x1"}
+			for k, v := range tt.receipt {
+				receipt[k] = v
+			}
+			node.Result("eth_getTransactionReceipt", receipt)
+			node.Result("eth_getBlockByNumber", map[string]string{"timestamp": "0x665a3c00"})
+
+			chain, _ := LookupChain(tt.chain)
+			tx, err := FetchTransaction(context.Background(), chain, txHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.Status != TxSuccess || tx.GasUsed != 21000 {
+				t.Errorf("status %s, gas used %d", tx.Status, tx.GasUsed)
+			}
+			if tx.Fee == nil || tx.Fee.Cmp(tt.fee) != 0 {
+				t.Errorf("Fee = %v, want %v", tx.Fee, tt.fee.FloatString(18))
+			}
+		})
+	}
+}
+
+func TestFetchTransactionStatus(t *testing.T) {
+	mined := map[string]interface{}{
+		"blockNumber": "0x10",
+		"from":        "0xd8da6bf26964af9d7eed9e03e53415d37aa96045",
+		"to":          "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"value":       "0xde0b6b3a7640000", // 1 ETH
+		"input":       "0x",
+	}
+	notMined := map[string]interface{}{}
+	for k, v := range mined {
+		if k != "blockNumber" {
+			notMined[k] = v
+		}
+	}
+	receipt := func(status string) map[string]interface{} {
+		return map[string]interface{}{"blockNumber": "0x10", "status": status, "gasUsed": "0x5208", "effectiveGasPrice": "0x3b9aca00"}
+	}
+
+	tests := []struct {
+		name    string
+		tx      map[string]interface{}
+		receipt interface{}
+		status  string
+		calls   []string
+	}{
+		{"in the mempool", notMined, nil, TxPending, []string{"eth_getTransactionByHash"}},
+		{"mined but not indexed", mined, nil, TxPending, []string{"eth_getTransactionByHash", "eth_getTransactionReceipt"}},
+		{"reverted", mined, receipt("0x0"), TxReverted, nil},
+		{"succeeded", mined, receipt("0x1"), TxSuccess, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := fakeNode(t, "ethereum")
+			node.Result("eth_getTransactionByHash", tt.tx)
+			node.Result("eth_getTransactionReceipt", tt.receipt)
+			node.Result("eth_getBlockByNumber", map[string]string{"timestamp": "0x665a3c00"})
+
+			chain, _ := LookupChain("ethereum")
+			tx, err := FetchTransaction(context.Background(), chain, txHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.Status != tt.status || tx.Value.Cmp(big.NewRat(1, 1)) != 0 || tx.To != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+				t.Errorf("tx = %+v", tx)
+			}
+			if tt.status == TxPending {
+				if tx.Fee != nil || tx.GasUsed != 0 || tx.Block != 0 || !tx.Time.IsZero() {
+					t.Errorf("a pending tx has mining details: %+v", tx)
+				}
+			} else if tx.Fee == nil || tx.Block != 16 || tx.Time.IsZero() {
+				t.Errorf("a mined tx lacks mining details: %+v", tx)
+			}
+			if tt.calls != nil && !slices.Equal(node.Methods(), tt.calls) {
+				t.Errorf("called %v, want %v", node.Methods(), tt.calls)
+			}
+		})
+	}
+}
+
+func TestFetchTransactionNotFound(t *testing.T) {
+	node := fakeNode(t, "ethereum")
+	node.Result("eth_getTransactionByHash", nil)
+	chain, _ := LookupChain("ethereum")
+
+	if _, err := FetchTransaction(context.Background(), chain, txHash); !errors.Is(err, ErrTxNotFound) {
+		t.Errorf("an unknown hash: err = %v, want ErrTxNotFound", err)
+	}
+	for _, hash := range []string{"", "0x1234", txHash[2:], txHash + "00", strings.Replace(txHash, "8", "g", 1)} {
+		if _, err := FetchTransaction(context.Background(), chain, hash); !errors.Is(err, ErrInvalidTxHash) {
+			t.Errorf("FetchTransaction(%q): err = %v, want ErrInvalidTxHash", hash, err)
+		}
+	}
+	if len(node.Methods()) != 1 {
+		t.Errorf("invalid hashes were sent to the node: %v", node.Methods())
+	}
+}
+
+func TestDecodeInput(t *testing.T) {
+	const (
+		usdc      = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		recipient = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	)
+	address := make([]byte, 32)
+	hex.Decode(address[12:], []byte(recipient[2:]))
+	amount := big.NewInt(1_500_000).FillBytes(make([]byte, 32)) // 1.5 USDC
+
+	tests := []struct {
+		name     string
+		to       string
+		input    string
+		method   string
+		selector string
+		transfer *TokenTransfer
+	}{
+		{"USDC transfer", usdc, "0x" + calldata("transfer(address,uint256)", address, amount), "transfer", "0xa9059cbb",
+			&TokenTransfer{To: recipient, Amount: big.NewRat(3, 2)}},
+		{"transfer of an unknown token", recipient, "0x" + calldata("transfer(address,uint256)", address, amount), "transfer", "0xa9059cbb", nil},
+		{"approve", usdc, "0x" + calldata("approve(address,uint256)", address, amount), "approve", "0x095ea7b3", nil},
+		{"swap", recipient, "0x" + calldata("exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))"), "exactInputSingle", "0x414bf389", nil},
+		{"unknown method", recipient, "0xdeadbeef0000", "", "0xdeadbeef", nil},
+		{"plain transfer", recipient, "0x", "", "", nil},
+		{"garbage", recipient, "0xnothex", "", "", nil},
+	}
+	chain, _ := LookupChain("ethereum")
+	for _, tt := range tests {
+		tx := &Transaction{Chain: chain, To: tt.to}
+		decodeInput(tx, tt.input)
+		if tx.Method != tt.method || tx.Selector != tt.selector {
+			t.Errorf("%s: method %q, selector %q, want %q, %q", tt.name, tx.Method, tx.Selector, tt.method, tt.selector)
+		}
+		switch got := tx.TokenTransfer; {
+		case (got == nil) != (tt.transfer == nil):
+			t.Errorf("%s: token transfer = %+v", tt.name, got)
+		case got != nil && (got.Token.Symbol != "USDC" || got.To != tt.transfer.To || got.Amount.Cmp(tt.transfer.Amount) != 0):
+			t.Errorf("%s: token transfer = %+v, want %+v", tt.name, got, tt.transfer)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/philip-857.bit/byb-bot/internal/database"
+	"github.com/philip-857.bit/byb-bot/internal/i18n"
+)
+
+const (
+	// walletTokens is how many token balances /wallet shows, largest first.
+	walletTokens = 5
+	// walletTransactions is how many recent transactions /wallet shows.
+	walletTransactions = 5
+)
+
+// Wallet is what /wallet shows about an address on one chain.
+type Wallet struct {
+	Address string
+	Chain   *Chain
+	// Balance is the native coin balance, in whole coins.
+	Balance    *big.Rat
+	BalanceUSD float64
+	// Sent is the number of transactions the address has sent, its nonce.
+	Sent       uint64
+	IsContract bool
+	Tokens     []WalletToken
+	// Transactions are the most recent ones, newest first. They need an explorer API key,
+	// and HasHistory tells whether they could be looked up at all.
+	Transactions []WalletTx
+	HasHistory   bool
+	// Names are the primary ENS names of the counterparties in Transactions, for display.
+	Names map[string]string
+}
+
+// WalletToken is a non-zero token balance, in whole tokens.
+type WalletToken struct {
+	Token    Token
+	Amount   *big.Rat
+	ValueUSD float64
+}
+
+// WalletTx is a transaction in an address's history.
+type WalletTx struct {
+	Hash   string
+	From   string
+	To     string
+	Value  *big.Rat // In whole native coins
+	Time   time.Time
+	Failed bool
+}
+
+// FetchWallet reads the balances of an address over JSON-RPC and its recent transactions from
+// the explorer. Token balances and history are best effort: a failing token is left out rather
+// than failing the whole lookup.
+func FetchWallet(ctx context.Context, chain *Chain, address string) (*Wallet, error) {
+	rpc := chain.RPC()
+	w := &Wallet{Address: address, Chain: chain}
+
+	var balance, nonce, code string
+	if err := rpc.Call(ctx, &balance, "eth_getBalance", address, "latest"); err != nil {
+		return nil, err
+	}
+	wei, err := parseHexBig(balance)
+	if err != nil {
+		return nil, err
+	}
+	w.Balance = toUnits(wei, 18)
+	if err := rpc.Call(ctx, &nonce, "eth_getTransactionCount", address, "latest"); err != nil {
+		return nil, err
+	}
+	sent, err := parseHexBig(nonce)
+	if err != nil {
+		return nil, err
+	}
+	w.Sent = sent.Uint64()
+	if err := rpc.Call(ctx, &code, "eth_getCode", address, "latest"); err == nil {
+		w.IsContract = code != "" && code != "0x"
+	}
+	w.BalanceUSD = valueUSD(ctx, chain.NativeCoinID, w.Balance)
+
+	for _, token := range knownTokens[chain.Key] {
+		amount, err := TokenBalance(ctx, rpc, token.Address, address)
+		if err != nil {
+			log.Printf("Failed to read %s balance of %s on %s: %v", token.Symbol, address, chain.Name, err)
+			continue
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+		units := toUnits(amount, token.Decimals)
+		w.Tokens = append(w.Tokens, WalletToken{Token: token, Amount: units, ValueUSD: valueUSD(ctx, token.CoinID, units)})
+	}
+	sort.SliceStable(w.Tokens, func(i, j int) bool { return w.Tokens[i].ValueUSD > w.Tokens[j].ValueUSD })
+	if len(w.Tokens) > walletTokens {
+		w.Tokens = w.Tokens[:walletTokens]
+	}
+
+	if Cfg != nil && Cfg.EtherscanAPIKey != "" {
+		txs, err := explorerTransactions(ctx, chain, address, Cfg.EtherscanAPIKey)
+		if err != nil {
+			log.Printf("Failed to fetch transactions of %s on %s: %v", address, chain.Name, err)
+		} else {
+			w.Transactions, w.HasHistory = txs, true
+		}
+	}
+	return w, nil
+}
+
+// valueUSD values an amount of a coin at its current USD price, or returns 0 if the price is unknown.
+func valueUSD(ctx context.Context, coinID string, amount *big.Rat) float64 {
+	if coinID == "" || amount.Sign() == 0 {
+		return 0
+	}
+	coin, err := Prices.Coin(ctx, coinID)
+	if err != nil {
+		log.Printf("Failed to price %q for wallet: %v", coinID, err)
+		return 0
+	}
+	quote, _ := coin.Quote(DefaultCurrency)
+	value, _ := new(big.Rat).Mul(amount, new(big.Rat).SetFloat64(quote.Price)).Float64()
+	return value
+}
+
+// explorerTransactions lists the latest transactions of an address from the Etherscan v2 multichain API.
+func explorerTransactions(ctx context.Context, chain *Chain, address, apiKey string) ([]WalletTx, error) {
+	raw, err := etherscan(ctx, chain, apiKey, url.Values{
+		"module": {"account"}, "action": {"txlist"}, "address": {address},
+		"page": {"1"}, "offset": {strconv.Itoa(walletTransactions)}, "sort": {"desc"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []struct {
+		Hash      string `json:"hash"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		Value     string `json:"value"`
+		TimeStamp string `json:"timeStamp"`
+		IsError   string `json:"isError"`
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse Etherscan transactions: %v: %w", err, ErrBadResponse)
+		}
+	}
+
+	txs := make([]WalletTx, 0, len(result))
+	for _, r := range result {
+		wei, ok := new(big.Int).SetString(r.Value, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid transaction value %q from Etherscan: %w", r.Value, ErrBadResponse)
+		}
+		ts, _ := strconv.ParseInt(r.TimeStamp, 10, 64)
+		txs = append(txs, WalletTx{
+			Hash:   r.Hash,
+			From:   checksumOrEmpty(r.From),
+			To:     checksumOrEmpty(r.To),
+			Value:  toUnits(wei, 18),
+			Time:   time.Unix(ts, 0),
+			Failed: r.IsError == "1",
+		})
+	}
+	return txs, nil
+}
+
+// checksumOrEmpty checksums an address from an API, keeping "" for contract creations.
+func checksumOrEmpty(addr string) string {
+	if addr == "" {
+		return ""
+	}
+	return ChecksumAddress(addr)
+}
+
+// HandleWalletCommand shows the balances and recent transactions of an address:
+// /wallet <address|ens name> [chain].
+func HandleWalletCommand(bot *tgbotapi.BotAPI, db database.Store, message *tgbotapi.Message) {
+	l := i18n.ForMessage(db, message)
+
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 || len(fields) > 2 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.wallet_usage")))
+		return
+	}
+	chainName := ""
+	if len(fields) == 2 {
+		chainName = fields[1]
+	}
+	chain, ok := LookupChain(chainName)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, l.T("web3.gas_unknown_chain", chainName, strings.Join(ChainKeys(), ", "))))
+		return
+	}
+
+	ctx := context.Background()
+	address, ensName, err := ResolveAddressInput(ctx, fields[0])
+	if err != nil {
+		log.Printf("Failed to resolve wallet %q: %v", fields[0], err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, WalletErrorText(l, fields[0], err)))
+		return
+	}
+
+	if ensName == "" {
+		// Show the primary name of a pasted address; without one the address speaks for itself.
+		ensName, _ = LookupENSName(ctx, address)
+	}
+
+	bot.Request(tgbotapi.NewChatAction(message.Chat.ID, tgbotapi.ChatTyping))
+	wallet, err := FetchWallet(ctx, chain, address)
+	if err != nil {
+		log.Printf("Failed to fetch wallet %s on %s: %v", address, chain.Name, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, WalletErrorText(l, fields[0], err)))
+		return
+	}
+
+	counterparties := make([]string, 0, len(wallet.Transactions))
+	for _, tx := range wallet.Transactions {
+		counterparties = append(counterparties, tx.From, tx.To)
+	}
+	wallet.Names = AddressNames(ctx, counterparties...)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, WalletText(l, wallet, ensName))
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	bot.Send(msg)
+}
+
+// WalletText formats the Markdown summary of a wallet.
+func WalletText(l i18n.Localizer, w *Wallet, ensName string) string {
+	lines := []string{l.T("web3.wallet_title", w.Chain.Name), "`" + w.Address + "`"}
+	if ensName != "" {
+		lines = append(lines, l.T("web3.wallet_ens", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, ensName)))
+	}
+	if w.IsContract {
+		lines = append(lines, l.T("web3.wallet_contract"))
+	}
+
+	lines = append(lines, "", l.T("web3.wallet_balance", formatUnits(w.Balance), w.Chain.NativeSymbol)+usdSuffix(w.BalanceUSD))
+	lines = append(lines, l.T("web3.wallet_sent", groupThousands(strconv.FormatUint(w.Sent, 10))))
+
+	if len(w.Tokens) > 0 {
+		lines = append(lines, "", l.T("web3.wallet_tokens_header"))
+		for _, t := range w.Tokens {
+			lines = append(lines, fmt.Sprintf("• `%s %s`%s", formatUnits(t.Amount), t.Token.Symbol, usdSuffix(t.ValueUSD)))
+		}
+	}
+
+	switch {
+	case !w.HasHistory:
+		lines = append(lines, "", l.T("web3.wallet_no_history"))
+	case len(w.Transactions) == 0:
+		lines = append(lines, "", l.T("web3.wallet_no_transactions"))
+	default:
+		lines = append(lines, "", l.T("web3.wallet_transactions_header"))
+		for _, tx := range w.Transactions {
+			lines = append(lines, walletTxLine(l, w, tx))
+		}
+	}
+	lines = append(lines, "", l.T("web3.wallet_explorer", w.Chain.Explorer+"/address/"+w.Address))
+	return strings.Join(lines, "\n")
+}
+
+// walletTxLine formats one transaction as seen from the wallet: outgoing, incoming or failed.
+func walletTxLine(l i18n.Localizer, w *Wallet, tx WalletTx) string {
+	icon, key, other := "⬇️", "web3.wallet_tx_in", tx.From
+	if strings.EqualFold(tx.From, w.Address) {
+		icon, key, other = "⬆️", "web3.wallet_tx_out", tx.To
+	}
+	if tx.Failed {
+		icon = "❌"
+	}
+	counterparty := ShortAddress(other)
+	if name := w.Names[other]; name != "" {
+		counterparty = tgbotapi.EscapeText(tgbotapi.ModeMarkdown, name)
+	}
+	amount := fmt.Sprintf("%s %s", formatUnits(tx.Value), w.Chain.NativeSymbol)
+	return fmt.Sprintf("%s [%s](%s/tx/%s) · %s", icon, l.T(key, amount, counterparty),
+		w.Chain.Explorer, tx.Hash, tx.Time.UTC().Format("Jan 2 15:04"))
+}
+
+// usdSuffix shows a USD value after an amount, or nothing when the value is unknown.
+func usdSuffix(usd float64) string {
+	if usd <= 0 {
+		return ""
+	}
+	return " (~" + FormatMoney(DefaultCurrency, usd) + ")"
+}
+
+// WalletErrorText picks the message for a failed wallet lookup.
+func WalletErrorText(l i18n.Localizer, input string, err error) string {
+	switch {
+	case errors.Is(err, ErrBadChecksum):
+		return l.T("web3.wallet_bad_checksum", input)
+	case errors.Is(err, ErrInvalidAddress):
+		return l.T("web3.wallet_invalid", input)
+	case errors.Is(err, ErrENSNotFound):
+		return l.T("web3.wallet_ens_not_found", input)
+	case errors.Is(err, ErrRateLimited):
+		return l.T("web3.price_rate_limited")
+	default:
+		return l.T("web3.wallet_error")
+	}
+}